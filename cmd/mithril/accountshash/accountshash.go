@@ -0,0 +1,96 @@
+package accountshash
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/replay"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+var (
+	Cmd = cobra.Command{
+		Use:   "accounts-hash",
+		Short: "Calculate the accounts hash of an AccountsDB and compare it against its snapshot manifest",
+		Run:   run,
+	}
+
+	path         string
+	manifestPath string
+)
+
+func init() {
+	Cmd.Flags().StringVarP(&path, "path", "p", "", "Path of AccountsDB to calculate the accounts hash of")
+	Cmd.Flags().StringVarP(&manifestPath, "manifest", "m", "", "Path of snapshot manifest to compare against (defaults to the AccountsDB's manifest)")
+}
+
+func run(c *cobra.Command, args []string) {
+	if path == "" {
+		klog.Exitf("must specify an AccountsDB directory path")
+	}
+
+	if manifestPath == "" {
+		manifestPath = fmt.Sprintf("%s/manifest", path)
+	}
+
+	accountsDb, err := accountsdb.OpenDb(path)
+	if err != nil {
+		klog.Exitf("unable to open accounts db %s: %s", path, err)
+	}
+	defer accountsDb.CloseDb()
+
+	manifest, err := snapshot.LoadManifestFromFile(manifestPath)
+	if err != nil {
+		klog.Exitf("unable to open manifest file %s: %s", manifestPath, err)
+	}
+
+	mismatch := false
+
+	// the accounts hash over the full snapshot is recorded in the BankHashInfo of full snapshots
+	if manifest.AccountsDb.BankHashInfo.SnapshotHash != [32]byte{} {
+		mismatch = !verifyFullAccountsHash(accountsDb, manifest.Bank.Slot, manifest.AccountsDb.BankHashInfo.SnapshotHash) || mismatch
+	}
+
+	// incremental snapshots instead record the hashes of both the full snapshot it builds on
+	// and the accounts modified since.
+	persistence := &manifest.BankIncrementalSnapshotPersistence
+	if persistence.FullSlot != 0 {
+		mismatch = !verifyFullAccountsHash(accountsDb, persistence.FullSlot, persistence.FullHash) || mismatch
+
+		start := time.Now()
+		incrementalHash, _, err := replay.CalculateIncrementalAccountsHash(accountsDb, persistence.FullSlot, manifest.Bank.Slot)
+		if err != nil {
+			klog.Exitf("failed to calculate incremental accounts hash: %s", err)
+		}
+		mismatch = !compareAccountsHash("incremental", manifest.Bank.Slot, incrementalHash, persistence.IncrementalHash, time.Since(start)) || mismatch
+	}
+
+	if mismatch {
+		os.Exit(1)
+	}
+}
+
+func verifyFullAccountsHash(accountsDb *accountsdb.AccountsDb, slot uint64, expected [32]byte) bool {
+	start := time.Now()
+	accountsHash, _, err := replay.CalculateAccountsHash(accountsDb, slot)
+	if err != nil {
+		klog.Exitf("failed to calculate accounts hash: %s", err)
+	}
+	return compareAccountsHash("full", slot, accountsHash, expected, time.Since(start))
+}
+
+func compareAccountsHash(kind string, slot uint64, calculated [32]byte, expected [32]byte, elapsed time.Duration) bool {
+	if calculated != expected {
+		klog.Errorf("%s accounts hash mismatch at slot %d: calculated %s, expected %s (took %s)",
+			kind, slot, base58.Encode(calculated[:]), base58.Encode(expected[:]), elapsed)
+		return false
+	}
+
+	klog.Infof("%s accounts hash at slot %d matches manifest: %s (took %s)", kind, slot, base58.Encode(calculated[:]), elapsed)
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 
 	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/mithril/accountshash"
 	"go.firedancer.io/radiance/cmd/mithril/node"
 	"k8s.io/klog/v2"
 
@@ -27,6 +28,7 @@ func init() {
 
 	cmd.AddCommand(
 		&node.Cmd,
+		&accountshash.Cmd,
 	)
 }
 
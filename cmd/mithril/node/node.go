@@ -3,14 +3,19 @@
 package node
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"time"
 
-	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/spf13/cobra"
 	"go.firedancer.io/radiance/pkg/accountsdb"
 	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/blockstore"
 	"go.firedancer.io/radiance/pkg/replay"
 	"go.firedancer.io/radiance/pkg/rpcclient"
+	"go.firedancer.io/radiance/pkg/sealevel"
 	"go.firedancer.io/radiance/pkg/snapshot"
 	"k8s.io/klog/v2"
 )
@@ -26,39 +31,162 @@ var (
 	loadFromAccountsDb bool
 	updateAccountsDb   bool
 	path               string
+	incrementalPath    string
 	outputDir          string
 	slot               int64
+	endSlot            int64
+	continuous         bool
+	blockSourceType    string
+	blockSourcePath    string
+	rpcEndpoint        string
+	bankHashSourceType string
+	bankHashSourcePath string
+	snapshotOutputDir  string
+	snapshotFullSlot   int64
+	cleanInterval      uint64
+	acctCacheSizeMb    uint64
+	txParallelism      int
+	checkSerialEquiv   bool
+	skipSigVerify      bool
+	divergenceDir      string
+	divergenceFormat   string
+	maxDivergences     int64
 )
 
+// interval at which to re-request a block that has not yet been finalized
+const blockNotAvailableRetryInterval = 2 * time.Second
+
+// number of slots between logs of the cache stats in continuous mode
+const statsLogInterval = 100
+
 func init() {
 	Cmd.Flags().BoolVarP(&loadFromSnapshot, "snapshot", "s", false, "Load from a full snapshot")
 	Cmd.Flags().BoolVarP(&loadFromAccountsDb, "accountsdb", "a", false, "Load from AccountsDB")
 	Cmd.Flags().BoolVarP(&updateAccountsDb, "update-accounts-db", "u", false, "Update accountsdb after execution")
 	Cmd.Flags().StringVarP(&path, "path", "p", "", "Path of full snapshot or AccountsDB to load from")
+	Cmd.Flags().StringVarP(&incrementalPath, "incremental-snapshot", "i", "", "Path of incremental snapshot to apply on top of the full snapshot or AccountsDB")
 	Cmd.Flags().StringVarP(&outputDir, "out", "o", "", "Output path for writing AccountsDB data to")
 	Cmd.Flags().Int64VarP(&slot, "slot", "b", -1, "Block at which to begin replaying")
+	Cmd.Flags().Int64VarP(&endSlot, "end-slot", "e", -1, "Last block to replay in continuous mode (-1 for no limit)")
+	Cmd.Flags().BoolVarP(&continuous, "continuous", "c", false, "Replay every subsequent slot, starting from the AccountsDB slot, until a bankhash mismatch")
+	Cmd.Flags().StringVar(&blockSourceType, "block-source", "rpc", "Source of blocks to replay: rpc, blockstore or json")
+	Cmd.Flags().StringVar(&blockSourcePath, "block-source-path", "", "Path of blockstore RocksDB or directory of getBlock JSON files")
+	Cmd.Flags().StringVar(&rpcEndpoint, "rpc", "https://api.mainnet-beta.solana.com", "RPC endpoint to fetch blocks and slot leaders from")
+	Cmd.Flags().StringVar(&bankHashSourceType, "bankhash-source", "none", "Source of expected bankhashes: none, file, manifest or blockstore")
+	Cmd.Flags().StringVar(&bankHashSourcePath, "bankhash-source-path", "", "Path of slot/bankhash pairs file, snapshot manifest or blockstore RocksDB")
+	Cmd.Flags().StringVar(&snapshotOutputDir, "snapshot-out", "", "Directory to write a snapshot archive of the AccountsDB to once replay has finished")
+	Cmd.Flags().Int64Var(&snapshotFullSlot, "snapshot-full-slot", -1, "Write an incremental snapshot relative to the full snapshot at this slot, rather than a full snapshot")
+	Cmd.Flags().Uint64Var(&cleanInterval, "clean-interval", 500, "Number of slots between cleans of the AccountsDB in continuous mode (0 to disable)")
+	Cmd.Flags().Uint64Var(&acctCacheSizeMb, "account-cache-size", accountsdb.DefaultAcctCacheSize/(1024*1024), "Size in MiB of the AccountsDB's cache of recently read accounts (0 to disable)")
+	Cmd.Flags().IntVar(&txParallelism, "tx-parallelism", runtime.NumCPU(), "Maximum number of a block's non-conflicting transactions to execute concurrently (1 for serial execution)")
+	Cmd.Flags().StringVar(&divergenceDir, "divergence-report-dir", "", "Directory to write a report of each slot's divergences from on-chain transaction metadata to")
+	Cmd.Flags().StringVar(&divergenceFormat, "divergence-report-format", "json", "Format of divergence reports: json or csv")
+	Cmd.Flags().Int64Var(&maxDivergences, "max-divergences", -1, "Stop replay and exit with an error once more than this many divergences have been found (-1 for no limit)")
+	Cmd.Flags().BoolVar(&checkSerialEquiv, "check-serial-equivalence", false, "Also execute each block's transactions serially, and stop replay if the outcome differs from parallel execution")
+	Cmd.Flags().BoolVar(&skipSigVerify, "skip-sigverify", false, "Skip verification of transaction signatures and precompile instructions when replaying trusted blocks")
 }
 
-func newBlockFromBlockResult(blockResult *rpc.GetBlockResult) (*replay.Block, error) {
-	block := new(replay.Block)
+func newBlockSource(rpcEndpointSet bool) (replay.BlockSource, error) {
+	switch blockSourceType {
+	case "rpc":
+		return replay.NewRpcBlockSource(rpcclient.NewRpcClient(rpcEndpoint)), nil
+
+	case "json":
+		if blockSourcePath == "" {
+			return nil, fmt.Errorf("must specify a directory of getBlock JSON files for the json block source")
+		}
+
+		// the leaders are only fetched via RPC if an endpoint is given explicitly, so that
+		// saved blocks can be replayed offline
+		var leaders replay.LeaderSource
+		if rpcEndpointSet {
+			leaders = rpcclient.NewRpcClient(rpcEndpoint)
+		}
+		return replay.NewJsonDirBlockSource(blockSourcePath, leaders)
+
+	case "blockstore":
+		if blockSourcePath == "" {
+			return nil, fmt.Errorf("must specify a blockstore RocksDB path for the blockstore block source")
+		}
+
+		db, err := blockstore.OpenReadOnly(blockSourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open blockstore: %w", err)
+		}
 
-	for _, tx := range blockResult.Transactions {
-		txParsed, err := tx.GetTransaction()
+		walker, err := blockstore.NewBlockWalk([]blockstore.WalkHandle{{DB: db}}, 2)
 		if err != nil {
 			return nil, err
 		}
-		block.Transactions = append(block.Transactions, txParsed)
-		block.TxMetas = append(block.TxMetas, tx.Meta)
+
+		// the blockstore does not record slot leaders, so they are fetched via RPC
+		return replay.NewBlockstoreBlockSource(walker, rpcclient.NewRpcClient(rpcEndpoint)), nil
+
+	default:
+		return nil, fmt.Errorf("unknown block source %s", blockSourceType)
 	}
+}
 
-	block.Blockhash = blockResult.Blockhash
-	block.ExpectedBankhash = base58.MustDecodeFromString("CbMK7uc68PPo5pPc5sfDJP6RGcwEcBJcrgZmuEZNEv6T")
+func newBankHashSource() (replay.BankHashSource, error) {
+	path := bankHashSourcePath
+	if path == "" && bankHashSourceType == "blockstore" {
+		path = blockSourcePath
+	}
+
+	switch bankHashSourceType {
+	case "none":
+		return nil, nil
+
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("must specify a file of slot and bankhash pairs for the file bankhash source")
+		}
+		return replay.NewBankHashFileSource(path)
+
+	case "manifest":
+		if path == "" {
+			return nil, fmt.Errorf("must specify a manifest file for the manifest bankhash source")
+		}
+
+		manifest, err := snapshot.LoadManifestFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return replay.NewManifestBankHashSource(manifest), nil
+
+	case "blockstore":
+		if path == "" {
+			return nil, fmt.Errorf("must specify a blockstore RocksDB path for the blockstore bankhash source")
+		}
+
+		db, err := blockstore.OpenReadOnly(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open blockstore: %w", err)
+		}
+		return replay.NewBlockstoreBankHashSource(db), nil
+
+	default:
+		return nil, fmt.Errorf("unknown bankhash source %s", bankHashSourceType)
+	}
+}
 
-	for _, tx := range block.Transactions {
-		block.NumSignatures += uint64(tx.Message.Header.NumRequiredSignatures)
+func setExpectedBankHash(bankHashSource replay.BankHashSource, block *replay.Block) error {
+	if bankHashSource == nil {
+		return nil
 	}
 
-	return block, nil
+	expectedBankHash, found, err := bankHashSource.ExpectedBankHash(block.Slot)
+	if err != nil {
+		return fmt.Errorf("error fetching expected bankhash for slot %d: %w", block.Slot, err)
+	}
+
+	if found {
+		block.ExpectedBankhash = expectedBankHash
+	} else {
+		klog.Infof("no expected bankhash available for slot %d", block.Slot)
+	}
+
+	return nil
 }
 
 func run(c *cobra.Command, args []string) {
@@ -68,13 +196,23 @@ func run(c *cobra.Command, args []string) {
 		return
 	}
 
-	if slot < 0 {
+	if slot < 0 && !continuous {
 		if loadFromAccountsDb {
 			klog.Errorf("must specify a slot at which to begin replaying")
 			return
 		}
 	}
 
+	if continuous && !updateAccountsDb {
+		klog.Errorf("continuous replay requires the AccountsDB to be updated after each block")
+		return
+	}
+
+	if snapshotOutputDir != "" && !updateAccountsDb {
+		klog.Errorf("writing a snapshot requires the AccountsDB to be updated after each block")
+		return
+	}
+
 	var err error
 	var accountsDbDir string
 
@@ -94,11 +232,6 @@ func run(c *cobra.Command, args []string) {
 
 		klog.Infof("successfully created accounts db from snapshot %s", path)
 
-		// just processing the snapshot - not executing blocks.
-		if slot < 0 {
-			return
-		}
-
 		accountsDbDir = outputDir
 	} else if loadFromAccountsDb {
 		if path == "" {
@@ -108,6 +241,22 @@ func run(c *cobra.Command, args []string) {
 		accountsDbDir = path
 	}
 
+	if incrementalPath != "" {
+		klog.Infof("applying incremental snapshot at %s to AccountsDB at %s", incrementalPath, accountsDbDir)
+
+		err = snapshot.ApplyIncrementalSnapshot(incrementalPath, accountsDbDir)
+		if err != nil {
+			klog.Exitf("failed to apply incremental snapshot %s: %s", incrementalPath, err)
+		}
+
+		klog.Infof("successfully applied incremental snapshot %s", incrementalPath)
+	}
+
+	// just processing the snapshot - not executing blocks.
+	if loadFromSnapshot && slot < 0 && !continuous {
+		return
+	}
+
 	klog.Infof("loading from AccountsDB at %s", accountsDbDir)
 
 	accountsDb, err := accountsdb.OpenDb(accountsDbDir)
@@ -116,37 +265,245 @@ func run(c *cobra.Command, args []string) {
 	}
 	defer accountsDb.CloseDb()
 
+	accountsDb.SetAcctCacheSize(acctCacheSizeMb * 1024 * 1024)
+
 	manifest, err := snapshot.LoadManifestFromFile(fmt.Sprintf("%s/manifest", accountsDbDir))
 	if err != nil {
 		klog.Fatalf("unable to open manifest file")
 	}
 
-	rpcc := rpcclient.NewRpcClient("https://api.mainnet-beta.solana.com")
-	blockResult, err := rpcc.GetBlockFinalized(uint64(slot))
+	// the AccountsDB is at the snapshot slot, unless blocks have since been replayed on top of it
+	parentSlot := manifest.Bank.Slot
+	if lastSlot, ok := accountsDb.LastSlot(); ok {
+		parentSlot = lastSlot
+	}
+	parentBankHash := accountsDb.BankHash()
+
+	if slot < 0 {
+		slot = int64(parentSlot + 1)
+	}
+
+	replayCtx, err := replay.NewReplayCtx(accountsDb, manifest)
 	if err != nil {
-		klog.Fatalf("error fetching block: %s\n", err)
+		klog.Fatalf("unable to create replay context: %s", err)
+	}
+	replayCtx.TxParallelism = txParallelism
+	replayCtx.CheckSerialEquivalence = checkSerialEquiv
+	replayCtx.SkipSigVerify = skipSigVerify
+
+	replayCtx.Divergences, err = replay.NewDivergenceCollector(divergenceDir, replay.DivergenceReportFormat(divergenceFormat))
+	if err != nil {
+		klog.Fatalf("%s", err)
 	}
 
-	block, err := newBlockFromBlockResult(blockResult)
+	blockSource, err := newBlockSource(c.Flags().Changed("rpc"))
 	if err != nil {
-		klog.Fatalf("error creating block from BlockResult: %s\n", err)
+		klog.Fatalf("unable to create block source: %s", err)
+	}
+
+	bankHashSource, err := newBankHashSource()
+	if err != nil {
+		klog.Fatalf("unable to create bankhash source: %s", err)
+	}
+
+	if continuous {
+		err = replayContinuously(c.Context(), blockSource, bankHashSource, replayCtx, parentSlot, parentBankHash)
+		if err != nil {
+			klog.Exitf("%s", err)
+		}
+		writeSnapshot(replayCtx)
+		return
 	}
 
-	leader, err := rpcc.GetLeaderForSlot(uint64(slot))
+	block, err := blockSource.GetBlock(uint64(slot))
 	if err != nil {
-		klog.Fatalf("error fetching leader for slot: %s\n", err)
+		klog.Fatalf("error fetching block: %s\n", err)
 	}
 
-	block.Slot = uint64(slot)
-	block.ParentBankhash = manifest.Bank.Hash
+	block.ParentSlot = parentSlot
+	block.ParentBankhash = parentBankHash
 	block.Manifest = manifest
-	block.Leader = leader
-	block.Reward = replay.BlockRewardsInfo{Leader: blockResult.Rewards[0].Pubkey, Lamports: uint64(blockResult.Rewards[0].Lamports), PostBalance: blockResult.Rewards[0].PostBalance}
 
-	err = replay.ProcessBlock(accountsDb, block, updateAccountsDb)
+	err = setExpectedBankHash(bankHashSource, block)
 	if err != nil {
-		klog.Errorf("error encountered during block replay: %s\n", err)
+		klog.Fatalf("%s", err)
+	}
+
+	err = replay.ProcessBlock(replayCtx, block, updateAccountsDb)
+	logDivergences(replayCtx)
+	if divergenceErr := checkDivergences(replayCtx); divergenceErr != nil {
+		klog.Exitf("%s", divergenceErr)
+	}
+
+	var mismatchErr *replay.BankHashMismatchError
+	if errors.As(err, &mismatchErr) {
+		klog.Exitf("%s", mismatchErr)
+	} else if err != nil {
+		klog.Exitf("error encountered during block replay: %s", err)
 	} else {
 		klog.Infof("block replayed successfully.\n")
+		writeSnapshot(replayCtx)
 	}
 }
+
+func logCacheStats(accountsDb *accountsdb.AccountsDb) {
+	stats := accountsDb.AcctCacheStats()
+	klog.Infof("account cache: %.2f%% hit rate (%d hits, %d misses), %d accounts cached using %d bytes",
+		stats.HitRate()*100, stats.Hits, stats.Misses, stats.NumAccts, stats.SizeBytes)
+
+	programStats := sealevel.LoadedProgramsStats()
+	klog.Infof("loaded-program cache: %d hits, %d misses, %d evictions, %d invalidations, %d programs cached using %d bytes",
+		programStats.Hits, programStats.Misses, programStats.Evictions, programStats.Invalidations, programStats.NumPrograms, programStats.SizeBytes)
+}
+
+func logDivergences(replayCtx *replay.ReplayCtx) {
+	numDivergences, numByKind := replayCtx.Divergences.NumDivergences()
+	klog.Infof("found %d divergences from on-chain transaction metadata: %v", numDivergences, numByKind)
+}
+
+// checkDivergences returns an error if the number of divergences found exceeds the threshold.
+func checkDivergences(replayCtx *replay.ReplayCtx) error {
+	numDivergences, _ := replayCtx.Divergences.NumDivergences()
+	if maxDivergences >= 0 && numDivergences > uint64(maxDivergences) {
+		return fmt.Errorf("found %d divergences from on-chain transaction metadata, exceeding the maximum of %d", numDivergences, maxDivergences)
+	}
+	return nil
+}
+
+func cleanAccountsDb(replayCtx *replay.ReplayCtx, slot uint64) error {
+	// the accounts modified since the full snapshot slot, including zero-lamport accounts,
+	// are needed in order to write an incremental snapshot.
+	maxCleanSlot := slot
+	if snapshotOutputDir != "" && snapshotFullSlot >= 0 {
+		maxCleanSlot = min(maxCleanSlot, uint64(snapshotFullSlot))
+	}
+
+	start := time.Now()
+	stats, err := replayCtx.CleanAccountsDb(maxCleanSlot)
+	if err != nil {
+		return fmt.Errorf("failed to clean AccountsDB: %w", err)
+	}
+
+	klog.Infof("cleaned AccountsDB up to slot %d in %s: removed %d accounts (%d zero-lamport), shrunk %d and deleted %d appendvecs, reclaiming %d bytes",
+		maxCleanSlot, time.Since(start), stats.NumAcctsRemoved, stats.NumZeroLamportRemoved, stats.NumFilesShrunk, stats.NumFilesDeleted, stats.BytesReclaimed)
+
+	return nil
+}
+
+func writeSnapshot(replayCtx *replay.ReplayCtx) {
+	if snapshotOutputDir == "" {
+		return
+	}
+
+	var archivePath string
+	var err error
+	if snapshotFullSlot >= 0 {
+		archivePath, err = replayCtx.CreateIncrementalSnapshot(uint64(snapshotFullSlot), snapshotOutputDir)
+	} else {
+		archivePath, err = replayCtx.CreateFullSnapshot(snapshotOutputDir)
+	}
+
+	if err != nil {
+		klog.Exitf("failed to write snapshot: %s", err)
+	}
+
+	klog.Infof("wrote snapshot %s", archivePath)
+}
+
+// replayContinuously replays every slot following parentSlot in order, skipping slots for
+// which no block was produced, and chaining each computed bankhash into the next block.
+// It returns upon the first bankhash mismatch, upon reaching endSlot, or when ctx is cancelled.
+func replayContinuously(ctx context.Context, blockSource replay.BlockSource, bankHashSource replay.BankHashSource, replayCtx *replay.ReplayCtx, parentSlot uint64, parentBankHash [32]byte) error {
+	startSlot := uint64(slot)
+	if startSlot <= parentSlot {
+		return fmt.Errorf("start slot %d must be after the AccountsDB slot %d", startSlot, parentSlot)
+	}
+
+	klog.Infof("replaying continuously from slot %d (parent slot %d, parent bankhash %s)", startSlot, parentSlot, base58.Encode(parentBankHash[:]))
+
+	var numReplayed uint64
+	var numSkipped uint64
+	start := time.Now()
+
+	for currentSlot := startSlot; endSlot < 0 || currentSlot <= uint64(endSlot); {
+		if ctx.Err() != nil {
+			klog.Infof("replay interrupted at slot %d", currentSlot)
+			break
+		}
+
+		block, err := blockSource.GetBlock(currentSlot)
+		if err == replay.ErrNoMoreBlocks {
+			klog.Infof("no more blocks available from block source after slot %d", parentSlot)
+			break
+		} else if err == replay.ErrSlotSkipped {
+			klog.Infof("slot %d was skipped, moving on to next slot", currentSlot)
+			numSkipped++
+			currentSlot++
+			continue
+		} else if err == replay.ErrBlockNotAvailable {
+			klog.Infof("block for slot %d not yet available, retrying", currentSlot)
+			select {
+			case <-ctx.Done():
+			case <-time.After(blockNotAvailableRetryInterval):
+			}
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error fetching block for slot %d: %w", currentSlot, err)
+		}
+
+		block.ParentSlot = parentSlot
+		block.ParentBankhash = parentBankHash
+		block.Manifest = replayCtx.Manifest
+
+		err = setExpectedBankHash(bankHashSource, block)
+		if err != nil {
+			return err
+		}
+
+		err = replay.ProcessBlock(replayCtx, block, true)
+		var mismatchErr *replay.BankHashMismatchError
+		if errors.As(err, &mismatchErr) {
+			klog.Errorf("bankhash mismatch at slot %d:\n"+
+				"\tparent slot:          %d\n"+
+				"\tparent bankhash:      %s\n"+
+				"\tcalculated bankhash:  %s\n"+
+				"\texpected bankhash:    %s\n"+
+				"\treplayed %d slots (%d skipped) before mismatch",
+				mismatchErr.Slot, mismatchErr.ParentSlot, base58.Encode(mismatchErr.ParentBankHash[:]),
+				base58.Encode(mismatchErr.Calculated[:]), base58.Encode(mismatchErr.Expected[:]), numReplayed, numSkipped)
+			return mismatchErr
+		} else if err != nil {
+			return fmt.Errorf("error encountered during replay of slot %d: %w", currentSlot, err)
+		}
+
+		klog.Infof("replayed slot %d, bankhash %s", currentSlot, base58.Encode(block.BankHash[:]))
+
+		err = checkDivergences(replayCtx)
+		if err != nil {
+			return err
+		}
+
+		parentSlot = currentSlot
+		parentBankHash = block.BankHash
+		numReplayed++
+		currentSlot++
+
+		if cleanInterval != 0 && numReplayed%cleanInterval == 0 {
+			err = cleanAccountsDb(replayCtx, parentSlot)
+			if err != nil {
+				return err
+			}
+		}
+
+		if numReplayed%statsLogInterval == 0 {
+			logCacheStats(replayCtx.AccountsDb)
+		}
+	}
+
+	klog.Infof("replayed %d slots (%d skipped) in %s. last slot %d, bankhash %s", numReplayed, numSkipped,
+		time.Since(start), parentSlot, base58.Encode(parentBankHash[:]))
+	logCacheStats(replayCtx.AccountsDb)
+	logDivergences(replayCtx)
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"go.firedancer.io/radiance/cmd/radiance/blockstore"
 	"go.firedancer.io/radiance/cmd/radiance/gossip"
 	"go.firedancer.io/radiance/cmd/radiance/replay"
+	"go.firedancer.io/radiance/cmd/radiance/tool"
 	"k8s.io/klog/v2"
 
 	// Load in instruction pretty-printing
@@ -31,6 +32,7 @@ func init() {
 		&blockstore.Cmd,
 		&gossip.Cmd,
 		&replay.Cmd,
+		&tool.Cmd,
 	)
 }
 
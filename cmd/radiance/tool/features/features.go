@@ -0,0 +1,124 @@
+package features
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	featuregates "go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+var (
+	Cmd = cobra.Command{
+		Use:   "features",
+		Short: "List the feature gates active in an AccountsDB or snapshot, and those not implemented by mithril",
+		Args:  cobra.NoArgs,
+		Run:   run,
+	}
+
+	accountsDbPath string
+	snapshotPath   string
+)
+
+func init() {
+	Cmd.Flags().StringVar(&accountsDbPath, "accountsdb", "", "Path of AccountsDB to read feature gate accounts from")
+	Cmd.Flags().StringVar(&snapshotPath, "snapshot", "", "Path of full snapshot to read feature gate accounts from")
+}
+
+type featureStatus struct {
+	gate           featuregates.FeatureGate
+	status         string
+	activationSlot uint64
+}
+
+func run(c *cobra.Command, args []string) {
+	if (accountsDbPath == "") == (snapshotPath == "") {
+		klog.Exitf("must specify exactly one of an AccountsDB directory path or a snapshot path")
+	}
+
+	// a snapshot is read by building a temporary AccountsDB from it
+	if snapshotPath != "" {
+		dir, err := os.MkdirTemp("", "radiance-features-")
+		if err != nil {
+			klog.Exitf("unable to create temporary directory: %s", err)
+		}
+		defer os.RemoveAll(dir)
+
+		klog.Infof("building AccountsDB from snapshot at %s", snapshotPath)
+		err = snapshot.BuildAccountsIndexFromSnapshot(snapshotPath, dir)
+		if err != nil {
+			klog.Exitf("failed to build AccountsDB from snapshot %s: %s", snapshotPath, err)
+		}
+		accountsDbPath = dir
+	}
+
+	accountsDb, err := accountsdb.OpenDb(accountsDbPath)
+	if err != nil {
+		klog.Exitf("unable to open accounts db %s: %s", accountsDbPath, err)
+	}
+	defer accountsDb.CloseDb()
+
+	manifest, err := snapshot.LoadManifestFromFile(fmt.Sprintf("%s/manifest", accountsDbPath))
+	if err != nil {
+		klog.Exitf("unable to open manifest of accounts db %s: %s", accountsDbPath, err)
+	}
+	slot := manifest.Bank.Slot
+
+	statuses := make([]featureStatus, 0, len(featuregates.AllFeatureGates))
+	for _, gate := range featuregates.AllFeatureGates {
+		statuses = append(statuses, readFeatureStatus(accountsDb, gate, slot))
+	}
+
+	fmt.Printf("feature gates as of slot %d:\n\n", slot)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tADDRESS\tSTATUS\tACTIVATION SLOT\tIMPLEMENTED")
+	for _, s := range statuses {
+		if s.status == "inactive" {
+			continue
+		}
+		activationSlot := "-"
+		if s.status == "active" || s.status == "scheduled" {
+			activationSlot = fmt.Sprintf("%d", s.activationSlot)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%t\n", s.gate.Name, solana.PublicKeyFromBytes(s.gate.Address[:]), s.status, activationSlot, s.gate.Implemented)
+	}
+	writer.Flush()
+
+	fmt.Printf("\nfeature gates not implemented by mithril:\n\n")
+	writer = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tADDRESS\tSTATUS\tDESCRIPTION")
+	for _, s := range statuses {
+		if !s.gate.Implemented {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", s.gate.Name, solana.PublicKeyFromBytes(s.gate.Address[:]), s.status, s.gate.Description)
+		}
+	}
+	writer.Flush()
+}
+
+// readFeatureStatus determines the status of a feature gate as of the given slot from its
+// account: active, pending activation at the next epoch boundary, scheduled for activation
+// after the slot, or inactive if it has no account.
+func readFeatureStatus(accountsDb *accountsdb.AccountsDb, gate featuregates.FeatureGate, slot uint64) featureStatus {
+	acct, err := accountsDb.GetAccount(solana.PublicKeyFromBytes(gate.Address[:]))
+	if err != nil || acct.Lamports == 0 || acct.Owner != featuregates.FeatureProgramAddr {
+		return featureStatus{gate: gate, status: "inactive"}
+	}
+
+	feature, err := featuregates.UnmarshalFeature(acct.Data)
+	if err != nil {
+		klog.Warningf("unable to parse account of feature %s: %s", gate.Name, err)
+		return featureStatus{gate: gate, status: "invalid"}
+	}
+
+	if feature.ActivatedAt == nil {
+		return featureStatus{gate: gate, status: "pending"}
+	} else if *feature.ActivatedAt > slot {
+		return featureStatus{gate: gate, status: "scheduled", activationSlot: *feature.ActivatedAt}
+	}
+	return featureStatus{gate: gate, status: "active", activationSlot: *feature.ActivatedAt}
+}
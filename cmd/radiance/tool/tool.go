@@ -1,6 +1,9 @@
 package tool
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/spf13/cobra"
+	"go.firedancer.io/radiance/cmd/radiance/tool/features"
+)
 
 var Cmd = cobra.Command{
 	Use:   "tool",
@@ -8,5 +11,7 @@ var Cmd = cobra.Command{
 }
 
 func init() {
-	Cmd.AddCommand()
+	Cmd.AddCommand(
+		&features.Cmd,
+	)
 }
@@ -120,3 +120,13 @@ func (a *Account) MarshalWithEncoder(encoder *bin.Encoder) error {
 	_ = encoder.WriteBool(a.Executable)
 	return encoder.WriteUint64(a.RentEpoch, bin.LE)
 }
+
+// Clone returns a deep copy of the account.
+func (a *Account) Clone() *Account {
+	clone := *a
+	if a.Data != nil {
+		clone.Data = make([]byte, len(a.Data))
+		copy(clone.Data, a.Data)
+	}
+	return &clone
+}
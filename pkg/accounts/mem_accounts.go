@@ -2,22 +2,29 @@ package accounts
 
 import (
 	"fmt"
+	"sync"
 
 	"go.firedancer.io/radiance/pkg/base58"
 )
 
+// MemAccounts is an in-memory set of accounts, which is safe for concurrent use.
 type MemAccounts struct {
-	Map map[[32]byte]*Account
+	Map  map[[32]byte]*Account
+	lock *sync.RWMutex
 }
 
 func NewMemAccounts() MemAccounts {
 	return MemAccounts{
-		Map: make(map[[32]byte]*Account),
+		Map:  make(map[[32]byte]*Account),
+		lock: new(sync.RWMutex),
 	}
 }
 
 func (m MemAccounts) GetAccount(pubkey *[32]byte) (*Account, error) {
+	m.lock.RLock()
 	acct, ok := m.Map[*pubkey]
+	m.lock.RUnlock()
+
 	if !ok {
 		return nil, fmt.Errorf("no such account %s found", base58.Encode(pubkey[:]))
 	}
@@ -25,6 +32,20 @@ func (m MemAccounts) GetAccount(pubkey *[32]byte) (*Account, error) {
 }
 
 func (m MemAccounts) SetAccount(pubkey *[32]byte, acct *Account) error {
+	m.lock.Lock()
 	m.Map[*pubkey] = acct
+	m.lock.Unlock()
 	return nil
 }
+
+// Clone returns a deep copy of the accounts.
+func (m MemAccounts) Clone() MemAccounts {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	clone := NewMemAccounts()
+	for pubkey, acct := range m.Map {
+		clone.Map[pubkey] = acct.Clone()
+	}
+	return clone
+}
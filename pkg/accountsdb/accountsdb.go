@@ -5,25 +5,61 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/Overclock-Validator/sniper"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/accounts"
+	"k8s.io/klog/v2"
 )
 
 type AccountsDb struct {
 	indexDb       *sniper.Store
+	dbDir         string
 	acctsDir      string
 	indexDir      string
 	largestFileId atomic.Uint64
 	bankHash      [32]byte
+	lastSlot      uint64
+	hasLastSlot   bool
+
+	// serialises index updates between StoreAccounts and Clean
+	indexLock sync.Mutex
+
+	// opened upon first use, and guarded by indexLock (see PubkeysInRangeAtSlot)
+	rangeIndexDb *sniper.Store
+
+	// snapshots of the index that are yet to be released, guarded by indexLock
+	indexSnapshots []*IndexSnapshot
+
+	// appendvec files removed by Clean are only deleted once no readers retain them
+	retainLock     sync.Mutex
+	numRetainers   int
+	pendingDeletes []AppendVecInfo
+
+	cleanLock sync.Mutex
+	cleanSlot uint64
+
+	appendVecPool *appendVecPool
+	acctCache     *acctCache
+
+	// accounts written in slots that are yet to be rooted, layered over the rooted state
+	forkLock sync.RWMutex
+	unrooted map[uint64]*unrootedSlot
+	rootSlot uint64
 }
 
 var (
-	ErrNoAccount = errors.New("ErrNoAccount")
+	ErrNoAccount         = errors.New("ErrNoAccount")
+	ErrCorruptIndexEntry = errors.New("ErrCorruptIndexEntry")
+	ErrCorruptAppendVec  = errors.New("ErrCorruptAppendVec")
 )
 
 func OpenDb(accountsDbDir string) (*AccountsDb, error) {
@@ -72,6 +108,33 @@ func OpenDb(accountsDbDir string) (*AccountsDb, error) {
 		return nil, fmt.Errorf("only got %d bytes", bytesRead)
 	}
 
+	// the last_slot file is only written once blocks have been replayed on top of the snapshot,
+	// so its absence is not an error.
+	var lastSlot uint64
+	var hasLastSlot bool
+	lastSlotBytes, err := os.ReadFile(fmt.Sprintf("%s/last_slot", accountsDbDir))
+	if err == nil {
+		if len(lastSlotBytes) != 8 {
+			return nil, fmt.Errorf("last_slot file had %d bytes rather than 8", len(lastSlotBytes))
+		}
+		lastSlot = binary.LittleEndian.Uint64(lastSlotBytes)
+		hasLastSlot = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// likewise, the clean_slot file is only written once the AccountsDB has been cleaned
+	var cleanSlot uint64
+	cleanSlotBytes, err := os.ReadFile(fmt.Sprintf("%s/clean_slot", accountsDbDir))
+	if err == nil {
+		if len(cleanSlotBytes) != 8 {
+			return nil, fmt.Errorf("clean_slot file had %d bytes rather than 8", len(cleanSlotBytes))
+		}
+		cleanSlot = binary.LittleEndian.Uint64(cleanSlotBytes)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
 	// attempt to open the index kv store
 	indexDir := fmt.Sprintf("%s/index", accountsDbDir)
 	db, err := sniper.Open(sniper.Dir(indexDir), sniper.ChunksCollision(32))
@@ -80,18 +143,60 @@ func OpenDb(accountsDbDir string) (*AccountsDb, error) {
 		return nil, err
 	}
 
-	accountsDb := &AccountsDb{indexDb: db, acctsDir: appendVecsDir, indexDir: indexDir}
+	accountsDb := &AccountsDb{indexDb: db, dbDir: accountsDbDir, acctsDir: appendVecsDir, indexDir: indexDir, lastSlot: lastSlot,
+		hasLastSlot: hasLastSlot, cleanSlot: cleanSlot, unrooted: make(map[uint64]*unrootedSlot), rootSlot: lastSlot,
+		appendVecPool: newAppendVecPool(appendVecsDir, DefaultMaxMappedAppendVecs), acctCache: newAcctCache(DefaultAcctCacheSize)}
 	accountsDb.largestFileId.Store(largestFileId)
 	copy(accountsDb.bankHash[:], bankHashBytes)
 
 	return accountsDb, nil
 }
 
+// CreateDb creates an empty AccountsDB in accountsDbDir, and opens it.
+func CreateDb(accountsDbDir string) (*AccountsDb, error) {
+	for _, dir := range []string{"accounts", "index"} {
+		err := os.MkdirAll(fmt.Sprintf("%s/%s", accountsDbDir, dir), 0775)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := os.WriteFile(fmt.Sprintf("%s/largest_file_id", accountsDbDir), make([]byte, 8), 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(fmt.Sprintf("%s/bank_hash", accountsDbDir), make([]byte, 32), 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenDb(accountsDbDir)
+}
+
 func (accountsDb *AccountsDb) CloseDb() {
 	accountsDb.indexDb.Close()
+	if accountsDb.rangeIndexDb != nil {
+		accountsDb.rangeIndexDb.Close()
+	}
+	accountsDb.appendVecPool.close()
+}
+
+// SetAcctCacheSize sets the maximum size in bytes of the accounts held in the account
+// cache. A size of zero disables the cache.
+func (accountsDb *AccountsDb) SetAcctCacheSize(maxBytes uint64) {
+	accountsDb.acctCache.resize(maxBytes)
+}
+
+// AcctCacheStats returns the account cache's hit and miss counts and current size.
+func (accountsDb *AccountsDb) AcctCacheStats() AcctCacheStats {
+	return accountsDb.acctCache.stats()
 }
 
 func (accountsDb *AccountsDb) GetAccount(pubkey solana.PublicKey) (*accounts.Account, error) {
+	release := accountsDb.RetainAppendVecs()
+	defer release()
+
 	acctIdxEntryBytes, err := accountsDb.indexDb.Get(pubkey[:])
 	if err != nil {
 		return nil, ErrNoAccount
@@ -99,36 +204,50 @@ func (accountsDb *AccountsDb) GetAccount(pubkey solana.PublicKey) (*accounts.Acc
 
 	acctIdxEntry, err := unmarshalAcctIdxEntry(acctIdxEntryBytes)
 	if err != nil {
-		panic("failed to unmarshal AccountIndexEntry from index kv database")
+		return nil, fmt.Errorf("%w: account %s: %s", ErrCorruptIndexEntry, pubkey, err)
+	}
+
+	acct, cached := accountsDb.acctCache.get(pubkey, acctIdxEntry)
+	if cached {
+		return acct, nil
 	}
 
-	appendVecFileName := fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, acctIdxEntry.Slot, acctIdxEntry.FileId)
-	appendVecFile, err := os.Open(appendVecFileName)
+	acct, err = accountsDb.readAccount(pubkey, acctIdxEntry)
 	if err != nil {
 		return nil, err
 	}
-	defer appendVecFile.Close()
 
-	offset, err := appendVecFile.Seek(int64(acctIdxEntry.Offset), 0)
+	accountsDb.acctCache.put(acctIdxEntry, acct)
+
+	return acct, nil
+}
+
+// readAccount reads the account at the given index entry from its memory-mapped appendvec.
+func (accountsDb *AccountsDb) readAccount(pubkey solana.PublicKey, acctIdxEntry *AccountIndexEntry) (*accounts.Account, error) {
+	data, releaseAppendVec, err := accountsDb.appendVecPool.acquire(AppendVecInfo{Slot: acctIdxEntry.Slot, FileId: acctIdxEntry.FileId})
 	if err != nil {
-		panic(fmt.Sprintf("file seek failed: %s\n", err))
-	}
-	if offset != int64(acctIdxEntry.Offset) {
-		panic(fmt.Sprintf("file seek gave wrong idx (%d)\n", offset))
+		return nil, err
 	}
+	defer releaseAppendVec()
 
-	acct, err := unmarshalAcctFromAppendVecAcctHeader(appendVecFile)
-	if err != nil {
-		panic(fmt.Sprintf("failed to unmarshal account from appendvec file %s: %s", appendVecFileName, err))
+	parser := &appendVecParser{Buf: data, FileSize: uint64(len(data)), Offset: acctIdxEntry.Offset,
+		FileId: acctIdxEntry.FileId, Slot: acctIdxEntry.Slot}
+
+	acct, _, err := parser.ReadNextAcct()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no account at offset %d of appendvec %d.%d", ErrCorruptAppendVec, acctIdxEntry.Offset, acctIdxEntry.Slot, acctIdxEntry.FileId)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: failed to read account at offset %d of appendvec %d.%d: %s", ErrCorruptAppendVec, acctIdxEntry.Offset, acctIdxEntry.Slot, acctIdxEntry.FileId, err)
 	}
 
 	if acct.Key != pubkey {
-		panic(fmt.Sprintf("account unmarshaled from appendvec file %s has the wrong pubkey", appendVecFileName))
+		return nil, fmt.Errorf("%w: account at offset %d of appendvec %d.%d is %s rather than %s", ErrCorruptAppendVec, acctIdxEntry.Offset, acctIdxEntry.Slot, acctIdxEntry.FileId, acct.Key, pubkey)
 	}
 
-	acct.Slot = acctIdxEntry.Slot
+	// the account's data references the mapping, which may be unmapped once released
+	acct.Data = bytes.Clone(acct.Data)
 
-	return acct, err
+	return acct, nil
 }
 
 func (accountsDb *AccountsDb) StoreAccounts(accts []*accounts.Account, slot uint64) error {
@@ -146,48 +265,272 @@ func (accountsDb *AccountsDb) StoreAccounts(accts []*accounts.Account, slot uint
 	appendVecAcctsBuf := new(bytes.Buffer)
 	appendVecAcctsBuf.Grow(int(marshaledSize))
 
+	indexEntries := make([]AccountIndexEntry, len(accts))
+
+	for idx, acct := range accts {
+		// offset field is specified as the current num of bytes written to the appendvec buffer.
+		indexEntries[idx] = AccountIndexEntry{Slot: slot, FileId: fileId, Offset: uint64(appendVecAcctsBuf.Len())}
+
+		// marshal up the account as an appendvec style account and write it to the buffer
+		appendVecAcct := AppendVecAccount{DataLen: uint64(len(acct.Data)), Pubkey: acct.Key, Lamports: acct.Lamports,
+			RentEpoch: acct.RentEpoch, Owner: acct.Owner, Executable: acct.Executable, Data: acct.Data}
+
+		err = appendVecAcct.Marshal(appendVecAcctsBuf)
+		if err != nil {
+			return err
+		}
+	}
+
+	// write the appendvecs data into the file before the index entries referring to it are
+	// written, such that readers never observe a partially written appendvec.
+	n, err := appendVecFile.Write(appendVecAcctsBuf.Bytes())
+	if err != nil {
+		return err
+	} else if n != appendVecAcctsBuf.Len() {
+		return fmt.Errorf("only wrote %d appendvec account bytes, rather than %d", n, appendVecAcctsBuf.Len())
+	}
+
 	indexWriter := new(bytes.Buffer)
 	indexWriter.Grow(24)
 	indexEncoder := bin.NewBinEncoder(indexWriter)
 
-	for _, acct := range accts {
+	accountsDb.indexLock.Lock()
+	defer accountsDb.indexLock.Unlock()
 
-		// create index entry, encode it and write it to the index kv store
-		// offset field is specified as the current num of bytes written to the appendvec buffer.
-		indexWriter.Reset()
-		indexEntry := AccountIndexEntry{Slot: slot, FileId: fileId, Offset: uint64(appendVecAcctsBuf.Len())}
+	var newPubkeys []solana.PublicKey
 
-		err = indexEntry.MarshalWithEncoder(indexEncoder)
+	for idx, acct := range accts {
+		// encode the index entry and write it to the index kv store
+		indexWriter.Reset()
+		err = indexEntries[idx].MarshalWithEncoder(indexEncoder)
 		if err != nil {
 			return err
 		}
 
+		if accountsDb.rangeIndexDb != nil || len(accountsDb.indexSnapshots) != 0 {
+			current, err := accountsDb.indexEntry(acct.Key)
+			if err != nil {
+				return err
+			}
+			if current == nil && accountsDb.rangeIndexDb != nil {
+				newPubkeys = append(newPubkeys, acct.Key)
+			}
+			accountsDb.preserveIndexEntryLocked(acct.Key, current)
+		}
+
 		err = accountsDb.indexDb.Set(acct.Key[:], indexWriter.Bytes(), 0)
 		if err != nil {
 			return err
 		}
 
-		// marshal up the account as an appendvec style account and write it to the buffer
-		appendVecAcct := AppendVecAccount{DataLen: uint64(len(acct.Data)), Pubkey: acct.Key, Lamports: acct.Lamports,
-			RentEpoch: acct.RentEpoch, Owner: acct.Owner, Executable: acct.Executable, Data: acct.Data}
+		// the cached version is superseded, and would no longer be returned anyway
+		accountsDb.acctCache.remove(acct.Key)
+	}
 
-		err = appendVecAcct.Marshal(appendVecAcctsBuf)
+	// accounts not previously in the index are added to the range index, if it has been built
+	for _, pubkey := range newPubkeys {
+		err = accountsDb.addToRangeIndexLocked(rangeIndexPrefix(pubkey), []solana.PublicKey{pubkey})
 		if err != nil {
 			return err
 		}
 	}
 
-	// write the appendvecs data into the file
-	n, err := appendVecFile.Write(appendVecAcctsBuf.Bytes())
+	return nil
+}
+
+// AppendVecInfo identifies an appendvec file in the AccountsDB's accounts directory.
+type AppendVecInfo struct {
+	Slot   uint64
+	FileId uint64
+}
+
+// AppendVecs returns all appendvec files currently in the AccountsDB, ordered by slot and
+// then by file ID, i.e. from oldest to newest.
+func (accountsDb *AccountsDb) AppendVecs() ([]AppendVecInfo, error) {
+	dirEntries, err := os.ReadDir(accountsDb.acctsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	appendVecs := make([]AppendVecInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		slotStr, fileIdStr, found := strings.Cut(dirEntry.Name(), ".")
+		if dirEntry.IsDir() || !found {
+			continue
+		}
+
+		slot, err := strconv.ParseUint(slotStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		fileId, err := strconv.ParseUint(fileIdStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		appendVecs = append(appendVecs, AppendVecInfo{Slot: slot, FileId: fileId})
+	}
+
+	sort.Slice(appendVecs, func(i, j int) bool {
+		if appendVecs[i].Slot != appendVecs[j].Slot {
+			return appendVecs[i].Slot < appendVecs[j].Slot
+		}
+		return appendVecs[i].FileId < appendVecs[j].FileId
+	})
+
+	return appendVecs, nil
+}
+
+// ScanAppendVec calls fn for every account stored in the given appendvec, including
+// accounts that have since been superseded by a newer version. The account passed to fn
+// references a buffer that is only valid for the duration of the call. Callers scanning
+// the appendvecs returned by AppendVecs should retain them via RetainAppendVecs.
+func (accountsDb *AccountsDb) ScanAppendVec(appendVec AppendVecInfo, fn func(acct *accounts.Account, entry *AccountIndexEntry) error) error {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, appendVec.Slot, appendVec.FileId))
 	if err != nil {
 		return err
-	} else if n != appendVecAcctsBuf.Len() {
-		return fmt.Errorf("only wrote %d appendvec account bytes, rather than %d", n, appendVecAcctsBuf.Len())
 	}
 
-	return nil
+	parser := &appendVecParser{Buf: data, FileSize: uint64(len(data)), FileId: appendVec.FileId, Slot: appendVec.Slot}
+
+	for {
+		acct, entry, err := parser.ReadNextAcct()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading appendvec %d.%d at offset %d: %w", appendVec.Slot, appendVec.FileId, parser.Offset, err)
+		}
+
+		err = fn(acct, entry)
+		if err != nil {
+			return err
+		}
+	}
 }
 
 func (accountsDb *AccountsDb) BankHash() [32]byte {
 	return accountsDb.bankHash
 }
+
+// LastSlot returns the last slot replayed on top of the AccountsDB, and false if
+// no blocks have been replayed since it was built from a snapshot.
+func (accountsDb *AccountsDb) LastSlot() (uint64, bool) {
+	return accountsDb.lastSlot, accountsDb.hasLastSlot
+}
+
+// SetBankHash records the bank hash of a replayed slot, persisting it alongside the
+// slot number so that replay can be resumed from the AccountsDB at a later time.
+func (accountsDb *AccountsDb) SetBankHash(slot uint64, bankHash [32]byte) error {
+	var lastSlotBytes [8]byte
+	binary.LittleEndian.PutUint64(lastSlotBytes[:], slot)
+
+	err := os.WriteFile(fmt.Sprintf("%s/bank_hash", accountsDb.dbDir), bankHash[:], 0666)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(fmt.Sprintf("%s/last_slot", accountsDb.dbDir), lastSlotBytes[:], 0666)
+	if err != nil {
+		return err
+	}
+
+	accountsDb.bankHash = bankHash
+	accountsDb.lastSlot = slot
+	accountsDb.hasLastSlot = true
+
+	return nil
+}
+
+// EpochAccountsHashInfo records an epoch accounts hash (EAH) calculation started whilst
+// replaying on top of the AccountsDB, over the accounts state as of Slot, and its result
+// once Done.
+type EpochAccountsHashInfo struct {
+	Epoch uint64
+	Slot  uint64
+	Done  bool
+	Hash  [32]byte
+}
+
+const epochAcctsHashInfoLen = 8 + 8 + 1 + 32
+
+// SetEpochAccountsHash persists the state of an EAH calculation alongside the last slot, such
+// that an EAH started before replay is resumed from the AccountsDB can be mixed into the
+// bankhash at the EAH stop slot.
+func (accountsDb *AccountsDb) SetEpochAccountsHash(info *EpochAccountsHashInfo) error {
+	var infoBytes [epochAcctsHashInfoLen]byte
+	binary.LittleEndian.PutUint64(infoBytes[0:], info.Epoch)
+	binary.LittleEndian.PutUint64(infoBytes[8:], info.Slot)
+	if info.Done {
+		infoBytes[16] = 1
+	}
+	copy(infoBytes[17:], info.Hash[:])
+
+	return os.WriteFile(fmt.Sprintf("%s/epoch_accounts_hash", accountsDb.dbDir), infoBytes[:], 0666)
+}
+
+// EpochAccountsHash returns the state of the most recently started EAH calculation persisted
+// by SetEpochAccountsHash, and nil if none has been.
+func (accountsDb *AccountsDb) EpochAccountsHash() (*EpochAccountsHashInfo, error) {
+	infoBytes, err := os.ReadFile(fmt.Sprintf("%s/epoch_accounts_hash", accountsDb.dbDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(infoBytes) != epochAcctsHashInfoLen {
+		return nil, fmt.Errorf("epoch_accounts_hash file had %d bytes rather than %d", len(infoBytes), epochAcctsHashInfoLen)
+	}
+
+	info := &EpochAccountsHashInfo{Epoch: binary.LittleEndian.Uint64(infoBytes[0:]), Slot: binary.LittleEndian.Uint64(infoBytes[8:]),
+		Done: infoBytes[16] != 0}
+	copy(info.Hash[:], infoBytes[17:])
+
+	return info, nil
+}
+
+// RetainAppendVecs prevents appendvec files from being deleted by Clean until the returned
+// release function is called, so that appendvecs that have been listed by AppendVecs or
+// referenced by the index remain readable. Files that Clean has removed in the meantime
+// are deleted once the last retainer releases them.
+func (accountsDb *AccountsDb) RetainAppendVecs() (release func()) {
+	accountsDb.retainLock.Lock()
+	accountsDb.numRetainers++
+	accountsDb.retainLock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			accountsDb.retainLock.Lock()
+			defer accountsDb.retainLock.Unlock()
+
+			accountsDb.numRetainers--
+			if accountsDb.numRetainers == 0 {
+				accountsDb.deletePendingLocked()
+			}
+		})
+	}
+}
+
+// removeAppendVecFile deletes an appendvec file, deferring the deletion if it is retained.
+func (accountsDb *AccountsDb) removeAppendVecFile(appendVec AppendVecInfo) {
+	accountsDb.retainLock.Lock()
+	defer accountsDb.retainLock.Unlock()
+
+	accountsDb.pendingDeletes = append(accountsDb.pendingDeletes, appendVec)
+	if accountsDb.numRetainers == 0 {
+		accountsDb.deletePendingLocked()
+	}
+}
+
+func (accountsDb *AccountsDb) deletePendingLocked() {
+	for _, appendVec := range accountsDb.pendingDeletes {
+		accountsDb.appendVecPool.evict(appendVec)
+
+		filename := fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, appendVec.Slot, appendVec.FileId)
+		err := os.Remove(filename)
+		if err != nil && !os.IsNotExist(err) {
+			klog.Errorf("failed to delete appendvec file %s: %s", filename, err)
+		}
+	}
+	accountsDb.pendingDeletes = nil
+}
@@ -0,0 +1,389 @@
+package accountsdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+func newTestAccountsDb(t *testing.T) *AccountsDb {
+	acctsDb, err := CreateDb(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(acctsDb.CloseDb)
+
+	return acctsDb
+}
+
+func TestGetAccountCached(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID, Data: []byte{1, 2, 3}}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a}, 10))
+
+	acct, err := acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), acct.Lamports)
+	assert.Equal(t, uint64(10), acct.Slot)
+
+	// modifying a returned account does not affect the cached version
+	acct.Data[0] = 0xff
+	acct, err = acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, acct.Data)
+	assert.Equal(t, AcctCacheStats{Hits: 1, Misses: 1, NumAccts: 1, SizeBytes: hdrLen + 3}, acctsDb.AcctCacheStats())
+
+	// a newer version supersedes the cached one
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 2000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 11))
+
+	acct, err = acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2000), acct.Lamports)
+	assert.Equal(t, uint64(11), acct.Slot)
+
+	_, err = acctsDb.GetAccount(solana.NewWallet().PublicKey())
+	assert.ErrorIs(t, err, ErrNoAccount)
+}
+
+func TestAcctCacheEviction(t *testing.T) {
+	cache := newAcctCache(3 * (hdrLen + 8))
+
+	var accts []*accounts.Account
+	for idx := uint64(0); idx < 4; idx++ {
+		acct := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: idx, Data: make([]byte, 8)}
+		cache.put(&AccountIndexEntry{Slot: idx}, acct)
+		accts = append(accts, acct)
+	}
+
+	// the least recently used account was evicted
+	_, cached := cache.get(accts[0].Key, &AccountIndexEntry{Slot: 0})
+	assert.False(t, cached)
+
+	acct, cached := cache.get(accts[1].Key, &AccountIndexEntry{Slot: 1})
+	require.True(t, cached)
+	assert.Equal(t, uint64(1), acct.Lamports)
+
+	// a lookup for a different version of a cached account misses
+	_, cached = cache.get(accts[2].Key, &AccountIndexEntry{Slot: 5})
+	assert.False(t, cached)
+
+	cache.resize(hdrLen + 8)
+	assert.Equal(t, uint64(1), cache.stats().NumAccts)
+
+	// accounts 2 and 3 were evicted, since account 1 was used most recently
+	_, cached = cache.get(accts[1].Key, &AccountIndexEntry{Slot: 1})
+	assert.True(t, cached)
+}
+
+func TestGetAccountCorruptAppendVec(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a}, 10))
+
+	// zero out the appendvec, so that the indexed account can no longer be found
+	filename := filepath.Join(acctsDb.acctsDir, "10.1")
+	require.NoError(t, os.WriteFile(filename, make([]byte, hdrLen), 0666))
+
+	_, err := acctsDb.GetAccount(a.Key)
+	assert.ErrorIs(t, err, ErrCorruptAppendVec)
+}
+
+func TestUnrootedForks(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(10))
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a}, 10))
+
+	// slot 11 has two competing children, 12 and 13
+	a11 := &accounts.Account{Key: a.Key, Lamports: 1100, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{a11}, 11, 10))
+
+	a12 := &accounts.Account{Key: a.Key, Lamports: 1200, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{a12}, 12, 11))
+
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 5, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{b}, 13, 11))
+
+	assert.ErrorIs(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{b}, 15, 14), ErrUnknownParentSlot)
+	assert.ErrorIs(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{b}, 9, 8), ErrSlotRooted)
+
+	for slot, lamports := range map[uint64]uint64{10: 1000, 11: 1100, 12: 1200, 13: 1100} {
+		acct, err := acctsDb.GetAccountAtSlot(slot, a.Key)
+		require.NoError(t, err)
+		assert.Equal(t, lamports, acct.Lamports, "slot %d", slot)
+	}
+
+	// b only exists on the fork through slot 13
+	_, err := acctsDb.GetAccountAtSlot(12, b.Key)
+	assert.ErrorIs(t, err, ErrNoAccount)
+	_, err = acctsDb.GetAccountAtSlot(13, b.Key)
+	assert.NoError(t, err)
+
+	// unrooted accounts are not visible in the rooted state
+	acct, err := acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), acct.Lamports)
+
+	// rooting slot 12 flushes slots 11 and 12, and discards the dead fork through slot 13
+	require.NoError(t, acctsDb.Root(12))
+	assert.Equal(t, uint64(12), acctsDb.RootSlot())
+	assert.Empty(t, acctsDb.UnrootedSlots())
+
+	acct, err = acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1200), acct.Lamports)
+	assert.Equal(t, uint64(12), acct.Slot)
+
+	_, err = acctsDb.GetAccount(b.Key)
+	assert.ErrorIs(t, err, ErrNoAccount)
+
+	appendVecs, err := acctsDb.AppendVecs()
+	require.NoError(t, err)
+	assert.Equal(t, []AppendVecInfo{{Slot: 10, FileId: 1}, {Slot: 11, FileId: 2}, {Slot: 12, FileId: 3}}, appendVecs)
+}
+
+func TestPurgeUnrootedSlot(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(10))
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{a}, 11, 10))
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{a}, 12, 11))
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{a}, 13, 10))
+
+	// purging slot 11 also purges its descendant, slot 12
+	require.NoError(t, acctsDb.Purge(11))
+	assert.Equal(t, []uint64{13}, acctsDb.UnrootedSlots())
+
+	_, err := acctsDb.GetAccountAtSlot(12, a.Key)
+	assert.ErrorIs(t, err, ErrNoAccount)
+
+	assert.ErrorIs(t, acctsDb.Root(11), ErrSlotNotUnrooted)
+	assert.ErrorIs(t, acctsDb.Purge(11), ErrSlotNotUnrooted)
+
+	appendVecs, err := acctsDb.AppendVecs()
+	require.NoError(t, err)
+	assert.Empty(t, appendVecs)
+}
+
+func TestPubkeysInRangeAtSlot(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(10))
+
+	newAcct := func(prefix ...byte) *accounts.Account {
+		acct := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+		copy(acct.Key[:], prefix)
+		return acct
+	}
+
+	a, b, c := newAcct(0x10), newAcct(0x20, 0x00, 0x01), newAcct(0x20, 0x00, 0x02)
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b}, 10))
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a}, 10))
+
+	start, end := solana.PublicKey{0x10}, solana.PublicKey{0x30}
+
+	// the range index is built from the appendvecs upon first use
+	pubkeys, err := acctsDb.PubkeysInRangeAtSlot(10, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, []solana.PublicKey{a.Key, b.Key}, pubkeys)
+
+	// and thereafter includes accounts stored in rooted and unrooted slots
+	require.NoError(t, acctsDb.StoreAccountsUnrooted([]*accounts.Account{c}, 11, 10))
+	pubkeys, err = acctsDb.PubkeysInRangeAtSlot(11, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, []solana.PublicKey{a.Key, b.Key, c.Key}, pubkeys)
+
+	pubkeys, err = acctsDb.PubkeysInRangeAtSlot(10, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, []solana.PublicKey{a.Key, b.Key}, pubkeys)
+
+	require.NoError(t, acctsDb.Root(11))
+	pubkeys, err = acctsDb.PubkeysInRangeAtSlot(11, b.Key, end)
+	require.NoError(t, err)
+	assert.Equal(t, []solana.PublicKey{b.Key, c.Key}, pubkeys)
+
+	pubkeys, err = acctsDb.PubkeysInRangeAtSlot(11, solana.PublicKey{0x40}, solana.PublicKey{0x50})
+	require.NoError(t, err)
+	assert.Empty(t, pubkeys)
+}
+
+func forEachBinAccts(t *testing.T, snapshot *IndexSnapshot, minSlot uint64, maxSlot uint64) map[solana.PublicKey]uint64 {
+	lamports := make(map[solana.PublicKey]uint64)
+	var prev *IndexedAccount
+	err := snapshot.ForEachBin(minSlot, maxSlot, 4, func(bin []IndexedAccount) error {
+		for idx := range bin {
+			// bins are visited in pubkey order
+			if prev != nil {
+				assert.Negative(t, bytes.Compare(prev.Pubkey[:], bin[idx].Pubkey[:]))
+			}
+			prev = &bin[idx]
+
+			acct, err := snapshot.ReadAccount(&bin[idx])
+			require.NoError(t, err)
+			lamports[acct.Key] = acct.Lamports
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return lamports
+}
+
+func TestIndexSnapshot(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+
+	var accts []*accounts.Account
+	for idx := uint64(0); idx < 32; idx++ {
+		accts = append(accts, &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000 + idx, Owner: solana.SystemProgramID})
+	}
+	require.NoError(t, acctsDb.StoreAccounts(accts[:16], 10))
+	require.NoError(t, acctsDb.SetRootSlot(10))
+
+	snapshot := acctsDb.SnapshotIndex()
+	defer snapshot.Release()
+	assert.Equal(t, uint64(10), snapshot.Slot())
+
+	// accounts modified, added and removed after the snapshot was taken are not observed
+	aUpdated := &accounts.Account{Key: accts[0].Key, Lamports: 1, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts(append([]*accounts.Account{aUpdated}, accts[16:]...), 11))
+	bClosed := &accounts.Account{Key: accts[1].Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{bClosed}, 12))
+
+	expected := make(map[solana.PublicKey]uint64)
+	for _, acct := range accts {
+		expected[acct.Key] = acct.Lamports
+	}
+	expected[accts[0].Key] = 1
+
+	// the accounts state as of slots preceding the entries in the index is resolved from the
+	// appendvecs, as long as those slots have not been cleaned.
+	uncleaned := acctsDb.SnapshotIndex()
+	assert.Equal(t, expected, forEachBinAccts(t, uncleaned, 0, 11))
+	uncleaned.Release()
+
+	_, err := acctsDb.Clean(12)
+	require.NoError(t, err)
+
+	expected = make(map[solana.PublicKey]uint64)
+	for _, acct := range accts[:16] {
+		expected[acct.Key] = acct.Lamports
+	}
+	assert.Equal(t, expected, forEachBinAccts(t, snapshot, 0, 10))
+
+	// whereas a snapshot taken afterwards observes them
+	later := acctsDb.SnapshotIndex()
+	defer later.Release()
+
+	expected[accts[0].Key] = 1
+	delete(expected, accts[1].Key)
+	for _, acct := range accts[16:] {
+		expected[acct.Key] = acct.Lamports
+	}
+	assert.Equal(t, expected, forEachBinAccts(t, later, 0, 12))
+
+	// only the accounts last modified within the given slots are yielded
+	modified := map[solana.PublicKey]uint64{accts[0].Key: 1}
+	for _, acct := range accts[16:] {
+		modified[acct.Key] = acct.Lamports
+	}
+	assert.Equal(t, modified, forEachBinAccts(t, later, 11, 11))
+}
+
+func TestClean(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100, Owner: solana.SystemProgramID}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 200, Owner: solana.SystemProgramID}
+	c := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 300, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b, c}, 10))
+
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 150, Owner: solana.SystemProgramID}
+	bClosed := &accounts.Account{Key: b.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated, bClosed}, 11))
+
+	cUpdated := &accounts.Account{Key: c.Key, Lamports: 350, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{cUpdated}, 13))
+	require.NoError(t, acctsDb.SetRootSlot(13))
+
+	// the versions of a and b in slot 10 were superseded at or before the clean slot, whereas
+	// that of c was superseded after it, and so remains part of the state as of slot 12
+	stats, err := acctsDb.Clean(12)
+	require.NoError(t, err)
+	assert.Equal(t, CleanStats{NumAcctsRemoved: 3, NumZeroLamportRemoved: 1, NumFilesShrunk: 2,
+		BytesReclaimed: 3 * hdrLen}, *stats)
+
+	_, err = acctsDb.GetAccount(b.Key)
+	assert.ErrorIs(t, err, ErrNoAccount)
+
+	snapshot := acctsDb.SnapshotIndex()
+	assert.Equal(t, map[solana.PublicKey]uint64{a.Key: 150, c.Key: 300}, forEachBinAccts(t, snapshot, 0, 12))
+	snapshot.Release()
+
+	stats, err = acctsDb.Clean(13)
+	require.NoError(t, err)
+	assert.Equal(t, CleanStats{NumAcctsRemoved: 1, NumFilesDeleted: 1, BytesReclaimed: hdrLen}, *stats)
+
+	acct, err := acctsDb.GetAccount(c.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(350), acct.Lamports)
+}
+
+func TestCleanRecreatedAccount(t *testing.T) {
+	acctsDb := newTestAccountsDb(t)
+
+	// the appendvec of slot 10 remains mostly live once a's version in it is dead
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100, Owner: solana.SystemProgramID}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 200, Owner: solana.SystemProgramID, Data: make([]byte, 4096)}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b}, 10))
+
+	aClosed := &accounts.Account{Key: a.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aClosed}, 20))
+	require.NoError(t, acctsDb.SetRootSlot(20))
+
+	stats, err := acctsDb.Clean(20)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.NumZeroLamportRemoved)
+	assert.Equal(t, uint64(1), stats.NumFilesShrunk)
+
+	aRecreated := &accounts.Account{Key: a.Key, Lamports: 300, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aRecreated}, 30))
+	require.NoError(t, acctsDb.SetRootSlot(30))
+
+	// a did not exist as of slot 25, so its version in slot 10 must not be resolved
+	snapshot := acctsDb.SnapshotIndex()
+	defer snapshot.Release()
+	assert.Equal(t, map[solana.PublicKey]uint64{b.Key: 200}, forEachBinAccts(t, snapshot, 0, 25))
+	assert.Equal(t, map[solana.PublicKey]uint64{a.Key: 300, b.Key: 200}, forEachBinAccts(t, snapshot, 0, 30))
+}
+
+func TestLastSlot(t *testing.T) {
+	dir := t.TempDir()
+	acctsDb, err := CreateDb(dir)
+	require.NoError(t, err)
+
+	_, ok := acctsDb.LastSlot()
+	assert.False(t, ok)
+
+	// slot 0 is a slot like any other once replayed
+	require.NoError(t, acctsDb.SetBankHash(0, [32]byte{1}))
+	lastSlot, ok := acctsDb.LastSlot()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), lastSlot)
+	acctsDb.CloseDb()
+
+	acctsDb, err = OpenDb(dir)
+	require.NoError(t, err)
+	defer acctsDb.CloseDb()
+
+	lastSlot, ok = acctsDb.LastSlot()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), lastSlot)
+	assert.Equal(t, [32]byte{1}, acctsDb.BankHash())
+}
@@ -0,0 +1,19 @@
+// Package accountsdbtest provides AccountsDB fixtures for tests.
+package accountsdbtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+)
+
+// NewAccountsDb creates an empty AccountsDB in a temporary directory, which is closed and
+// removed once the test completes.
+func NewAccountsDb(t testing.TB) *accountsdb.AccountsDb {
+	acctsDb, err := accountsdb.CreateDb(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(acctsDb.CloseDb)
+
+	return acctsDb
+}
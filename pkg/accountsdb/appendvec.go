@@ -24,9 +24,13 @@ type AppendVecAccount struct {
 }
 
 const (
-	hdrLen        = 136
-	dataLenOffset = 8
-	pubkeyOffset  = 16
+	hdrLen           = 136
+	dataLenOffset    = 8
+	pubkeyOffset     = 16
+	lamportsOffset   = 48
+	rentEpochOffset  = 56
+	ownerOffset      = 64
+	executableOffset = 96
 )
 
 type appendVecParser struct {
@@ -59,6 +63,49 @@ func (parser *appendVecParser) ParseNextAcct() (solana.PublicKey, *AccountIndexE
 	return pubkey, entry, nil
 }
 
+// ReadNextAcct parses the account at the parser's current offset. The account's data
+// references the parser's buffer rather than being copied out of it. The end of the
+// appendvec is reached when the buffer is exhausted or when a zeroed-out header is found,
+// since appendvec files from snapshots may extend beyond their used length.
+func (parser *appendVecParser) ReadNextAcct() (*accounts.Account, *AccountIndexEntry, error) {
+	if parser.Offset+hdrLen > parser.FileSize {
+		return nil, nil, io.EOF
+	}
+
+	hdr := parser.Buf[parser.Offset : parser.Offset+hdrLen]
+	if isZeroed(hdr[:lamportsOffset+8]) {
+		return nil, nil, io.EOF
+	}
+
+	dataLen := binary.LittleEndian.Uint64(hdr[dataLenOffset:])
+	acct := &accounts.Account{Key: solana.PublicKeyFromBytes(hdr[pubkeyOffset : pubkeyOffset+32]),
+		Lamports: binary.LittleEndian.Uint64(hdr[lamportsOffset:]), RentEpoch: binary.LittleEndian.Uint64(hdr[rentEpochOffset:]),
+		Owner: solana.PublicKeyFromBytes(hdr[ownerOffset : ownerOffset+32]), Executable: hdr[executableOffset] != 0,
+		Slot: parser.Slot}
+
+	entry := &AccountIndexEntry{Slot: parser.Slot, FileId: parser.FileId, Offset: parser.Offset}
+
+	parser.Offset += hdrLen
+
+	if parser.Offset+dataLen > parser.FileSize {
+		return nil, nil, fmt.Errorf("overflow")
+	}
+
+	acct.Data = parser.Buf[parser.Offset : parser.Offset+dataLen]
+	parser.Offset += util.AlignUp(dataLen, 8)
+
+	return acct, entry, nil
+}
+
+func isZeroed(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // TODO: optimise by rewriting without binary.Read(), which uses fairly expensive reflection
 func (acct *AppendVecAccount) Unmarshal(buf io.Reader) error {
 	var err error
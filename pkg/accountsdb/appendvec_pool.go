@@ -0,0 +1,149 @@
+package accountsdb
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// DefaultMaxMappedAppendVecs is the default maximum number of appendvec files kept
+// memory-mapped at once. Each mapping counts against the kernel's vm.max_map_count
+// limit, which defaults to 65530 on Linux.
+const DefaultMaxMappedAppendVecs = 16384
+
+type mappedAppendVec struct {
+	info AppendVecInfo
+	data []byte
+
+	// the mapping is only unmapped once evicted and no longer in use by readers
+	refs    int
+	evicted bool
+	elem    *list.Element
+}
+
+// appendVecPool keeps the most recently used appendvec files memory-mapped, so that
+// account reads do not require opening, seeking and reading files.
+type appendVecPool struct {
+	lock        sync.Mutex
+	acctsDir    string
+	mappings    map[AppendVecInfo]*mappedAppendVec
+	lru         *list.List
+	maxMappings int
+}
+
+func newAppendVecPool(acctsDir string, maxMappings int) *appendVecPool {
+	return &appendVecPool{acctsDir: acctsDir, mappings: make(map[AppendVecInfo]*mappedAppendVec),
+		lru: list.New(), maxMappings: maxMappings}
+}
+
+// acquire returns the contents of the given appendvec file, mapping it if it is not already
+// mapped. The returned buffer is only valid until the returned release function is called.
+func (pool *appendVecPool) acquire(info AppendVecInfo) ([]byte, func(), error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	mapping, exists := pool.mappings[info]
+	if exists {
+		pool.lru.MoveToFront(mapping.elem)
+	} else {
+		data, err := mapAppendVecFile(fmt.Sprintf("%s/%d.%d", pool.acctsDir, info.Slot, info.FileId))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mapping = &mappedAppendVec{info: info, data: data}
+		mapping.elem = pool.lru.PushFront(mapping)
+		pool.mappings[info] = mapping
+
+		for pool.lru.Len() > pool.maxMappings {
+			pool.evictLocked(pool.lru.Back().Value.(*mappedAppendVec))
+		}
+	}
+
+	mapping.refs++
+
+	var once sync.Once
+	return mapping.data, func() {
+		once.Do(func() {
+			pool.lock.Lock()
+			defer pool.lock.Unlock()
+
+			mapping.refs--
+			if mapping.evicted && mapping.refs == 0 {
+				unmapAppendVecFile(mapping)
+			}
+		})
+	}, nil
+}
+
+// evict unmaps the given appendvec file if it is mapped, once it is no longer in use.
+func (pool *appendVecPool) evict(info AppendVecInfo) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	mapping, exists := pool.mappings[info]
+	if exists {
+		pool.evictLocked(mapping)
+	}
+}
+
+// close unmaps all appendvec files that are not in use.
+func (pool *appendVecPool) close() {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, mapping := range pool.mappings {
+		pool.evictLocked(mapping)
+	}
+}
+
+func (pool *appendVecPool) evictLocked(mapping *mappedAppendVec) {
+	delete(pool.mappings, mapping.info)
+	pool.lru.Remove(mapping.elem)
+	mapping.evicted = true
+
+	if mapping.refs == 0 {
+		unmapAppendVecFile(mapping)
+	}
+}
+
+func mapAppendVecFile(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// empty files cannot be mapped
+	if fileInfo.Size() == 0 {
+		return []byte{}, nil
+	}
+
+	data, err := unix.Mmap(int(file.Fd()), 0, int(fileInfo.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap appendvec file %s: %w", filename, err)
+	}
+
+	return data, nil
+}
+
+func unmapAppendVecFile(mapping *mappedAppendVec) {
+	if len(mapping.data) == 0 {
+		return
+	}
+
+	err := unix.Munmap(mapping.data)
+	if err != nil {
+		klog.Errorf("failed to munmap appendvec file %d.%d: %s", mapping.info.Slot, mapping.info.FileId, err)
+	}
+	mapping.data = nil
+}
@@ -0,0 +1,135 @@
+package accountsdb
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// DefaultAcctCacheSize is the default maximum size in bytes of the accounts held in the
+// AccountsDB's account cache.
+const DefaultAcctCacheSize = 512 * 1024 * 1024
+
+// AcctCacheStats describes the state and effectiveness of the AccountsDB's account cache.
+type AcctCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	NumAccts  uint64
+	SizeBytes uint64
+}
+
+// HitRate returns the fraction of account lookups served from the cache.
+func (stats AcctCacheStats) HitRate() float64 {
+	if stats.Hits+stats.Misses == 0 {
+		return 0
+	}
+	return float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+}
+
+type acctCacheEntry struct {
+	entry AccountIndexEntry
+	acct  *accounts.Account
+}
+
+// acctCache is a bounded LRU cache of recently read accounts. Each cached account is tagged
+// with the index entry it was read from, and is only returned for lookups with a matching
+// index entry, so that cached accounts never outlive the version in the index.
+type acctCache struct {
+	lock     sync.Mutex
+	maxBytes uint64
+	size     uint64
+	entries  map[solana.PublicKey]*list.Element
+	lru      *list.List
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newAcctCache(maxBytes uint64) *acctCache {
+	return &acctCache{maxBytes: maxBytes, entries: make(map[solana.PublicKey]*list.Element), lru: list.New()}
+}
+
+func acctCacheEntrySize(acct *accounts.Account) uint64 {
+	return hdrLen + uint64(len(acct.Data))
+}
+
+// get returns a copy of the cached account for pubkey, provided that it was read from
+// the given index entry.
+func (cache *acctCache) get(pubkey solana.PublicKey, entry *AccountIndexEntry) (*accounts.Account, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	elem, exists := cache.entries[pubkey]
+	if !exists || elem.Value.(*acctCacheEntry).entry != *entry {
+		cache.misses.Add(1)
+		return nil, false
+	}
+
+	cache.hits.Add(1)
+	cache.lru.MoveToFront(elem)
+
+	return elem.Value.(*acctCacheEntry).acct.Clone(), true
+}
+
+// put caches a copy of acct, which was read from the given index entry.
+func (cache *acctCache) put(entry *AccountIndexEntry, acct *accounts.Account) {
+	size := acctCacheEntrySize(acct)
+	acct = acct.Clone()
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if size > cache.maxBytes {
+		return
+	}
+
+	cache.removeLocked(acct.Key)
+
+	cache.entries[acct.Key] = cache.lru.PushFront(&acctCacheEntry{entry: *entry, acct: acct})
+	cache.size += size
+
+	for cache.size > cache.maxBytes {
+		cache.removeLocked(cache.lru.Back().Value.(*acctCacheEntry).acct.Key)
+	}
+}
+
+// remove drops the cached account for pubkey, if any.
+func (cache *acctCache) remove(pubkey solana.PublicKey) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.removeLocked(pubkey)
+}
+
+func (cache *acctCache) removeLocked(pubkey solana.PublicKey) {
+	elem, exists := cache.entries[pubkey]
+	if !exists {
+		return
+	}
+
+	delete(cache.entries, pubkey)
+	cache.lru.Remove(elem)
+	cache.size -= acctCacheEntrySize(elem.Value.(*acctCacheEntry).acct)
+}
+
+// resize changes the maximum size of the cache, evicting accounts as necessary.
+func (cache *acctCache) resize(maxBytes uint64) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.maxBytes = maxBytes
+	for cache.size > cache.maxBytes {
+		cache.removeLocked(cache.lru.Back().Value.(*acctCacheEntry).acct.Key)
+	}
+}
+
+func (cache *acctCache) stats() AcctCacheStats {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	return AcctCacheStats{Hits: cache.hits.Load(), Misses: cache.misses.Load(),
+		NumAccts: uint64(len(cache.entries)), SizeBytes: cache.size}
+}
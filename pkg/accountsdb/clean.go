@@ -0,0 +1,334 @@
+package accountsdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/util"
+)
+
+// appendvecs whose live accounts take up less than this fraction of the file are rewritten
+const shrinkRatio = 0.8
+
+// CleanStats summarises the work done by a call to Clean.
+type CleanStats struct {
+	NumAcctsRemoved       uint64
+	NumZeroLamportRemoved uint64
+	NumFilesShrunk        uint64
+	NumFilesDeleted       uint64
+	BytesReclaimed        uint64
+}
+
+// Clean garbage collects the account versions stored at or before maxCleanSlot that are no
+// longer needed, as judged against the index: versions superseded by the account's newest
+// version, if it was stored at or before maxCleanSlot, and zero-lamport accounts whose newest
+// version was stored at or before maxCleanSlot (along with their older versions), which are
+// also removed from the index. Versions superseded after maxCleanSlot are left for a later
+// clean. Appendvecs with no remaining live accounts are deleted, and sparse appendvecs
+// are rewritten into dense ones. Appendvecs holding any version of an account removed from
+// the index are always rewritten, however live, so that no version of a removed account
+// outlives it: were the account recreated, its stale versions would otherwise be taken for
+// its state as of the slots before it was recreated.
+//
+// The accounts state as of any slot at or beyond maxCleanSlot is unaffected, so the full
+// accounts hash as of such slots is unchanged. Callers must therefore not clean beyond the
+// slot of an in-flight EAH calculation, nor beyond the base slot of an incremental accounts
+// hash that is yet to be calculated, since zero-lamport accounts are part of the latter.
+//
+// Clean may run concurrently with reads. Files are only deleted once no longer retained
+// by readers (see RetainAppendVecs).
+func (accountsDb *AccountsDb) Clean(maxCleanSlot uint64) (*CleanStats, error) {
+	accountsDb.cleanLock.Lock()
+	defer accountsDb.cleanLock.Unlock()
+
+	stats := new(CleanStats)
+	if maxCleanSlot <= accountsDb.cleanSlot {
+		return stats, nil
+	}
+
+	release := accountsDb.RetainAppendVecs()
+	defer release()
+
+	appendVecs, err := accountsDb.AppendVecs()
+	if err != nil {
+		return nil, err
+	}
+
+	// each account version is checked against the account's index entry, so that no more
+	// than a single appendvec's accounts are held in memory at a time. appendvecs are cleaned
+	// newest first, such that a zero-lamport account is removed from the index before its
+	// older versions are visited.
+	removed := make(map[solana.PublicKey]struct{})
+	for idx := len(appendVecs) - 1; idx >= 0; idx-- {
+		appendVec := appendVecs[idx]
+		if appendVec.Slot > maxCleanSlot {
+			continue
+		}
+
+		err = accountsDb.cleanAppendVec(appendVec, maxCleanSlot, removed, stats)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cleanSlotBytes [8]byte
+	binary.LittleEndian.PutUint64(cleanSlotBytes[:], maxCleanSlot)
+	err = os.WriteFile(fmt.Sprintf("%s/clean_slot", accountsDb.dbDir), cleanSlotBytes[:], 0666)
+	if err != nil {
+		return nil, err
+	}
+	accountsDb.cleanSlot = maxCleanSlot
+
+	return stats, nil
+}
+
+// CleanSlot returns the slot up to which the AccountsDB has been cleaned, as of which and
+// beyond the accounts state remains derivable from the appendvecs.
+func (accountsDb *AccountsDb) CleanSlot() uint64 {
+	accountsDb.cleanLock.Lock()
+	defer accountsDb.cleanLock.Unlock()
+
+	return accountsDb.cleanSlot
+}
+
+// cleanAppendVec removes the dead account versions from an appendvec, deleting it if no
+// live versions remain, and rewriting it if it has become sufficiently sparse or if it
+// holds a version of an account in removed.
+func (accountsDb *AccountsDb) cleanAppendVec(appendVec AppendVecInfo, maxCleanSlot uint64, removed map[solana.PublicKey]struct{},
+	stats *CleanStats) error {
+	filename := fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, appendVec.Slot, appendVec.FileId)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	type acctVersion struct {
+		pubkey   solana.PublicKey
+		lamports uint64
+		offset   uint64
+		size     uint64
+	}
+
+	var versions []acctVersion
+	parser := &appendVecParser{Buf: data, FileSize: uint64(len(data)), FileId: appendVec.FileId, Slot: appendVec.Slot}
+	err = parser.scanHeaders(func(pubkey solana.PublicKey, lamports uint64, entry *AccountIndexEntry, size uint64) {
+		versions = append(versions, acctVersion{pubkey: pubkey, lamports: lamports, offset: entry.Offset, size: size})
+	})
+	if err != nil {
+		return fmt.Errorf("error reading appendvec %s: %w", filename, err)
+	}
+
+	var live []acctVersion
+	var liveBytes uint64
+	var numDead uint64
+
+	for _, version := range versions {
+		entry := AccountIndexEntry{Slot: appendVec.Slot, FileId: appendVec.FileId, Offset: version.offset}
+		dead, err := accountsDb.isDeadAcctVersion(version.pubkey, &entry, version.lamports, maxCleanSlot, removed, stats)
+		if err != nil {
+			return err
+		}
+
+		if dead {
+			numDead++
+			continue
+		}
+		live = append(live, version)
+		liveBytes += version.size
+	}
+
+	// the account is only added to removed once its newest version has been visited, which
+	// may come later in the same appendvec than its older versions.
+	var holdsRemoved bool
+	for _, version := range versions {
+		_, isRemoved := removed[version.pubkey]
+		holdsRemoved = holdsRemoved || isRemoved
+	}
+
+	if len(live) == 0 {
+		accountsDb.removeAppendVecFile(appendVec)
+		stats.NumFilesDeleted++
+		stats.NumAcctsRemoved += numDead
+		stats.BytesReclaimed += uint64(len(data))
+		return nil
+	}
+
+	if !holdsRemoved && float64(liveBytes) >= shrinkRatio*float64(len(data)) {
+		return nil
+	}
+
+	// the shrunk appendvec keeps the slot of the original, such that the accounts state as of
+	// any slot at or beyond maxCleanSlot remains derivable from the appendvecs.
+	buf := new(bytes.Buffer)
+	buf.Grow(int(liveBytes))
+	for _, acct := range live {
+		buf.Write(data[acct.offset : acct.offset+acct.size])
+	}
+
+	shrunkFile, fileId, err := accountsDb.createAppendVecFile(appendVec.Slot)
+	if err != nil {
+		return err
+	}
+
+	_, err = shrunkFile.Write(buf.Bytes())
+	closeErr := shrunkFile.Close()
+	if err != nil {
+		return err
+	} else if closeErr != nil {
+		return closeErr
+	}
+
+	var newOffset uint64
+	for _, acct := range live {
+		oldEntry := AccountIndexEntry{Slot: appendVec.Slot, FileId: appendVec.FileId, Offset: acct.offset}
+		newEntry := AccountIndexEntry{Slot: appendVec.Slot, FileId: fileId, Offset: newOffset}
+
+		err = accountsDb.replaceIndexEntryIfMatches(acct.pubkey, &oldEntry, &newEntry)
+		if err != nil {
+			return err
+		}
+
+		newOffset += acct.size
+	}
+
+	accountsDb.removeAppendVecFile(appendVec)
+
+	stats.NumFilesShrunk++
+	stats.NumAcctsRemoved += numDead
+	stats.BytesReclaimed += uint64(len(data)) - liveBytes
+
+	return nil
+}
+
+// isDeadAcctVersion returns true if the account version at entry is not needed for the
+// accounts state as of maxCleanSlot or any later slot, as judged by the account's index entry,
+// which refers to its newest version. A version is dead if the account has since been removed
+// from the index, or if it was superseded at or before maxCleanSlot. A version superseded
+// after maxCleanSlot is retained, since it may be part of the state as of maxCleanSlot.
+//
+// A zero-lamport version that the index refers to is dead, and is removed from the index,
+// rendering the account's older versions dead too. Accounts found to have been removed are
+// added to removed, and their versions remain dead even if the account is recreated whilst
+// the clean is in progress.
+func (accountsDb *AccountsDb) isDeadAcctVersion(pubkey solana.PublicKey, entry *AccountIndexEntry, lamports uint64, maxCleanSlot uint64,
+	removed map[solana.PublicKey]struct{}, stats *CleanStats) (bool, error) {
+	_, isRemoved := removed[pubkey]
+	if isRemoved {
+		return true, nil
+	}
+
+	current, err := accountsDb.indexEntry(pubkey)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil {
+		removed[pubkey] = struct{}{}
+		return true, nil
+	} else if *current != *entry {
+		return current.Slot <= maxCleanSlot, nil
+	} else if lamports != 0 {
+		return false, nil
+	}
+
+	// the version remains dead if it was superseded after maxCleanSlot in the meantime
+	wasRemoved, err := accountsDb.removeIndexEntryIfMatches(pubkey, entry)
+	if err != nil {
+		return false, err
+	}
+	if wasRemoved {
+		stats.NumZeroLamportRemoved++
+	}
+	removed[pubkey] = struct{}{}
+
+	return true, nil
+}
+
+// createAppendVecFile creates a new, empty appendvec file for the given slot.
+func (accountsDb *AccountsDb) createAppendVecFile(slot uint64) (*os.File, uint64, error) {
+	for {
+		fileId := accountsDb.largestFileId.Add(1)
+		file, err := os.OpenFile(fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, slot, fileId), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return file, fileId, err
+	}
+}
+
+func (accountsDb *AccountsDb) removeIndexEntryIfMatches(pubkey solana.PublicKey, entry *AccountIndexEntry) (bool, error) {
+	accountsDb.indexLock.Lock()
+	defer accountsDb.indexLock.Unlock()
+
+	current, err := accountsDb.indexEntry(pubkey)
+	if err != nil || current == nil || *current != *entry {
+		return false, err
+	}
+
+	accountsDb.preserveIndexEntryLocked(pubkey, current)
+	return accountsDb.indexDb.Delete(pubkey[:])
+}
+
+func (accountsDb *AccountsDb) replaceIndexEntryIfMatches(pubkey solana.PublicKey, oldEntry *AccountIndexEntry, newEntry *AccountIndexEntry) error {
+	accountsDb.indexLock.Lock()
+	defer accountsDb.indexLock.Unlock()
+
+	current, err := accountsDb.indexEntry(pubkey)
+	if err != nil || current == nil || *current != *oldEntry {
+		return err
+	}
+
+	writer := new(bytes.Buffer)
+	err = newEntry.MarshalWithEncoder(bin.NewBinEncoder(writer))
+	if err != nil {
+		return err
+	}
+
+	accountsDb.preserveIndexEntryLocked(pubkey, current)
+	return accountsDb.indexDb.Set(pubkey[:], writer.Bytes(), 0)
+}
+
+// indexEntry returns the index entry for pubkey, or nil if it is not in the index.
+func (accountsDb *AccountsDb) indexEntry(pubkey solana.PublicKey) (*AccountIndexEntry, error) {
+	entryBytes, err := accountsDb.indexDb.Get(pubkey[:])
+	if err != nil {
+		return nil, nil
+	}
+	return unmarshalAcctIdxEntry(entryBytes)
+}
+
+func (accountsDb *AccountsDb) scanAppendVecHeaders(appendVec AppendVecInfo, fn func(pubkey solana.PublicKey, lamports uint64, entry *AccountIndexEntry, size uint64)) error {
+	data, err := os.ReadFile(fmt.Sprintf("%s/%d.%d", accountsDb.acctsDir, appendVec.Slot, appendVec.FileId))
+	if err != nil {
+		return err
+	}
+
+	parser := &appendVecParser{Buf: data, FileSize: uint64(len(data)), FileId: appendVec.FileId, Slot: appendVec.Slot}
+	err = parser.scanHeaders(fn)
+	if err != nil {
+		return fmt.Errorf("error reading appendvec %d.%d: %w", appendVec.Slot, appendVec.FileId, err)
+	}
+
+	return nil
+}
+
+// scanHeaders calls fn with the pubkey, lamports, location and size (including padding)
+// of each account in the appendvec.
+func (parser *appendVecParser) scanHeaders(fn func(pubkey solana.PublicKey, lamports uint64, entry *AccountIndexEntry, size uint64)) error {
+	for {
+		acct, entry, err := parser.ReadNextAcct()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		fn(acct.Key, acct.Lamports, entry, hdrLen+util.AlignUp(uint64(len(acct.Data)), 8))
+	}
+}
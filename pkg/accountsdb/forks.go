@@ -0,0 +1,220 @@
+package accountsdb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+var (
+	ErrUnknownParentSlot = errors.New("ErrUnknownParentSlot")
+	ErrSlotRooted        = errors.New("ErrSlotRooted")
+	ErrSlotNotUnrooted   = errors.New("ErrSlotNotUnrooted")
+)
+
+// unrootedSlot holds the accounts written in a slot that has not yet been rooted. Its
+// accounts are layered on top of those of its parent, which is either another unrooted
+// slot or the rooted state in the appendvecs.
+type unrootedSlot struct {
+	slot       uint64
+	parentSlot uint64
+	accts      map[solana.PublicKey]*accounts.Account
+}
+
+// StoreAccountsUnrooted stores accounts written in an unrooted slot. The accounts are held in
+// memory, and only become visible to reads at the slot or its descendants (see
+// GetAccountAtSlot) until the slot is rooted by Root, or discarded by Purge.
+//
+// The parent slot must either be the current root or an unrooted slot. Storing accounts
+// into a slot more than once layers the later accounts on top of the earlier ones.
+func (accountsDb *AccountsDb) StoreAccountsUnrooted(accts []*accounts.Account, slot uint64, parentSlot uint64) error {
+	accountsDb.forkLock.Lock()
+	defer accountsDb.forkLock.Unlock()
+
+	if slot <= accountsDb.rootSlot {
+		return fmt.Errorf("%w: slot %d, root %d", ErrSlotRooted, slot, accountsDb.rootSlot)
+	}
+
+	unrooted, exists := accountsDb.unrooted[slot]
+	if exists && unrooted.parentSlot != parentSlot {
+		return fmt.Errorf("slot %d already has parent %d rather than %d", slot, unrooted.parentSlot, parentSlot)
+	} else if !exists {
+		_, parentUnrooted := accountsDb.unrooted[parentSlot]
+		if parentSlot != accountsDb.rootSlot && !parentUnrooted {
+			return fmt.Errorf("%w: slot %d has parent %d, root %d", ErrUnknownParentSlot, slot, parentSlot, accountsDb.rootSlot)
+		}
+
+		unrooted = &unrootedSlot{slot: slot, parentSlot: parentSlot, accts: make(map[solana.PublicKey]*accounts.Account, len(accts))}
+		accountsDb.unrooted[slot] = unrooted
+	}
+
+	for _, acct := range accts {
+		acctCopy := acct.Clone()
+		acctCopy.Slot = slot
+		unrooted.accts[acct.Key] = acctCopy
+	}
+
+	return nil
+}
+
+// GetAccountAtSlot returns the account as of the given slot, resolving it through the slot's
+// chain of unrooted ancestors before falling back to the rooted state. Slots that are not
+// unrooted resolve to the rooted state.
+func (accountsDb *AccountsDb) GetAccountAtSlot(slot uint64, pubkey solana.PublicKey) (*accounts.Account, error) {
+	accountsDb.forkLock.RLock()
+	for {
+		unrooted, exists := accountsDb.unrooted[slot]
+		if !exists {
+			break
+		}
+
+		acct, exists := unrooted.accts[pubkey]
+		if exists {
+			accountsDb.forkLock.RUnlock()
+			return acct.Clone(), nil
+		}
+
+		slot = unrooted.parentSlot
+	}
+	accountsDb.forkLock.RUnlock()
+
+	return accountsDb.GetAccount(pubkey)
+}
+
+// SetRootSlot sets the slot of the rooted state, for AccountsDBs that have been built from
+// a snapshot and not yet had any slots rooted on top of it.
+func (accountsDb *AccountsDb) SetRootSlot(slot uint64) error {
+	accountsDb.forkLock.Lock()
+	defer accountsDb.forkLock.Unlock()
+
+	if len(accountsDb.unrooted) != 0 {
+		return fmt.Errorf("unable to set root slot to %d with %d unrooted slots", slot, len(accountsDb.unrooted))
+	}
+
+	accountsDb.rootSlot = slot
+	return nil
+}
+
+// RootSlot returns the most recently rooted slot, i.e. the slot as of which the appendvecs
+// and index describe the accounts state.
+func (accountsDb *AccountsDb) RootSlot() uint64 {
+	accountsDb.forkLock.RLock()
+	defer accountsDb.forkLock.RUnlock()
+
+	return accountsDb.rootSlot
+}
+
+// UnrootedSlots returns the slots that are currently unrooted, in ascending order.
+func (accountsDb *AccountsDb) UnrootedSlots() []uint64 {
+	accountsDb.forkLock.RLock()
+	defer accountsDb.forkLock.RUnlock()
+
+	slots := make([]uint64, 0, len(accountsDb.unrooted))
+	for slot := range accountsDb.unrooted {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+
+	return slots
+}
+
+// Root makes the given unrooted slot the new root, flushing the accounts written in it and
+// in its unrooted ancestors to appendvecs, oldest first. Unrooted slots that do not descend
+// from the new root are on dead forks, and are discarded.
+func (accountsDb *AccountsDb) Root(slot uint64) error {
+	accountsDb.forkLock.Lock()
+	defer accountsDb.forkLock.Unlock()
+
+	if slot == accountsDb.rootSlot {
+		return nil
+	}
+
+	_, exists := accountsDb.unrooted[slot]
+	if !exists {
+		return fmt.Errorf("%w: slot %d", ErrSlotNotUnrooted, slot)
+	}
+
+	var chain []*unrootedSlot
+	for ancestor := slot; ; {
+		unrooted, exists := accountsDb.unrooted[ancestor]
+		if !exists {
+			break
+		}
+		chain = append(chain, unrooted)
+		ancestor = unrooted.parentSlot
+	}
+
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		unrooted := chain[idx]
+
+		if len(unrooted.accts) != 0 {
+			accts := make([]*accounts.Account, 0, len(unrooted.accts))
+			for _, acct := range unrooted.accts {
+				accts = append(accts, acct)
+			}
+
+			err := accountsDb.StoreAccounts(accts, unrooted.slot)
+			if err != nil {
+				return fmt.Errorf("failed to flush accounts of slot %d: %w", unrooted.slot, err)
+			}
+		}
+
+		delete(accountsDb.unrooted, unrooted.slot)
+		accountsDb.rootSlot = unrooted.slot
+	}
+
+	// the remaining unrooted slots either descend from the new root or are on dead forks
+	var deadSlots []uint64
+	for remaining := range accountsDb.unrooted {
+		if !accountsDb.descendsFromLocked(remaining, slot) {
+			deadSlots = append(deadSlots, remaining)
+		}
+	}
+
+	for _, deadSlot := range deadSlots {
+		delete(accountsDb.unrooted, deadSlot)
+	}
+
+	return nil
+}
+
+// Purge discards the accounts written in the given unrooted slot and in all of its
+// descendants, such as when the slot turns out to be on a dead fork or fails to replay.
+func (accountsDb *AccountsDb) Purge(slot uint64) error {
+	accountsDb.forkLock.Lock()
+	defer accountsDb.forkLock.Unlock()
+
+	_, exists := accountsDb.unrooted[slot]
+	if !exists {
+		return fmt.Errorf("%w: slot %d", ErrSlotNotUnrooted, slot)
+	}
+
+	purgedSlots := []uint64{slot}
+	for remaining := range accountsDb.unrooted {
+		if accountsDb.descendsFromLocked(remaining, slot) {
+			purgedSlots = append(purgedSlots, remaining)
+		}
+	}
+
+	for _, purgedSlot := range purgedSlots {
+		delete(accountsDb.unrooted, purgedSlot)
+	}
+
+	return nil
+}
+
+// descendsFromLocked returns true if the unrooted slot has ancestor among its ancestors.
+func (accountsDb *AccountsDb) descendsFromLocked(slot uint64, ancestor uint64) bool {
+	for {
+		unrooted, exists := accountsDb.unrooted[slot]
+		if !exists {
+			return false
+		} else if unrooted.parentSlot == ancestor {
+			return true
+		}
+		slot = unrooted.parentSlot
+	}
+}
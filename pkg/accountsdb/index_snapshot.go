@@ -0,0 +1,375 @@
+package accountsdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+)
+
+// the size of a record in the index's backup stream: a header holding the size class,
+// status, key length (u16 BE), value length (u32 BE) and expiry, followed by the value
+// and then the key.
+const (
+	indexBackupHdrLen      = 12
+	indexBackupVersionLen  = 1
+	indexEntryLen          = 24
+	indexSnapshotRecordLen = solana.PublicKeyLength + indexEntryLen
+)
+
+// IndexedAccount is the index entry of an account, as yielded by an IndexSnapshot.
+type IndexedAccount struct {
+	Pubkey solana.PublicKey
+	Entry  AccountIndexEntry
+}
+
+// IndexSnapshot is a point-in-time view of the index, taken by SnapshotIndex. Index entries
+// that are overwritten or removed whilst the snapshot is held are preserved until it is
+// released, such that the accounts state as of the snapshot can be iterated over in the
+// background whilst further slots are rooted.
+type IndexSnapshot struct {
+	accountsDb *AccountsDb
+	slot       uint64
+
+	// the entries as of the snapshot of accounts whose entry has since changed, or nil for
+	// accounts added since. guarded by the AccountsDB's indexLock.
+	preimages map[solana.PublicKey]*AccountIndexEntry
+
+	releaseAppendVecs func()
+}
+
+// isNewerThan returns true if the account version at entry was stored after the one at other.
+func (entry *AccountIndexEntry) isNewerThan(other *AccountIndexEntry) bool {
+	if entry.Slot != other.Slot {
+		return entry.Slot > other.Slot
+	} else if entry.FileId != other.FileId {
+		return entry.FileId > other.FileId
+	}
+	return entry.Offset > other.Offset
+}
+
+// SnapshotIndex takes a snapshot of the index as of the current root slot. The appendvecs
+// referenced by the snapshot are retained until it is released by Release.
+func (accountsDb *AccountsDb) SnapshotIndex() *IndexSnapshot {
+	accountsDb.forkLock.RLock()
+	defer accountsDb.forkLock.RUnlock()
+
+	accountsDb.indexLock.Lock()
+	defer accountsDb.indexLock.Unlock()
+
+	snapshot := &IndexSnapshot{accountsDb: accountsDb, slot: accountsDb.rootSlot,
+		preimages: make(map[solana.PublicKey]*AccountIndexEntry), releaseAppendVecs: accountsDb.RetainAppendVecs()}
+	accountsDb.indexSnapshots = append(accountsDb.indexSnapshots, snapshot)
+
+	return snapshot
+}
+
+// Slot returns the root slot as of which the snapshot was taken.
+func (snapshot *IndexSnapshot) Slot() uint64 {
+	return snapshot.slot
+}
+
+// Release stops preserving the index entries of the snapshot, and releases the appendvecs
+// that it retained.
+func (snapshot *IndexSnapshot) Release() {
+	accountsDb := snapshot.accountsDb
+
+	accountsDb.indexLock.Lock()
+	for idx, other := range accountsDb.indexSnapshots {
+		if other == snapshot {
+			accountsDb.indexSnapshots = append(accountsDb.indexSnapshots[:idx], accountsDb.indexSnapshots[idx+1:]...)
+			break
+		}
+	}
+	snapshot.preimages = nil
+	accountsDb.indexLock.Unlock()
+
+	snapshot.releaseAppendVecs()
+}
+
+// preserveIndexEntryLocked records the current index entry of pubkey, which is about to be
+// changed, in the snapshots that have not yet recorded it. The caller must hold indexLock.
+func (accountsDb *AccountsDb) preserveIndexEntryLocked(pubkey solana.PublicKey, current *AccountIndexEntry) {
+	for _, snapshot := range accountsDb.indexSnapshots {
+		_, preserved := snapshot.preimages[pubkey]
+		if !preserved {
+			snapshot.preimages[pubkey] = current
+		}
+	}
+}
+
+// ReadAccount reads the account at an index entry yielded by the snapshot.
+func (snapshot *IndexSnapshot) ReadAccount(acct *IndexedAccount) (*accounts.Account, error) {
+	return snapshot.accountsDb.readAccount(acct.Pubkey, &acct.Entry)
+}
+
+// ForEachBin calls fn with the index entries of the accounts state as of maxSlot, restricted
+// to the accounts last modified at or after minSlot. The entries are partitioned by pubkey
+// into numBins bins, which are staged on disk, such that only a single bin is held in memory
+// at a time. fn is called for each bin in turn, in pubkey order, with the bin's entries
+// sorted by pubkey.
+//
+// Accounts whose entry in the snapshot is newer than maxSlot are resolved to their most
+// recent version at or before maxSlot from the appendvecs, so maxSlot may precede the
+// snapshot's slot, but must not precede the AccountsDB's clean slot.
+func (snapshot *IndexSnapshot) ForEachBin(minSlot uint64, maxSlot uint64, numBins int, fn func(bin []IndexedAccount) error) error {
+	accountsDb := snapshot.accountsDb
+
+	tmpDir, err := os.MkdirTemp(accountsDb.dbDir, "index_snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bins, err := newIndexSnapshotBins(tmpDir, numBins)
+	if err != nil {
+		return err
+	}
+	defer bins.close()
+
+	// accounts whose entry is newer than maxSlot, mapped to their version as of maxSlot
+	stale := make(map[solana.PublicKey]*AccountIndexEntry)
+
+	err = snapshot.forEachEntry(tmpDir, func(pubkey solana.PublicKey, entry *AccountIndexEntry) error {
+		if entry.Slot > maxSlot {
+			stale[pubkey] = nil
+			return nil
+		} else if entry.Slot < minSlot {
+			return nil
+		}
+		return bins.add(pubkey, entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(stale) != 0 {
+		err = snapshot.resolveStaleEntries(minSlot, maxSlot, stale)
+		if err != nil {
+			return err
+		}
+
+		for pubkey, entry := range stale {
+			if entry == nil {
+				continue
+			}
+			err = bins.add(pubkey, entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for binIdx := 0; binIdx < numBins; binIdx++ {
+		bin, err := bins.load(binIdx)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(bin, func(i, j int) bool {
+			return bytes.Compare(bin[i].Pubkey[:], bin[j].Pubkey[:]) < 0
+		})
+
+		err = fn(bin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forEachEntry calls fn for each index entry as of the snapshot. The index is first copied
+// to a file in tmpDir, so that the index is not locked for the duration of the iteration.
+func (snapshot *IndexSnapshot) forEachEntry(tmpDir string, fn func(pubkey solana.PublicKey, entry *AccountIndexEntry) error) error {
+	accountsDb := snapshot.accountsDb
+
+	backupFile, err := os.Create(filepath.Join(tmpDir, "index"))
+	if err != nil {
+		return err
+	}
+	defer backupFile.Close()
+
+	writer := bufio.NewWriterSize(backupFile, 1<<20)
+	err = accountsDb.indexDb.Backup(writer)
+	if err != nil {
+		return fmt.Errorf("failed to copy index: %w", err)
+	}
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	// entries changed since the snapshot were preserved before the change, and hence
+	// before the copy observed the change.
+	accountsDb.indexLock.Lock()
+	preimages := make(map[solana.PublicKey]*AccountIndexEntry, len(snapshot.preimages))
+	for pubkey, entry := range snapshot.preimages {
+		preimages[pubkey] = entry
+	}
+	accountsDb.indexLock.Unlock()
+
+	_, err = backupFile.Seek(indexBackupVersionLen, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(backupFile, 1<<20)
+
+	var record [indexBackupHdrLen + indexEntryLen + solana.PublicKeyLength]byte
+	for {
+		_, err = io.ReadFull(reader, record[:indexBackupHdrLen])
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: failed to read index copy: %s", ErrCorruptIndexEntry, err)
+		}
+
+		keyLen := binary.BigEndian.Uint16(record[2:4])
+		valLen := binary.BigEndian.Uint32(record[4:8])
+		if keyLen != solana.PublicKeyLength || valLen != indexEntryLen {
+			return fmt.Errorf("%w: index record with key length %d and value length %d", ErrCorruptIndexEntry, keyLen, valLen)
+		}
+
+		_, err = io.ReadFull(reader, record[indexBackupHdrLen:])
+		if err != nil {
+			return fmt.Errorf("%w: failed to read index copy: %s", ErrCorruptIndexEntry, err)
+		}
+
+		pubkey := solana.PublicKeyFromBytes(record[indexBackupHdrLen+indexEntryLen:])
+		entry, err := unmarshalAcctIdxEntry(record[indexBackupHdrLen : indexBackupHdrLen+indexEntryLen])
+		if err != nil {
+			return fmt.Errorf("%w: account %s: %s", ErrCorruptIndexEntry, pubkey, err)
+		}
+
+		preimage, changed := preimages[pubkey]
+		if changed {
+			delete(preimages, pubkey)
+			entry = preimage
+		}
+		if entry == nil {
+			continue
+		}
+
+		err = fn(pubkey, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	// accounts removed from the index since the snapshot
+	for pubkey, entry := range preimages {
+		if entry == nil {
+			continue
+		}
+
+		err = fn(pubkey, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveStaleEntries finds the most recent version stored between minSlot and maxSlot of
+// each of the stale accounts, leaving accounts with no such version nil. Since Clean leaves
+// no version of the accounts that it removes from the index, such a version is the account's
+// state as of maxSlot, as long as maxSlot is not before the clean slot.
+func (snapshot *IndexSnapshot) resolveStaleEntries(minSlot uint64, maxSlot uint64, stale map[solana.PublicKey]*AccountIndexEntry) error {
+	accountsDb := snapshot.accountsDb
+
+	appendVecs, err := accountsDb.AppendVecs()
+	if err != nil {
+		return err
+	}
+
+	for _, appendVec := range appendVecs {
+		if appendVec.Slot < minSlot || appendVec.Slot > maxSlot {
+			continue
+		}
+
+		err = accountsDb.scanAppendVecHeaders(appendVec, func(pubkey solana.PublicKey, lamports uint64, entry *AccountIndexEntry, size uint64) {
+			version, isStale := stale[pubkey]
+			if isStale && (version == nil || entry.isNewerThan(version)) {
+				entryCopy := *entry
+				stale[pubkey] = &entryCopy
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexSnapshotBins stages index entries on disk, partitioned into bins by pubkey.
+type indexSnapshotBins struct {
+	files   []*os.File
+	writers []*bufio.Writer
+}
+
+func newIndexSnapshotBins(dir string, numBins int) (*indexSnapshotBins, error) {
+	bins := &indexSnapshotBins{files: make([]*os.File, 0, numBins), writers: make([]*bufio.Writer, 0, numBins)}
+	for binIdx := 0; binIdx < numBins; binIdx++ {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("bin.%d", binIdx)))
+		if err != nil {
+			bins.close()
+			return nil, err
+		}
+		bins.files = append(bins.files, file)
+		bins.writers = append(bins.writers, bufio.NewWriterSize(file, 1<<16))
+	}
+	return bins, nil
+}
+
+// binIdx partitions pubkeys by their first two bytes, such that bins are in pubkey order.
+func (bins *indexSnapshotBins) binIdx(pubkey solana.PublicKey) int {
+	return int(binary.BigEndian.Uint16(pubkey[:2])) * len(bins.files) >> 16
+}
+
+func (bins *indexSnapshotBins) add(pubkey solana.PublicKey, entry *AccountIndexEntry) error {
+	var record [indexSnapshotRecordLen]byte
+	copy(record[:], pubkey[:])
+	binary.LittleEndian.PutUint64(record[32:], entry.Slot)
+	binary.LittleEndian.PutUint64(record[40:], entry.FileId)
+	binary.LittleEndian.PutUint64(record[48:], entry.Offset)
+
+	_, err := bins.writers[bins.binIdx(pubkey)].Write(record[:])
+	return err
+}
+
+// load reads back the entries staged in a bin.
+func (bins *indexSnapshotBins) load(binIdx int) ([]IndexedAccount, error) {
+	err := bins.writers[binIdx].Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(bins.files[binIdx].Name())
+	if err != nil {
+		return nil, err
+	}
+
+	bin := make([]IndexedAccount, len(data)/indexSnapshotRecordLen)
+	for idx := range bin {
+		record := data[idx*indexSnapshotRecordLen:]
+		copy(bin[idx].Pubkey[:], record)
+		bin[idx].Entry = AccountIndexEntry{Slot: binary.LittleEndian.Uint64(record[32:]),
+			FileId: binary.LittleEndian.Uint64(record[40:]), Offset: binary.LittleEndian.Uint64(record[48:])}
+	}
+	return bin, nil
+}
+
+func (bins *indexSnapshotBins) close() {
+	for _, file := range bins.files {
+		file.Close()
+	}
+}
@@ -0,0 +1,227 @@
+package accountsdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Overclock-Validator/sniper"
+	"github.com/gagliardetto/solana-go"
+)
+
+// the range index buckets pubkeys by the first rangeIndexPrefixLen bytes of the pubkey
+const rangeIndexPrefixLen = 3
+
+// the number of pubkeys accumulated in memory whilst building the range index before being
+// merged into its buckets
+const rangeIndexBuildBatchSize = 1 << 22
+
+// set in the range index once it has been fully built from the appendvecs
+var rangeIndexBuiltKey = []byte("built")
+
+// The account index is a hash table, and hence cannot enumerate the pubkeys in a range, as
+// rent collection requires. The range index supplements it with a kv store mapping each
+// pubkey prefix to the sorted list of pubkeys with that prefix. It is built from the
+// appendvecs upon first use, and thereafter maintained by StoreAccounts.
+//
+// Pubkeys are never removed from the range index, so it may contain pubkeys of accounts that
+// have since been removed from the index by Clean.
+
+func rangeIndexPrefix(pubkey solana.PublicKey) uint32 {
+	return uint32(pubkey[0])<<16 | uint32(pubkey[1])<<8 | uint32(pubkey[2])
+}
+
+func rangeIndexKey(prefix uint32) []byte {
+	var key [4]byte
+	binary.BigEndian.PutUint32(key[:], prefix)
+	return key[4-rangeIndexPrefixLen:]
+}
+
+func unmarshalRangeIndexBucket(data []byte) []solana.PublicKey {
+	pubkeys := make([]solana.PublicKey, len(data)/solana.PublicKeyLength)
+	for idx := range pubkeys {
+		copy(pubkeys[idx][:], data[idx*solana.PublicKeyLength:])
+	}
+	return pubkeys
+}
+
+// sortAndDedupePubkeys sorts the pubkeys and removes duplicates in place.
+func sortAndDedupePubkeys(pubkeys []solana.PublicKey) []solana.PublicKey {
+	sort.Slice(pubkeys, func(i, j int) bool {
+		return bytes.Compare(pubkeys[i][:], pubkeys[j][:]) < 0
+	})
+
+	deduped := pubkeys[:0]
+	for idx, pubkey := range pubkeys {
+		if idx == 0 || pubkey != pubkeys[idx-1] {
+			deduped = append(deduped, pubkey)
+		}
+	}
+	return deduped
+}
+
+// addToRangeIndexLocked adds the pubkeys, which must all share the same prefix, to the
+// prefix's bucket in the range index. The caller must hold indexLock.
+func (accountsDb *AccountsDb) addToRangeIndexLocked(prefix uint32, pubkeys []solana.PublicKey) error {
+	key := rangeIndexKey(prefix)
+
+	existing, err := accountsDb.rangeIndexDb.Get(key)
+	if err == nil {
+		pubkeys = append(unmarshalRangeIndexBucket(existing), pubkeys...)
+	}
+	pubkeys = sortAndDedupePubkeys(pubkeys)
+
+	if len(pubkeys) == len(existing)/solana.PublicKeyLength {
+		return nil
+	}
+
+	bucket := make([]byte, 0, len(pubkeys)*solana.PublicKeyLength)
+	for _, pubkey := range pubkeys {
+		bucket = append(bucket, pubkey[:]...)
+	}
+
+	return accountsDb.rangeIndexDb.Set(key, bucket, 0)
+}
+
+// openRangeIndexLocked opens the range index, building it from the appendvecs if it has not
+// yet been built. The caller must hold indexLock.
+func (accountsDb *AccountsDb) openRangeIndexLocked() error {
+	if accountsDb.rangeIndexDb != nil {
+		return nil
+	}
+
+	rangeIndexDir := fmt.Sprintf("%s/range_index", accountsDb.dbDir)
+	db, err := sniper.Open(sniper.Dir(rangeIndexDir), sniper.ChunksCollision(32))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Get(rangeIndexBuiltKey)
+	if err == nil {
+		accountsDb.rangeIndexDb = db
+		return nil
+	}
+
+	// a partially built range index is rebuilt from scratch
+	db.Close()
+	err = os.RemoveAll(rangeIndexDir)
+	if err != nil {
+		return err
+	}
+
+	db, err = sniper.Open(sniper.Dir(rangeIndexDir), sniper.ChunksCollision(32))
+	if err != nil {
+		return err
+	}
+	accountsDb.rangeIndexDb = db
+
+	err = accountsDb.buildRangeIndexLocked()
+	if err != nil {
+		accountsDb.rangeIndexDb = nil
+		db.Close()
+		return fmt.Errorf("failed to build range index: %w", err)
+	}
+
+	return db.Set(rangeIndexBuiltKey, []byte{1}, 0)
+}
+
+func (accountsDb *AccountsDb) buildRangeIndexLocked() error {
+	release := accountsDb.RetainAppendVecs()
+	defer release()
+
+	appendVecs, err := accountsDb.AppendVecs()
+	if err != nil {
+		return err
+	}
+
+	batch := make(map[uint32][]solana.PublicKey)
+	var batchSize int
+
+	flush := func() error {
+		for prefix, pubkeys := range batch {
+			err := accountsDb.addToRangeIndexLocked(prefix, pubkeys)
+			if err != nil {
+				return err
+			}
+		}
+		clear(batch)
+		batchSize = 0
+		return nil
+	}
+
+	for _, appendVec := range appendVecs {
+		err = accountsDb.scanAppendVecHeaders(appendVec, func(pubkey solana.PublicKey, lamports uint64, entry *AccountIndexEntry, size uint64) {
+			prefix := rangeIndexPrefix(pubkey)
+			batch[prefix] = append(batch[prefix], pubkey)
+			batchSize++
+		})
+		if err != nil {
+			return err
+		}
+
+		if batchSize >= rangeIndexBuildBatchSize {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// PubkeysInRangeAtSlot returns the pubkeys of the accounts as of the given slot that fall
+// within the inclusive range [start, end], in ascending order. The pubkeys of accounts that
+// have since been closed may be included, and such accounts are either missing or have zero
+// lamports when read with GetAccountAtSlot.
+//
+// The range index is built upon the first call, which requires a scan of all appendvecs.
+func (accountsDb *AccountsDb) PubkeysInRangeAtSlot(slot uint64, start solana.PublicKey, end solana.PublicKey) ([]solana.PublicKey, error) {
+	inRange := func(pubkey solana.PublicKey) bool {
+		return bytes.Compare(pubkey[:], start[:]) >= 0 && bytes.Compare(pubkey[:], end[:]) <= 0
+	}
+
+	var pubkeys []solana.PublicKey
+
+	accountsDb.forkLock.RLock()
+	for {
+		unrooted, exists := accountsDb.unrooted[slot]
+		if !exists {
+			break
+		}
+
+		for pubkey := range unrooted.accts {
+			if inRange(pubkey) {
+				pubkeys = append(pubkeys, pubkey)
+			}
+		}
+
+		slot = unrooted.parentSlot
+	}
+	accountsDb.forkLock.RUnlock()
+
+	accountsDb.indexLock.Lock()
+	defer accountsDb.indexLock.Unlock()
+
+	err := accountsDb.openRangeIndexLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	for prefix := rangeIndexPrefix(start); prefix <= rangeIndexPrefix(end); prefix++ {
+		bucket, err := accountsDb.rangeIndexDb.Get(rangeIndexKey(prefix))
+		if err != nil {
+			continue
+		}
+
+		for _, pubkey := range unmarshalRangeIndexBucket(bucket) {
+			if inRange(pubkey) {
+				pubkeys = append(pubkeys, pubkey)
+			}
+		}
+	}
+
+	return sortAndDedupePubkeys(pubkeys), nil
+}
@@ -19,6 +19,7 @@ type DB struct {
 	CfDataShred *grocksdb.ColumnFamilyHandle
 	CfCodeShred *grocksdb.ColumnFamilyHandle
 	CfTxStatus  *grocksdb.ColumnFamilyHandle
+	CfBankHash  *grocksdb.ColumnFamilyHandle
 }
 
 func OpenReadWrite(path string) (*DB, error) {
@@ -143,6 +144,8 @@ func getCfOpts(db *DB, name string) (**grocksdb.ColumnFamilyHandle, *grocksdb.Op
 		return &db.CfDataShred, grocksdb.NewDefaultOptions()
 	case CfCodeShred:
 		return &db.CfCodeShred, grocksdb.NewDefaultOptions()
+	case CfBankHash:
+		return &db.CfBankHash, grocksdb.NewDefaultOptions()
 	default:
 		return &handle, grocksdb.NewDefaultOptions()
 	}
@@ -45,3 +45,11 @@ func (s *SlotMeta) IsFull() bool {
 	}
 	return s.Consumed == s.LastIndex+1
 }
+
+// FrozenHashVersioned is data stored in CfBankHash
+type FrozenHashVersioned struct {
+	// only a single version ("Current") is defined so far
+	Version              uint32
+	FrozenHash           [32]byte
+	IsDuplicateConfirmed bool
+}
@@ -3,6 +3,7 @@
 package blockstore
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/linxGnu/grocksdb"
@@ -29,3 +30,12 @@ func (d *DB) GetSlotMeta(slot uint64) (*SlotMeta, error) {
 	key := MakeSlotKey(slot)
 	return GetBincode[SlotMeta](d.DB, d.CfMeta, key[:])
 }
+
+// GetBankHash returns the frozen bank hash of a given slot.
+func (d *DB) GetBankHash(slot uint64) (*FrozenHashVersioned, error) {
+	if d.CfBankHash == nil {
+		return nil, errors.New("missing column family " + CfBankHash)
+	}
+	key := MakeSlotKey(slot)
+	return GetBincode[FrozenHashVersioned](d.DB, d.CfBankHash, key[:])
+}
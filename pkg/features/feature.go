@@ -0,0 +1,125 @@
+package features
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/base58"
+)
+
+// FeatureProgramAddr is the owner of feature gate accounts.
+var FeatureProgramAddr = base58.MustDecodeFromString("Feature111111111111111111111111111111111111")
+
+var ErrInvalidFeatureAccount = errors.New("ErrInvalidFeatureAccount")
+
+// Feature is the state of a feature gate account. A feature whose account exists but has
+// not yet been activated is pending activation, and is activated at the next epoch boundary.
+type Feature struct {
+	ActivatedAt *uint64
+}
+
+func (feature *Feature) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	hasActivatedAt, err := decoder.ReadBool()
+	if err != nil {
+		return err
+	}
+
+	if hasActivatedAt {
+		activatedAt, err := decoder.ReadUint64(bin.LE)
+		if err != nil {
+			return err
+		}
+		feature.ActivatedAt = &activatedAt
+	}
+
+	return nil
+}
+
+func (feature *Feature) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if feature.ActivatedAt == nil {
+		return encoder.WriteBool(false)
+	}
+
+	err := encoder.WriteBool(true)
+	if err != nil {
+		return err
+	}
+	return encoder.WriteUint64(*feature.ActivatedAt, bin.LE)
+}
+
+// UnmarshalFeature decodes the data of a feature gate account.
+func UnmarshalFeature(data []byte) (*Feature, error) {
+	feature := new(Feature)
+	err := feature.UnmarshalWithDecoder(bin.NewBinDecoder(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFeatureAccount, err)
+	}
+	return feature, nil
+}
+
+// Marshal encodes the feature into the start of the feature gate account's data.
+func (feature *Feature) Marshal(data []byte) error {
+	buf := new(bytes.Buffer)
+	err := feature.MarshalWithEncoder(bin.NewBinEncoder(buf))
+	if err != nil {
+		return err
+	}
+
+	if len(data) < buf.Len() {
+		return fmt.Errorf("%w: account data too small", ErrInvalidFeatureAccount)
+	}
+	copy(data, buf.Bytes())
+	return nil
+}
+
+// ApplyFeature records the activation of the feature gate as of the given slot, according to
+// the state of its account. A feature activated after the slot is not yet active. A feature
+// pending activation is activated at the slot if includePending is set, as it is in the first
+// slot of an epoch, in which case ApplyFeature returns true, and the feature's account must be
+// updated to record the activation slot.
+func (f *Features) ApplyFeature(gate FeatureGate, feature *Feature, slot uint64, includePending bool) bool {
+	if feature.ActivatedAt == nil {
+		if !includePending {
+			return false
+		}
+		activatedAt := slot
+		feature.ActivatedAt = &activatedAt
+		f.EnableFeature(gate, slot)
+		return true
+	}
+
+	if *feature.ActivatedAt <= slot {
+		f.EnableFeature(gate, *feature.ActivatedAt)
+	}
+	return false
+}
+
+// Hash returns a hash of the set of enabled features and their activation slots, which
+// identifies the exact feature set in effect irrespective of the order features were enabled
+// in.
+func (f *Features) Hash() [32]byte {
+	enabled := make([]FeatureGate, 0, len(*f))
+	for gate, info := range *f {
+		if info.Enabled {
+			enabled = append(enabled, gate)
+		}
+	}
+	slices.SortFunc(enabled, func(a, b FeatureGate) int {
+		return bytes.Compare(a.Address[:], b.Address[:])
+	})
+
+	hasher := sha256.New()
+	for _, gate := range enabled {
+		hasher.Write(gate.Address[:])
+		hasher.Write(binary.LittleEndian.AppendUint64(nil, (*f)[gate].ActivationSlot))
+	}
+
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
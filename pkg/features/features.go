@@ -4,9 +4,15 @@ import (
 	"fmt"
 )
 
+//go:generate go run gen_gates.go
+
+// FeatureGate is a feature gate of the runtime. Implemented is set for the feature gates whose
+// behaviour mithril implements.
 type FeatureGate struct {
-	Name    string
-	Address [32]byte
+	Name        string
+	Address     [32]byte
+	Description string
+	Implemented bool
 }
 
 type FeatureActivationInfo struct {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // The TestFflags_EnableAndDisable function tests that the
@@ -25,3 +26,81 @@ func TestFflags_ListEnabled(t *testing.T) {
 	f.EnableFeature(StopTruncatingStringsInSyscalls, 0)
 	assert.Equal(t, f.AllEnabled(), []string{"feature StopTruncatingStringsInSyscalls (16FMCmgLzCNNz6eTwGanbyN2ZxvTBSLuQ6DZhgeMshg) enabled"})
 }
+
+func TestFeature_MarshalAndUnmarshal(t *testing.T) {
+	feature, err := UnmarshalFeature([]byte{1, 0x39, 0x30, 0, 0, 0, 0, 0, 0})
+	require.NoError(t, err)
+	require.NotNil(t, feature.ActivatedAt)
+	assert.Equal(t, uint64(12345), *feature.ActivatedAt)
+
+	feature, err = UnmarshalFeature(make([]byte, 9))
+	require.NoError(t, err)
+	assert.Nil(t, feature.ActivatedAt)
+
+	_, err = UnmarshalFeature([]byte{1, 0x39})
+	assert.ErrorIs(t, err, ErrInvalidFeatureAccount)
+
+	activatedAt := uint64(12345)
+	data := make([]byte, 9)
+	require.NoError(t, (&Feature{ActivatedAt: &activatedAt}).Marshal(data))
+	assert.Equal(t, []byte{1, 0x39, 0x30, 0, 0, 0, 0, 0, 0}, data)
+	assert.ErrorIs(t, (&Feature{ActivatedAt: &activatedAt}).Marshal(make([]byte, 1)), ErrInvalidFeatureAccount)
+}
+
+func TestFeatures_ApplyFeature(t *testing.T) {
+	f := NewFeaturesDefault()
+
+	// features activated at or before the slot are enabled with their activation slot
+	activatedAt := uint64(100)
+	assert.False(t, f.ApplyFeature(StopTruncatingStringsInSyscalls, &Feature{ActivatedAt: &activatedAt}, 100, false))
+	activationSlot, enabled := f.ActivationSlot(StopTruncatingStringsInSyscalls)
+	assert.True(t, enabled)
+	assert.Equal(t, uint64(100), activationSlot)
+
+	assert.False(t, f.ApplyFeature(LastRestartSlotSysvar, &Feature{ActivatedAt: &activatedAt}, 99, false))
+	assert.False(t, f.IsActive(LastRestartSlotSysvar))
+
+	// pending features are only activated at epoch boundaries
+	feature := &Feature{}
+	assert.False(t, f.ApplyFeature(EnablePartitionedEpochReward, feature, 200, false))
+	assert.False(t, f.IsActive(EnablePartitionedEpochReward))
+	assert.Nil(t, feature.ActivatedAt)
+
+	assert.True(t, f.ApplyFeature(EnablePartitionedEpochReward, feature, 200, true))
+	activationSlot, enabled = f.ActivationSlot(EnablePartitionedEpochReward)
+	assert.True(t, enabled)
+	assert.Equal(t, uint64(200), activationSlot)
+	require.NotNil(t, feature.ActivatedAt)
+	assert.Equal(t, uint64(200), *feature.ActivatedAt)
+}
+
+func TestFeatures_Hash(t *testing.T) {
+	f1 := NewFeaturesDefault()
+	f1.EnableFeature(StopTruncatingStringsInSyscalls, 10)
+	f1.EnableFeature(EnablePartitionedEpochReward, 20)
+
+	f2 := NewFeaturesDefault()
+	f2.EnableFeature(EnablePartitionedEpochReward, 20)
+	f2.EnableFeature(StopTruncatingStringsInSyscalls, 10)
+	f2.DisableFeature(LastRestartSlotSysvar)
+	assert.Equal(t, f1.Hash(), f2.Hash())
+
+	f2.EnableFeature(StopTruncatingStringsInSyscalls, 11)
+	assert.NotEqual(t, f1.Hash(), f2.Hash())
+
+	f2.EnableFeature(StopTruncatingStringsInSyscalls, 10)
+	f2.EnableFeature(LastRestartSlotSysvar, 10)
+	assert.NotEqual(t, f1.Hash(), f2.Hash())
+}
+
+func TestFflags_AllFeatureGatesUnique(t *testing.T) {
+	names := make(map[string]bool)
+	addresses := make(map[[32]byte]bool)
+	for _, gate := range AllFeatureGates {
+		assert.False(t, names[gate.Name], "duplicate feature gate name %s", gate.Name)
+		assert.False(t, addresses[gate.Address], "duplicate feature gate address for %s", gate.Name)
+		assert.NotEmpty(t, gate.Description, "feature gate %s has no description", gate.Name)
+		names[gate.Name] = true
+		addresses[gate.Address] = true
+	}
+}
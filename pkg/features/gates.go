@@ -1,40 +1,398 @@
+// Code generated by gen_gates.go from gates.csv; DO NOT EDIT.
+
 package features
 
-import (
-	"go.firedancer.io/radiance/pkg/base58"
-)
+import "go.firedancer.io/radiance/pkg/base58"
 
-var StopTruncatingStringsInSyscalls = FeatureGate{Name: "StopTruncatingStringsInSyscalls", Address: base58.MustDecodeFromString("16FMCmgLzCNNz6eTwGanbyN2ZxvTBSLuQ6DZhgeMshg")}
-var EnablePartitionedEpochReward = FeatureGate{Name: "EnablePartitionedEpochReward", Address: base58.MustDecodeFromString("41tVp5qR1XwWRt5WifvtSQyuxtqQWJgEK8w91AtBqSwP")}
-var LastRestartSlotSysvar = FeatureGate{Name: "LastRestartSlotSysvar", Address: base58.MustDecodeFromString("HooKD5NC9QNxk25QuzCssB8ecrEzGt6eXEPBUxWp1LaR")}
-var Libsecp256k1FailOnBadCount = FeatureGate{Name: "Libsecp256k1FailOnBadCount", Address: base58.MustDecodeFromString("8aXvSuopd1PUj7UhehfXJRg6619RHp8ZvwTyyJHdUYsj")}
-var Libsecp256k1FailOnBadCount2 = FeatureGate{Name: "Libsecp256k1FailOnBadCount2", Address: base58.MustDecodeFromString("54KAoNiUERNoWWUhTWWwXgym94gzoXFVnHyQwPA18V9A")}
-var EnableBpfLoaderSetAuthorityCheckedIx = FeatureGate{Name: "EnableBpfLoaderSetAuthorityCheckedIx", Address: base58.MustDecodeFromString("5x3825XS7M2A3Ekbn5VGGkvFoAg5qrRWkTrY4bARP1GL")}
-var LoosenCpiSizeRestriction = FeatureGate{Name: "LoosenCpiSizeRestriction", Address: base58.MustDecodeFromString("GDH5TVdbTPUpRnXaRyQqiKUa7uZAbZ28Q2N9bhbKoMLm")}
-var IncreaseTxAccountLockLimit = FeatureGate{Name: "IncreaseTxAccountLockLimit", Address: base58.MustDecodeFromString("9LZdXeKGeBV6hRLdxS1rHbHoEUsKqesCC2ZAPTPKJAbK")}
-var VoteStateAddVoteLatency = FeatureGate{Name: "VoteStateAddVoteLatency", Address: base58.MustDecodeFromString("7axKe5BTYBDD87ftzWbk5DfzWMGyRvqmWTduuo22Yaqy")}
-var AllowCommissionDecreaseAtAnyTime = FeatureGate{Name: "AllowCommissionDecreaseAtAnyTime", Address: base58.MustDecodeFromString("decoMktMcnmiq6t3u7g5BfgcQu91nKZr6RvMYf9z1Jb")}
-var CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch = FeatureGate{Name: "CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch", Address: base58.MustDecodeFromString("noRuG2kzACwgaY7TVmLRnUNPLKNVQE1fb7X55YWBehp")}
-var TimelyVoteCredits = FeatureGate{Name: "TimelyVoteCredits", Address: base58.MustDecodeFromString("2oXpeh141pPZCTCFHBsvCwG2BtaHZZAtrVhwaxSy6brS")}
-var ReduceStakeWarmupCooldown = FeatureGate{Name: "ReduceStakeWarmupCooldown", Address: base58.MustDecodeFromString("GwtDQBghCTBgmX2cpEGNPxTEBUTQRaDMGTr5qychdGMj")}
-var StakeRaiseMinimumDelegationTo1Sol = FeatureGate{Name: "StakeRaiseMinimumDelegationTo1Sol", Address: base58.MustDecodeFromString("9onWzzvCzNC2jfhxxeqRgs5q7nFAAKpCUvkj6T6GJK9i")}
-var StakeRedelegateInstruction = FeatureGate{Name: "StakeRedelegateInstruction", Address: base58.MustDecodeFromString("2KKG3C6RBnxQo9jVVrbzsoSh41TDXLK7gBc9gduyxSzW")}
-var RequireRentExemptSplitDestination = FeatureGate{Name: "RequireRentExemptSplitDestination", Address: base58.MustDecodeFromString("D2aip4BBr8NPWtU9vLrwrBvbuaQ8w1zV38zFLxx4pfBV")}
-var DeprecateExecutableMetaUpdateInBpfLoader = FeatureGate{Name: "DeprecateExecutableMetaUpdateInBpfLoader", Address: base58.MustDecodeFromString("k6uR1J9VtKJnTukBV2Eo15BEy434MBg8bT6hHQgmU8v")}
-var RelaxAuthoritySignerCheckForLookupTableCreation = FeatureGate{Name: "RelaxAuthoritySignerCheckForLookupTableCreation", Address: base58.MustDecodeFromString("FKAcEvNgSY79RpqsPNUV5gDyumopH4cEHqUxyfm8b8Ap")}
-var DedupeConfigProgramSigners = FeatureGate{Name: "DedupeConfigProgramSigners", Address: base58.MustDecodeFromString("8kEuAshXLsgkUEdcFVLqrjCGGHVWFW99ZZpxvAzzMtBp")}
-var Ed25519PrecompileVerifyStrict = FeatureGate{Name: "Ed25519PrecompileVerifyStrict", Address: base58.MustDecodeFromString("ed9tNscbWLYBooxWA7FE2B5KHWs8A6sxfY8EzezEcoo")}
-var AbortOnInvalidCurve = FeatureGate{Name: "AbortOnInvalidCurve", Address: base58.MustDecodeFromString("FuS3FPfJDKSNot99ECLXtp3rueq36hMNStJkPJwWodLh")}
-var Curve25519SyscallEnabled = FeatureGate{Name: "Curve25519SyscallEnabled", Address: base58.MustDecodeFromString("7rcw5UtqgDTBBv2EcynNfYckgdAaH1MAsCjKgXMkN7Ri")}
-var SimplifyAltBn128SyscallErrorCodes = FeatureGate{Name: "SimplityAltBn128SyscallErrorCodes", Address: base58.MustDecodeFromString("JDn5q3GBeqzvUa7z67BbmVHVdE3EbUAjvFep3weR3jxX")}
-var EnableAltbn128CompressionSyscall = FeatureGate{Name: "EnableAltbn128CompressionSyscall", Address: base58.MustDecodeFromString("EJJewYSddEEtSZHiqugnvhQHiWyZKjkFDQASd7oKSagn")}
-var EnableAltBn128Syscall = FeatureGate{Name: "EnableAltBn128Syscall", Address: base58.MustDecodeFromString("A16q37opZdQMCbe5qJ6xpBB9usykfv8jZaMkxvZQi4GJ")}
+var StopTruncatingStringsInSyscalls = FeatureGate{Name: "StopTruncatingStringsInSyscalls", Address: base58.MustDecodeFromString("16FMCmgLzCNNz6eTwGanbyN2ZxvTBSLuQ6DZhgeMshg"), Description: "Stop truncating strings in syscalls", Implemented: true}
+var EnablePartitionedEpochReward = FeatureGate{Name: "EnablePartitionedEpochReward", Address: base58.MustDecodeFromString("41tVp5qR1XwWRt5WifvtSQyuxtqQWJgEK8w91AtBqSwP"), Description: "Enable partitioned epoch reward", Implemented: true}
+var LastRestartSlotSysvar = FeatureGate{Name: "LastRestartSlotSysvar", Address: base58.MustDecodeFromString("HooKD5NC9QNxk25QuzCssB8ecrEzGt6eXEPBUxWp1LaR"), Description: "Enable new sysvar last_restart_slot", Implemented: true}
+var Libsecp256k1FailOnBadCount = FeatureGate{Name: "Libsecp256k1FailOnBadCount", Address: base58.MustDecodeFromString("8aXvSuopd1PUj7UhehfXJRg6619RHp8ZvwTyyJHdUYsj"), Description: "Fail libsecp256k1_verify if count appears wrong", Implemented: true}
+var Libsecp256k1FailOnBadCount2 = FeatureGate{Name: "Libsecp256k1FailOnBadCount2", Address: base58.MustDecodeFromString("54KAoNiUERNoWWUhTWWwXgym94gzoXFVnHyQwPA18V9A"), Description: "Fail libsecp256k1_verify if count appears wrong", Implemented: true}
+var EnableBpfLoaderSetAuthorityCheckedIx = FeatureGate{Name: "EnableBpfLoaderSetAuthorityCheckedIx", Address: base58.MustDecodeFromString("5x3825XS7M2A3Ekbn5VGGkvFoAg5qrRWkTrY4bARP1GL"), Description: "Enable bpf upgradeable loader SetAuthorityChecked instruction", Implemented: true}
+var LoosenCpiSizeRestriction = FeatureGate{Name: "LoosenCpiSizeRestriction", Address: base58.MustDecodeFromString("GDH5TVdbTPUpRnXaRyQqiKUa7uZAbZ28Q2N9bhbKoMLm"), Description: "Loosen cpi size restrictions", Implemented: true}
+var IncreaseTxAccountLockLimit = FeatureGate{Name: "IncreaseTxAccountLockLimit", Address: base58.MustDecodeFromString("9LZdXeKGeBV6hRLdxS1rHbHoEUsKqesCC2ZAPTPKJAbK"), Description: "Increase tx account lock limit to 128", Implemented: true}
+var VoteStateAddVoteLatency = FeatureGate{Name: "VoteStateAddVoteLatency", Address: base58.MustDecodeFromString("7axKe5BTYBDD87ftzWbk5DfzWMGyRvqmWTduuo22Yaqy"), Description: "Replace Lockout with LandedVote (including vote latency) in vote state", Implemented: true}
+var AllowCommissionDecreaseAtAnyTime = FeatureGate{Name: "AllowCommissionDecreaseAtAnyTime", Address: base58.MustDecodeFromString("decoMktMcnmiq6t3u7g5BfgcQu91nKZr6RvMYf9z1Jb"), Description: "Allow commission decrease at any time in epoch", Implemented: true}
+var CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch = FeatureGate{Name: "CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch", Address: base58.MustDecodeFromString("noRuG2kzACwgaY7TVmLRnUNPLKNVQE1fb7X55YWBehp"), Description: "Vote account commission updates only allowed in first half of epoch", Implemented: true}
+var TimelyVoteCredits = FeatureGate{Name: "TimelyVoteCredits", Address: base58.MustDecodeFromString("2oXpeh141pPZCTCFHBsvCwG2BtaHZZAtrVhwaxSy6brS"), Description: "Use timeliness of votes in determining credits to award", Implemented: true}
+var ReduceStakeWarmupCooldown = FeatureGate{Name: "ReduceStakeWarmupCooldown", Address: base58.MustDecodeFromString("GwtDQBghCTBgmX2cpEGNPxTEBUTQRaDMGTr5qychdGMj"), Description: "Reduce stake warmup cooldown from 25% to 9%", Implemented: true}
+var StakeRaiseMinimumDelegationTo1Sol = FeatureGate{Name: "StakeRaiseMinimumDelegationTo1Sol", Address: base58.MustDecodeFromString("9onWzzvCzNC2jfhxxeqRgs5q7nFAAKpCUvkj6T6GJK9i"), Description: "Raise minimum stake delegation to 1.0 SOL", Implemented: true}
+var StakeRedelegateInstruction = FeatureGate{Name: "StakeRedelegateInstruction", Address: base58.MustDecodeFromString("2KKG3C6RBnxQo9jVVrbzsoSh41TDXLK7gBc9gduyxSzW"), Description: "Enable the redelegate stake instruction", Implemented: true}
+var RequireRentExemptSplitDestination = FeatureGate{Name: "RequireRentExemptSplitDestination", Address: base58.MustDecodeFromString("D2aip4BBr8NPWtU9vLrwrBvbuaQ8w1zV38zFLxx4pfBV"), Description: "Require stake split destination account to be rent exempt", Implemented: true}
+var DeprecateExecutableMetaUpdateInBpfLoader = FeatureGate{Name: "DeprecateExecutableMetaUpdateInBpfLoader", Address: base58.MustDecodeFromString("k6uR1J9VtKJnTukBV2Eo15BEy434MBg8bT6hHQgmU8v"), Description: "Deprecate executable meta flag update in bpf loader", Implemented: true}
+var RelaxAuthoritySignerCheckForLookupTableCreation = FeatureGate{Name: "RelaxAuthoritySignerCheckForLookupTableCreation", Address: base58.MustDecodeFromString("FKAcEvNgSY79RpqsPNUV5gDyumopH4cEHqUxyfm8b8Ap"), Description: "Relax authority signer check for lookup table creation", Implemented: true}
+var DedupeConfigProgramSigners = FeatureGate{Name: "DedupeConfigProgramSigners", Address: base58.MustDecodeFromString("8kEuAshXLsgkUEdcFVLqrjCGGHVWFW99ZZpxvAzzMtBp"), Description: "Dedupe config program signers", Implemented: false}
+var Ed25519PrecompileVerifyStrict = FeatureGate{Name: "Ed25519PrecompileVerifyStrict", Address: base58.MustDecodeFromString("ed9tNscbWLYBooxWA7FE2B5KHWs8A6sxfY8EzezEcoo"), Description: "Use strict verification in ed25519 precompile", Implemented: true}
+var AbortOnInvalidCurve = FeatureGate{Name: "AbortOnInvalidCurve", Address: base58.MustDecodeFromString("FuS3FPfJDKSNot99ECLXtp3rueq36hMNStJkPJwWodLh"), Description: "Abort when elliptic curve syscalls invoked on invalid curve id", Implemented: true}
+var Curve25519SyscallEnabled = FeatureGate{Name: "Curve25519SyscallEnabled", Address: base58.MustDecodeFromString("7rcw5UtqgDTBBv2EcynNfYckgdAaH1MAsCjKgXMkN7Ri"), Description: "Enable curve25519 syscalls", Implemented: true}
+var SimplifyAltBn128SyscallErrorCodes = FeatureGate{Name: "SimplifyAltBn128SyscallErrorCodes", Address: base58.MustDecodeFromString("JDn5q3GBeqzvUa7z67BbmVHVdE3EbUAjvFep3weR3jxX"), Description: "Simplify alt_bn128 syscall error codes", Implemented: false}
+var EnableAltbn128CompressionSyscall = FeatureGate{Name: "EnableAltbn128CompressionSyscall", Address: base58.MustDecodeFromString("EJJewYSddEEtSZHiqugnvhQHiWyZKjkFDQASd7oKSagn"), Description: "Enable alt_bn128 compression syscalls", Implemented: true}
+var EnableAltBn128Syscall = FeatureGate{Name: "EnableAltBn128Syscall", Address: base58.MustDecodeFromString("A16q37opZdQMCbe5qJ6xpBB9usykfv8jZaMkxvZQi4GJ"), Description: "Enable alt_bn128 syscalls", Implemented: true}
+var MovePrecompileVerificationToSvm = FeatureGate{Name: "MovePrecompileVerificationToSvm", Address: base58.MustDecodeFromString("9ypxGLzkMxi89eDerRKXWDXe44UY2z4hBig4mDhNq5Dp"), Description: "Move precompile verification into the SVM", Implemented: true}
+var SetExemptRentEpochMax = FeatureGate{Name: "SetExemptRentEpochMax", Address: base58.MustDecodeFromString("5wAGiy15X1Jb2hkHnPDCM8oB9V42VNA9ftNVFK84dEgv"), Description: "Set rent epoch to Epoch::MAX for rent-exempt accounts", Implemented: true}
+var SkipRentRewrites = FeatureGate{Name: "SkipRentRewrites", Address: base58.MustDecodeFromString("CGB2jM8pwZkeeiXQ66kBMyBR6Np61mggL7XUsmLjVcrw"), Description: "Skip rewriting rent exempt accounts during rent collection", Implemented: true}
+var DisableRentFeesCollection = FeatureGate{Name: "DisableRentFeesCollection", Address: base58.MustDecodeFromString("CJzY83ggJHqPGDq8VisV3U91jDJLuEaALZooBrXtnnLU"), Description: "Disable rent fees collection", Implemented: true}
+var PicoInflation = FeatureGate{Name: "PicoInflation", Address: base58.MustDecodeFromString("4RWNif6C2WCNiKVW7otP4G7dkmkHGyKQWRpuZ1pxKU5m"), Description: "Pico inflation", Implemented: true}
+var FullInflationDevnetAndTestnet = FeatureGate{Name: "FullInflationDevnetAndTestnet", Address: base58.MustDecodeFromString("DT4n6ABDqs6w4bnfwrXT9rsprcPf6cdDga1egctaPkLC"), Description: "Full inflation on devnet and testnet", Implemented: true}
+var FullInflationMainnetCertusoneVote = FeatureGate{Name: "FullInflationMainnetCertusoneVote", Address: base58.MustDecodeFromString("BzBBveUDymEYoYzcMWNQCx3cd4jQs7puaVFHLtsbB6fm"), Description: "Community vote allowing Certus One to enable full inflation", Implemented: true}
+var FullInflationMainnetCertusoneEnable = FeatureGate{Name: "FullInflationMainnetCertusoneEnable", Address: base58.MustDecodeFromString("7XRJcS5Ud5vxGB54JbK9N2vBZVwnwdBNeJW1ibRgD9gx"), Description: "Full inflation enabled by Certus One", Implemented: true}
+var DeprecateRewardsSysvar = FeatureGate{Name: "DeprecateRewardsSysvar", Address: base58.MustDecodeFromString("GaBtBJvmS4Arjj5W1NmFcyvPjsHN38UGYDq2MDwbs9Qu"), Description: "Deprecate unused rewards sysvar", Implemented: false}
+var Secp256k1ProgramEnabled = FeatureGate{Name: "Secp256k1ProgramEnabled", Address: base58.MustDecodeFromString("E3PHP7w8kB7np3CTQ1qQ2tW3KCtjRSXBQgW9vM2mWv2Y"), Description: "Secp256k1 program", Implemented: false}
+var SplTokenV2MultisigFix = FeatureGate{Name: "SplTokenV2MultisigFix", Address: base58.MustDecodeFromString("E5JiFDQCwyC6QfT9REFyMpfK2mHcmv1GUDySU1Ue7TYv"), Description: "Spl-token multisig fix", Implemented: false}
+var NoOverflowRentDistribution = FeatureGate{Name: "NoOverflowRentDistribution", Address: base58.MustDecodeFromString("4kpdyrcj5jS47CZb2oJGfVxjYbsMm2Kx97gFyZrxxwXz"), Description: "No overflow rent distribution", Implemented: false}
+var FilterStakeDelegationAccounts = FeatureGate{Name: "FilterStakeDelegationAccounts", Address: base58.MustDecodeFromString("GE7fRxmW46K6EmCD9AMZSbnaJ2e3LfqCZzdHi9hmYAgi"), Description: "Filter stake_delegation_accounts #14062", Implemented: false}
+var RequireCustodianForLockedStakeAuthorize = FeatureGate{Name: "RequireCustodianForLockedStakeAuthorize", Address: base58.MustDecodeFromString("D4jsDcXaqdW8tDAWn8H4R25Cdns2YwLneujSL1zvjW6R"), Description: "Require custodian to authorize withdrawer change for locked stake", Implemented: false}
+var SplTokenV2SelfTransferFix = FeatureGate{Name: "SplTokenV2SelfTransferFix", Address: base58.MustDecodeFromString("BL99GYhdjjcv6ys22C9wPgn2aTVERDbPHHo4NbS3hgp7"), Description: "Spl-token self-transfer fix", Implemented: false}
+var WarpTimestampAgain = FeatureGate{Name: "WarpTimestampAgain", Address: base58.MustDecodeFromString("GvDsGDkH5gyzwpDhxNixx8vtx1kwYHH13RiNAPw27zXb"), Description: "Warp timestamp again and adjust bounding to 25% fast 80% slow #15204", Implemented: false}
+var CheckInitVoteData = FeatureGate{Name: "CheckInitVoteData", Address: base58.MustDecodeFromString("3ccR6QpxGYsAbWyfevEtBNGfWV4xBffxRj2tD6A9i39F"), Description: "Check initialized Vote data", Implemented: false}
+var Secp256k1RecoverSyscallEnabled = FeatureGate{Name: "Secp256k1RecoverSyscallEnabled", Address: base58.MustDecodeFromString("6RvdSWHh8oh72Dp7wMTS2DBkf3fRPtChfNrAo3cZZoXJ"), Description: "Secp256k1_recover syscall", Implemented: false}
+var SystemTransferZeroCheck = FeatureGate{Name: "SystemTransferZeroCheck", Address: base58.MustDecodeFromString("BrTR9hzw4WBGFP65AJMbpAo64DcA3U6jdPSga9fMV5cS"), Description: "Perform all checks for transfers of 0 lamports", Implemented: false}
+var Blake3SyscallEnabled = FeatureGate{Name: "Blake3SyscallEnabled", Address: base58.MustDecodeFromString("HTW2pSyErTj4BV6KBM9NZ9VBUJVxt7sacNWcf76wtzb3"), Description: "Blake3 syscall", Implemented: false}
+var VerifyTxSignaturesLen = FeatureGate{Name: "VerifyTxSignaturesLen", Address: base58.MustDecodeFromString("EVW9B5xD9FFK7vw1SBARwMA4s5eRo5eKJdKpsBikzKBz"), Description: "Prohibit extra transaction signatures", Implemented: false}
+var VoteStakeCheckedInstructions = FeatureGate{Name: "VoteStakeCheckedInstructions", Address: base58.MustDecodeFromString("BcWknVcgvonN8sL4HE4XFuEVgfcee5MwxWPAgP6ZV89X"), Description: "Vote/state program checked instructions #18345", Implemented: false}
+var RentForSysvars = FeatureGate{Name: "RentForSysvars", Address: base58.MustDecodeFromString("BKCPBQQBZqggVnFso5nQ8rQ4RwwogYwjuUt9biBjxwNF"), Description: "Collect rent from accounts owned by sysvars", Implemented: false}
+var Libsecp256k105UpgradeEnabled = FeatureGate{Name: "Libsecp256k105UpgradeEnabled", Address: base58.MustDecodeFromString("DhsYfRjxfnh2g7HKJYSzT79r74Afa1wbHkAgHndrA1oy"), Description: "Upgrade libsecp256k1 to v0.5.0", Implemented: false}
+var TxWideComputeCap = FeatureGate{Name: "TxWideComputeCap", Address: base58.MustDecodeFromString("5ekBxc8itEnPv4NzGJtr8BVVQLNMQuLMNQQj7pHoLNZ9"), Description: "Transaction wide compute cap", Implemented: false}
+var SplTokenV2SetAuthorityFix = FeatureGate{Name: "SplTokenV2SetAuthorityFix", Address: base58.MustDecodeFromString("FToKNBYyiF4ky9s8WsmLBXHCht17Ek7RXaLZGHzzQhJ1"), Description: "Spl-token set_authority fix", Implemented: false}
+var MergeNonceErrorIntoSystemError = FeatureGate{Name: "MergeNonceErrorIntoSystemError", Address: base58.MustDecodeFromString("21AWDosvp3pBamFW91KB35pNoaoZVTM7ess8nr2nt53B"), Description: "Merge NonceError into SystemError", Implemented: false}
+var DisableFeesSysvar = FeatureGate{Name: "DisableFeesSysvar", Address: base58.MustDecodeFromString("JAN1trEUEtZjgXYzNBYHU9DYd7GnThhXfFP7SzPXkPsG"), Description: "Disable fees sysvar", Implemented: true}
+var StakeMergeWithUnmatchedCreditsObserved = FeatureGate{Name: "StakeMergeWithUnmatchedCreditsObserved", Address: base58.MustDecodeFromString("meRgp4ArRPhD3KtCY9c5yAf2med7mBLsjKTPeVUHqBL"), Description: "Allow merging active stakes with unmatched credits_observed #18985", Implemented: false}
+var ZkTokenSdkEnabled = FeatureGate{Name: "ZkTokenSdkEnabled", Address: base58.MustDecodeFromString("zk1snxsc6Fh3wsGNbbHAJNHiJoYgF29mMnTSusGx5EJ"), Description: "Enable Zk Token proof program and syscalls", Implemented: true}
+var Curve25519RestrictMsmLength = FeatureGate{Name: "Curve25519RestrictMsmLength", Address: base58.MustDecodeFromString("eca6zf6JJRjQsYYPkBHF3N32MTzur4n2WL4QiiacPCL"), Description: "Restrict curve25519 multiscalar multiplication vector lengths #34763", Implemented: false}
+var VersionedTxMessageEnabled = FeatureGate{Name: "VersionedTxMessageEnabled", Address: base58.MustDecodeFromString("3KZZ6Ks1885aGBQ45fwRcPXVBCtzUvxhUTkwKMR41Tca"), Description: "Enable versioned transaction message processing", Implemented: false}
+var InstructionsSysvarOwnedBySysvar = FeatureGate{Name: "InstructionsSysvarOwnedBySysvar", Address: base58.MustDecodeFromString("H3kBSaKdeiUsyHmeHqjJYNc27jesXZ6zWj3zWkowQbkV"), Description: "Fix owner for instructions sysvar", Implemented: false}
+var StakeProgramAdvanceActivatingCreditsObserved = FeatureGate{Name: "StakeProgramAdvanceActivatingCreditsObserved", Address: base58.MustDecodeFromString("SAdVFw3RZvzbo6DvySbSdBnHN4gkzSTH9dSxesyKKPj"), Description: "Enable advancing credits observed for activation epoch #19309", Implemented: false}
+var CreditsAutoRewind = FeatureGate{Name: "CreditsAutoRewind", Address: base58.MustDecodeFromString("BUS12ciZ5gCoFafUHWW8qaFMMtwFQGVxjsDheWLdqBE2"), Description: "Auto rewind stake's credits_observed if (accidental) vote recreation is detected #22546", Implemented: false}
+var DemoteProgramWriteLocks = FeatureGate{Name: "DemoteProgramWriteLocks", Address: base58.MustDecodeFromString("3E3jV7v9VcdJL8iYZUMax9DiDno8j7EWUVbhm9RtShj2"), Description: "Demote program write locks to readonly except when upgradeable loader present #19593 #20265", Implemented: false}
+var Ed25519ProgramEnabled = FeatureGate{Name: "Ed25519ProgramEnabled", Address: base58.MustDecodeFromString("6ppMXNYLhVd7GcsZ5uV11wQEW7spppiMVfqQv5SXhDpX"), Description: "Enable builtin ed25519 signature verify program", Implemented: false}
+var ReturnDataSyscallEnabled = FeatureGate{Name: "ReturnDataSyscallEnabled", Address: base58.MustDecodeFromString("DwScAzPUjuv65TMbDnFY7AgwmotzWy3xpEJMXM3hZFaB"), Description: "Enable sol_{set/get}_return_data syscall", Implemented: false}
+var ReduceRequiredDeployBalance = FeatureGate{Name: "ReduceRequiredDeployBalance", Address: base58.MustDecodeFromString("EBeznQDjcPG8491sFsKZYBi5S5jTVXMpAKNDJMQPS2kq"), Description: "Reduce required payer balance for program deploys", Implemented: false}
+var SolLogDataSyscallEnabled = FeatureGate{Name: "SolLogDataSyscallEnabled", Address: base58.MustDecodeFromString("6uaHcKPGUy4J7emLBgUTeufhJdiwhngW6a1R9B7c2ob9"), Description: "Enable sol_log_data syscall", Implemented: false}
+var StakesRemoveDelegationIfInactive = FeatureGate{Name: "StakesRemoveDelegationIfInactive", Address: base58.MustDecodeFromString("HFpdDDNQjvcXnXKec697HDDsyk6tFoWS2o8fkxuhQZpL"), Description: "Remove delegations from stakes cache when inactive", Implemented: false}
+var DoSupportRealloc = FeatureGate{Name: "DoSupportRealloc", Address: base58.MustDecodeFromString("75m6ysz33AfLA5DDEzWM1obBrnPQRSsdVQ2nRmc8Vuu1"), Description: "Support account data reallocation", Implemented: false}
+var PreventCallingPrecompilesAsPrograms = FeatureGate{Name: "PreventCallingPrecompilesAsPrograms", Address: base58.MustDecodeFromString("4ApgRX3ud6p7LNMJmsuaAcZY5HWctGPr5obAsjB3A54d"), Description: "Prevent calling precompiles as programs", Implemented: false}
+var OptimizeEpochBoundaryUpdates = FeatureGate{Name: "OptimizeEpochBoundaryUpdates", Address: base58.MustDecodeFromString("265hPS8k8xJ37ot82KEgjRunsUp5w4n4Q4VwwiN9i9ps"), Description: "Optimize epoch boundary updates", Implemented: false}
+var RemoveNativeLoader = FeatureGate{Name: "RemoveNativeLoader", Address: base58.MustDecodeFromString("HTTgmruMYRZEntyL3EdCDdnS6e4D5wRq1FA7kQsb66qq"), Description: "Remove support for the native loader", Implemented: false}
+var SendToTpuVotePort = FeatureGate{Name: "SendToTpuVotePort", Address: base58.MustDecodeFromString("C5fh68nJ7uyKAuYZg2x9sEQ5YrVf3dkW6oojNBSc3Jvo"), Description: "Send votes to the tpu vote port", Implemented: false}
+var RequestableHeapSize = FeatureGate{Name: "RequestableHeapSize", Address: base58.MustDecodeFromString("CCu4boMmfLuqcmfTLPHQiUo22ZdUsXjgzPAURYaWt1Bw"), Description: "Requestable heap frame size", Implemented: false}
+var DisableFeeCalculator = FeatureGate{Name: "DisableFeeCalculator", Address: base58.MustDecodeFromString("2jXx2yDmGysmBKfKYNgLj2DQyAQv6mMk2BPh4eSbyB4H"), Description: "Deprecate fee calculator", Implemented: false}
+var AddComputeBudgetProgram = FeatureGate{Name: "AddComputeBudgetProgram", Address: base58.MustDecodeFromString("4d5AKtxoh93Dwm1vHXUU3iRATuMndx1c431KgT2td52r"), Description: "Add compute_budget_program", Implemented: false}
+var NonceMustBeWritable = FeatureGate{Name: "NonceMustBeWritable", Address: base58.MustDecodeFromString("BiCU7M5w8ZCMykVSyhZ7Q3m2SWoR2qrEQ86ERcDX77ME"), Description: "Nonce must be writable", Implemented: false}
+var SplTokenV330Release = FeatureGate{Name: "SplTokenV330Release", Address: base58.MustDecodeFromString("Ftok2jhqAqxUWEiCVRrfRs9DPppWP8cgTB7NQNKL88mS"), Description: "Spl-token v3.3.0 release", Implemented: false}
+var LeaveNonceOnSuccess = FeatureGate{Name: "LeaveNonceOnSuccess", Address: base58.MustDecodeFromString("E8MkiWZNNPGU6n55jkGzyj8ghUmjCHRmDFdYYFYHxWhQ"), Description: "Leave nonce as is on success", Implemented: false}
+var RejectEmptyInstructionWithoutProgram = FeatureGate{Name: "RejectEmptyInstructionWithoutProgram", Address: base58.MustDecodeFromString("9kdtFSrXHQg3hKkbXkQ6trJ3Ja1xpJ22CTFSNAciEwmL"), Description: "Fail instructions which have native_loader as program_id directly", Implemented: false}
+var FixedMemcpyNonoverlappingCheck = FeatureGate{Name: "FixedMemcpyNonoverlappingCheck", Address: base58.MustDecodeFromString("36PRUK2Dz6HWYdG9SpjeAsF5F3KxnFCakA2BZMbtMhSb"), Description: "Use correct check for nonoverlapping regions in memcpy syscall", Implemented: false}
+var RejectNonRentExemptVoteWithdraws = FeatureGate{Name: "RejectNonRentExemptVoteWithdraws", Address: base58.MustDecodeFromString("7txXZZD6Um59YoLMF7XUNimbMjsqsWhc7g2EniiTrmp1"), Description: "Fail vote withdraw instructions which leave the account non-rent-exempt", Implemented: false}
+var EvictInvalidStakesCacheEntries = FeatureGate{Name: "EvictInvalidStakesCacheEntries", Address: base58.MustDecodeFromString("EMX9Q7TVFAmQ9V1CggAkhMzhXSg8ECp7fHrWQX2G1chf"), Description: "Evict invalid stakes cache entries on epoch boundaries", Implemented: false}
+var AllowVotesToDirectlyUpdateVoteState = FeatureGate{Name: "AllowVotesToDirectlyUpdateVoteState", Address: base58.MustDecodeFromString("Ff8b1fBeB86q8cjq47ZhsQLgv5EkHu3G1C99zjUfAzrq"), Description: "Enable direct vote state update", Implemented: false}
+var MaxTxAccountLocks = FeatureGate{Name: "MaxTxAccountLocks", Address: base58.MustDecodeFromString("CBkDroRDqm8HwHe6ak9cguPjUomrASEkfmxEaZ5CNNxz"), Description: "Enforce max number of locked accounts per transaction", Implemented: false}
+var RequireRentExemptAccounts = FeatureGate{Name: "RequireRentExemptAccounts", Address: base58.MustDecodeFromString("BkFDxiJQWZXGTZaJQxH7wVEHkAmwCgSEVkrvswFfRJPD"), Description: "Require all new transaction accounts with data to be rent-exempt", Implemented: false}
+var FilterVotesOutsideSlotHashes = FeatureGate{Name: "FilterVotesOutsideSlotHashes", Address: base58.MustDecodeFromString("3gtZPqvPpsbXZVCx6hceMfWxtsmrjMzmg8C7PLKSxS2d"), Description: "Filter vote slots older than the earliest slot present in the slot hashes history", Implemented: false}
+var UpdateSyscallBaseCosts = FeatureGate{Name: "UpdateSyscallBaseCosts", Address: base58.MustDecodeFromString("2h63t332mGCCsWK2nqqqHhN4U9ayyqhLVFvczznHDoTZ"), Description: "Update syscall base costs", Implemented: false}
+var StakeDeactivateDelinquentInstruction = FeatureGate{Name: "StakeDeactivateDelinquentInstruction", Address: base58.MustDecodeFromString("437r62HoAdUb63amq3D7ENnBLDhHT2xY8eFkLJYVKK4x"), Description: "Enable the deactivate delinquent stake instruction #23932", Implemented: false}
+var VoteWithdrawAuthorityMayChangeAuthorizedVoter = FeatureGate{Name: "VoteWithdrawAuthorityMayChangeAuthorizedVoter", Address: base58.MustDecodeFromString("AVZS3ZsN4gi6Rkx2QUibYuSJG3S6QHib7xCYhG6vGJxU"), Description: "Vote account withdraw authority may change the authorized voter #22521", Implemented: false}
+var SplAssociatedTokenAccountV104 = FeatureGate{Name: "SplAssociatedTokenAccountV104", Address: base58.MustDecodeFromString("FaTa4SpiaSNH44PGC4z8bnGVTkSRYaWvrBs3KTu8XQQq"), Description: "SPL associated-token-account v1.0.4 release #24125", Implemented: false}
+var RejectVoteAccountCloseUnlessZeroCreditEpoch = FeatureGate{Name: "RejectVoteAccountCloseUnlessZeroCreditEpoch", Address: base58.MustDecodeFromString("ALBk3EWdeAg2WAGf6GPDUf1nynyNqCdEVmgouG7rpuCj"), Description: "Fail vote account withdraw to 0 unless account earned 0 credits in last completed epoch", Implemented: false}
+var AddGetProcessedSiblingInstructionSyscall = FeatureGate{Name: "AddGetProcessedSiblingInstructionSyscall", Address: base58.MustDecodeFromString("CFK1hRCNy8JJuAAY8Pb2GjLFNdCThS2qwZNe3izzBMgn"), Description: "Add add_get_processed_sibling_instruction_syscall", Implemented: false}
+var BankTransactionCountFix = FeatureGate{Name: "BankTransactionCountFix", Address: base58.MustDecodeFromString("Vo5siZ442SaZBKPXNocthiXysNviW4UYPwRFggmbgAp"), Description: "Fixes Bank::transaction_count to include all committed transactions not just successful ones", Implemented: false}
+var DisableBpfDeprecatedLoadInstructions = FeatureGate{Name: "DisableBpfDeprecatedLoadInstructions", Address: base58.MustDecodeFromString("3XgNukcZWf9o3HdA3fpJbm94XFc4qpvTXc8h1wxYwiPi"), Description: "Disable ldabs* and ldind* SBF instructions", Implemented: false}
+var DisableBpfUnresolvedSymbolsAtRuntime = FeatureGate{Name: "DisableBpfUnresolvedSymbolsAtRuntime", Address: base58.MustDecodeFromString("4yuaYAj2jGMGTh1sSmi4G2eFS5VhYRxQUKHGvBgjdh1S"), Description: "Disable reporting of unresolved SBF symbols at runtime", Implemented: false}
+var RecordInstructionInTransactionContextPush = FeatureGate{Name: "RecordInstructionInTransactionContextPush", Address: base58.MustDecodeFromString("3aJdcZqxoLpSBxgeYGjPwaYS1zzcByxUDqJkbzWAH1Zb"), Description: "Move the CPI stack overflow check to the end of push", Implemented: false}
+var SyscallSaturatedMath = FeatureGate{Name: "SyscallSaturatedMath", Address: base58.MustDecodeFromString("HyrbKftCdJ5CrUfEti6x26Cj7rZLNe32weugk7tLcWb8"), Description: "Syscalls use saturated math", Implemented: false}
+var CheckPhysicalOverlapping = FeatureGate{Name: "CheckPhysicalOverlapping", Address: base58.MustDecodeFromString("nWBqjr3gpETbiaVj3CBJ3HFC5TMdnJDGt21hnvSTvVZ"), Description: "Check physical overlapping regions", Implemented: false}
+var LimitSecp256k1RecoveryId = FeatureGate{Name: "LimitSecp256k1RecoveryId", Address: base58.MustDecodeFromString("7g9EUwj4j7CS21Yx1wvgWLjSZeh5aPq8x9kpoPwXM8n8"), Description: "Limit secp256k1 recovery id", Implemented: false}
+var DisableDeprecatedLoader = FeatureGate{Name: "DisableDeprecatedLoader", Address: base58.MustDecodeFromString("GTUMCZ8LTNxVfxdrw7ZsDFTxXb7TutYkzJnFwinpE6dg"), Description: "Disable the deprecated BPF loader", Implemented: false}
+var CheckSliceTranslationSize = FeatureGate{Name: "CheckSliceTranslationSize", Address: base58.MustDecodeFromString("GmC19j9qLn2RFk5NduX6QXaDhVpGncVVBzyM8e9WMz2F"), Description: "Check size when translating slices", Implemented: false}
+var StakeSplitUsesRentSysvar = FeatureGate{Name: "StakeSplitUsesRentSysvar", Address: base58.MustDecodeFromString("FQnc7U4koHqWgRvFaBJjZnV8VPg6L6wWK33yJeDp4yvV"), Description: "Stake split instruction uses rent sysvar", Implemented: false}
+var AddGetMinimumDelegationInstructionToStakeProgram = FeatureGate{Name: "AddGetMinimumDelegationInstructionToStakeProgram", Address: base58.MustDecodeFromString("St8k9dVXP97xT6faW24YmRSYConLbhsMJA4TJTBLmMT"), Description: "Add GetMinimumDelegation instruction to stake program", Implemented: false}
+var ErrorOnSyscallBpfFunctionHashCollisions = FeatureGate{Name: "ErrorOnSyscallBpfFunctionHashCollisions", Address: base58.MustDecodeFromString("8199Q2gMD2kwgfopK5qqVWuDbegLgpuFUFHCcUJQDN8b"), Description: "Error on syscall/bpf function hash collisions", Implemented: false}
+var RejectCallxR10 = FeatureGate{Name: "RejectCallxR10", Address: base58.MustDecodeFromString("3NKRSwpySNwD3TvP5pHnRmkAQRsdkXWRr1WaQh8p4PWX"), Description: "Reject bpf callx r10 instructions", Implemented: false}
+var DropRedundantTurbinePath = FeatureGate{Name: "DropRedundantTurbinePath", Address: base58.MustDecodeFromString("4Di3y24QFLt5QEUPZtbnjyfQKfm6ZMTfa6Dw1psfoMKU"), Description: "Drop redundant turbine path", Implemented: false}
+var ExecutablesIncurCpiDataCost = FeatureGate{Name: "ExecutablesIncurCpiDataCost", Address: base58.MustDecodeFromString("7GUcYgq4tVtaqNCKT3dho9r4665Qp5TxCZ27Qgjx3829"), Description: "Executables incur CPI data costs", Implemented: false}
+var FixRecentBlockhashes = FeatureGate{Name: "FixRecentBlockhashes", Address: base58.MustDecodeFromString("6iyggb5MTcsvdcugX7bEKbHV8c6jdLbpHwkncrgLMhfo"), Description: "Stop adding hashes for skipped slots to recent blockhashes", Implemented: false}
+var UpdateRewardsFromCachedAccounts = FeatureGate{Name: "UpdateRewardsFromCachedAccounts", Address: base58.MustDecodeFromString("28s7i3htzhahXQKqmS2ExzbEoUypg9krwvtK2M9UWXh9"), Description: "Update rewards from cached accounts", Implemented: false}
+var IncludeAccountIndexInRentError = FeatureGate{Name: "IncludeAccountIndexInRentError", Address: base58.MustDecodeFromString("2R72wpcQ7qV7aTJWUumdn8u5wmmTyXbK7qzEy7YSAgyY"), Description: "Include account index in rent tx error #25190", Implemented: false}
+var AddShredTypeToShredSeed = FeatureGate{Name: "AddShredTypeToShredSeed", Address: base58.MustDecodeFromString("Ds87KVeqhbv7Jw8W6avsS1mqz3Mw5J3pRTpPoDQ2QdiJ"), Description: "Add shred-type to shred seed #25556", Implemented: false}
+var WarpTimestampWithAVengeance = FeatureGate{Name: "WarpTimestampWithAVengeance", Address: base58.MustDecodeFromString("3BX6SBeEBibHaVQXywdkcgyUk6evfYZkHdztXiDtEpFS"), Description: "Warp timestamp again and adjust bounding to 150% slow #25666", Implemented: false}
+var SeparateNonceFromBlockhash = FeatureGate{Name: "SeparateNonceFromBlockhash", Address: base58.MustDecodeFromString("Gea3ZkK2N4pHuVZVxWcnAtS6UEDdyumdYt4pFcKjA3ar"), Description: "Separate durable nonce and blockhash domains #25744", Implemented: false}
+var EnableDurableNonce = FeatureGate{Name: "EnableDurableNonce", Address: base58.MustDecodeFromString("4EJQtF2pkRyawwcTVfQutzq4Sa5hRhibF6QAK1QXhtEX"), Description: "Enable durable nonce #25744", Implemented: false}
+var VoteStateUpdateCreditPerDequeue = FeatureGate{Name: "VoteStateUpdateCreditPerDequeue", Address: base58.MustDecodeFromString("CveezY6FDLVBToHDcvJRmtMouqzsmj4UXYh5ths5G5Uv"), Description: "Calculate vote credits for VoteStateUpdate per vote dequeue to match credit awards for Vote instruction", Implemented: false}
+var QuickBailOnPanic = FeatureGate{Name: "QuickBailOnPanic", Address: base58.MustDecodeFromString("DpJREPyuMZ5nDfU6H3WTqSqUFSXAfw8u7xqmWtEwJDcP"), Description: "Quick bail on panic", Implemented: false}
+var NonceMustBeAuthorized = FeatureGate{Name: "NonceMustBeAuthorized", Address: base58.MustDecodeFromString("HxrEu1gXuH7iD3Puua1ohd5n4iUKJyFNtNxk9DVJkvgr"), Description: "Nonce must be authorized", Implemented: false}
+var NonceMustBeAdvanceable = FeatureGate{Name: "NonceMustBeAdvanceable", Address: base58.MustDecodeFromString("3u3Er5Vc2jVcwz4xr2GJeSAXT3fAj6ADHZ4BJMZiScFd"), Description: "Durable nonces must be advanceable", Implemented: false}
+var VoteAuthorizeWithSeed = FeatureGate{Name: "VoteAuthorizeWithSeed", Address: base58.MustDecodeFromString("6tRxEYKuy2L5nnv5bgn7iT28MxUbYxp5h7F3Ncf1exrT"), Description: "An instruction you can use to change a vote accounts authority when the current authority is a derived key #25860", Implemented: false}
+var PreserveRentEpochForRentExemptAccounts = FeatureGate{Name: "PreserveRentEpochForRentExemptAccounts", Address: base58.MustDecodeFromString("HH3MUYReL2BvqqA3oEcAa7txju5GY6G4nxJ51zvsEjEZ"), Description: "Preserve rent epoch for rent exempt accounts #26479", Implemented: false}
+var EnableBpfLoaderExtendProgramIx = FeatureGate{Name: "EnableBpfLoaderExtendProgramIx", Address: base58.MustDecodeFromString("8Zs9W7D9MpSEtUWSQdGniZk2cNmV22y6FLJwCx53asme"), Description: "Enable bpf upgradeable loader ExtendProgram instruction #25234", Implemented: false}
+var EnableEarlyVerificationOfAccountModifications = FeatureGate{Name: "EnableEarlyVerificationOfAccountModifications", Address: base58.MustDecodeFromString("7Vced912WrRnfjaiKRiNBcbuFw7RrnLv3E3z95Y4GTNc"), Description: "Enable early verification of account modifications #25899", Implemented: false}
+var PreventCreditingAccountsThatEndRentPaying = FeatureGate{Name: "PreventCreditingAccountsThatEndRentPaying", Address: base58.MustDecodeFromString("812kqX67odAp5NFwM8D2N24cku7WTm9CHUTFUXaDkWPn"), Description: "Prevent crediting rent paying accounts #26606", Implemented: false}
+var CapBpfProgramInstructionAccounts = FeatureGate{Name: "CapBpfProgramInstructionAccounts", Address: base58.MustDecodeFromString("9k5ijzTbYPtjzu8wj2ErH9v45xecHzQ1x4PMYMMxFgdM"), Description: "Enforce max number of accounts per bpf program instruction #26628", Implemented: false}
+var UseDefaultUnitsInFeeCalculation = FeatureGate{Name: "UseDefaultUnitsInFeeCalculation", Address: base58.MustDecodeFromString("8sKQrMQoUHtQSUP83SPG4ta2JDjSAiWs7t5aJ9uEd6To"), Description: "Use default units per instruction in fee calculation #26785", Implemented: false}
+var CompactVoteStateUpdates = FeatureGate{Name: "CompactVoteStateUpdates", Address: base58.MustDecodeFromString("86HpNqzutEZwLcPxS6EHDcMNYWk6ikhteg9un7Y2PBKE"), Description: "Compact vote state updates to lower block size", Implemented: false}
+var IncrementalSnapshotOnlyIncrementalHashCalculation = FeatureGate{Name: "IncrementalSnapshotOnlyIncrementalHashCalculation", Address: base58.MustDecodeFromString("25vqsfjk7Nv1prsQJmA4Xu1bN61s8LXCBGUPp8Rfy1UF"), Description: "Only hash accounts in incremental snapshot during incremental snapshot creation #26799", Implemented: false}
+var DisableCpiSettingExecutableAndRentEpoch = FeatureGate{Name: "DisableCpiSettingExecutableAndRentEpoch", Address: base58.MustDecodeFromString("B9cdB55u4jQsDNsdTK525yE9dmSc5Ga7YBaBrDFvEhM9"), Description: "Disable setting is_executable and_rent_epoch in CPI #26987", Implemented: false}
+var OnLoadPreserveRentEpochForRentExemptAccounts = FeatureGate{Name: "OnLoadPreserveRentEpochForRentExemptAccounts", Address: base58.MustDecodeFromString("CpkdQmspsaZZ8FVAouQTtTWZkc8eeQ7V3uj7dWz543rZ"), Description: "On accounts-db load preserve rent epoch for rent exempt accounts #26479", Implemented: false}
+var AccountHashIgnoreSlot = FeatureGate{Name: "AccountHashIgnoreSlot", Address: base58.MustDecodeFromString("SVn36yVApPLYsa8koK3qUcy14zXDnqkNYWyUh1f4oK1"), Description: "Ignore slot when calculating an account hash #28420", Implemented: false}
+var StopSiblingInstructionSearchAtParent = FeatureGate{Name: "StopSiblingInstructionSearchAtParent", Address: base58.MustDecodeFromString("EYVpEP7uzH1CoXzbD6PubGhYmnxRXPeq3PPsm1ba3gpo"), Description: "Stop the search in get_processed_sibling_instruction when the parent instruction is reached #27289", Implemented: false}
+var VoteStateUpdateRootFix = FeatureGate{Name: "VoteStateUpdateRootFix", Address: base58.MustDecodeFromString("G74BkWBzmsByZ1kxHy44H3wjwp5hp7JbrGRuDpco22tY"), Description: "Fix root in vote state updates #27361", Implemented: false}
+var CleanUpDelegationErrors = FeatureGate{Name: "CleanUpDelegationErrors", Address: base58.MustDecodeFromString("Bj2jmUsM2iRhfdLLDSTkhM5UQRQvQHm57HSmPibPtEyu"), Description: "Return InsufficientDelegation instead of InsufficientFunds or InsufficientStake where applicable #31206", Implemented: false}
+var CheckedArithmeticInFeeValidation = FeatureGate{Name: "CheckedArithmeticInFeeValidation", Address: base58.MustDecodeFromString("5Pecy6ie6XGm22pc9d4P9W5c31BugcFBuy6hsP2zkETv"), Description: "Checked arithmetic in fee validation #31273", Implemented: false}
+var ReviseTurbineEpochStakes = FeatureGate{Name: "ReviseTurbineEpochStakes", Address: base58.MustDecodeFromString("BTWmtJC8U5ZLMbBUUA1k6As62sYjPEjAiNAT55xYGdJU"), Description: "Revise turbine epoch stakes", Implemented: false}
+var EnablePoseidonSyscall = FeatureGate{Name: "EnablePoseidonSyscall", Address: base58.MustDecodeFromString("FL9RsQA6TVUoh5xJQ9d936RHSebA1NLQqe3Zv9sXZRpr"), Description: "Enable Poseidon syscall", Implemented: false}
+var RemainingComputeUnitsSyscallEnabled = FeatureGate{Name: "RemainingComputeUnitsSyscallEnabled", Address: base58.MustDecodeFromString("5TuppMutoyzhUSfuYdhgzD47F92GL1g89KpCZQKqedxP"), Description: "Enable the remaining_compute_units syscall", Implemented: true}
+var EnableProgramRuntimeV2AndLoaderV4 = FeatureGate{Name: "EnableProgramRuntimeV2AndLoaderV4", Address: base58.MustDecodeFromString("8oBxsYqnCvUTGzgEpxPcnVf7MLbWWPYddE33PftFeBBd"), Description: "Enable Program-Runtime-v2 and Loader-v4 #33293", Implemented: true}
+var BetterErrorCodesForTxLamportCheck = FeatureGate{Name: "BetterErrorCodesForTxLamportCheck", Address: base58.MustDecodeFromString("Ffswd3egL3tccB6Rv3XY6oqfdzn913vUcjCSnpvCKpfx"), Description: "Better error codes for tx lamport check #33353", Implemented: false}
+var UpdateHashesPerTick = FeatureGate{Name: "UpdateHashesPerTick", Address: base58.MustDecodeFromString("3uFHb9oKdGfgZGJK9EHaAXN4USvnQtAFC13Fh5gGFS5B"), Description: "Update desired hashes per tick on epoch boundary", Implemented: false}
+var EnableTurbineFanoutExperiments = FeatureGate{Name: "EnableTurbineFanoutExperiments", Address: base58.MustDecodeFromString("D31EFnLgdiysi84Woo3of4JMu7VmasUS3Z7j9HYXCeLY"), Description: "Enable turbine fanout experiments #29393", Implemented: false}
+var DisableTurbineFanoutExperiments = FeatureGate{Name: "DisableTurbineFanoutExperiments", Address: base58.MustDecodeFromString("Gz1aLrbeQ4Q6PTSafCZcGWZXz91yVRi7ASFzFEr1U4sa"), Description: "Disable turbine fanout experiments #29393", Implemented: false}
+var DropLegacyShreds = FeatureGate{Name: "DropLegacyShreds", Address: base58.MustDecodeFromString("GV49KKQdBNaiv2pgqhS2Dy3GWYJGXMTVYbYkdk91orRy"), Description: "Drops legacy shreds #34328", Implemented: false}
+var ConsumeBlockstoreDuplicateProofs = FeatureGate{Name: "ConsumeBlockstoreDuplicateProofs", Address: base58.MustDecodeFromString("6YsBCejwK96GZCkJ6mkZ4b68oP63z2PLoQmWjC7ggTqZ"), Description: "Consume duplicate proofs from blockstore in consensus #34372", Implemented: false}
+var IndexErasureConflictDuplicateProofs = FeatureGate{Name: "IndexErasureConflictDuplicateProofs", Address: base58.MustDecodeFromString("dupPajaLy2SSn8ko42aZz4mHANDNrLe8Nw8VQgFecLa"), Description: "Generate duplicate proofs for index and erasure conflicts #34360", Implemented: false}
+var MerkleConflictDuplicateProofs = FeatureGate{Name: "MerkleConflictDuplicateProofs", Address: base58.MustDecodeFromString("mrkPjRg79B2oK2ZLgd7S3AfEJaX9B6gAF3H9aEykRUS"), Description: "Generate duplicate proofs for merkle root conflicts #34270", Implemented: false}
+var DisableBpfLoaderInstructions = FeatureGate{Name: "DisableBpfLoaderInstructions", Address: base58.MustDecodeFromString("7WeS1vfPRgeeoXArLh7879YcB9mgE9ktjPDtajXeWfXn"), Description: "Disable bpf loader management instructions #34194", Implemented: false}
+var EnableZkProofFromAccount = FeatureGate{Name: "EnableZkProofFromAccount", Address: base58.MustDecodeFromString("zkiTNuzBKxrCLMKehzuQeKZyLtX2yvFcEKMML8nExU8"), Description: "Enable zk token proof program to read proof from accounts instead of instruction data #34750", Implemented: false}
+var CostModelRequestedWriteLockCost = FeatureGate{Name: "CostModelRequestedWriteLockCost", Address: base58.MustDecodeFromString("wLckV1a64ngtcKPRGU4S4grVTestXjmNjxBjaKZrAcn"), Description: "Cost model uses number of requested write locks #34819", Implemented: false}
+var EnableGossipDuplicateProofIngestion = FeatureGate{Name: "EnableGossipDuplicateProofIngestion", Address: base58.MustDecodeFromString("FNKCMBzYUdjhHyPdsKG2LSmdzH8TCHXn3ytj8RNBS4nG"), Description: "Enable gossip duplicate proof ingestion #32963", Implemented: false}
+var ChainedMerkleConflictDuplicateProofs = FeatureGate{Name: "ChainedMerkleConflictDuplicateProofs", Address: base58.MustDecodeFromString("chaie9S2zVfuxJKNRGkyTDokLwWxx6kD2ZLsqQHaDD8"), Description: "Generate duplicate proofs for chained merkle root conflicts", Implemented: false}
+var EnableChainedMerkleShreds = FeatureGate{Name: "EnableChainedMerkleShreds", Address: base58.MustDecodeFromString("7uZBkJXJ1HkuP6R3MJfZs7mLwymBcDbKdqbF51ZWLier"), Description: "Enable chained Merkle shreds #34916", Implemented: false}
+var RemoveRoundingInFeeCalculation = FeatureGate{Name: "RemoveRoundingInFeeCalculation", Address: base58.MustDecodeFromString("BtVN7YjDzNE6Dk7kTT7YTDgMNUZTNgiSJgsdzAeTg2jF"), Description: "Removing unwanted rounding in fee calculation #34982", Implemented: false}
+var EnableTowerSyncIx = FeatureGate{Name: "EnableTowerSyncIx", Address: base58.MustDecodeFromString("tSynMCspg4xFiCj1v3TDb4c7crMR5tSBhLz4sF7rrNA"), Description: "Enable tower sync vote instruction", Implemented: false}
+var DeprecateUnusedLegacyVotePlumbing = FeatureGate{Name: "DeprecateUnusedLegacyVotePlumbing", Address: base58.MustDecodeFromString("6Uf8S75PVh91MYgPQSHnjRAPQq6an5BDv9vomrCwDqLe"), Description: "Deprecate unused legacy vote tx plumbing", Implemented: false}
+var RewardFullPriorityFee = FeatureGate{Name: "RewardFullPriorityFee", Address: base58.MustDecodeFromString("3opE3EzAKnUftUDURkzMgwpNgimBAypW1mNDYH4x4Zg7"), Description: "Reward full priority fee to validators #34731", Implemented: false}
+var GetSysvarSyscallEnabled = FeatureGate{Name: "GetSysvarSyscallEnabled", Address: base58.MustDecodeFromString("CLCoTADvV64PSrnR6QXty6Fwrt9Xc6EdxSJE4wLRePjq"), Description: "Enable syscall for fetching Sysvar bytes #615", Implemented: true}
+var MigrateFeatureGateProgramToCoreBpf = FeatureGate{Name: "MigrateFeatureGateProgramToCoreBpf", Address: base58.MustDecodeFromString("4eohviozzEeivk1y9UbrnekbAFMDQyJz5JjA9Y6gyvky"), Description: "Migrate Feature Gate program to Core BPF (programify) #1003", Implemented: false}
+var VoteOnlyFullFecSets = FeatureGate{Name: "VoteOnlyFullFecSets", Address: base58.MustDecodeFromString("ffecLRhhakKSGhMuc6Fz2Lnfq4uT9q3iu9ZsNaPLxPc"), Description: "Vote only full fec sets", Implemented: false}
+var MigrateConfigProgramToCoreBpf = FeatureGate{Name: "MigrateConfigProgramToCoreBpf", Address: base58.MustDecodeFromString("2Fr57nzzkLYXW695UdDxDeR5fhnZWSttZeZYemrnpGFV"), Description: "Migrate Config program to Core BPF #1378", Implemented: false}
+var EnableGetEpochStakeSyscall = FeatureGate{Name: "EnableGetEpochStakeSyscall", Address: base58.MustDecodeFromString("7mScTYkJXsbdrcwTQRs7oeCSXoJm4WjzBsRyf8bCU3Np"), Description: "Enable syscall: sol_get_epoch_stake #884", Implemented: true}
+var MigrateAddressLookupTableProgramToCoreBpf = FeatureGate{Name: "MigrateAddressLookupTableProgramToCoreBpf", Address: base58.MustDecodeFromString("C97eKZygrkU4JxJsZdjgbUY7iQR7rKTr4NyDWo2E5pRm"), Description: "Migrate Address Lookup Table program to Core BPF #1651", Implemented: false}
+var ZkElgamalProofProgramEnabled = FeatureGate{Name: "ZkElgamalProofProgramEnabled", Address: base58.MustDecodeFromString("zkhiy5oLowR7HY4zogXjCjeMXyruLqBwSWH21qcFtnv"), Description: "Enable ZkElGamalProof program", Implemented: true}
+var VerifyRetransmitterSignature = FeatureGate{Name: "VerifyRetransmitterSignature", Address: base58.MustDecodeFromString("BZ5g4hRbu5hLQQBdPyo2z9icGyJ8Khiyj3QS6dhWijTb"), Description: "Verify retransmitter signature #1840", Implemented: false}
+var MoveStakeAndMoveLamportsIxs = FeatureGate{Name: "MoveStakeAndMoveLamportsIxs", Address: base58.MustDecodeFromString("7bTK6Jis8Xpfrs8ZoUfiMDPazTcdPcTWheZFJTA5Z6X4"), Description: "Enable MoveStake and MoveLamports stake program instructions #1610", Implemented: false}
+var VoteOnlyRetransmitterSignedFecSets = FeatureGate{Name: "VoteOnlyRetransmitterSignedFecSets", Address: base58.MustDecodeFromString("RfEcA95xnhuwooVAhUUksEJLZBF7xKCLuqrJoqk4Zph"), Description: "Vote only on retransmitter signed fec sets", Implemented: false}
+var EnableTransactionLoadingFailureFees = FeatureGate{Name: "EnableTransactionLoadingFailureFees", Address: base58.MustDecodeFromString("PaymEPK2oqwT9TXAVfadjztH2H6KfLEB9Hhd5Q5frvP"), Description: "Enable fees for some additional transaction failures SIMD-0082", Implemented: false}
+var EnableTurbineExtendedFanoutExperiments = FeatureGate{Name: "EnableTurbineExtendedFanoutExperiments", Address: base58.MustDecodeFromString("BZn14Liea52wtBwrXUxTv6vojuTTmfc7XGEDTXrvMD7b"), Description: "Enable turbine extended fanout experiments #", Implemented: false}
+var DeprecateLegacyVoteIxs = FeatureGate{Name: "DeprecateLegacyVoteIxs", Address: base58.MustDecodeFromString("depVvnQ2UysGrhwdiwU42tCadZL8GcBb1i2GYhMopQv"), Description: "Deprecate legacy vote instructions", Implemented: false}
+var PartitionedEpochRewardsSuperfeature = FeatureGate{Name: "PartitionedEpochRewardsSuperfeature", Address: base58.MustDecodeFromString("PERzQrt5gBD1XEe2c9XdFWqwgHY3mr7cYWbm5V772V8"), Description: "Replaces enable_partitioned_epoch_reward to enable partitioned rewards at epoch boundary SIMD-0118", Implemented: false}
+var EnableSecp256r1Precompile = FeatureGate{Name: "EnableSecp256r1Precompile", Address: base58.MustDecodeFromString("sr11RdZWgbHTHxSroPALe6zgaT5A1K9LcE4nfsZS4gi"), Description: "Enable secp256r1 precompile SIMD-0075", Implemented: false}
+var AccountsLtHash = FeatureGate{Name: "AccountsLtHash", Address: base58.MustDecodeFromString("LtHaSHHsUge7EWTPVrmpuexKz6uVHZXZL6cgJa7W7Zn"), Description: "Enables lattice-based accounts hash #3333", Implemented: false}
+var RemoveAccountsDeltaHash = FeatureGate{Name: "RemoveAccountsDeltaHash", Address: base58.MustDecodeFromString("LTdLt9Ycbyoipz5fLysCi1NnDnASsZfmJLJXts5ZxZz"), Description: "Removes accounts delta hash SIMD-0223", Implemented: false}
+var RaiseBlockLimitsTo50m = FeatureGate{Name: "RaiseBlockLimitsTo50m", Address: base58.MustDecodeFromString("5oMCU3JPaFLr8Zr4ct7yFA7jdk6Mw1RmB8K4u9ZbS42z"), Description: "Raise block limit to 50M SIMD-0207", Implemented: false}
+var FixAltBn128MultiplicationInputLength = FeatureGate{Name: "FixAltBn128MultiplicationInputLength", Address: base58.MustDecodeFromString("bn2puAyxUx6JUabAxYdKdJ5QHbNNmKw8dCGuGCyRrFN"), Description: "Fix alt_bn128 multiplication input length SIMD-0222 #3686", Implemented: false}
+var LiftCpiCallerRestriction = FeatureGate{Name: "LiftCpiCallerRestriction", Address: base58.MustDecodeFromString("HcW8ZjBezYYgvcbxNJwqv1t484Y2556qJsfNDWvJGZRH"), Description: "Allow copying account data back to the CPI caller's account", Implemented: false}
+var DisableAccountLoaderSpecialCase = FeatureGate{Name: "DisableAccountLoaderSpecialCase", Address: base58.MustDecodeFromString("EQUMpNFr7Nacb1sva56xn1aLfBxppEoSBH8RRVdkcD1x"), Description: "Disable account loader special case #3513", Implemented: false}
+var EnableBigModExpSyscall = FeatureGate{Name: "EnableBigModExpSyscall", Address: base58.MustDecodeFromString("EBq48m8irRKuE7ZnMTLvLg2UuGSqhe8s8oMqnmja1fJw"), Description: "Add big_mod_exp syscall #28503", Implemented: true}
+var DisableDeployOfAllocFreeSyscall = FeatureGate{Name: "DisableDeployOfAllocFreeSyscall", Address: base58.MustDecodeFromString("79HWsX9rpnnJBPcdNURVqygpMAfxdrAirzAGAVmf92im"), Description: "Disable new deployments of deprecated sol_alloc_free_ syscall", Implemented: false}
+var NativeProgramsConsumeCu = FeatureGate{Name: "NativeProgramsConsumeCu", Address: base58.MustDecodeFromString("8pgXCMNXC8qyEFypuwpXyRxLXZdpM4Qo72gJ6k87A6wL"), Description: "Native program should consume compute units #30620", Implemented: false}
+var EnableRequestHeapFrameIx = FeatureGate{Name: "EnableRequestHeapFrameIx", Address: base58.MustDecodeFromString("Hr1nUA9b7NJ6eChS26o7Vi8gYYDDwWD3YeBfzJkTbU86"), Description: "Enable transaction to request heap frame using compute budget instruction #30076", Implemented: false}
+var AddSetComputeUnitPriceIx = FeatureGate{Name: "AddSetComputeUnitPriceIx", Address: base58.MustDecodeFromString("98std1NSHqXi9WYvFShfVepRdCoq1qvsp8fsR2XZtG8g"), Description: "Add compute budget ix for setting a compute unit price", Implemented: false}
+var StakeMinimumDelegationForRewards = FeatureGate{Name: "StakeMinimumDelegationForRewards", Address: base58.MustDecodeFromString("G6ANXD6ptCSyNd9znZm7j4dEczAJCfx7Cy43oBx3rKHJ"), Description: "Stakes must be at least the minimum delegation to earn rewards", Implemented: false}
+var DisableRehashForRentEpoch = FeatureGate{Name: "DisableRehashForRentEpoch", Address: base58.MustDecodeFromString("DTVTkmw3JSofd8CJVJte8PXEbxNQ2yZijvVr3pe2APPj"), Description: "On accounts hash calculation do not try to rehash accounts #28934", Implemented: false}
+var AddSetTxLoadedAccountsDataSizeInstruction = FeatureGate{Name: "AddSetTxLoadedAccountsDataSizeInstruction", Address: base58.MustDecodeFromString("G6vbf1UBok8MWb8m25ex86aoQHeKTzDKzuZADHkShqm6"), Description: "Add compute budget instruction for setting account data size per transaction #30366", Implemented: false}
+var IncludeLoadedAccountsDataSizeInFeeCalculation = FeatureGate{Name: "IncludeLoadedAccountsDataSizeInFeeCalculation", Address: base58.MustDecodeFromString("EaQpmC6GtRssaZ3PCUM5YksGqUdMLeZ46BQXYtHYakDS"), Description: "Include transaction loaded accounts data size in base fee calculation #30657", Implemented: false}
+var SimplifyWritableProgramAccountCheck = FeatureGate{Name: "SimplifyWritableProgramAccountCheck", Address: base58.MustDecodeFromString("5ZCcFAzJ1zsFKe1KSZa9K92jhx7gkcKj97ci2DBo1vwj"), Description: "Simplify checks performed for writable upgradeable program accounts #30559", Implemented: false}
+var DelayVisibilityOfProgramDeployment = FeatureGate{Name: "DelayVisibilityOfProgramDeployment", Address: base58.MustDecodeFromString("GmuBvtFb2aHfSfMXpuFeWZGHyDeCLPS79s48fmCWCfM5"), Description: "Delay visibility of program upgrades #30085", Implemented: false}
+var ApplyCostTrackerDuringReplay = FeatureGate{Name: "ApplyCostTrackerDuringReplay", Address: base58.MustDecodeFromString("2ry7ygxiYURULZCrypHhveanvP5tzZ4toRwVp89oCNSj"), Description: "Apply cost tracker to blocks during replay #29595", Implemented: false}
+var BpfAccountDataDirectMapping = FeatureGate{Name: "BpfAccountDataDirectMapping", Address: base58.MustDecodeFromString("EenyoWx9UMXYKpR8mW5Jmfmy2fRjzUtM7NduYMY8bx33"), Description: "Use memory regions to map account data into the rbpf vm instead of copying the data", Implemented: false}
+var RoundUpHeapSize = FeatureGate{Name: "RoundUpHeapSize", Address: base58.MustDecodeFromString("CE2et8pqgyQMP2mQRg3CgvX8nJBKUArMu3wfiQiQKY1y"), Description: "Round up heap size when calculating heap cost #30679", Implemented: false}
+var LimitMaxInstructionTraceLength = FeatureGate{Name: "LimitMaxInstructionTraceLength", Address: base58.MustDecodeFromString("GQALDaC48fEhZGWRj9iL5Q889emJKcj3aCvHF7VCbbF4"), Description: "Limit max instruction trace length #27939", Implemented: false}
+var CheckSyscallOutputsDoNotOverlap = FeatureGate{Name: "CheckSyscallOutputsDoNotOverlap", Address: base58.MustDecodeFromString("3uRVPBpyEJRo1emLCrq38eLRFGcu6uKSpUXqGvU8T7SZ"), Description: "Check syscall outputs do_not overlap #28600", Implemented: false}
+var EpochAccountsHash = FeatureGate{Name: "EpochAccountsHash", Address: base58.MustDecodeFromString("5GpmAKxaGsWWbPp4bNXFLJxZVvG92ctxf7jQnzTQjF3n"), Description: "Enable epoch accounts hash calculation #27539", Implemented: false}
+var SwitchToNewElfParser = FeatureGate{Name: "SwitchToNewElfParser", Address: base58.MustDecodeFromString("Cdkc8PPTeTNUPoZEfCY5AyetUrEdkZtNPMgz58nqyaHD"), Description: "Switch to new ELF parser #30497", Implemented: false}
 
-var AllFeatureGates = []FeatureGate{StopTruncatingStringsInSyscalls, EnablePartitionedEpochReward, LastRestartSlotSysvar,
-	Libsecp256k1FailOnBadCount, Libsecp256k1FailOnBadCount2, EnableBpfLoaderSetAuthorityCheckedIx,
-	LoosenCpiSizeRestriction, IncreaseTxAccountLockLimit, VoteStateAddVoteLatency, AllowCommissionDecreaseAtAnyTime,
-	CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch, TimelyVoteCredits, ReduceStakeWarmupCooldown,
-	StakeRaiseMinimumDelegationTo1Sol, StakeRedelegateInstruction, RequireRentExemptSplitDestination,
-	DeprecateExecutableMetaUpdateInBpfLoader, RelaxAuthoritySignerCheckForLookupTableCreation, DedupeConfigProgramSigners,
-	Ed25519PrecompileVerifyStrict, AbortOnInvalidCurve, Curve25519SyscallEnabled, SimplifyAltBn128SyscallErrorCodes,
-	EnableAltbn128CompressionSyscall, EnableAltBn128Syscall}
+// AllFeatureGates is the registry of all known feature gates.
+var AllFeatureGates = []FeatureGate{
+	StopTruncatingStringsInSyscalls,
+	EnablePartitionedEpochReward,
+	LastRestartSlotSysvar,
+	Libsecp256k1FailOnBadCount,
+	Libsecp256k1FailOnBadCount2,
+	EnableBpfLoaderSetAuthorityCheckedIx,
+	LoosenCpiSizeRestriction,
+	IncreaseTxAccountLockLimit,
+	VoteStateAddVoteLatency,
+	AllowCommissionDecreaseAtAnyTime,
+	CommissionUpdatesOnlyAllowedInFirstHalfOfEpoch,
+	TimelyVoteCredits,
+	ReduceStakeWarmupCooldown,
+	StakeRaiseMinimumDelegationTo1Sol,
+	StakeRedelegateInstruction,
+	RequireRentExemptSplitDestination,
+	DeprecateExecutableMetaUpdateInBpfLoader,
+	RelaxAuthoritySignerCheckForLookupTableCreation,
+	DedupeConfigProgramSigners,
+	Ed25519PrecompileVerifyStrict,
+	AbortOnInvalidCurve,
+	Curve25519SyscallEnabled,
+	SimplifyAltBn128SyscallErrorCodes,
+	EnableAltbn128CompressionSyscall,
+	EnableAltBn128Syscall,
+	MovePrecompileVerificationToSvm,
+	SetExemptRentEpochMax,
+	SkipRentRewrites,
+	DisableRentFeesCollection,
+	PicoInflation,
+	FullInflationDevnetAndTestnet,
+	FullInflationMainnetCertusoneVote,
+	FullInflationMainnetCertusoneEnable,
+	DeprecateRewardsSysvar,
+	Secp256k1ProgramEnabled,
+	SplTokenV2MultisigFix,
+	NoOverflowRentDistribution,
+	FilterStakeDelegationAccounts,
+	RequireCustodianForLockedStakeAuthorize,
+	SplTokenV2SelfTransferFix,
+	WarpTimestampAgain,
+	CheckInitVoteData,
+	Secp256k1RecoverSyscallEnabled,
+	SystemTransferZeroCheck,
+	Blake3SyscallEnabled,
+	VerifyTxSignaturesLen,
+	VoteStakeCheckedInstructions,
+	RentForSysvars,
+	Libsecp256k105UpgradeEnabled,
+	TxWideComputeCap,
+	SplTokenV2SetAuthorityFix,
+	MergeNonceErrorIntoSystemError,
+	DisableFeesSysvar,
+	StakeMergeWithUnmatchedCreditsObserved,
+	ZkTokenSdkEnabled,
+	Curve25519RestrictMsmLength,
+	VersionedTxMessageEnabled,
+	InstructionsSysvarOwnedBySysvar,
+	StakeProgramAdvanceActivatingCreditsObserved,
+	CreditsAutoRewind,
+	DemoteProgramWriteLocks,
+	Ed25519ProgramEnabled,
+	ReturnDataSyscallEnabled,
+	ReduceRequiredDeployBalance,
+	SolLogDataSyscallEnabled,
+	StakesRemoveDelegationIfInactive,
+	DoSupportRealloc,
+	PreventCallingPrecompilesAsPrograms,
+	OptimizeEpochBoundaryUpdates,
+	RemoveNativeLoader,
+	SendToTpuVotePort,
+	RequestableHeapSize,
+	DisableFeeCalculator,
+	AddComputeBudgetProgram,
+	NonceMustBeWritable,
+	SplTokenV330Release,
+	LeaveNonceOnSuccess,
+	RejectEmptyInstructionWithoutProgram,
+	FixedMemcpyNonoverlappingCheck,
+	RejectNonRentExemptVoteWithdraws,
+	EvictInvalidStakesCacheEntries,
+	AllowVotesToDirectlyUpdateVoteState,
+	MaxTxAccountLocks,
+	RequireRentExemptAccounts,
+	FilterVotesOutsideSlotHashes,
+	UpdateSyscallBaseCosts,
+	StakeDeactivateDelinquentInstruction,
+	VoteWithdrawAuthorityMayChangeAuthorizedVoter,
+	SplAssociatedTokenAccountV104,
+	RejectVoteAccountCloseUnlessZeroCreditEpoch,
+	AddGetProcessedSiblingInstructionSyscall,
+	BankTransactionCountFix,
+	DisableBpfDeprecatedLoadInstructions,
+	DisableBpfUnresolvedSymbolsAtRuntime,
+	RecordInstructionInTransactionContextPush,
+	SyscallSaturatedMath,
+	CheckPhysicalOverlapping,
+	LimitSecp256k1RecoveryId,
+	DisableDeprecatedLoader,
+	CheckSliceTranslationSize,
+	StakeSplitUsesRentSysvar,
+	AddGetMinimumDelegationInstructionToStakeProgram,
+	ErrorOnSyscallBpfFunctionHashCollisions,
+	RejectCallxR10,
+	DropRedundantTurbinePath,
+	ExecutablesIncurCpiDataCost,
+	FixRecentBlockhashes,
+	UpdateRewardsFromCachedAccounts,
+	IncludeAccountIndexInRentError,
+	AddShredTypeToShredSeed,
+	WarpTimestampWithAVengeance,
+	SeparateNonceFromBlockhash,
+	EnableDurableNonce,
+	VoteStateUpdateCreditPerDequeue,
+	QuickBailOnPanic,
+	NonceMustBeAuthorized,
+	NonceMustBeAdvanceable,
+	VoteAuthorizeWithSeed,
+	PreserveRentEpochForRentExemptAccounts,
+	EnableBpfLoaderExtendProgramIx,
+	EnableEarlyVerificationOfAccountModifications,
+	PreventCreditingAccountsThatEndRentPaying,
+	CapBpfProgramInstructionAccounts,
+	UseDefaultUnitsInFeeCalculation,
+	CompactVoteStateUpdates,
+	IncrementalSnapshotOnlyIncrementalHashCalculation,
+	DisableCpiSettingExecutableAndRentEpoch,
+	OnLoadPreserveRentEpochForRentExemptAccounts,
+	AccountHashIgnoreSlot,
+	StopSiblingInstructionSearchAtParent,
+	VoteStateUpdateRootFix,
+	CleanUpDelegationErrors,
+	CheckedArithmeticInFeeValidation,
+	ReviseTurbineEpochStakes,
+	EnablePoseidonSyscall,
+	RemainingComputeUnitsSyscallEnabled,
+	EnableProgramRuntimeV2AndLoaderV4,
+	BetterErrorCodesForTxLamportCheck,
+	UpdateHashesPerTick,
+	EnableTurbineFanoutExperiments,
+	DisableTurbineFanoutExperiments,
+	DropLegacyShreds,
+	ConsumeBlockstoreDuplicateProofs,
+	IndexErasureConflictDuplicateProofs,
+	MerkleConflictDuplicateProofs,
+	DisableBpfLoaderInstructions,
+	EnableZkProofFromAccount,
+	CostModelRequestedWriteLockCost,
+	EnableGossipDuplicateProofIngestion,
+	ChainedMerkleConflictDuplicateProofs,
+	EnableChainedMerkleShreds,
+	RemoveRoundingInFeeCalculation,
+	EnableTowerSyncIx,
+	DeprecateUnusedLegacyVotePlumbing,
+	RewardFullPriorityFee,
+	GetSysvarSyscallEnabled,
+	MigrateFeatureGateProgramToCoreBpf,
+	VoteOnlyFullFecSets,
+	MigrateConfigProgramToCoreBpf,
+	EnableGetEpochStakeSyscall,
+	MigrateAddressLookupTableProgramToCoreBpf,
+	ZkElgamalProofProgramEnabled,
+	VerifyRetransmitterSignature,
+	MoveStakeAndMoveLamportsIxs,
+	VoteOnlyRetransmitterSignedFecSets,
+	EnableTransactionLoadingFailureFees,
+	EnableTurbineExtendedFanoutExperiments,
+	DeprecateLegacyVoteIxs,
+	PartitionedEpochRewardsSuperfeature,
+	EnableSecp256r1Precompile,
+	AccountsLtHash,
+	RemoveAccountsDeltaHash,
+	RaiseBlockLimitsTo50m,
+	FixAltBn128MultiplicationInputLength,
+	LiftCpiCallerRestriction,
+	DisableAccountLoaderSpecialCase,
+	EnableBigModExpSyscall,
+	DisableDeployOfAllocFreeSyscall,
+	NativeProgramsConsumeCu,
+	EnableRequestHeapFrameIx,
+	AddSetComputeUnitPriceIx,
+	StakeMinimumDelegationForRewards,
+	DisableRehashForRentEpoch,
+	AddSetTxLoadedAccountsDataSizeInstruction,
+	IncludeLoadedAccountsDataSizeInFeeCalculation,
+	SimplifyWritableProgramAccountCheck,
+	DelayVisibilityOfProgramDeployment,
+	ApplyCostTrackerDuringReplay,
+	BpfAccountDataDirectMapping,
+	RoundUpHeapSize,
+	LimitMaxInstructionTraceLength,
+	CheckSyscallOutputsDoNotOverlap,
+	EpochAccountsHash,
+	SwitchToNewElfParser,
+}
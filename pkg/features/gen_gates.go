@@ -0,0 +1,107 @@
+//go:build ignore
+
+// gen_gates generates gates.go from the feature gate registry in gates.csv, which lists the
+// name, address, implementation status and description of each feature gate.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+
+	"go.firedancer.io/radiance/pkg/base58"
+)
+
+func main() {
+	err := generate("gates.csv", "gates.go")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen_gates: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+type gate struct {
+	name        string
+	address     string
+	implemented bool
+	description string
+}
+
+func readGates(filename string) ([]gate, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no header", filename)
+	}
+
+	gates := make([]gate, 0, len(records)-1)
+	names := make(map[string]bool)
+	addresses := make(map[string]bool)
+
+	for idx, record := range records[1:] {
+		line := idx + 2
+		if len(record) != 4 {
+			return nil, fmt.Errorf("%s:%d: expected 4 fields, got %d", filename, line, len(record))
+		}
+
+		g := gate{name: record[0], address: record[1], description: record[3]}
+		g.implemented, err = strconv.ParseBool(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid implemented field: %w", filename, line, err)
+		}
+
+		if _, err = base58.DecodeFromString(g.address); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid address %s: %w", filename, line, g.address, err)
+		}
+		if names[g.name] || addresses[g.address] {
+			return nil, fmt.Errorf("%s:%d: duplicate feature gate %s (%s)", filename, line, g.name, g.address)
+		}
+		names[g.name] = true
+		addresses[g.address] = true
+
+		gates = append(gates, g)
+	}
+
+	return gates, nil
+}
+
+func generate(csvFilename string, goFilename string) error {
+	gates, err := readGates(csvFilename)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by gen_gates.go from %s; DO NOT EDIT.\n\n", csvFilename)
+	fmt.Fprintf(buf, "package features\n\n")
+	fmt.Fprintf(buf, "import \"go.firedancer.io/radiance/pkg/base58\"\n\n")
+
+	for _, g := range gates {
+		fmt.Fprintf(buf, "var %s = FeatureGate{Name: %q, Address: base58.MustDecodeFromString(%q), Description: %q, Implemented: %t}\n",
+			g.name, g.name, g.address, g.description, g.implemented)
+	}
+
+	fmt.Fprintf(buf, "\n// AllFeatureGates is the registry of all known feature gates.\n")
+	fmt.Fprintf(buf, "var AllFeatureGates = []FeatureGate{\n")
+	for _, g := range gates {
+		fmt.Fprintf(buf, "\t%s,\n", g.name)
+	}
+	fmt.Fprintf(buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(goFilename, src, 0644)
+}
@@ -81,7 +81,7 @@ func ApplyTxFees(tx *solana.Transaction, instrs []sealevel.Instruction, transact
 	}
 
 	if feePayerAcct.Lamports < totalTxFee {
-		return 0, 0, sealevel.InstrErrInsufficientFunds
+		return 0, 0, sealevel.NewTransactionError(sealevel.TxErrCodeInsufficientFundsForFee)
 	}
 
 	klog.Infof("tx fee: %d", totalTxFee)
@@ -92,7 +92,9 @@ func ApplyTxFees(tx *solana.Transaction, instrs []sealevel.Instruction, transact
 	return totalTxFee, feePayerAcct.Lamports, nil
 }
 
-func DistributeTxFees(acctsDb *accountsdb.AccountsDb, slotCtx *sealevel.SlotCtx, leader solana.PublicKey, totalFees uint64) {
+// DistributeTxFees pays half of the block's tx fees to the leader, returning the remainder,
+// which is burned.
+func DistributeTxFees(acctsDb *accountsdb.AccountsDb, slotCtx *sealevel.SlotCtx, leader solana.PublicKey, totalFees uint64) uint64 {
 	feesToLeader := (totalFees * 50) / 100
 
 	var leaderAcct *accounts.Account
@@ -101,7 +103,7 @@ func DistributeTxFees(acctsDb *accountsdb.AccountsDb, slotCtx *sealevel.SlotCtx,
 	leaderAcct, err = slotCtx.GetAccount(leader)
 	if err != nil {
 		// if leader didn't appear at all in the block, then its latest state should be retrieveable from accountsdb
-		leaderAcct, err = acctsDb.GetAccount(leader)
+		leaderAcct, err = acctsDb.GetAccountAtSlot(slotCtx.ParentSlot, leader)
 		if err != nil {
 			panic(fmt.Sprintf("unable to get leader acct %s from both slotCtx and accountsdb", leader))
 		}
@@ -116,4 +118,6 @@ func DistributeTxFees(acctsDb *accountsdb.AccountsDb, slotCtx *sealevel.SlotCtx,
 	}
 
 	klog.Infof("calculated fees for leader: %d, post-balance: %d (%s)", feesToLeader, leaderAcct.Lamports, leader)
+
+	return totalFees - feesToLeader
 }
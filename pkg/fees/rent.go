@@ -1,11 +1,13 @@
 package fees
 
 import (
-	"fmt"
+	"math"
 
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
 )
 
 const (
@@ -76,14 +78,14 @@ func checkRentStateTransitionAllowed(preRentState *RentStateInfo, postRentState
 			return nil
 		} else if postRentState.RentState == RentStateRentPaying {
 			if preRentState.RentState == RentStateUninitialized {
-				return fmt.Errorf("rent state transition not allowed")
+				return sealevel.NewTxErrInsufficientFundsForRent(idx)
 			} else if preRentState.RentState == RentStateRentExempt {
-				return fmt.Errorf("rent state transition not allowed")
+				return sealevel.NewTxErrInsufficientFundsForRent(idx)
 			} else if preRentState.RentState == RentStateRentPaying {
 				if postRentState.RentPayingInfo.DataSize == preRentState.RentPayingInfo.DataSize && postRentState.RentPayingInfo.Lamports <= preRentState.RentPayingInfo.Lamports {
 					return nil
 				} else {
-					return fmt.Errorf("rent state transition not allowed")
+					return sealevel.NewTxErrInsufficientFundsForRent(idx)
 				}
 			}
 		}
@@ -106,3 +108,77 @@ func VerifyRentStateChanges(preStates []*RentStateInfo, postStates []*RentStateI
 
 	return nil
 }
+
+// RentExemptRentEpoch is the rent epoch of accounts found to be rent-exempt, which rent
+// collection thereafter leaves alone.
+const RentExemptRentEpoch = math.MaxUint64
+
+// accounts are charged rent for this many bytes of storage overhead in addition to their data
+const rentAccountStorageOverhead = 128
+
+// rentDue returns the rent owed by an account for the epochs since its rent epoch up to and
+// including the following epoch, or true if the account is rent-exempt.
+func rentDue(rentCollector *snapshot.RentCollector, lamports uint64, dataLen uint64, rentEpoch uint64) (uint64, bool) {
+	if rentCollector.Rent.IsExempt(lamports, dataLen) {
+		return 0, true
+	}
+
+	var slotsElapsed uint64
+	for epoch := rentEpoch; epoch <= rentCollector.Epoch; epoch++ {
+		slotsElapsed += rentCollector.EpochSchedule.SlotsInEpoch(epoch + 1)
+	}
+
+	var yearsElapsed float64
+	if rentCollector.SlotsPerYear != 0 {
+		yearsElapsed = float64(slotsElapsed) / rentCollector.SlotsPerYear
+	}
+
+	return uint64(float64((dataLen+rentAccountStorageOverhead)*rentCollector.Rent.LamportsPerUint8Year) * yearsElapsed), false
+}
+
+// CollectRentFromAccount collects the rent due from an account visited by the rent collection
+// pass of a slot in rentCollector.Epoch, returning the amount collected. Accounts that can't
+// pay the rent due are emptied, and those that are rent-exempt have their rent epoch set to
+// RentExemptRentEpoch. Once rent fee collection is disabled, no rent is collected, and only
+// the rent epochs of rent-exempt accounts are updated.
+func CollectRentFromAccount(rentCollector *snapshot.RentCollector, f *features.Features, acct *accounts.Account) uint64 {
+	if f.IsActive(features.DisableRentFeesCollection) {
+		if acct.RentEpoch != RentExemptRentEpoch {
+			_, isExempt := rentDue(rentCollector, acct.Lamports, uint64(len(acct.Data)), acct.RentEpoch)
+			if isExempt {
+				acct.RentEpoch = RentExemptRentEpoch
+			}
+		}
+		return 0
+	}
+
+	if acct.RentEpoch == RentExemptRentEpoch || acct.RentEpoch > rentCollector.Epoch {
+		return 0
+	}
+
+	// executable accounts and the incinerator never pay rent
+	isExempt := acct.Executable || acct.Key == sealevel.IncineratorAddr
+	var due uint64
+	if !isExempt {
+		due, isExempt = rentDue(rentCollector, acct.Lamports, uint64(len(acct.Data)), acct.RentEpoch)
+	}
+
+	if isExempt {
+		if f.IsActive(features.SetExemptRentEpochMax) {
+			acct.RentEpoch = RentExemptRentEpoch
+		}
+		return 0
+	} else if due == 0 {
+		return 0
+	}
+
+	if acct.Lamports <= due {
+		collected := acct.Lamports
+		*acct = accounts.Account{Slot: acct.Slot, Key: acct.Key}
+		return collected
+	}
+
+	acct.Lamports -= due
+	acct.RentEpoch = rentCollector.Epoch + 1
+	return due
+}
@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"runtime"
+
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"golang.org/x/sync/errgroup"
+)
+
+// number of bins that the index entries are partitioned into by pubkey, such that only a
+// single bin of entries and their hashes is held in memory at a time.
+const numAcctHashBins = 256
+
+// CalculateAccountsHash calculates the full accounts hash over the accounts state as of
+// maxSlot, i.e. over the live index entries as of maxSlot, along with the total lamports
+// held by those accounts (the capitalization). Accounts with zero lamports are excluded.
+func CalculateAccountsHash(acctsDb *accountsdb.AccountsDb, maxSlot uint64) ([32]byte, uint64, error) {
+	snapshot := acctsDb.SnapshotIndex()
+	defer snapshot.Release()
+
+	return calculateAccountsHashFromIndex(snapshot, 0, maxSlot, false)
+}
+
+// CalculateIncrementalAccountsHash calculates the incremental accounts hash over the
+// accounts modified after baseSlot (the full snapshot slot), up to and including maxSlot.
+// Unlike the full accounts hash, accounts with zero lamports are included, with a zeroed hash.
+// The total lamports held by the modified accounts is also returned.
+func CalculateIncrementalAccountsHash(acctsDb *accountsdb.AccountsDb, baseSlot uint64, maxSlot uint64) ([32]byte, uint64, error) {
+	snapshot := acctsDb.SnapshotIndex()
+	defer snapshot.Release()
+
+	return calculateAccountsHashFromIndex(snapshot, baseSlot+1, maxSlot, true)
+}
+
+// calculateAccountsHashFromIndex hashes the accounts in the index snapshot that were last
+// modified between minSlot and maxSlot. The snapshot's entries are visited one bin at a time
+// in pubkey order, and the account hashes within each bin are calculated in parallel.
+func calculateAccountsHashFromIndex(snapshot *accountsdb.IndexSnapshot, minSlot uint64, maxSlot uint64, includeZeroLamportAccts bool) ([32]byte, uint64, error) {
+	var tree merkleTree
+	var capitalization uint64
+
+	err := snapshot.ForEachBin(minSlot, maxSlot, numAcctHashBins, func(bin []accountsdb.IndexedAccount) error {
+		hashes := make([][32]byte, len(bin))
+		included := make([]bool, len(bin))
+		lamports := make([]uint64, len(bin))
+
+		var group errgroup.Group
+		numWorkers := runtime.NumCPU()
+		chunkSize := (len(bin) + numWorkers - 1) / numWorkers
+
+		for start := 0; start < len(bin); start += chunkSize {
+			end := min(start+chunkSize, len(bin))
+			group.Go(func() error {
+				for idx := start; idx < end; idx++ {
+					acct, err := snapshot.ReadAccount(&bin[idx])
+					if err != nil {
+						return err
+					}
+
+					lamports[idx] = acct.Lamports
+					if acct.Lamports == 0 {
+						// zero-lamport accounts have a zeroed hash
+						included[idx] = includeZeroLamportAccts
+						continue
+					}
+
+					hashes[idx] = calculateSingleAcctHash(*acct).Hash
+					included[idx] = true
+				}
+				return nil
+			})
+		}
+
+		err := group.Wait()
+		if err != nil {
+			return err
+		}
+
+		for idx := range bin {
+			if included[idx] {
+				tree.add(hashes[idx])
+				capitalization += lamports[idx]
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return [32]byte{}, 0, err
+	}
+
+	var accountsHash [32]byte
+	copy(accountsHash[:], tree.root())
+	return accountsHash, capitalization, nil
+}
@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/accountsdb/accountsdbtest"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func expectedAccountsHash(acctHashes []acctHash) [32]byte {
+	sort.Slice(acctHashes, func(i, j int) bool {
+		return pubkeyCmp(acctHashes[i].Pubkey, acctHashes[j].Pubkey)
+	})
+
+	hashes := make([][]byte, len(acctHashes))
+	for idx := range acctHashes {
+		hashes[idx] = acctHashes[idx].Hash[:]
+	}
+
+	var accountsHash [32]byte
+	copy(accountsHash[:], computeMerkleRootLoop(hashes))
+	return accountsHash
+}
+
+func TestCalculateAccountsHash(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID, Data: []byte{1, 2, 3}}
+	c := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 3000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b, c}, 10))
+
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 500, Owner: solana.SystemProgramID}
+	cClosed := &accounts.Account{Key: c.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated, cClosed}, 11))
+
+	hashAtSlot10, capitalization, err := CalculateAccountsHash(acctsDb, 10)
+	require.NoError(t, err)
+	expected := expectedAccountsHash([]acctHash{calculateSingleAcctHash(*a), calculateSingleAcctHash(*b), calculateSingleAcctHash(*c)})
+	assert.Equal(t, expected, hashAtSlot10)
+	assert.Equal(t, uint64(6000), capitalization)
+
+	// the zero-lamport account is excluded, and the later version of a is used
+	hashAtSlot11, capitalization, err := CalculateAccountsHash(acctsDb, 11)
+	require.NoError(t, err)
+	expected = expectedAccountsHash([]acctHash{calculateSingleAcctHash(*aUpdated), calculateSingleAcctHash(*b)})
+	assert.Equal(t, expected, hashAtSlot11)
+	assert.Equal(t, uint64(2500), capitalization)
+}
+
+func TestCalculateIncrementalAccountsHash(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b}, 10))
+
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 1500, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 11))
+
+	bClosed := &accounts.Account{Key: b.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{bClosed}, 12))
+
+	// only accounts modified after the base slot are included, and zero-lamport
+	// accounts are included with a zeroed hash.
+	incrementalHash, capitalization, err := CalculateIncrementalAccountsHash(acctsDb, 10, 12)
+	require.NoError(t, err)
+	expected := expectedAccountsHash([]acctHash{calculateSingleAcctHash(*aUpdated), {Pubkey: b.Key}})
+	assert.Equal(t, expected, incrementalHash)
+	assert.Equal(t, uint64(1500), capitalization)
+}
+
+func TestCleanAccountsDb(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	// slot 10's appendvec is left sparse by the later versions of a and b, and so is shrunk
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID, Data: make([]byte, 1024)}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID, Data: make([]byte, 1024)}
+	c := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 3000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b, c}, 10))
+
+	// slot 11's appendvec is entirely superseded, and so is deleted
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 1500, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 11))
+
+	aLatest := &accounts.Account{Key: a.Key, Lamports: 1700, Owner: solana.SystemProgramID}
+	bClosed := &accounts.Account{Key: b.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aLatest, bClosed}, 12))
+
+	// the zero-lamport version of b is removed along with its older version, leaving slot 12's
+	// appendvec half empty, and so it is shrunk too.
+
+	// beyond the clean slot, so left untouched
+	cUpdated := &accounts.Account{Key: c.Key, Lamports: 3500, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{cUpdated}, 13))
+
+	hashAtSlot12, _, err := CalculateAccountsHash(acctsDb, 12)
+	require.NoError(t, err)
+	hashAtSlot13, _, err := CalculateAccountsHash(acctsDb, 13)
+	require.NoError(t, err)
+
+	replayCtx := &ReplayCtx{AccountsDb: acctsDb}
+	stats, err := replayCtx.CleanAccountsDb(12)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.NumZeroLamportRemoved)
+	assert.Equal(t, uint64(2), stats.NumFilesShrunk)
+	assert.Equal(t, uint64(1), stats.NumFilesDeleted)
+	assert.Equal(t, uint64(4), stats.NumAcctsRemoved)
+
+	_, err = acctsDb.GetAccount(b.Key)
+	assert.ErrorIs(t, err, accountsdb.ErrNoAccount)
+
+	acct, err := acctsDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1700), acct.Lamports)
+
+	acct, err = acctsDb.GetAccount(c.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3500), acct.Lamports)
+
+	// the accounts state as of the clean slot and beyond is unchanged
+	hash, _, err := CalculateAccountsHash(acctsDb, 12)
+	require.NoError(t, err)
+	assert.Equal(t, hashAtSlot12, hash)
+
+	hash, _, err = CalculateAccountsHash(acctsDb, 13)
+	require.NoError(t, err)
+	assert.Equal(t, hashAtSlot13, hash)
+}
+
+func TestEpochAccountsHash(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b}, 10))
+	require.NoError(t, acctsDb.SetRootSlot(10))
+
+	replayCtx := &ReplayCtx{AccountsDb: acctsDb}
+	require.NoError(t, replayCtx.startEpochAccountsHashCalculation(1, 10))
+
+	// accounts stored whilst the EAH is being calculated do not affect it
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 500, Owner: solana.SystemProgramID}
+	c := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 3000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated, c}, 11))
+
+	eah, err := replayCtx.epochAccountsHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, expectedAccountsHash([]acctHash{calculateSingleAcctHash(*a), calculateSingleAcctHash(*b)}), eah)
+
+	_, err = replayCtx.epochAccountsHash(2)
+	assert.Error(t, err)
+}
+
+func TestRestoreEpochAccountsHash(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a}, 10))
+	require.NoError(t, acctsDb.SetRootSlot(10))
+	expected := expectedAccountsHash([]acctHash{calculateSingleAcctHash(*a)})
+
+	manifest := new(snapshot.SnapshotManifest)
+	manifest.Bank.Slot = 5
+
+	// an EAH calculation that was started beyond the last replayed slot is not restored
+	require.NoError(t, acctsDb.SetEpochAccountsHash(&accountsdb.EpochAccountsHashInfo{Epoch: 1, Slot: 10}))
+	require.NoError(t, acctsDb.SetBankHash(9, [32]byte{1}))
+	replayCtx, err := NewReplayCtx(acctsDb, manifest)
+	require.NoError(t, err)
+	assert.Nil(t, replayCtx.eah)
+
+	// one that was in flight is resumed over the accounts state as of its slot
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 500, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 11))
+	require.NoError(t, acctsDb.SetRootSlot(11))
+	require.NoError(t, acctsDb.SetBankHash(11, [32]byte{2}))
+
+	replayCtx, err = NewReplayCtx(acctsDb, manifest)
+	require.NoError(t, err)
+	eah, err := replayCtx.epochAccountsHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, eah)
+
+	// and one that had completed is restored as is
+	info, err := acctsDb.EpochAccountsHash()
+	require.NoError(t, err)
+	assert.Equal(t, &accountsdb.EpochAccountsHashInfo{Epoch: 1, Slot: 10, Done: true, Hash: expected}, info)
+
+	_, err = acctsDb.Clean(11)
+	require.NoError(t, err)
+
+	replayCtx, err = NewReplayCtx(acctsDb, manifest)
+	require.NoError(t, err)
+	eah, err = replayCtx.epochAccountsHash(1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, eah)
+
+	// whereas an in-flight calculation cannot be resumed once its slot has been cleaned
+	require.NoError(t, acctsDb.SetEpochAccountsHash(&accountsdb.EpochAccountsHashInfo{Epoch: 1, Slot: 10}))
+	_, err = NewReplayCtx(acctsDb, manifest)
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/base58"
+)
+
+func TestBankHashFileSource(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bankhashes")
+	contents := "# slot bankhash\n" +
+		"100 CbMK7uc68PPo5pPc5sfDJP6RGcwEcBJcrgZmuEZNEv6T\n" +
+		"\n" +
+		"102   9ugSt4ZhXSDJtVPV1u3t1j8uqZ8JVwJCNgJDLJXj7g3N\n"
+	require.NoError(t, os.WriteFile(filename, []byte(contents), 0644))
+
+	source, err := NewBankHashFileSource(filename)
+	require.NoError(t, err)
+
+	bankHash, found, err := source.ExpectedBankHash(100)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, base58.MustDecodeFromString("CbMK7uc68PPo5pPc5sfDJP6RGcwEcBJcrgZmuEZNEv6T"), bankHash)
+
+	_, found, err = source.ExpectedBankHash(101)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	bankHash, found, err = source.ExpectedBankHash(102)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, base58.MustDecodeFromString("9ugSt4ZhXSDJtVPV1u3t1j8uqZ8JVwJCNgJDLJXj7g3N"), bankHash)
+
+	require.NoError(t, os.WriteFile(filename, []byte("100\n"), 0644))
+	_, err = NewBankHashFileSource(filename)
+	assert.Error(t, err)
+}
@@ -25,6 +25,7 @@ type BlockRewardsInfo struct {
 
 type Block struct {
 	Slot             uint64
+	ParentSlot       uint64
 	Transactions     []*solana.Transaction
 	BankHash         [32]byte
 	ParentBankhash   [32]byte
@@ -33,8 +34,19 @@ type Block struct {
 	ExpectedBankhash [32]byte
 	Manifest         *snapshot.SnapshotManifest
 	TxMetas          []*rpc.TransactionMeta
+	TxStatusMetas    []*TransactionStatusMeta
 	Leader           solana.PublicKey
 	Reward           BlockRewardsInfo
+	FeatureSetHash   [32]byte
+}
+
+// txMeta returns the on-chain metadata for the transaction at idx, or nil if the
+// block came from a source that does not provide transaction metadata.
+func (block *Block) txMeta(idx int) *rpc.TransactionMeta {
+	if idx >= len(block.TxMetas) {
+		return nil
+	}
+	return block.TxMetas[idx]
 }
 
 func numBlockAccts(block *Block) uint64 {
@@ -57,7 +69,7 @@ func resolveAddrTableLookups(accountsDb *accountsdb.AccountsDb, block *Block) er
 
 		var skipLookup bool
 		for _, addrTableKey := range tx.Message.GetAddressTableLookups().GetTableIDs() {
-			acct, err := accountsDb.GetAccount(addrTableKey)
+			acct, err := accountsDb.GetAccountAtSlot(block.ParentSlot, addrTableKey)
 			if err != nil {
 				klog.Infof("unable to get address lookup table account: %s", addrTableKey)
 				skipLookup = true
@@ -81,9 +93,10 @@ func resolveAddrTableLookups(accountsDb *accountsdb.AccountsDb, block *Block) er
 			return err
 		}
 
+		// txs with lookups that fail to resolve are rejected when sanitized
 		err = tx.Message.ResolveLookups()
 		if err != nil {
-			return err
+			klog.Infof("unable to resolve address lookups for transaction %d: %s", idx, err)
 		}
 	}
 
@@ -112,9 +125,11 @@ func extractAndDedupeBlockAccts(block *Block) []solana.PublicKey {
 func isNativeProgram(pubkey solana.PublicKey) bool {
 	if pubkey == sealevel.SystemProgramAddr || pubkey == sealevel.BpfLoaderUpgradeableAddr ||
 		pubkey == sealevel.BpfLoader2Addr || pubkey == sealevel.BpfLoaderDeprecatedAddr ||
+		pubkey == sealevel.LoaderV4Addr ||
 		pubkey == sealevel.VoteProgramAddr || pubkey == sealevel.StakeProgramAddr ||
 		pubkey == sealevel.AddressLookupTableAddr || pubkey == sealevel.ConfigProgramAddr ||
-		pubkey == sealevel.ComputeBudgetProgramAddr {
+		pubkey == sealevel.ComputeBudgetProgramAddr || pubkey == sealevel.ZkElGamalProofProgramAddr ||
+		pubkey == sealevel.ZkTokenProofProgramAddr {
 		return true
 	} else {
 		return false
@@ -133,7 +148,7 @@ func loadBlockAccountsAndUpdateSysvars(accountsDb *accountsdb.AccountsDb, block
 	for _, pk := range dedupedAccts {
 		// retrieve account from accountsdb
 
-		acct, err := accountsDb.GetAccount(pk)
+		acct, err := accountsDb.GetAccountAtSlot(block.ParentSlot, pk)
 
 		// add the account to the slice, add a 'blank' account if the account doesn't exist,
 		// or return an error
@@ -169,7 +184,7 @@ func loadBlockAccountsAndUpdateSysvars(accountsDb *accountsdb.AccountsDb, block
 			sealevel.SysvarSlotHistoryAddr, sealevel.SysvarStakeHistoryAddr}
 
 		for _, sysvarAddr := range sysvarAddrs {
-			sysvarAcct, err := accountsDb.GetAccount(sysvarAddr)
+			sysvarAcct, err := accountsDb.GetAccountAtSlot(block.ParentSlot, sysvarAddr)
 			if err != nil {
 				panic(fmt.Sprintf("unable to retrieve sysvar %s from accountsdb", sysvarAddr))
 			}
@@ -217,19 +232,62 @@ func loadBlockAccountsAndUpdateSysvars(accountsDb *accountsdb.AccountsDb, block
 	return accts, epoch, nil
 }
 
-func scanAndEnableFeatures(acctsDb *accountsdb.AccountsDb, slot uint64) *features.Features {
+// scanAndEnableFeatures determines the feature set in effect in the block's slot from the
+// feature gate accounts as of its parent slot. In the first slot of an epoch, features pending
+// activation are activated, and their accounts returned with the activation slot recorded.
+func scanAndEnableFeatures(acctsDb *accountsdb.AccountsDb, block *Block, isNewEpoch bool) (*features.Features, []*accounts.Account) {
 	f := features.NewFeaturesDefault()
+	var activatedAccts []*accounts.Account
+
 	for _, featureGate := range features.AllFeatureGates {
-		_, err := acctsDb.GetAccount(featureGate.Address)
-		if err == nil {
-			klog.Infof("enabled feature: %s, %s", featureGate.Name, solana.PublicKeyFromBytes(featureGate.Address[:]))
-			f.EnableFeature(featureGate, slot)
+		acct, err := acctsDb.GetAccountAtSlot(block.ParentSlot, featureGate.Address)
+		if err != nil || acct.Lamports == 0 || acct.Owner != features.FeatureProgramAddr {
+			continue
+		}
+
+		feature, err := features.UnmarshalFeature(acct.Data)
+		if err != nil {
+			klog.Warningf("unable to parse account of feature %s: %s", featureGate.Name, err)
+			continue
+		}
+
+		if f.ApplyFeature(featureGate, feature, block.Slot, isNewEpoch) {
+			klog.Infof("activated pending feature: %s, %s", featureGate.Name, solana.PublicKeyFromBytes(featureGate.Address[:]))
+
+			// the feature is active even if its account can't record the activation slot
+			err = feature.Marshal(acct.Data)
+			if err != nil {
+				klog.Warningf("unable to record activation of feature %s: %s", featureGate.Name, err)
+				continue
+			}
+			activatedAccts = append(activatedAccts, acct)
+		} else if activationSlot, enabled := f.ActivationSlot(featureGate); enabled {
+			klog.Infof("enabled feature: %s, %s (activated at slot %d)", featureGate.Name, solana.PublicKeyFromBytes(featureGate.Address[:]), activationSlot)
 		}
 	}
-	return f
+
+	return f, activatedAccts
 }
 
-func ProcessBlock(acctsDb *accountsdb.AccountsDb, block *Block, updateAcctsDb bool) error {
+// activatesInflationFeature returns true if any of the activated feature accounts are those
+// of the features that enable inflation.
+func activatesInflationFeature(activatedFeatureAccts []*accounts.Account) bool {
+	inflationFeatures := []features.FeatureGate{features.PicoInflation, features.FullInflationDevnetAndTestnet,
+		features.FullInflationMainnetCertusoneVote, features.FullInflationMainnetCertusoneEnable}
+
+	for _, acct := range activatedFeatureAccts {
+		for _, featureGate := range inflationFeatures {
+			if acct.Key == solana.PublicKeyFromBytes(featureGate.Address[:]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func ProcessBlock(replayCtx *ReplayCtx, block *Block, updateAcctsDb bool) (err error) {
+	acctsDb := replayCtx.AccountsDb
 
 	// gather up all accounts used by the block and put them into a SlotCtx object
 	accts, epoch, err := loadBlockAccountsAndUpdateSysvars(acctsDb, block)
@@ -237,38 +295,143 @@ func ProcessBlock(acctsDb *accountsdb.AccountsDb, block *Block, updateAcctsDb bo
 		return err
 	}
 
-	f := scanAndEnableFeatures(acctsDb, block.Slot)
+	epochSchedule, err := readEpochScheduleSysvar(accts)
+	if err != nil {
+		err = fmt.Errorf("unable to read epoch schedule sysvar: %w", err)
+		return err
+	}
+	parentEpoch := epochSchedule.GetEpoch(block.ParentSlot)
+
+	f, activatedFeatureAccts := scanAndEnableFeatures(acctsDb, block, parentEpoch < epoch)
+	block.FeatureSetHash = f.Hash()
+	klog.Infof("feature set hash: %s", base58.Encode(block.FeatureSetHash[:]))
 
-	slotCtx := &sealevel.SlotCtx{Slot: block.Slot, Epoch: epoch, ParentSlot: block.Manifest.Bank.ParentSlot, Accounts: accts, AccountsDb: acctsDb, Replay: true, Features: f}
+	slotCtx := &sealevel.SlotCtx{Slot: block.Slot, Epoch: epoch, ParentSlot: block.ParentSlot, Accounts: accts, AccountsDb: acctsDb, Replay: true, Features: f}
 	slotCtx.ModifiedAccts = make(map[solana.PublicKey]bool)
 
-	var totalTxFees uint64
+	// the activation of features pending activation is recorded in their accounts
+	for _, acct := range activatedFeatureAccts {
+		err = slotCtx.SetAccount(acct.Key, acct)
+		if err != nil {
+			return err
+		}
+		slotCtx.ModifiedAccts[acct.Key] = true
+	}
 
-	// process & execute each transaction in turn
-	for idx, tx := range block.Transactions {
-		klog.Infof("[+] executing transaction %d, %s", idx+1, tx.Signatures[0])
-		txFee, txErr := ProcessTransaction(slotCtx, tx, block.TxMetas[idx])
-		if txErr != nil {
-			klog.Infof("tx %d returned error: %s\n", idx+1, txErr)
+	// nonce accounts are advanced to the durable nonce of the parent slot's blockhash
+	if replayCtx.blockhashQueue != nil && replayCtx.blockhashQueue.lastHash != nil {
+		slotCtx.Blockhash = *replayCtx.blockhashQueue.lastHash
+		slotCtx.LamportsPerSignature = replayCtx.blockhashQueue.lastLamportsPerSignature(replayCtx.Manifest.Bank.FeeCalculator.LamportsPerSignature)
+	}
+
+	// the clock and slothashes sysvars were updated for this slot, and hence are included
+	// in the accounts delta hash and persisted alongside the accounts modified by transactions.
+	slotCtx.ModifiedAccts[sealevel.SysvarClockAddr] = true
+	slotCtx.ModifiedAccts[sealevel.SysvarSlotHashesAddr] = true
+
+	// the first block of an epoch activates the stake for the epoch and pays out the rewards
+	// for the parent's epoch, which may be distributed over the blocks that follow
+	blockHeight := replayCtx.Manifest.Bank.BlockHeight + replayCtx.numBlocksReplayed + 1
+
+	// the changes to the epoch state are undone should the block fail to replay, including
+	// on a bankhash mismatch, such that the block may be replayed again
+	savedEpochState := replayCtx.saveEpochState(parentEpoch < slotCtx.Epoch)
+	bankHashVerified := false
+	defer func() {
+		if err != nil && !bankHashVerified {
+			replayCtx.restoreEpochState(savedEpochState)
 		}
+	}()
 
-		// check for success-failure return value divergences
-		if txErr == nil && block.TxMetas[idx].Err != nil {
-			klog.Infof("tx %s return value divergence: txErr was nil, but onchain err was %+v", tx.Signatures[0], block.TxMetas[idx].Err)
-		} else if txErr != nil && block.TxMetas[idx].Err == nil {
-			klog.Infof("tx %s return value divergence: txErr was %+v, but onchain err was nil", tx.Signatures[0], txErr)
+	if parentEpoch < slotCtx.Epoch {
+		err = replayCtx.processNewEpoch(slotCtx, epochSchedule, parentEpoch, blockHeight)
+		if err != nil {
+			return fmt.Errorf("failed to process new epoch %d: %w", slotCtx.Epoch, err)
 		}
+	}
 
-		totalTxFees += txFee
+	// the stakes of the slot's epoch are made available to programs via sol_get_epoch_stake
+	setEpochStakes(slotCtx, replayCtx.epochStakes)
+
+	err = replayCtx.distributeEpochRewards(slotCtx, blockHeight)
+	if err != nil {
+		return fmt.Errorf("failed to distribute epoch rewards: %w", err)
+	}
+
+	// in serial-equivalence checking mode, the transactions are additionally executed serially
+	// against a copy of the slot's accounts, and the outcomes compared.
+	var serialSlotCtx *sealevel.SlotCtx
+	if replayCtx.CheckSerialEquivalence && replayCtx.TxParallelism > 1 {
+		serialSlotCtx = cloneSlotCtx(slotCtx)
+	}
+
+	verifyErrs := verifyTransactions(slotCtx, block, replayCtx.TxParallelism, !replayCtx.SkipSigVerify)
+	msgHashes, nonceTxs := replayCtx.checkTransactions(block, verifyErrs)
+
+	block.TxStatusMetas, err = executeTransactions(slotCtx, block, verifyErrs, nonceTxs, replayCtx.TxParallelism)
+	if err != nil {
+		return err
+	}
+
+	if serialSlotCtx != nil {
+		serialTxStatusMetas, err := executeTransactions(serialSlotCtx, block, verifyErrs, nonceTxs, 1)
+		if err != nil {
+			return err
+		}
+
+		err = checkSerialEquivalence(block, slotCtx, block.TxStatusMetas, serialSlotCtx, serialTxStatusMetas)
+		if err != nil {
+			return err
+		}
+		klog.Infof("parallel execution of slot %d matched serial execution", block.Slot)
+	}
+
+	var totalTxFees, numCommittedTxs uint64
+	for idx, txStatus := range block.TxStatusMetas {
+		if txStatus.Err != nil {
+			klog.Infof("tx %d returned error: %s\n", idx+1, txStatus.Err)
+		}
+		totalTxFees += txStatus.Fee
+
+		// txs that failed before being charged a fee are not committed
+		if txStatus.Err == nil || txStatus.Fee != 0 {
+			numCommittedTxs++
+		}
+	}
+
+	divergences := blockDivergences(block)
+	for _, divergence := range divergences {
+		if divergence.Account != nil {
+			klog.Infof("tx %s %s divergence for %s: expected %s, but was %s", divergence.Signature, divergence.Kind, divergence.Account, divergence.Expected, divergence.Actual)
+		} else {
+			klog.Infof("tx %s %s divergence: expected %s, but was %s", divergence.Signature, divergence.Kind, divergence.Expected, divergence.Actual)
+		}
+	}
+
+	if replayCtx.Divergences != nil {
+		err = replayCtx.Divergences.Collect(block.Slot, divergences)
+		if err != nil {
+			return err
+		}
 	}
 
 	// apply account state updates to accountsdb and collect the account states for inclusion
 	// into the accounts delta hash and therefore the bankhash
 	modifiedAccts := make([]*accounts.Account, 0)
 
+	// collect rent from the accounts due for rent collection in this slot, as of the epoch
+	// of the slot
+	rentCollector := replayCtx.Manifest.Bank.RentCollector
+	rentCollector.Epoch = slotCtx.Epoch
+	collectedRent, err := collectRent(slotCtx, &rentCollector, replayCtx.Manifest.Bank.TicksPerSlot)
+	if err != nil {
+		return fmt.Errorf("failed to collect rent: %w", err)
+	}
+	klog.Infof("collected rent: %d", collectedRent)
+
 	// distribute tx fees to the leader by calculating 50% of the tx fees and adding the sum
 	// to the slot leader's lamports balance, subsequently including it in the accounts delta hash.
-	fees.DistributeTxFees(acctsDb, slotCtx, block.Leader, totalTxFees)
+	burnedFees := fees.DistributeTxFees(acctsDb, slotCtx, block.Leader, totalTxFees)
 
 	klog.Infof("from RPC fees for leader: %d, post-balance: %d (%s)", block.Reward.Lamports, block.Reward.PostBalance, block.Reward.Leader)
 
@@ -280,9 +443,23 @@ func ProcessBlock(acctsDb *accountsdb.AccountsDb, block *Block, updateAcctsDb bo
 		modifiedAccts = append(modifiedAccts, acct)
 	}
 
-	if len(modifiedAccts) > 0 && updateAcctsDb {
+	// the modified accounts are only rooted once the bankhash has been verified, and are
+	// otherwise purged, such that a block that fails to replay leaves the AccountsDB untouched.
+	if updateAcctsDb {
 		klog.Infof("updating accountsdb")
-		err = acctsDb.StoreAccounts(modifiedAccts, slotCtx.Slot)
+		err = acctsDb.StoreAccountsUnrooted(modifiedAccts, slotCtx.Slot, block.ParentSlot)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if err != nil {
+				purgeErr := acctsDb.Purge(slotCtx.Slot)
+				if purgeErr != nil {
+					klog.Errorf("failed to purge accounts of slot %d: %s", slotCtx.Slot, purgeErr)
+				}
+			}
+		}()
 	} else {
 		klog.Infof("accountsdb not updated")
 	}
@@ -292,11 +469,86 @@ func ProcessBlock(acctsDb *accountsdb.AccountsDb, block *Block, updateAcctsDb bo
 	acctDeltaHash := calculateAcctsDeltaHash(modifiedAccts)
 
 	// calculate bankhash
-	bankHash := calculateBankHash(slotCtx, acctDeltaHash, block.ParentBankhash, block.NumSignatures, block.Blockhash)
-	if bytes.Equal(bankHash, block.ExpectedBankhash[:]) {
+	bankHash := calculateBankHash(acctDeltaHash, block.ParentBankhash, block.NumSignatures, block.Blockhash)
+
+	if shouldIncludeEah(epochSchedule, slotCtx) {
+		eah, err := replayCtx.epochAccountsHash(slotCtx.Epoch)
+		if err != nil {
+			return err
+		}
+		klog.Infof("mixing epoch accounts hash %s into bankhash", base58.Encode(eah[:]))
+		bankHash = mixInEpochAccountsHash(bankHash, eah)
+	}
+
+	copy(block.BankHash[:], bankHash)
+
+	if block.ExpectedBankhash == [32]byte{} {
+		klog.Infof("calculated bankhash %s (no expected bankhash to compare against)", base58.Encode(bankHash))
+	} else if bytes.Equal(bankHash, block.ExpectedBankhash[:]) {
 		klog.Infof("calculated bankhash matched expected bankhash.")
 	} else {
-		klog.Infof("calculated bankhash (%s) mismatch (%s)", base58.Encode(bankHash), base58.Encode(block.ExpectedBankhash[:]))
+		return &BankHashMismatchError{Slot: block.Slot, ParentSlot: block.ParentSlot, ParentBankHash: block.ParentBankhash,
+			Calculated: block.BankHash, Expected: block.ExpectedBankhash}
+	}
+	bankHashVerified = true
+
+	if updateAcctsDb {
+		err = acctsDb.Root(slotCtx.Slot)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the EAH is calculated over the accounts state as of this slot, so its calculation
+	// must only begin once the accounts modified in this slot have been rooted.
+	if shouldStartEahCalculation(epochSchedule, slotCtx) {
+		err = replayCtx.startEpochAccountsHashCalculation(slotCtx.Epoch, slotCtx.Slot)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the block's blockhash may be used by the transactions of subsequent slots, and those of
+	// its transactions that were committed may not be processed again.
+	if replayCtx.blockhashQueue != nil {
+		lamportsPerSignature := replayCtx.blockhashQueue.lastLamportsPerSignature(replayCtx.Manifest.Bank.FeeCalculator.LamportsPerSignature)
+		replayCtx.blockhashQueue.registerHash(block.Blockhash, lamportsPerSignature)
+
+		for idx, txStatus := range block.TxStatusMetas {
+			// txs that failed before being charged a fee are not committed
+			if txStatus.Err == nil || txStatus.Fee != 0 {
+				tx := block.Transactions[idx]
+				replayCtx.statusCache.insert(block.Slot, [32]byte(tx.Message.RecentBlockhash), msgHashes[idx], tx.Signatures[0], txStatus.Err)
+			}
+		}
+		replayCtx.statusCache.addRoot(block.Slot)
+		replayCtx.statusCache.purge(block.Slot)
+	}
+
+	// the stakes cache picks up the stake and vote accounts modified in the block, and the
+	// collected rent, which isn't distributed, and the burned fees are taken out of circulation
+	if replayCtx.stakes != nil {
+		newRateActivationEpoch := newWarmupCooldownRateEpoch(epochSchedule, f)
+		for _, acct := range modifiedAccts {
+			replayCtx.stakes.storeAccount(acct, newRateActivationEpoch)
+		}
+		replayCtx.capitalization -= collectedRent + burnedFees
+	}
+
+	// the inflation parameters are replaced upon the activation of the inflation features,
+	// which replay does not implement
+	if activatesInflationFeature(activatedFeatureAccts) {
+		replayCtx.inflationChanged = true
+	}
+
+	replayCtx.transactionCount += numCommittedTxs
+	replayCtx.lastBank = &replayedBank{slot: block.Slot, parentSlot: block.ParentSlot, epoch: slotCtx.Epoch, bankHash: block.BankHash,
+		parentBankHash: block.ParentBankhash, acctsDeltaHash: [32]byte(acctDeltaHash), numSignatures: block.NumSignatures,
+		collectorId: block.Leader, collectorFees: totalTxFees, collectedRent: collectedRent}
+	replayCtx.numBlocksReplayed++
+
+	if updateAcctsDb {
+		err = acctsDb.SetBankHash(block.Slot, block.BankHash)
 	}
 
 	return err
@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.firedancer.io/radiance/pkg/rpcclient"
+)
+
+// BlockSource supplies blocks for replay. Blocks are requested in ascending slot order.
+//
+// Implementations return ErrSlotSkipped if no block was produced for (or rooted at) the slot,
+// ErrBlockNotAvailable if the block may become available later, and ErrNoMoreBlocks
+// once the source has been exhausted.
+type BlockSource interface {
+	GetBlock(slot uint64) (*Block, error)
+}
+
+// LeaderSource supplies the leader for a given slot, for block sources that do not
+// carry the slot leader along with the block.
+type LeaderSource interface {
+	GetLeaderForSlot(slot uint64) (solana.PublicKey, error)
+}
+
+var (
+	ErrSlotSkipped       = errors.New("ErrSlotSkipped")
+	ErrBlockNotAvailable = errors.New("ErrBlockNotAvailable")
+	ErrNoMoreBlocks      = errors.New("ErrNoMoreBlocks")
+)
+
+func newBlockFromBlockResult(blockResult *rpc.GetBlockResult) (*Block, error) {
+	block := new(Block)
+
+	for _, tx := range blockResult.Transactions {
+		txParsed, err := tx.GetTransaction()
+		if err != nil {
+			return nil, err
+		}
+		block.Transactions = append(block.Transactions, txParsed)
+		block.TxMetas = append(block.TxMetas, tx.Meta)
+	}
+
+	block.Blockhash = blockResult.Blockhash
+	block.ParentSlot = blockResult.ParentSlot
+
+	for _, tx := range block.Transactions {
+		block.NumSignatures += uint64(tx.Message.Header.NumRequiredSignatures)
+	}
+
+	for _, reward := range blockResult.Rewards {
+		if reward.RewardType == rpc.RewardTypeFee {
+			block.Reward = BlockRewardsInfo{Leader: reward.Pubkey, Lamports: uint64(reward.Lamports), PostBalance: reward.PostBalance}
+			break
+		}
+	}
+
+	return block, nil
+}
+
+// RpcBlockSource fetches finalized blocks from a Solana RPC endpoint.
+type RpcBlockSource struct {
+	rpcc *rpcclient.RpcClient
+}
+
+func NewRpcBlockSource(rpcc *rpcclient.RpcClient) *RpcBlockSource {
+	return &RpcBlockSource{rpcc: rpcc}
+}
+
+func (source *RpcBlockSource) GetBlock(slot uint64) (*Block, error) {
+	blockResult, err := source.rpcc.GetBlockFinalized(slot)
+	if rpcclient.IsSlotSkippedErr(err) {
+		return nil, ErrSlotSkipped
+	} else if rpcclient.IsBlockNotAvailableErr(err) {
+		return nil, ErrBlockNotAvailable
+	} else if err != nil {
+		return nil, err
+	}
+
+	block, err := newBlockFromBlockResult(blockResult)
+	if err != nil {
+		return nil, fmt.Errorf("error creating block from BlockResult: %w", err)
+	}
+
+	leader, err := source.rpcc.GetLeaderForSlot(slot)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching leader for slot: %w", err)
+	}
+
+	block.Slot = slot
+	block.Leader = leader
+
+	return block, nil
+}
+
+// JsonDirBlockSource reads blocks from a directory of saved getBlock responses, one
+// file per slot named "<slot>.json". Either the full JSON-RPC response or just its
+// result object may be saved, and transactions must be base64 encoded. Slots without
+// a file are treated as skipped, up to the highest slot in the directory.
+//
+// The leader is looked up via the given LeaderSource if one is provided. Otherwise it is
+// taken from the block's fee reward, and blocks without a fee reward cannot be replayed.
+type JsonDirBlockSource struct {
+	dir     string
+	maxSlot uint64
+	leaders LeaderSource
+}
+
+func NewJsonDirBlockSource(dir string, leaders LeaderSource) (*JsonDirBlockSource, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &JsonDirBlockSource{dir: dir, leaders: leaders}
+
+	var numBlocks uint64
+	for _, dirEntry := range dirEntries {
+		slotStr, isJson := strings.CutSuffix(dirEntry.Name(), ".json")
+		if dirEntry.IsDir() || !isJson {
+			continue
+		}
+
+		slot, err := strconv.ParseUint(slotStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		source.maxSlot = max(source.maxSlot, slot)
+		numBlocks++
+	}
+
+	if numBlocks == 0 {
+		return nil, fmt.Errorf("no getBlock JSON files found in %s", dir)
+	}
+
+	return source, nil
+}
+
+func (source *JsonDirBlockSource) GetBlock(slot uint64) (*Block, error) {
+	if slot > source.maxSlot {
+		return nil, ErrNoMoreBlocks
+	}
+
+	blockJson, err := os.ReadFile(filepath.Join(source.dir, fmt.Sprintf("%d.json", slot)))
+	if os.IsNotExist(err) {
+		return nil, ErrSlotSkipped
+	} else if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result *rpc.GetBlockResult `json:"result"`
+	}
+	err = json.Unmarshal(blockJson, &response)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse getBlock JSON for slot %d: %w", slot, err)
+	}
+
+	blockResult := response.Result
+	if blockResult == nil {
+		blockResult = new(rpc.GetBlockResult)
+		err = json.Unmarshal(blockJson, blockResult)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse getBlock JSON for slot %d: %w", slot, err)
+		}
+	}
+
+	block, err := newBlockFromBlockResult(blockResult)
+	if err != nil {
+		return nil, fmt.Errorf("error creating block from BlockResult: %w", err)
+	}
+
+	block.Slot = slot
+
+	if source.leaders != nil {
+		block.Leader, err = source.leaders.GetLeaderForSlot(slot)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching leader for slot: %w", err)
+		}
+	} else if !block.Reward.Leader.IsZero() {
+		// the slot leader receives the fee reward for the block
+		block.Leader = block.Reward.Leader
+	} else {
+		return nil, fmt.Errorf("block for slot %d has no fee reward to take the leader from, and no leader source was given", slot)
+	}
+
+	return block, nil
+}
@@ -0,0 +1,96 @@
+//go:build !lite
+
+package replay
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/blockstore"
+)
+
+// BlockstoreBlockSource reads rooted blocks from one or more blockstore RocksDB databases.
+//
+// The blockstore does not carry transaction status metadata or the slot leader, so blocks
+// are returned without TxMetas and the leader is looked up via the given LeaderSource.
+type BlockstoreBlockSource struct {
+	walk    *blockstore.BlockWalk
+	leaders LeaderSource
+	meta    *blockstore.SlotMeta
+	seeked  bool
+}
+
+func NewBlockstoreBlockSource(walk *blockstore.BlockWalk, leaders LeaderSource) *BlockstoreBlockSource {
+	return &BlockstoreBlockSource{walk: walk, leaders: leaders}
+}
+
+func (source *BlockstoreBlockSource) GetBlock(slot uint64) (*Block, error) {
+	if !source.seeked {
+		if !source.walk.Seek(slot) {
+			return nil, ErrNoMoreBlocks
+		}
+		source.seeked = true
+	}
+
+	// the walk only visits rooted slots, so any slot passed over was skipped.
+	for source.meta == nil || source.meta.Slot < slot {
+		meta, ok := source.walk.Next()
+		if !ok {
+			return nil, ErrNoMoreBlocks
+		}
+		source.meta = meta
+	}
+
+	if source.meta.Slot > slot {
+		return nil, ErrSlotSkipped
+	}
+
+	batches, err := source.walk.Entries(source.meta)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read entries for slot %d: %w", slot, err)
+	}
+
+	block := &Block{Slot: slot, ParentSlot: source.meta.ParentSlot}
+
+	var lastHash solana.Hash
+	for _, batch := range batches {
+		for _, entry := range batch {
+			for idx := range entry.Txns {
+				tx := &entry.Txns[idx]
+				block.Transactions = append(block.Transactions, tx)
+				block.NumSignatures += uint64(tx.Message.Header.NumRequiredSignatures)
+			}
+			lastHash = entry.Hash
+		}
+	}
+
+	// the blockhash of a block is the hash of its final entry
+	block.Blockhash = lastHash
+
+	block.Leader, err = source.leaders.GetLeaderForSlot(slot)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching leader for slot: %w", err)
+	}
+
+	return block, nil
+}
+
+// BlockstoreBankHashSource supplies expected bank hashes from the bank_hashes column
+// of a blockstore RocksDB database.
+type BlockstoreBankHashSource struct {
+	db *blockstore.DB
+}
+
+func NewBlockstoreBankHashSource(db *blockstore.DB) *BlockstoreBankHashSource {
+	return &BlockstoreBankHashSource{db: db}
+}
+
+func (source *BlockstoreBankHashSource) ExpectedBankHash(slot uint64) ([32]byte, bool, error) {
+	frozenHash, err := source.db.GetBankHash(slot)
+	if err == blockstore.ErrNotFound {
+		return [32]byte{}, false, nil
+	} else if err != nil {
+		return [32]byte{}, false, err
+	}
+	return frozenHash.FrozenHash, true, nil
+}
@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/base58"
+)
+
+type testLeaderSource map[uint64]solana.PublicKey
+
+func (leaders testLeaderSource) GetLeaderForSlot(slot uint64) (solana.PublicKey, error) {
+	return leaders[slot], nil
+}
+
+func TestJsonDirBlockSource(t *testing.T) {
+	source, err := NewJsonDirBlockSource(fixtures.Path(t, "blocks"), nil)
+	require.NoError(t, err)
+
+	// saved as a full JSON-RPC response
+	block, err := source.GetBlock(100)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(100), block.Slot)
+	assert.Equal(t, uint64(99), block.ParentSlot)
+	assert.Equal(t, 1, len(block.Transactions))
+	assert.Equal(t, 1, len(block.TxMetas))
+	assert.Equal(t, uint64(1), block.NumSignatures)
+	assert.Equal(t, uint64(5000), block.TxMetas[0].Fee)
+	assert.Equal(t, base58.MustDecodeFromString("9ugSt4ZhXSDJtVPV1u3t1j8uqZ8JVwJCNgJDLJXj7g3N"), block.Blockhash)
+	assert.Equal(t, base58.MustDecodeFromString("7Np41oeYqPefeNQEHSv1UDhYrehxin3NStELsSKCT4K2"), [32]byte(block.Leader))
+	assert.Equal(t, uint64(2500), block.Reward.Lamports)
+
+	// no file for the slot
+	_, err = source.GetBlock(101)
+	assert.Equal(t, ErrSlotSkipped, err)
+
+	// saved as just the getBlock result, without a fee reward to take the leader from
+	_, err = source.GetBlock(102)
+	assert.Error(t, err)
+
+	_, err = source.GetBlock(103)
+	assert.Equal(t, ErrNoMoreBlocks, err)
+}
+
+func TestJsonDirBlockSource_LeaderSource(t *testing.T) {
+	leader := solana.MustPublicKeyFromBase58("CakcnaRDHka2gXyfbEd2d3xsvkJkqsLw2akB3zsN1D2S")
+	source, err := NewJsonDirBlockSource(fixtures.Path(t, "blocks"), testLeaderSource{100: leader, 102: leader})
+	require.NoError(t, err)
+
+	// the leader schedule takes precedence over the fee reward
+	block, err := source.GetBlock(100)
+	require.NoError(t, err)
+	assert.Equal(t, leader, block.Leader)
+
+	block, err = source.GetBlock(102)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), block.ParentSlot)
+	assert.Equal(t, 0, len(block.Transactions))
+	assert.Equal(t, leader, block.Leader)
+}
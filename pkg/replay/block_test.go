@@ -0,0 +1,58 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb/accountsdbtest"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func newTestFeatureAccount(t *testing.T, gate features.FeatureGate, activatedAt *uint64) *accounts.Account {
+	data := make([]byte, 9)
+	require.NoError(t, (&features.Feature{ActivatedAt: activatedAt}).Marshal(data))
+	return &accounts.Account{Key: solana.PublicKeyFromBytes(gate.Address[:]), Lamports: 1000000, Data: data, Owner: features.FeatureProgramAddr}
+}
+
+func TestScanAndEnableFeatures(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(0))
+
+	activatedAt, futureActivation := uint64(5), uint64(50)
+	notFeatureAcct := newTestFeatureAccount(t, features.LastRestartSlotSysvar, &activatedAt)
+	notFeatureAcct.Owner = solana.SystemProgramID
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{
+		newTestFeatureAccount(t, features.StopTruncatingStringsInSyscalls, &activatedAt),
+		newTestFeatureAccount(t, features.EnablePartitionedEpochReward, nil),
+		newTestFeatureAccount(t, features.TimelyVoteCredits, &futureActivation),
+		notFeatureAcct,
+	}, 0))
+
+	block := &Block{Slot: 32, ParentSlot: 0}
+	f, activatedAccts := scanAndEnableFeatures(acctsDb, block, false)
+	activationSlot, enabled := f.ActivationSlot(features.StopTruncatingStringsInSyscalls)
+	assert.True(t, enabled)
+	assert.Equal(t, uint64(5), activationSlot)
+	assert.False(t, f.IsActive(features.EnablePartitionedEpochReward))
+	assert.False(t, f.IsActive(features.TimelyVoteCredits))
+	assert.False(t, f.IsActive(features.LastRestartSlotSysvar))
+	assert.Empty(t, activatedAccts)
+
+	// features pending activation are activated in the first slot of an epoch
+	activatedFeatures, activatedAccts := scanAndEnableFeatures(acctsDb, block, true)
+	activationSlot, enabled = activatedFeatures.ActivationSlot(features.EnablePartitionedEpochReward)
+	assert.True(t, enabled)
+	assert.Equal(t, uint64(32), activationSlot)
+	require.Len(t, activatedAccts, 1)
+	assert.Equal(t, solana.PublicKeyFromBytes(features.EnablePartitionedEpochReward.Address[:]), activatedAccts[0].Key)
+
+	feature, err := features.UnmarshalFeature(activatedAccts[0].Data)
+	require.NoError(t, err)
+	require.NotNil(t, feature.ActivatedAt)
+	assert.Equal(t, uint64(32), *feature.ActivatedAt)
+
+	assert.NotEqual(t, f.Hash(), activatedFeatures.Hash())
+}
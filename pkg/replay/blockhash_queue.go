@@ -0,0 +1,115 @@
+package replay
+
+import (
+	"sort"
+	"time"
+
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// maxProcessingAge is the number of slots, as counted by blockhashes registered in the
+// blockhash queue, for which a transaction's recent blockhash remains valid.
+const maxProcessingAge = 150
+
+type hashAge struct {
+	lamportsPerSignature uint64
+	hashIndex            uint64
+	timestamp            uint64
+}
+
+// blockhashQueue tracks the most recent blockhashes, one per slot, together with the fee
+// rate in effect for each, as the bank's blockhash queue does.
+type blockhashQueue struct {
+	lastHashIndex uint64
+	lastHash      *[32]byte
+	ages          map[[32]byte]hashAge
+	maxAge        uint64
+}
+
+func newBlockhashQueue(bhv *snapshot.BlockHashVec) *blockhashQueue {
+	queue := &blockhashQueue{lastHashIndex: bhv.LastHashIndex, maxAge: bhv.MaxAge,
+		ages: make(map[[32]byte]hashAge, len(bhv.Ages))}
+
+	if bhv.LastHash != nil {
+		lastHash := *bhv.LastHash
+		queue.lastHash = &lastHash
+	}
+
+	for _, age := range bhv.Ages {
+		queue.ages[age.Key] = hashAge{lamportsPerSignature: age.Val.FeeCalculator.LamportsPerSignature,
+			hashIndex: age.Val.HashIndex, timestamp: age.Val.Timestamp}
+	}
+
+	return queue
+}
+
+func isHashIndexValid(lastHashIndex uint64, maxAge uint64, hashIndex uint64) bool {
+	return lastHashIndex-hashIndex <= maxAge
+}
+
+// isHashValidForAge returns whether the hash is amongst the maxAge+1 most recently
+// registered blockhashes.
+func (queue *blockhashQueue) isHashValidForAge(hash [32]byte, maxAge uint64) bool {
+	age, ok := queue.ages[hash]
+	return ok && isHashIndexValid(queue.lastHashIndex, maxAge, age.hashIndex)
+}
+
+// lamportsPerSignature returns the fee rate in effect when the hash was registered.
+func (queue *blockhashQueue) lamportsPerSignature(hash [32]byte) (uint64, bool) {
+	age, ok := queue.ages[hash]
+	return age.lamportsPerSignature, ok
+}
+
+// registerHash appends the hash of a newly replayed slot to the queue, evicting the
+// blockhashes that have fallen out of the queue's maximum age.
+func (queue *blockhashQueue) registerHash(hash [32]byte, lamportsPerSignature uint64) {
+	queue.lastHashIndex++
+	if uint64(len(queue.ages)) >= queue.maxAge {
+		for key, age := range queue.ages {
+			if !isHashIndexValid(queue.lastHashIndex, queue.maxAge, age.hashIndex) {
+				delete(queue.ages, key)
+			}
+		}
+	}
+
+	queue.ages[hash] = hashAge{lamportsPerSignature: lamportsPerSignature, hashIndex: queue.lastHashIndex,
+		timestamp: uint64(time.Now().UnixMilli())}
+	queue.lastHash = &hash
+}
+
+// lastLamportsPerSignature returns the fee rate of the most recently registered blockhash,
+// or the given default if the queue is empty.
+func (queue *blockhashQueue) lastLamportsPerSignature(dflt uint64) uint64 {
+	if queue.lastHash == nil {
+		return dflt
+	}
+	if lamportsPerSignature, ok := queue.lamportsPerSignature(*queue.lastHash); ok {
+		return lamportsPerSignature
+	}
+	return dflt
+}
+
+// blockHashVec returns the queue in the form in which it is serialized into snapshot
+// manifests, with the blockhashes in the order in which they were registered.
+func (queue *blockhashQueue) blockHashVec() snapshot.BlockHashVec {
+	bhv := snapshot.BlockHashVec{LastHashIndex: queue.lastHashIndex, MaxAge: queue.maxAge,
+		Ages: make([]snapshot.HashAgePair, 0, len(queue.ages))}
+
+	if queue.lastHash != nil {
+		lastHash := *queue.lastHash
+		bhv.LastHash = &lastHash
+	}
+
+	for key, age := range queue.ages {
+		bhv.Ages = append(bhv.Ages, snapshot.HashAgePair{Key: key, Val: snapshot.HashAge{
+			FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: age.lamportsPerSignature},
+			HashIndex:     age.hashIndex, Timestamp: age.timestamp}})
+	}
+
+	sort.Slice(bhv.Ages, func(i, j int) bool {
+		return bhv.Ages[i].Val.HashIndex < bhv.Ages[j].Val.HashIndex
+	})
+
+	return bhv
+}
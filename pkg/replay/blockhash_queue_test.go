@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func TestBlockhashQueue(t *testing.T) {
+	lastHash := [32]byte{2}
+	queue := newBlockhashQueue(&snapshot.BlockHashVec{LastHashIndex: 2, LastHash: &lastHash, MaxAge: 3, Ages: []snapshot.HashAgePair{
+		{Key: [32]byte{1}, Val: snapshot.HashAge{FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: 5000}, HashIndex: 1}},
+		{Key: [32]byte{2}, Val: snapshot.HashAge{FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: 5000}, HashIndex: 2}},
+	}})
+
+	assert.True(t, queue.isHashValidForAge([32]byte{1}, 1))
+	assert.False(t, queue.isHashValidForAge([32]byte{1}, 0))
+	assert.False(t, queue.isHashValidForAge([32]byte{3}, 3))
+	assert.Equal(t, uint64(5000), queue.lastLamportsPerSignature(0))
+
+	queue.registerHash([32]byte{3}, 10000)
+	queue.registerHash([32]byte{4}, 10000)
+	assert.True(t, queue.isHashValidForAge([32]byte{1}, 3))
+	assert.False(t, queue.isHashValidForAge([32]byte{1}, 2))
+
+	// the queue is full, so hashes beyond the maximum age are evicted
+	queue.registerHash([32]byte{5}, 10000)
+	assert.False(t, queue.isHashValidForAge([32]byte{1}, 10))
+	assert.True(t, queue.isHashValidForAge([32]byte{2}, 3))
+	assert.Equal(t, uint64(10000), queue.lastLamportsPerSignature(0))
+
+	bhv := queue.blockHashVec()
+	assert.Equal(t, uint64(5), bhv.LastHashIndex)
+	assert.Equal(t, [32]byte{5}, *bhv.LastHash)
+	require.Len(t, bhv.Ages, 4)
+	for idx, age := range bhv.Ages {
+		assert.Equal(t, [32]byte{byte(idx + 2)}, age.Key)
+		assert.Equal(t, uint64(idx+2), age.Val.HashIndex)
+	}
+}
+
+func TestStatusCache(t *testing.T) {
+	cache := newStatusCache()
+	cache.insert(10, [32]byte{1}, [32]byte{7}, solana.Signature{17}, nil)
+	cache.insert(20, [32]byte{2}, [32]byte{8}, solana.Signature{18}, nil)
+
+	assert.True(t, cache.contains([32]byte{1}, [32]byte{7}))
+	assert.False(t, cache.contains([32]byte{2}, [32]byte{7}))
+
+	cache.purge(310)
+	assert.False(t, cache.contains([32]byte{1}, [32]byte{7}))
+	assert.True(t, cache.contains([32]byte{2}, [32]byte{8}))
+}
+
+func TestStatusCacheSlotDeltas(t *testing.T) {
+	cache := newStatusCache()
+	insufficientFunds := sealevel.NewTransactionError(sealevel.TxErrCodeInsufficientFundsForFee)
+	cache.insert(10, [32]byte{2}, [32]byte{7}, solana.Signature{17}, nil)
+	cache.insert(10, [32]byte{1}, [32]byte{8}, solana.Signature{18}, insufficientFunds)
+	cache.addRoot(10)
+
+	// a rooted slot without txs is carried regardless
+	cache.addRoot(11)
+
+	slotDeltas := cache.bankSlotDeltas()
+	require.Len(t, slotDeltas, 2)
+	assert.Equal(t, snapshot.BankSlotDelta{Slot: 11, IsRoot: true}, slotDeltas[1])
+
+	assert.Equal(t, uint64(10), slotDeltas[0].Slot)
+	assert.True(t, slotDeltas[0].IsRoot)
+	require.Len(t, slotDeltas[0].Statuses, 2)
+	assert.Equal(t, [32]byte{1}, slotDeltas[0].Statuses[0].Blockhash)
+	assert.Equal(t, []snapshot.TxStatus{{Key: [20]byte{8}, Err: insufficientFunds}, {Key: [20]byte{18}, Err: insufficientFunds}},
+		slotDeltas[0].Statuses[0].Txs)
+	assert.Equal(t, [32]byte{2}, slotDeltas[0].Statuses[1].Blockhash)
+	assert.Equal(t, []snapshot.TxStatus{{Key: [20]byte{7}}, {Key: [20]byte{17}}}, slotDeltas[0].Statuses[1].Txs)
+
+	// only the most recent statusCacheMaxAge rooted slots are retained
+	for slot := uint64(12); slot < 12+statusCacheMaxAge-1; slot++ {
+		cache.addRoot(slot)
+	}
+	slotDeltas = cache.bankSlotDeltas()
+	require.Len(t, slotDeltas, statusCacheMaxAge)
+	assert.Equal(t, uint64(11), slotDeltas[0].Slot)
+	assert.Empty(t, slotDeltas[0].Statuses)
+}
+
+func TestCheckTransactions(t *testing.T) {
+	queue := newBlockhashQueue(&snapshot.BlockHashVec{MaxAge: 300})
+	queue.registerHash(solana.Hash{1}, 5000)
+	replayCtx := &ReplayCtx{blockhashQueue: queue, statusCache: newStatusCache()}
+
+	tx := newTestSignedTx(t)
+	duplicateTx := *tx
+	processedTx := newTestSignedTx(t)
+	staleTx := newTestSignedTx(t)
+	staleTx.Message.RecentBlockhash = solana.Hash{2}
+	unverifiedTx := newTestSignedTx(t)
+
+	processedHash, err := txMessageHash(processedTx)
+	require.NoError(t, err)
+	replayCtx.statusCache.insert(1, [32]byte{1}, processedHash, processedTx.Signatures[0], nil)
+
+	block := &Block{Transactions: []*solana.Transaction{tx, &duplicateTx, processedTx, staleTx, unverifiedTx}}
+	signatureFailure := sealevel.NewTransactionError(sealevel.TxErrCodeSignatureFailure)
+	txErrs := []*sealevel.TransactionError{nil, nil, nil, nil, signatureFailure}
+	msgHashes, nonceTxs := replayCtx.checkTransactions(block, txErrs)
+
+	assert.Equal(t, []*sealevel.TransactionError{nil, sealevel.NewTransactionError(sealevel.TxErrCodeAlreadyProcessed),
+		sealevel.NewTransactionError(sealevel.TxErrCodeAlreadyProcessed), sealevel.NewTransactionError(sealevel.TxErrCodeBlockhashNotFound),
+		signatureFailure}, txErrs)
+	assert.Equal(t, msgHashes[0], msgHashes[1])
+	assert.Equal(t, processedHash, msgHashes[2])
+	assert.Equal(t, make([]bool, 5), nonceTxs)
+
+	// the blockhash is valid for maxProcessingAge slots after the one it was registered in
+	for slot := 0; slot < maxProcessingAge; slot++ {
+		queue.registerHash(solana.Hash{3, byte(slot)}, 5000)
+	}
+	txErrs = []*sealevel.TransactionError{nil}
+	replayCtx.checkTransactions(&Block{Transactions: []*solana.Transaction{tx}}, txErrs)
+	assert.Nil(t, txErrs[0])
+
+	queue.registerHash(solana.Hash{4}, 5000)
+	replayCtx.checkTransactions(&Block{Transactions: []*solana.Transaction{tx}}, txErrs)
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeBlockhashNotFound), txErrs[0])
+}
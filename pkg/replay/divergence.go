@@ -0,0 +1,263 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"k8s.io/klog/v2"
+)
+
+// DivergenceKind identifies the field of a transaction's status metadata in which replay
+// diverged from the transaction's on-chain execution.
+type DivergenceKind string
+
+const (
+	DivergenceStatus       DivergenceKind = "status"
+	DivergenceFee          DivergenceKind = "fee"
+	DivergencePreBalance   DivergenceKind = "pre_balance"
+	DivergencePostBalance  DivergenceKind = "post_balance"
+	DivergenceComputeUnits DivergenceKind = "compute_units"
+	DivergenceLogMessages  DivergenceKind = "log_messages"
+)
+
+// Divergence is a mismatch between the status metadata of a replayed transaction and that
+// of its on-chain execution. Account is set for divergences relating to a specific account.
+type Divergence struct {
+	Slot      uint64            `json:"slot"`
+	Kind      DivergenceKind    `json:"kind"`
+	Signature solana.Signature  `json:"signature"`
+	Account   *solana.PublicKey `json:"account,omitempty"`
+	Expected  string            `json:"expected"`
+	Actual    string            `json:"actual"`
+}
+
+type DivergenceReportFormat string
+
+const (
+	DivergenceReportJson DivergenceReportFormat = "json"
+	DivergenceReportCsv  DivergenceReportFormat = "csv"
+)
+
+// DivergenceCollector tallies the divergences found in replayed blocks, writing a report of
+// each slot's divergences to ReportDir, if set, in the given format.
+type DivergenceCollector struct {
+	ReportDir string
+	Format    DivergenceReportFormat
+
+	lock           sync.Mutex
+	numDivergences uint64
+	numByKind      map[DivergenceKind]uint64
+}
+
+func NewDivergenceCollector(reportDir string, format DivergenceReportFormat) (*DivergenceCollector, error) {
+	if format != DivergenceReportJson && format != DivergenceReportCsv {
+		return nil, fmt.Errorf("unknown divergence report format %q", format)
+	}
+	return &DivergenceCollector{ReportDir: reportDir, Format: format, numByKind: make(map[DivergenceKind]uint64)}, nil
+}
+
+// Collect records the divergences found in a slot, writing a report of them if there are any.
+func (collector *DivergenceCollector) Collect(slot uint64, divergences []Divergence) error {
+	collector.lock.Lock()
+	collector.numDivergences += uint64(len(divergences))
+	for _, divergence := range divergences {
+		collector.numByKind[divergence.Kind]++
+	}
+	collector.lock.Unlock()
+
+	if collector.ReportDir == "" || len(divergences) == 0 {
+		return nil
+	}
+
+	reportPath, err := WriteDivergenceReport(collector.ReportDir, collector.Format, slot, divergences)
+	if err != nil {
+		return fmt.Errorf("failed to write divergence report for slot %d: %w", slot, err)
+	}
+	klog.Infof("wrote report of %d divergences in slot %d to %s", len(divergences), slot, reportPath)
+
+	return nil
+}
+
+// NumDivergences returns the total number of divergences collected, and the number of each kind.
+func (collector *DivergenceCollector) NumDivergences() (uint64, map[DivergenceKind]uint64) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	numByKind := make(map[DivergenceKind]uint64, len(collector.numByKind))
+	for kind, num := range collector.numByKind {
+		numByKind[kind] = num
+	}
+	return collector.numDivergences, numByKind
+}
+
+// WriteDivergenceReport writes the divergences found in a slot to "divergences-<slot>.json" or
+// "divergences-<slot>.csv" in dir, returning the path of the written report.
+func WriteDivergenceReport(dir string, format DivergenceReportFormat, slot uint64, divergences []Divergence) (string, error) {
+	reportPath := filepath.Join(dir, fmt.Sprintf("divergences-%d.%s", slot, format))
+
+	file, err := os.Create(reportPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	switch format {
+	case DivergenceReportJson:
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(divergences)
+	case DivergenceReportCsv:
+		err = writeDivergencesCsv(file, divergences)
+	default:
+		err = fmt.Errorf("unknown divergence report format %q", format)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return reportPath, file.Close()
+}
+
+func writeDivergencesCsv(writer io.Writer, divergences []Divergence) error {
+	csvWriter := csv.NewWriter(writer)
+
+	err := csvWriter.Write([]string{"slot", "kind", "signature", "account", "expected", "actual"})
+	if err != nil {
+		return err
+	}
+
+	for _, divergence := range divergences {
+		var account string
+		if divergence.Account != nil {
+			account = divergence.Account.String()
+		}
+
+		err = csvWriter.Write([]string{strconv.FormatUint(divergence.Slot, 10), string(divergence.Kind), divergence.Signature.String(),
+			account, divergence.Expected, divergence.Actual})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// blockDivergences compares the status metadata of each of the block's replayed transactions
+// against its on-chain metadata, where the block source provides it.
+func blockDivergences(block *Block) []Divergence {
+	var divergences []Divergence
+	for idx, tx := range block.Transactions {
+		txMeta := block.txMeta(idx)
+		if txMeta == nil || idx >= len(block.TxStatusMetas) {
+			continue
+		}
+		divergences = append(divergences, txDivergences(block.Slot, tx, txMeta, block.TxStatusMetas[idx])...)
+	}
+	return divergences
+}
+
+func txDivergences(slot uint64, tx *solana.Transaction, expected *rpc.TransactionMeta, actual *TransactionStatusMeta) []Divergence {
+	var divergences []Divergence
+	addDivergence := func(kind DivergenceKind, account *solana.PublicKey, expected string, actual string) {
+		divergences = append(divergences, Divergence{Slot: slot, Kind: kind, Signature: tx.Signatures[0], Account: account,
+			Expected: expected, Actual: actual})
+	}
+
+	// statuses are compared in their RPC JSON form, and so down to the exact error variant,
+	// including the index of the failed instruction and any custom program error code. Errors
+	// not amongst the known variants are compared by whether the tx failed at all.
+	expectedStatus, actualStatus := "ok", "ok"
+	var expectedErr *sealevel.TransactionError
+	if expected.Err != nil {
+		errJson, _ := json.Marshal(expected.Err)
+		expectedStatus = string(errJson)
+
+		expectedErr = new(sealevel.TransactionError)
+		if err := json.Unmarshal(errJson, expectedErr); err != nil {
+			klog.Warningf("unable to decode on-chain error %s of tx %s: %s", errJson, tx.Signatures[0], err)
+			expectedErr = nil
+		}
+	}
+	if actual.Err != nil {
+		errJson, _ := json.Marshal(actual.Err)
+		actualStatus = string(errJson)
+	}
+
+	statusMatches := (expected.Err == nil) == (actual.Err == nil)
+	if statusMatches && expectedErr != nil {
+		statusMatches = *expectedErr == *actual.Err
+	}
+	if !statusMatches {
+		addDivergence(DivergenceStatus, nil, expectedStatus, actualStatus)
+	}
+
+	if expected.Fee != actual.Fee {
+		addDivergence(DivergenceFee, nil, strconv.FormatUint(expected.Fee, 10), strconv.FormatUint(actual.Fee, 10))
+	}
+
+	var acctKeys []solana.PublicKey
+	if acctMetas, err := tx.AccountMetaList(); err == nil {
+		for _, acctMeta := range acctMetas {
+			acctKeys = append(acctKeys, acctMeta.PublicKey)
+		}
+	}
+
+	compareBalances := func(kind DivergenceKind, expected []uint64, actual []uint64) {
+		if actual == nil {
+			// the tx failed before it was executed, so its balances are unknown
+			return
+		}
+		if len(expected) != len(actual) {
+			addDivergence(kind, nil, fmt.Sprintf("%d balances", len(expected)), fmt.Sprintf("%d balances", len(actual)))
+		}
+		for idx := 0; idx < len(expected) && idx < len(actual); idx++ {
+			if expected[idx] != actual[idx] {
+				var account *solana.PublicKey
+				if idx < len(acctKeys) {
+					account = &acctKeys[idx]
+				}
+				addDivergence(kind, account, strconv.FormatUint(expected[idx], 10), strconv.FormatUint(actual[idx], 10))
+			}
+		}
+	}
+
+	compareBalances(DivergencePreBalance, expected.PreBalances, actual.PreBalances)
+	compareBalances(DivergencePostBalance, expected.PostBalances, actual.PostBalances)
+
+	// the CUs consumed by failed txs depend upon the point of failure, which is covered by the
+	// status and log message comparisons
+	if expected.Err == nil && actual.Err == nil && expected.ComputeUnitsConsumed != nil && *expected.ComputeUnitsConsumed != actual.ComputeUnitsConsumed {
+		addDivergence(DivergenceComputeUnits, nil, strconv.FormatUint(*expected.ComputeUnitsConsumed, 10), strconv.FormatUint(actual.ComputeUnitsConsumed, 10))
+	}
+
+	// log messages are omitted by RPC nodes that do not record them, and only the first
+	// differing message is reported, since those following it typically differ as a result.
+	if expected.LogMessages != nil {
+		for idx := 0; idx < max(len(expected.LogMessages), len(actual.LogMessages)); idx++ {
+			var expectedMsg, actualMsg string
+			if idx < len(expected.LogMessages) {
+				expectedMsg = expected.LogMessages[idx]
+			}
+			if idx < len(actual.LogMessages) {
+				actualMsg = actual.LogMessages[idx]
+			}
+			if expectedMsg != actualMsg || idx >= len(expected.LogMessages) || idx >= len(actual.LogMessages) {
+				addDivergence(DivergenceLogMessages, nil, fmt.Sprintf("message %d: %s", idx, expectedMsg), fmt.Sprintf("message %d: %s", idx, actualMsg))
+				break
+			}
+		}
+	}
+
+	return divergences
+}
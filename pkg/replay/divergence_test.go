@@ -0,0 +1,128 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func newTestDivergenceTx(t *testing.T) (*solana.Transaction, solana.PublicKey, solana.PublicKey) {
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+
+	instr := solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{solana.Meta(payer).WRITE().SIGNER(), solana.Meta(recipient).WRITE()}, []byte{2})
+	tx, err := solana.NewTransaction([]solana.Instruction{instr}, solana.Hash{}, solana.TransactionPayer(payer))
+	require.NoError(t, err)
+	tx.Signatures = []solana.Signature{{1, 2, 3}}
+
+	return tx, payer, recipient
+}
+
+func TestTxDivergences(t *testing.T) {
+	tx, payer, recipient := newTestDivergenceTx(t)
+	cus := uint64(150)
+
+	expected := &rpc.TransactionMeta{Fee: 5000, PreBalances: []uint64{100000, 0, 1}, PostBalances: []uint64{85000, 10000, 1}, ComputeUnitsConsumed: &cus,
+		LogMessages: []string{"Program 11111111111111111111111111111111 invoke [1]", "Program 11111111111111111111111111111111 success"}}
+
+	// identical to the on-chain metadata
+	actual := &TransactionStatusMeta{Fee: 5000, PreBalances: []uint64{100000, 0, 1}, PostBalances: []uint64{85000, 10000, 1},
+		LogMessages: expected.LogMessages, ComputeUnitsConsumed: 150}
+	assert.Empty(t, txDivergences(7, tx, expected, actual))
+
+	actual = &TransactionStatusMeta{Fee: 10000, PreBalances: []uint64{100000, 0, 1}, PostBalances: []uint64{80000, 10001, 1},
+		LogMessages: []string{expected.LogMessages[0]}, ComputeUnitsConsumed: 140}
+	divergences := txDivergences(7, tx, expected, actual)
+
+	require.Len(t, divergences, 5)
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergenceFee, Signature: tx.Signatures[0], Expected: "5000", Actual: "10000"}, divergences[0])
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergencePostBalance, Signature: tx.Signatures[0], Account: &payer, Expected: "85000", Actual: "80000"}, divergences[1])
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergencePostBalance, Signature: tx.Signatures[0], Account: &recipient, Expected: "10000", Actual: "10001"}, divergences[2])
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergenceComputeUnits, Signature: tx.Signatures[0], Expected: "150", Actual: "140"}, divergences[3])
+	assert.Equal(t, DivergenceLogMessages, divergences[4].Kind)
+	assert.Equal(t, "message 1: Program 11111111111111111111111111111111 success", divergences[4].Expected)
+	assert.Equal(t, "message 1: ", divergences[4].Actual)
+
+	// failed on-chain, but succeeded in replay
+	expected.Err = map[string]interface{}{"InstructionError": []interface{}{0, "InvalidArgument"}}
+	expected.LogMessages = nil
+	actual = &TransactionStatusMeta{Fee: 5000, PreBalances: expected.PreBalances, PostBalances: expected.PostBalances}
+	divergences = txDivergences(7, tx, expected, actual)
+
+	require.Len(t, divergences, 1)
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergenceStatus, Signature: tx.Signatures[0], Expected: `{"InstructionError":[0,"InvalidArgument"]}`, Actual: "ok"}, divergences[0])
+
+	// failed both on-chain and in replay, but with differing errors
+	expected.Err = map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 1}}}
+	actual.Err = sealevel.NewTxErrInstructionError(0, &sealevel.CustomProgramErr{Code: 2})
+	divergences = txDivergences(7, tx, expected, actual)
+
+	require.Len(t, divergences, 1)
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergenceStatus, Signature: tx.Signatures[0], Expected: `{"InstructionError":[0,{"Custom":1}]}`,
+		Actual: `{"InstructionError":[0,{"Custom":2}]}`}, divergences[0])
+
+	actual.Err = sealevel.NewTxErrInstructionError(0, &sealevel.CustomProgramErr{Code: 1})
+	assert.Empty(t, txDivergences(7, tx, expected, actual))
+
+	// failed in replay before execution, so balances are unknown
+	actual = &TransactionStatusMeta{Err: sealevel.NewTransactionError(sealevel.TxErrCodeAccountNotFound)}
+	divergences = txDivergences(7, tx, &rpc.TransactionMeta{PreBalances: []uint64{1, 2, 3}, PostBalances: []uint64{1, 2, 3}}, actual)
+
+	require.Len(t, divergences, 1)
+	assert.Equal(t, Divergence{Slot: 7, Kind: DivergenceStatus, Signature: tx.Signatures[0], Expected: "ok", Actual: `"AccountNotFound"`}, divergences[0])
+}
+
+func TestDivergenceCollector(t *testing.T) {
+	tx, payer, _ := newTestDivergenceTx(t)
+	divergences := []Divergence{
+		{Slot: 7, Kind: DivergenceFee, Signature: tx.Signatures[0], Expected: "5000", Actual: "10000"},
+		{Slot: 7, Kind: DivergencePreBalance, Signature: tx.Signatures[0], Account: &payer, Expected: "1", Actual: "2"},
+	}
+
+	_, err := NewDivergenceCollector("", "xml")
+	assert.Error(t, err)
+
+	jsonDir, csvDir := t.TempDir(), t.TempDir()
+	jsonCollector, err := NewDivergenceCollector(jsonDir, DivergenceReportJson)
+	require.NoError(t, err)
+	csvCollector, err := NewDivergenceCollector(csvDir, DivergenceReportCsv)
+	require.NoError(t, err)
+
+	for _, collector := range []*DivergenceCollector{jsonCollector, csvCollector} {
+		require.NoError(t, collector.Collect(6, nil))
+		require.NoError(t, collector.Collect(7, divergences))
+
+		numDivergences, numByKind := collector.NumDivergences()
+		assert.Equal(t, uint64(2), numDivergences)
+		assert.Equal(t, map[DivergenceKind]uint64{DivergenceFee: 1, DivergencePreBalance: 1}, numByKind)
+	}
+
+	// reports are only written for slots with divergences
+	assert.NoFileExists(t, filepath.Join(jsonDir, "divergences-6.json"))
+
+	jsonReport, err := os.ReadFile(filepath.Join(jsonDir, "divergences-7.json"))
+	require.NoError(t, err)
+	var decoded []Divergence
+	require.NoError(t, json.Unmarshal(jsonReport, &decoded))
+	assert.Equal(t, divergences, decoded)
+
+	csvReport, err := os.Open(filepath.Join(csvDir, "divergences-7.csv"))
+	require.NoError(t, err)
+	defer csvReport.Close()
+	records, err := csv.NewReader(csvReport).ReadAll()
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"slot", "kind", "signature", "account", "expected", "actual"},
+		{"7", "fee", tx.Signatures[0].String(), "", "5000", "10000"},
+		{"7", "pre_balance", tx.Signatures[0].String(), payer.String(), "1", "2"},
+	}, records)
+}
@@ -0,0 +1,258 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+var (
+	ErrStakesUnknown          = errors.New("ErrStakesUnknown")
+	ErrEpochRewardsUnknown    = errors.New("ErrEpochRewardsUnknown")
+	ErrInvalidEpochRewards    = errors.New("ErrInvalidEpochRewards")
+	ErrParentBlockhashUnknown = errors.New("ErrParentBlockhashUnknown")
+	errNoEpochRewardsSysvar   = errors.New("no EpochRewards sysvar")
+)
+
+// epochState is the state of the replay context that is updated by the epoch boundary
+// processing and epoch rewards distribution of a block, prior to its bankhash being verified,
+// saved such that it can be restored should the block fail to replay.
+type epochState struct {
+	stakes         *stakesCache
+	epochStakes    []snapshot.EpochStakesPair
+	capitalization uint64
+	epochRewards   *epochRewardStatus
+}
+
+// saveEpochState saves the replay context's epoch state. The stakes cache is only modified
+// at epoch boundaries, and is copied only if the block being replayed crosses one.
+func (replayCtx *ReplayCtx) saveEpochState(newEpoch bool) *epochState {
+	state := &epochState{stakes: replayCtx.stakes, epochStakes: replayCtx.epochStakes, capitalization: replayCtx.capitalization,
+		epochRewards: replayCtx.epochRewards}
+	if newEpoch && replayCtx.stakes != nil {
+		state.stakes = replayCtx.stakes.clone()
+	}
+	return state
+}
+
+func (replayCtx *ReplayCtx) restoreEpochState(state *epochState) {
+	replayCtx.stakes = state.stakes
+	replayCtx.epochStakes = state.epochStakes
+	replayCtx.capitalization = state.capitalization
+	replayCtx.epochRewards = state.epochRewards
+}
+
+// epochRewardStatus is the state of a partitioned epoch rewards distribution in progress,
+// with a partition of the stake rewards distributed in each block from the starting block
+// height onwards.
+type epochRewardStatus struct {
+	distributionStartingBlockHeight uint64
+	partitions                      [][]stakeReward
+}
+
+// processNewEpoch runs the epoch boundary processing of the first block of an epoch, prior
+// to the execution of its transactions. The stakes cache is moved on to the new epoch and
+// recorded in the epoch stakes of the leader schedule epoch, and the inflation rewards for
+// the parent epoch are calculated. The vote accounts' commission is paid immediately, as are
+// the stake rewards, unless partitioned epoch rewards are enabled, in which case they are
+// distributed over the following blocks.
+func (replayCtx *ReplayCtx) processNewEpoch(slotCtx *sealevel.SlotCtx, epochSchedule *sealevel.SysvarEpochSchedule, parentEpoch uint64,
+	blockHeight uint64) error {
+	if replayCtx.stakes == nil {
+		return fmt.Errorf("%w: the stakes as of slot %d are not known", ErrStakesUnknown, slotCtx.ParentSlot)
+	}
+
+	// the parent slot's blockhash, from the blockhash queue, seeds the partitioning of the
+	// stake rewards
+	if slotCtx.Blockhash == [32]byte{} {
+		return fmt.Errorf("%w: the blockhash of slot %d is not known", ErrParentBlockhashUnknown, slotCtx.ParentSlot)
+	}
+
+	newRateActivationEpoch := newWarmupCooldownRateEpoch(epochSchedule, slotCtx.Features)
+	replayCtx.stakes.activateEpoch(slotCtx.Epoch, newRateActivationEpoch)
+
+	leaderScheduleEpoch := epochSchedule.LeaderScheduleEpoch(slotCtx.Slot)
+	replayCtx.epochStakes = updateEpochStakes(replayCtx.epochStakes, replayCtx.stakes, leaderScheduleEpoch)
+
+	bank := &replayCtx.Manifest.Bank
+	validatorRewards := previousEpochValidatorRewards(&bank.Inflation, epochSchedule, slotCtx.Features, slotCtx.Epoch, parentEpoch,
+		bank.SlotsPerYear, replayCtx.capitalization)
+
+	rewards, err := calculateEpochRewards(slotCtx, replayCtx.stakes, parentEpoch, validatorRewards, newRateActivationEpoch)
+	if err != nil {
+		return err
+	}
+
+	voteRewardsPaid, err := storeVoteRewards(slotCtx, rewards.voteRewards)
+	if err != nil {
+		return err
+	}
+	replayCtx.capitalization += voteRewardsPaid
+
+	klog.Infof("epoch %d: validator rewards %d, vote rewards paid %d, stake rewards %d for %d stake accounts", parentEpoch,
+		validatorRewards, voteRewardsPaid, rewards.totalStakeRewards, len(rewards.stakeRewards))
+
+	if slotCtx.Features.IsActive(features.EnablePartitionedEpochReward) {
+		numPartitions := numRewardPartitions(epochSchedule, slotCtx.Epoch, len(rewards.stakeRewards))
+		replayCtx.epochRewards = &epochRewardStatus{distributionStartingBlockHeight: blockHeight + 1,
+			partitions: hashRewardsIntoPartitions(rewards.stakeRewards, slotCtx.Blockhash, numPartitions)}
+
+		epochRewards := sealevel.SysvarEpochRewards{DistributionStartingBlockHeight: blockHeight + 1, NumPartitions: numPartitions,
+			ParentBlockhash: slotCtx.Blockhash, TotalRewards: voteRewardsPaid + rewards.totalStakeRewards, DistributedRewards: voteRewardsPaid,
+			Active: true}
+		epochRewards.TotalPoints = bin.Uint128{Lo: rewards.pointValue.points.Uint64(), Hi: rewards.pointValue.points.RShiftN(64).Uint64()}
+
+		err = replayCtx.writeEpochRewardsSysvar(slotCtx, &epochRewards)
+		if err != nil {
+			return err
+		}
+	} else {
+		stakeRewardsPaid, err := storeStakeRewards(slotCtx, replayCtx.stakes, rewards.stakeRewards)
+		if err != nil {
+			return err
+		}
+		replayCtx.capitalization += stakeRewardsPaid
+	}
+
+	return writeStakeHistorySysvar(slotCtx, replayCtx.stakes.stakeHistory)
+}
+
+// distributeEpochRewards distributes the partition of stake rewards due in the block at the
+// given block height, if a partitioned epoch rewards distribution is in progress, and ends
+// the distribution once all partitions have been distributed.
+//
+// A distribution that was in progress when the snapshot that replay began from was taken
+// cannot be resumed, since the rewards are not recorded in the snapshot.
+func (replayCtx *ReplayCtx) distributeEpochRewards(slotCtx *sealevel.SlotCtx, blockHeight uint64) error {
+	status := replayCtx.epochRewards
+	if status == nil {
+		epochRewards, err := readEpochRewardsSysvar(slotCtx)
+		if errors.Is(err, errNoEpochRewardsSysvar) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if epochRewards.Active {
+			return fmt.Errorf("%w: distribution began at block height %d", ErrEpochRewardsUnknown, epochRewards.DistributionStartingBlockHeight)
+		}
+		return nil
+	}
+
+	distributionEnd := status.distributionStartingBlockHeight + uint64(len(status.partitions))
+	if blockHeight < status.distributionStartingBlockHeight {
+		return nil
+	}
+
+	epochRewards, err := readEpochRewardsSysvar(slotCtx)
+	if err != nil {
+		return err
+	}
+
+	if blockHeight < distributionEnd {
+		partition := blockHeight - status.distributionStartingBlockHeight
+		distributed, err := storeStakeRewards(slotCtx, replayCtx.stakes, status.partitions[partition])
+		if err != nil {
+			return err
+		}
+
+		klog.Infof("distributed %d lamports of stake rewards in partition %d of %d", distributed, partition, len(status.partitions))
+		replayCtx.capitalization += distributed
+		epochRewards.DistributedRewards += distributed
+	}
+
+	if blockHeight+1 >= distributionEnd {
+		epochRewards.Active = false
+		replayCtx.epochRewards = nil
+	}
+
+	return replayCtx.writeEpochRewardsSysvar(slotCtx, epochRewards)
+}
+
+func readEpochRewardsSysvar(slotCtx *sealevel.SlotCtx) (*sealevel.SysvarEpochRewards, error) {
+	acct, err := loadAccount(slotCtx, sealevel.SysvarEpochRewardsAddr)
+	if errors.Is(err, accountsdb.ErrNoAccount) || (err == nil && acct.Lamports == 0) {
+		return nil, errNoEpochRewardsSysvar
+	} else if err != nil {
+		return nil, err
+	}
+
+	epochRewards := new(sealevel.SysvarEpochRewards)
+	err = epochRewards.UnmarshalWithDecoder(bin.NewBinDecoder(acct.Data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEpochRewards, err)
+	}
+
+	return epochRewards, nil
+}
+
+// writeEpochRewardsSysvar writes the EpochRewards sysvar, creating it if need be, with its
+// balance topped up to be rent exempt, which adds to the capitalization.
+func (replayCtx *ReplayCtx) writeEpochRewardsSysvar(slotCtx *sealevel.SlotCtx, epochRewards *sealevel.SysvarEpochRewards) error {
+	acct, err := loadAccount(slotCtx, sealevel.SysvarEpochRewardsAddr)
+	if errors.Is(err, accountsdb.ErrNoAccount) {
+		acct = &accounts.Account{Key: sealevel.SysvarEpochRewardsAddr, Lamports: 1, Owner: sealevel.SysvarOwnerAddr}
+		replayCtx.capitalization++
+	} else if err != nil {
+		return err
+	}
+
+	data := new(bytes.Buffer)
+	err = epochRewards.MarshalWithEncoder(bin.NewBinEncoder(data))
+	if err != nil {
+		return err
+	}
+
+	if len(acct.Data) < data.Len() {
+		acct.Data = make([]byte, data.Len())
+	}
+	copy(acct.Data, data.Bytes())
+
+	minBalance := replayCtx.Manifest.Bank.RentCollector.Rent.MinimumBalance(uint64(len(acct.Data)))
+	if acct.Lamports < minBalance {
+		replayCtx.capitalization += minBalance - acct.Lamports
+		acct.Lamports = minBalance
+	}
+
+	err = slotCtx.SetAccount(sealevel.SysvarEpochRewardsAddr, acct)
+	if err != nil {
+		return err
+	}
+	slotCtx.ModifiedAccts[sealevel.SysvarEpochRewardsAddr] = true
+	return nil
+}
+
+// writeStakeHistorySysvar writes the stake history into the StakeHistory sysvar, whose
+// account data is sized for the maximum number of entries.
+func writeStakeHistorySysvar(slotCtx *sealevel.SlotCtx, stakeHistory sealevel.SysvarStakeHistory) error {
+	acct, err := slotCtx.GetAccount(sealevel.SysvarStakeHistoryAddr)
+	if err != nil {
+		return err
+	}
+
+	data := new(bytes.Buffer)
+	err = stakeHistory.MarshalWithEncoder(bin.NewBinEncoder(data))
+	if err != nil {
+		return err
+	}
+
+	if len(acct.Data) < data.Len() {
+		acct.Data = make([]byte, data.Len())
+	}
+	copy(acct.Data, data.Bytes())
+
+	err = slotCtx.SetAccount(sealevel.SysvarStakeHistoryAddr, acct)
+	if err != nil {
+		return err
+	}
+	slotCtx.ModifiedAccts[sealevel.SysvarStakeHistoryAddr] = true
+	return nil
+}
@@ -0,0 +1,129 @@
+package replay
+
+import (
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/base58"
+	"k8s.io/klog/v2"
+)
+
+// epochAccountsHash is the epoch accounts hash (EAH) for an epoch: the accounts hash over
+// the full accounts state as of the first slot at or beyond the epoch's EAH start slot.
+// It is calculated in the background, and mixed into the bankhash at the EAH stop slot.
+type epochAccountsHash struct {
+	epoch uint64
+	slot  uint64
+	hash  [32]byte
+	err   error
+	done  chan struct{}
+}
+
+func newCompletedEpochAccountsHash(epoch uint64, slot uint64, hash [32]byte) *epochAccountsHash {
+	eah := &epochAccountsHash{epoch: epoch, slot: slot, hash: hash, done: make(chan struct{})}
+	close(eah.done)
+	return eah
+}
+
+// startEpochAccountsHashCalculation kicks off calculation of the EAH for the given epoch in
+// the background, over the accounts state as of slot, which must be the most recently rooted
+// slot. The index is snapshotted before returning, so that blocks replayed for subsequent
+// slots do not affect the calculation. The calculation is persisted in the AccountsDB, so
+// that it can be restored should replay be resumed from the AccountsDB.
+func (replayCtx *ReplayCtx) startEpochAccountsHashCalculation(epoch uint64, slot uint64) error {
+	err := replayCtx.AccountsDb.SetEpochAccountsHash(&accountsdb.EpochAccountsHashInfo{Epoch: epoch, Slot: slot})
+	if err != nil {
+		return err
+	}
+
+	replayCtx.calculateEpochAccountsHash(epoch, slot)
+	return nil
+}
+
+// calculateEpochAccountsHash calculates the EAH for the given epoch in the background, over
+// the accounts state as of slot, which must not precede the AccountsDB's clean slot. The EAH
+// is persisted in the AccountsDB once calculated.
+func (replayCtx *ReplayCtx) calculateEpochAccountsHash(epoch uint64, slot uint64) {
+	eah := &epochAccountsHash{epoch: epoch, slot: slot, done: make(chan struct{})}
+	replayCtx.eah = eah
+
+	klog.Infof("starting epoch accounts hash calculation for epoch %d at slot %d", epoch, slot)
+
+	acctsDb := replayCtx.AccountsDb
+	snapshot := acctsDb.SnapshotIndex()
+
+	// the calculation remains in flight until the EAH is persisted, such that the accounts
+	// state as of slot is not cleaned whilst the EAH could still need recalculating.
+	go func() {
+		defer close(eah.done)
+		defer snapshot.Release()
+		eah.hash, _, eah.err = calculateAccountsHashFromIndex(snapshot, 0, slot, false)
+		if eah.err != nil {
+			return
+		}
+
+		klog.Infof("epoch accounts hash for epoch %d (slot %d): %s", epoch, slot, base58.Encode(eah.hash[:]))
+		eah.err = acctsDb.SetEpochAccountsHash(&accountsdb.EpochAccountsHashInfo{Epoch: epoch, Slot: slot, Done: true, Hash: eah.hash})
+	}()
+}
+
+// restoreEpochAccountsHash restores the EAH calculation persisted in the AccountsDB, if it was
+// started whilst replaying on top of the snapshot, recalculating the EAH if the calculation
+// had not completed. A calculation started after lastSlot is ignored, since it is started
+// again once that slot is replayed again.
+func (replayCtx *ReplayCtx) restoreEpochAccountsHash(lastSlot uint64) error {
+	info, err := replayCtx.AccountsDb.EpochAccountsHash()
+	if err != nil {
+		return err
+	} else if info == nil || info.Slot <= replayCtx.Manifest.Bank.Slot || info.Slot > lastSlot {
+		return nil
+	}
+
+	if info.Done {
+		replayCtx.eah = newCompletedEpochAccountsHash(info.Epoch, info.Slot, info.Hash)
+		return nil
+	}
+
+	// cleaning is limited to the slot of an in-flight calculation
+	cleanSlot := replayCtx.AccountsDb.CleanSlot()
+	if cleanSlot > info.Slot {
+		return fmt.Errorf("unable to resume epoch accounts hash calculation for epoch %d at slot %d, as AccountsDB has been cleaned up to slot %d",
+			info.Epoch, info.Slot, cleanSlot)
+	}
+
+	klog.Infof("resuming epoch accounts hash calculation for epoch %d", info.Epoch)
+	replayCtx.calculateEpochAccountsHash(info.Epoch, info.Slot)
+
+	return nil
+}
+
+// epochAccountsHash waits for the EAH calculation for the given epoch to complete.
+func (replayCtx *ReplayCtx) epochAccountsHash(epoch uint64) ([32]byte, error) {
+	eah := replayCtx.eah
+	if eah == nil || eah.epoch != epoch {
+		return [32]byte{}, fmt.Errorf("no epoch accounts hash calculated for epoch %d", epoch)
+	}
+
+	<-eah.done
+	if eah.err != nil {
+		return [32]byte{}, fmt.Errorf("epoch accounts hash calculation for epoch %d failed: %w", epoch, eah.err)
+	}
+
+	return eah.hash, nil
+}
+
+// inFlightEpochAccountsHashSlot returns the slot as of which an EAH is currently being
+// calculated, and false if no calculation is in flight.
+func (replayCtx *ReplayCtx) inFlightEpochAccountsHashSlot() (uint64, bool) {
+	eah := replayCtx.eah
+	if eah == nil {
+		return 0, false
+	}
+
+	select {
+	case <-eah.done:
+		return 0, false
+	default:
+		return eah.slot, true
+	}
+}
@@ -0,0 +1,448 @@
+package replay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/ryanavella/wide"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// the number of stake accounts whose rewards are distributed in each block of a partitioned
+// epoch rewards distribution
+const stakeAccountStoresPerBlock = 4096
+
+// the distribution of partitioned epoch rewards spans at most a tenth of an epoch
+const maxFactorOfRewardBlocksInEpoch = 10
+
+func inflationTotal(inflation *snapshot.Inflation, year float64) float64 {
+	tapered := inflation.Initial * math.Pow(1.0-inflation.Taper, year)
+	if tapered > inflation.Terminal {
+		return tapered
+	}
+	return inflation.Terminal
+}
+
+func inflationFoundation(inflation *snapshot.Inflation, year float64) float64 {
+	if year < inflation.FoundationTerm {
+		return inflationTotal(inflation, year) * inflation.Foundation
+	}
+	return 0
+}
+
+func inflationValidator(inflation *snapshot.Inflation, year float64) float64 {
+	return inflationTotal(inflation, year) - inflationFoundation(inflation, year)
+}
+
+// inflationStartSlot returns the slot at which inflation began, being the earliest
+// activation of full inflation, or else the activation of pico inflation.
+func inflationStartSlot(f *features.Features) uint64 {
+	var fullInflationSlots []uint64
+	if f.IsActive(features.FullInflationMainnetCertusoneVote) && f.IsActive(features.FullInflationMainnetCertusoneEnable) {
+		slot, _ := f.ActivationSlot(features.FullInflationMainnetCertusoneEnable)
+		fullInflationSlots = append(fullInflationSlots, slot)
+	}
+	if slot, activated := f.ActivationSlot(features.FullInflationDevnetAndTestnet); activated {
+		fullInflationSlots = append(fullInflationSlots, slot)
+	}
+
+	if len(fullInflationSlots) != 0 {
+		return slices.Min(fullInflationSlots)
+	}
+
+	slot, _ := f.ActivationSlot(features.PicoInflation)
+	return slot
+}
+
+// previousEpochValidatorRewards returns the inflation rewards for the validators and their
+// stakers for the previous epoch, being the validators' share of the year's inflation over
+// the capitalization as of the end of the previous epoch, prorated to the previous epoch.
+func previousEpochValidatorRewards(inflation *snapshot.Inflation, epochSchedule *sealevel.SysvarEpochSchedule, f *features.Features,
+	epoch uint64, prevEpoch uint64, slotsPerYear float64, prevCapitalization uint64) uint64 {
+	// inflation accrues from the start of the epoch prior to its activation
+	inflationStartEpoch := safemath.SaturatingSubU64(epochSchedule.GetEpoch(inflationStartSlot(f)), 1)
+	numSlots := epochSchedule.FirstSlotInEpoch(epoch) - epochSchedule.FirstSlotInEpoch(inflationStartEpoch)
+	slotInYear := float64(numSlots) / slotsPerYear
+
+	validatorRate := inflationValidator(inflation, slotInYear)
+	prevEpochDurationInYears := float64(epochSchedule.SlotsInEpoch(prevEpoch)) / slotsPerYear
+
+	return uint64(validatorRate * float64(prevCapitalization) * prevEpochDurationInYears)
+}
+
+// pointValue is the value of the points earned by stakes over an epoch, with each point
+// worth rewards/points lamports.
+type pointValue struct {
+	rewards uint64
+	points  wide.Uint128
+}
+
+// calculateStakePointsAndCredits returns the points earned by the stake since it last
+// observed the vote account's credits, being its effective stake in each epoch multiplied
+// by the credits earned by the vote account in that epoch, along with the credits observed
+// by the stake thereafter. forceCreditsUpdate is set if the vote account's credits have
+// gone backwards, in which case the credits observed are reset without reward.
+func calculateStakePointsAndCredits(stake *sealevel.Stake, voteState *sealevel.VoteState, stakeHistory sealevel.SysvarStakeHistory,
+	newRateActivationEpoch *uint64) (points wide.Uint128, newCreditsObserved uint64, forceCreditsUpdate bool) {
+	creditsInStake := stake.CreditsObserved
+	creditsInVote := voteState.Credits()
+
+	if creditsInVote < creditsInStake {
+		return wide.Uint128{}, creditsInVote, true
+	} else if creditsInVote == creditsInStake {
+		return wide.Uint128{}, creditsInStake, false
+	}
+
+	newCreditsObserved = creditsInStake
+	for _, epochCredits := range voteState.EpochCredits {
+		stakeAmount := stake.Delegation.Stake(epochCredits.Epoch, stakeHistory, newRateActivationEpoch)
+
+		var earnedCredits uint64
+		if creditsInStake < epochCredits.PrevCredits {
+			// the stake observed the entire epoch
+			earnedCredits = epochCredits.Credits - epochCredits.PrevCredits
+		} else if creditsInStake < epochCredits.Credits {
+			// the stake was delegated or redeemed during the epoch
+			earnedCredits = epochCredits.Credits - newCreditsObserved
+		}
+
+		newCreditsObserved = max(newCreditsObserved, epochCredits.Credits)
+		points = points.Add(wide.Uint128FromUint64(stakeAmount).Mul(wide.Uint128FromUint64(earnedCredits)))
+	}
+
+	return points, newCreditsObserved, false
+}
+
+// commissionSplit splits rewards between the voter and the staker by the vote account's
+// commission, noting whether both were due a share.
+func commissionSplit(commission byte, rewards uint64) (voterRewards uint64, stakerRewards uint64, isSplit bool) {
+	switch min(commission, 100) {
+	case 0:
+		return 0, rewards, false
+	case 100:
+		return rewards, 0, false
+	default:
+		voter := wide.Uint128FromUint64(rewards).Mul(wide.Uint128FromUint64(uint64(commission))).Div(wide.Uint128FromUint64(100))
+		staker := wide.Uint128FromUint64(rewards).Mul(wide.Uint128FromUint64(uint64(100 - commission))).Div(wide.Uint128FromUint64(100))
+		return voter.Uint64(), staker.Uint64(), true
+	}
+}
+
+// calculateStakeRewards returns the rewards due to the staker and the voter for the stake's
+// points earned as of the rewarded epoch, and the stake's new credits observed. It returns
+// false if there are no rewards to redeem, in which case the stake is left as it is.
+func calculateStakeRewards(rewardedEpoch uint64, stake *sealevel.Stake, value *pointValue, voteState *sealevel.VoteState,
+	stakeHistory sealevel.SysvarStakeHistory, newRateActivationEpoch *uint64) (stakerRewards uint64, voterRewards uint64, newCreditsObserved uint64, ok bool, err error) {
+	points, newCreditsObserved, forceCreditsUpdate := calculateStakePointsAndCredits(stake, voteState, stakeHistory, newRateActivationEpoch)
+
+	// credits observed are moved forward without reward when there are no rewards, or for
+	// stake activated in the rewarded epoch
+	if value.rewards == 0 || stake.Delegation.ActivationEpoch == rewardedEpoch {
+		forceCreditsUpdate = true
+	}
+
+	if forceCreditsUpdate {
+		return 0, 0, newCreditsObserved, true, nil
+	}
+
+	if points == (wide.Uint128{}) || value.points == (wide.Uint128{}) {
+		return 0, 0, 0, false, nil
+	}
+
+	product, err := safemath.CheckedMulU128(points, wide.Uint128FromUint64(value.rewards))
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	rewards := product.Div(value.points)
+	if !rewards.IsUint64() {
+		return 0, 0, 0, false, fmt.Errorf("stake rewards of %s overflow", rewards)
+	}
+
+	if rewards.Uint64() == 0 {
+		return 0, 0, 0, false, nil
+	}
+
+	voterRewards, stakerRewards, isSplit := commissionSplit(voteState.Commission, rewards.Uint64())
+
+	// rewards are not redeemed if either side of a split would lose out on a lamport
+	if isSplit && (voterRewards == 0 || stakerRewards == 0) {
+		return 0, 0, 0, false, nil
+	}
+
+	return stakerRewards, voterRewards, newCreditsObserved, true, nil
+}
+
+// stakeReward is the reward due to a stake account, and the account's stake once the reward
+// has been redeemed.
+type stakeReward struct {
+	stakePubkey solana.PublicKey
+	stake       sealevel.Stake
+	lamports    uint64
+}
+
+// epochRewards is the outcome of the calculation of an epoch's rewards: the vote accounts
+// to be stored, along with the commission due to them, and the rewards due to the stake
+// accounts.
+type epochRewards struct {
+	pointValue        pointValue
+	voteRewards       map[solana.PublicKey]uint64
+	stakeRewards      []stakeReward
+	totalStakeRewards uint64
+}
+
+// rewardCandidate is a stake delegated to a valid vote account, which may earn rewards.
+type rewardCandidate struct {
+	stakePubkey solana.PublicKey
+	stake       sealevel.Stake
+	voterPubkey solana.PublicKey
+}
+
+// calculateEpochRewards calculates the rewards of each stake delegated to a vote account in
+// the stakes cache, for the votes credited to the vote accounts up to the rewarded epoch,
+// sharing validatorRewards between them by the points that they earned.
+func calculateEpochRewards(slotCtx *sealevel.SlotCtx, cache *stakesCache, rewardedEpoch uint64, validatorRewards uint64,
+	newRateActivationEpoch *uint64) (*epochRewards, error) {
+	voteStates := make(map[solana.PublicKey]*sealevel.VoteState, len(cache.voteAccounts))
+	for pubkey, voteAcct := range cache.voteAccounts {
+		if voteAcct.account.Owner != sealevel.VoteProgramAddr {
+			continue
+		}
+
+		voteStateVersions, err := sealevel.UnmarshalVersionedVoteState(voteAcct.account.Data)
+		if err != nil {
+			continue
+		}
+		voteStates[pubkey] = voteStateVersions.ConvertToCurrent()
+	}
+
+	var candidates []rewardCandidate
+	totalPoints := wide.Uint128{}
+	for _, stakePubkey := range sortedPubkeys(cache.delegations) {
+		voterPubkey := cache.delegations[stakePubkey].VoterPubkey
+		voteState, exists := voteStates[voterPubkey]
+		if !exists {
+			continue
+		}
+
+		stakeAcct, err := loadAccount(slotCtx, stakePubkey)
+		if errors.Is(err, accountsdb.ErrNoAccount) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to load stake account %s: %w", stakePubkey, err)
+		}
+
+		stakeState, err := sealevel.UnmarshalStakeState(stakeAcct.Data)
+		if err != nil || stakeState.Status != sealevel.StakeStateV2StatusStake {
+			continue
+		}
+		stake := stakeState.Stake.Stake
+
+		points, _, _ := calculateStakePointsAndCredits(&stake, voteState, cache.stakeHistory, newRateActivationEpoch)
+		totalPoints = totalPoints.Add(points)
+
+		candidates = append(candidates, rewardCandidate{stakePubkey: stakePubkey, stake: stake, voterPubkey: voterPubkey})
+	}
+
+	rewards := &epochRewards{voteRewards: make(map[solana.PublicKey]uint64)}
+
+	// no rewards are paid if no points were earned
+	if totalPoints == (wide.Uint128{}) {
+		return rewards, nil
+	}
+	rewards.pointValue = pointValue{rewards: validatorRewards, points: totalPoints}
+
+	for _, candidate := range candidates {
+		stakerRewards, voterRewards, newCreditsObserved, ok, err := calculateStakeRewards(rewardedEpoch, &candidate.stake,
+			&rewards.pointValue, voteStates[candidate.voterPubkey], cache.stakeHistory, newRateActivationEpoch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate rewards for %s: %w", candidate.stakePubkey, err)
+		} else if !ok {
+			continue
+		}
+
+		// vote accounts are stored whenever a stake delegated to them redeems its rewards
+		rewards.voteRewards[candidate.voterPubkey] += voterRewards
+
+		stake := candidate.stake
+		stake.CreditsObserved = newCreditsObserved
+		stake.Delegation.StakeLamports += stakerRewards
+
+		rewards.stakeRewards = append(rewards.stakeRewards, stakeReward{stakePubkey: candidate.stakePubkey, stake: stake, lamports: stakerRewards})
+		rewards.totalStakeRewards += stakerRewards
+	}
+
+	return rewards, nil
+}
+
+// numRewardPartitions returns the number of blocks over which the stake rewards are
+// distributed, such that each distributes at most stakeAccountStoresPerBlock.
+func numRewardPartitions(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint64, numStakeRewards int) uint64 {
+	if epochSchedule.Warmup && epoch < epochSchedule.FirstNormalEpoch {
+		return 1
+	}
+
+	numChunks := (uint64(numStakeRewards) + stakeAccountStoresPerBlock - 1) / stakeAccountStoresPerBlock
+	maxPartitions := max(1, epochSchedule.SlotsPerEpoch/maxFactorOfRewardBlocksInEpoch)
+	return min(max(numChunks, 1), maxPartitions)
+}
+
+func sipRound(v0, v1, v2, v3 uint64) (uint64, uint64, uint64, uint64) {
+	v0 += v1
+	v1 = bits.RotateLeft64(v1, 13)
+	v1 ^= v0
+	v0 = bits.RotateLeft64(v0, 32)
+	v2 += v3
+	v3 = bits.RotateLeft64(v3, 16)
+	v3 ^= v2
+	v0 += v3
+	v3 = bits.RotateLeft64(v3, 21)
+	v3 ^= v0
+	v2 += v1
+	v1 = bits.RotateLeft64(v1, 17)
+	v1 ^= v2
+	v2 = bits.RotateLeft64(v2, 32)
+	return v0, v1, v2, v3
+}
+
+// sipHash returns the SipHash-c-d of msg under the key (k0, k1).
+func sipHash(c int, d int, k0 uint64, k1 uint64, msg []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	compress := func(m uint64) {
+		v3 ^= m
+		for round := 0; round < c; round++ {
+			v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+		}
+		v0 ^= m
+	}
+
+	tailLen := len(msg) % 8
+	for idx := 0; idx < len(msg)-tailLen; idx += 8 {
+		compress(binary.LittleEndian.Uint64(msg[idx:]))
+	}
+
+	var tail [8]byte
+	copy(tail[:], msg[len(msg)-tailLen:])
+	compress(binary.LittleEndian.Uint64(tail[:]) | uint64(len(msg))<<56)
+
+	v2 ^= 0xff
+	for round := 0; round < d; round++ {
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	}
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// rewardPartition returns the partition in which the stake account's rewards are
+// distributed, by SipHash-1-3 of the stake account's pubkey seeded with the parent
+// blockhash of the first block of the epoch.
+func rewardPartition(parentBlockhash [32]byte, stakePubkey solana.PublicKey, numPartitions uint64) uint64 {
+	hash := sipHash(1, 3, 0, 0, append(parentBlockhash[:], stakePubkey[:]...))
+	partition, _ := bits.Mul64(numPartitions, hash)
+	return partition
+}
+
+func hashRewardsIntoPartitions(stakeRewards []stakeReward, parentBlockhash [32]byte, numPartitions uint64) [][]stakeReward {
+	partitions := make([][]stakeReward, numPartitions)
+	for _, reward := range stakeRewards {
+		partition := rewardPartition(parentBlockhash, reward.stakePubkey, numPartitions)
+		partitions[partition] = append(partitions[partition], reward)
+	}
+	return partitions
+}
+
+// storeVoteRewards pays the commission due to each vote account, returning the total paid.
+func storeVoteRewards(slotCtx *sealevel.SlotCtx, voteRewards map[solana.PublicKey]uint64) (uint64, error) {
+	var totalPaid uint64
+	for _, pubkey := range sortedPubkeys(voteRewards) {
+		acct, err := loadAccount(slotCtx, pubkey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load vote account %s: %w", pubkey, err)
+		}
+
+		acct.Lamports, err = safemath.CheckedAddU64(acct.Lamports, voteRewards[pubkey])
+		if err != nil {
+			continue
+		}
+
+		err = slotCtx.SetAccount(pubkey, acct)
+		if err != nil {
+			return 0, err
+		}
+		slotCtx.ModifiedAccts[pubkey] = true
+		totalPaid += voteRewards[pubkey]
+	}
+
+	return totalPaid, nil
+}
+
+// storeStakeRewards pays the rewards due to the stake accounts, and updates their stake,
+// returning the total paid. Rewards are not paid to stake accounts that are no longer
+// delegated as of the parent slot.
+func storeStakeRewards(slotCtx *sealevel.SlotCtx, cache *stakesCache, stakeRewards []stakeReward) (uint64, error) {
+	var totalPaid uint64
+	for _, reward := range stakeRewards {
+		if _, delegated := cache.delegations[reward.stakePubkey]; !delegated {
+			continue
+		}
+
+		acct, err := loadAccount(slotCtx, reward.stakePubkey)
+		if errors.Is(err, accountsdb.ErrNoAccount) {
+			continue
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to load stake account %s: %w", reward.stakePubkey, err)
+		}
+
+		stakeState, err := sealevel.UnmarshalStakeState(acct.Data)
+		if err != nil || stakeState.Status != sealevel.StakeStateV2StatusStake {
+			continue
+		}
+
+		lamports, err := safemath.CheckedAddU64(acct.Lamports, reward.lamports)
+		if err != nil {
+			continue
+		}
+
+		stakeState.Stake.Stake = reward.stake
+		stakeStateBytes, err := sealevel.MarshalStakeState(stakeState)
+		if err != nil || len(stakeStateBytes) > len(acct.Data) {
+			continue
+		}
+
+		acct.Lamports = lamports
+		copy(acct.Data, stakeStateBytes)
+
+		err = slotCtx.SetAccount(reward.stakePubkey, acct)
+		if err != nil {
+			return 0, err
+		}
+		slotCtx.ModifiedAccts[reward.stakePubkey] = true
+		totalPaid += reward.lamports
+	}
+
+	return totalPaid, nil
+}
+
+// loadAccount returns the account as of the slot's state thus far, which is that of the
+// parent slot for accounts not loaded for the block.
+func loadAccount(slotCtx *sealevel.SlotCtx, pubkey solana.PublicKey) (*accounts.Account, error) {
+	acct, err := slotCtx.GetAccount(pubkey)
+	if err == nil {
+		return acct, nil
+	}
+	return slotCtx.GetAccountFromAccountsDb(pubkey)
+}
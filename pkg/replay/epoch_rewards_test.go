@@ -0,0 +1,251 @@
+package replay
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/ryanavella/wide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb/accountsdbtest"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func TestSipHash(t *testing.T) {
+	// SipHash-2-4 reference test vectors, keyed with 00..0f over messages 00..(n-1)
+	var k0, k1 uint64 = 0x0706050403020100, 0x0f0e0d0c0b0a0908
+	msg := make([]byte, 15)
+	for idx := range msg {
+		msg[idx] = byte(idx)
+	}
+
+	assert.Equal(t, uint64(0x726fdb47dd0e0e31), sipHash(2, 4, k0, k1, msg[:0]))
+	assert.Equal(t, uint64(0x93f5f5799a932462), sipHash(2, 4, k0, k1, msg[:8]))
+	assert.Equal(t, uint64(0xa129ca6149be45e5), sipHash(2, 4, k0, k1, msg[:15]))
+}
+
+func TestRewardPartition(t *testing.T) {
+	parentBlockhash := [32]byte{1, 2, 3}
+
+	counts := make([]int, 10)
+	for idx := 0; idx < 1000; idx++ {
+		stakePubkey := solana.NewWallet().PublicKey()
+		partition := rewardPartition(parentBlockhash, stakePubkey, 10)
+		require.Less(t, partition, uint64(10))
+		assert.Equal(t, partition, rewardPartition(parentBlockhash, stakePubkey, 10))
+		assert.Zero(t, rewardPartition(parentBlockhash, stakePubkey, 1))
+		counts[partition]++
+	}
+
+	// stake accounts are spread evenly across the partitions
+	for _, count := range counts {
+		assert.Greater(t, count, 50)
+	}
+}
+
+func TestNumRewardPartitions(t *testing.T) {
+	epochSchedule := &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000}
+	assert.Equal(t, uint64(1), numRewardPartitions(epochSchedule, 100, 0))
+	assert.Equal(t, uint64(1), numRewardPartitions(epochSchedule, 100, stakeAccountStoresPerBlock))
+	assert.Equal(t, uint64(2), numRewardPartitions(epochSchedule, 100, stakeAccountStoresPerBlock+1))
+
+	// the distribution spans at most a tenth of the epoch
+	epochSchedule = &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32}
+	assert.Equal(t, uint64(3), numRewardPartitions(epochSchedule, 100, 100*stakeAccountStoresPerBlock))
+
+	// and happens in a single block during warmup
+	epochSchedule = &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000, Warmup: true, FirstNormalEpoch: 14}
+	assert.Equal(t, uint64(1), numRewardPartitions(epochSchedule, 13, 100*stakeAccountStoresPerBlock))
+}
+
+func TestPreviousEpochValidatorRewards(t *testing.T) {
+	// each epoch is half a year, so inflation is long since tapered to the terminal rate
+	epochSchedule := &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000}
+	inflation := &snapshot.Inflation{Initial: 0.08, Terminal: 0.015, Taper: 0.15}
+	f := features.NewFeaturesDefault()
+
+	rewards := previousEpochValidatorRewards(inflation, epochSchedule, f, 40, 39, 864000, 1000000000)
+	assert.InDelta(t, 7500000, rewards, 1)
+
+	// the foundation's share is taken out of inflation during the foundation term
+	inflation.Foundation = 0.05
+	inflation.FoundationTerm = 100
+	rewards = previousEpochValidatorRewards(inflation, epochSchedule, f, 40, 39, 864000, 1000000000)
+	assert.InDelta(t, 7125000, rewards, 1)
+
+	// inflation dates from the epoch prior to its activation, so the first epoch is at the
+	// initial rate
+	inflation = &snapshot.Inflation{Initial: 0.08, Terminal: 0.015, Taper: 0.15}
+	f.EnableFeature(features.FullInflationDevnetAndTestnet, 40*432000)
+	rewards = previousEpochValidatorRewards(inflation, epochSchedule, f, 40, 39, 864000, 1000000000)
+	assert.InDelta(t, 0.08*math.Pow(0.85, 0.5)*1000000000*0.5, rewards, 1)
+}
+
+func newTestVoteState(commission byte, epochCredits []sealevel.EpochCredits) *sealevel.VoteState {
+	return &sealevel.VoteState{Commission: commission, EpochCredits: epochCredits}
+}
+
+func TestCalculateStakePointsAndCredits(t *testing.T) {
+	voteState := newTestVoteState(10, []sealevel.EpochCredits{{Epoch: 1, Credits: 100, PrevCredits: 0}, {Epoch: 2, Credits: 250, PrevCredits: 100}})
+	stake := &sealevel.Stake{Delegation: sealevel.Delegation{StakeLamports: 1000, DeactivationEpoch: math.MaxUint64}}
+
+	for _, test := range []struct {
+		creditsObserved    uint64
+		points             uint64
+		newCreditsObserved uint64
+		forceCreditsUpdate bool
+	}{
+		{0, 1000 * 250, 250, false},
+		{150, 1000 * 100, 250, false},
+		{250, 0, 250, false},
+		{300, 0, 250, true},
+	} {
+		stake.CreditsObserved = test.creditsObserved
+		points, newCreditsObserved, forceCreditsUpdate := calculateStakePointsAndCredits(stake, voteState, nil, nil)
+		assert.Equal(t, wide.Uint128FromUint64(test.points), points, "credits observed %d", test.creditsObserved)
+		assert.Equal(t, test.newCreditsObserved, newCreditsObserved, "credits observed %d", test.creditsObserved)
+		assert.Equal(t, test.forceCreditsUpdate, forceCreditsUpdate, "credits observed %d", test.creditsObserved)
+	}
+}
+
+func TestCommissionSplit(t *testing.T) {
+	for _, test := range []struct {
+		commission byte
+		rewards    uint64
+		voter      uint64
+		staker     uint64
+		isSplit    bool
+	}{
+		{0, 1000, 0, 1000, false},
+		{100, 1000, 1000, 0, false},
+		{200, 1000, 1000, 0, false},
+		{10, 1000, 100, 900, true},
+		{1, 50, 0, 49, true},
+	} {
+		voter, staker, isSplit := commissionSplit(test.commission, test.rewards)
+		assert.Equal(t, test.voter, voter, "commission %d", test.commission)
+		assert.Equal(t, test.staker, staker, "commission %d", test.commission)
+		assert.Equal(t, test.isSplit, isSplit, "commission %d", test.commission)
+	}
+}
+
+func TestCalculateStakeRewards(t *testing.T) {
+	voteState := newTestVoteState(10, []sealevel.EpochCredits{{Epoch: 1, Credits: 100, PrevCredits: 0}, {Epoch: 2, Credits: 250, PrevCredits: 100}})
+	stake := &sealevel.Stake{Delegation: sealevel.Delegation{StakeLamports: 1000, DeactivationEpoch: math.MaxUint64}}
+	value := &pointValue{rewards: 1000, points: wide.Uint128FromUint64(500000)}
+
+	stakerRewards, voterRewards, newCreditsObserved, ok, err := calculateStakeRewards(2, stake, value, voteState, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(450), stakerRewards)
+	assert.Equal(t, uint64(50), voterRewards)
+	assert.Equal(t, uint64(250), newCreditsObserved)
+
+	// stake activated in the rewarded epoch only has its credits observed moved forward
+	stake.Delegation.ActivationEpoch = 2
+	stakerRewards, voterRewards, newCreditsObserved, ok, err = calculateStakeRewards(2, stake, value, voteState, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, stakerRewards)
+	assert.Zero(t, voterRewards)
+	assert.Equal(t, uint64(250), newCreditsObserved)
+
+	// rewards aren't redeemed if a split would leave either side without a lamport
+	stake.Delegation.ActivationEpoch = 0
+	value = &pointValue{rewards: 9, points: wide.Uint128FromUint64(500000)}
+	_, _, _, ok, err = calculateStakeRewards(2, stake, value, voteState, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPartitionedEpochRewards(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(0))
+
+	votePubkey := solana.NewWallet().PublicKey()
+	stakePubkey := solana.NewWallet().PublicKey()
+	voteAcct := newTestVoteAccount(t, votePubkey, solana.NewWallet().PublicKey(), 10, []sealevel.EpochCredits{{Epoch: 0, Credits: 100}})
+	stakeAcct := newTestStakeAccount(t, stakePubkey, votePubkey, 1000000000, math.MaxUint64, 0)
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{voteAcct, stakeAcct}, 0))
+
+	cache := newStakesCache(&snapshot.Stakes{})
+	cache.storeAccount(voteAcct, nil)
+	cache.storeAccount(stakeAcct, nil)
+
+	manifest := &snapshot.SnapshotManifest{}
+	manifest.Bank.Inflation = snapshot.Inflation{Initial: 0.08, Terminal: 0.015, Taper: 0.15}
+	manifest.Bank.SlotsPerYear = 3200
+	manifest.Bank.RentCollector.Rent = sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2}
+	replayCtx := &ReplayCtx{AccountsDb: acctsDb, Manifest: manifest, stakes: cache, capitalization: 1000000000000}
+
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.EnablePartitionedEpochReward, 0)
+	slotCtx := &sealevel.SlotCtx{Slot: 32, ParentSlot: 0, Epoch: 1, Accounts: accounts.NewMemAccounts(), AccountsDb: acctsDb, Features: f,
+		ModifiedAccts: make(map[solana.PublicKey]bool), Blockhash: [32]byte{1}}
+	require.NoError(t, slotCtx.SetAccount(sealevel.SysvarStakeHistoryAddr,
+		&accounts.Account{Key: sealevel.SysvarStakeHistoryAddr, Lamports: 1, Data: make([]byte, 16392), Owner: sealevel.SysvarOwnerAddr}))
+
+	epochSchedule := &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 32, LeaderScheduleSlotOffset: 32}
+	validatorRewards := previousEpochValidatorRewards(&manifest.Bank.Inflation, epochSchedule, f, 1, 0, 3200, 1000000000000)
+
+	// the epoch boundary cannot be crossed without the parent slot's blockhash
+	slotCtx.Blockhash = [32]byte{}
+	require.ErrorIs(t, replayCtx.processNewEpoch(slotCtx, epochSchedule, 0, 100), ErrParentBlockhashUnknown)
+	slotCtx.Blockhash = [32]byte{1}
+
+	require.NoError(t, replayCtx.processNewEpoch(slotCtx, epochSchedule, 0, 100))
+
+	assert.Equal(t, uint64(1), cache.epoch)
+	assert.Equal(t, uint64(0), cache.stakeHistory[0].Epoch)
+	require.Len(t, replayCtx.epochStakes, 1)
+	assert.Equal(t, uint64(2), replayCtx.epochStakes[0].Key)
+
+	// the vote account's commission is paid in the first block of the epoch
+	// with the commission split rounded down on both sides
+	voteRewards, stakeRewards := validatorRewards*10/100, validatorRewards*90/100
+	acct, err := slotCtx.GetAccount(votePubkey)
+	require.NoError(t, err)
+	assert.Equal(t, voteAcct.Lamports+voteRewards, acct.Lamports)
+
+	epochRewards, err := readEpochRewardsSysvar(slotCtx)
+	require.NoError(t, err)
+	assert.Equal(t, sealevel.SysvarEpochRewards{DistributionStartingBlockHeight: 101, NumPartitions: 1, ParentBlockhash: [32]byte{1},
+		TotalPoints: epochRewards.TotalPoints, TotalRewards: voteRewards + stakeRewards, DistributedRewards: voteRewards, Active: true}, *epochRewards)
+	assert.Equal(t, uint64(1000000000*100), epochRewards.TotalPoints.Lo)
+	assert.Equal(t, map[solana.PublicKey]bool{votePubkey: true, sealevel.SysvarEpochRewardsAddr: true, sealevel.SysvarStakeHistoryAddr: true},
+		slotCtx.ModifiedAccts)
+
+	// and the stake rewards in the following block
+	require.NoError(t, replayCtx.distributeEpochRewards(slotCtx, 100))
+	acct, err = slotCtx.GetAccount(stakePubkey)
+	require.Error(t, err)
+
+	require.NoError(t, replayCtx.distributeEpochRewards(slotCtx, 101))
+	acct, err = slotCtx.GetAccount(stakePubkey)
+	require.NoError(t, err)
+	assert.Equal(t, stakeAcct.Lamports+stakeRewards, acct.Lamports)
+
+	stakeState, err := sealevel.UnmarshalStakeState(acct.Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), stakeState.Stake.Stake.CreditsObserved)
+	assert.Equal(t, 1000000000+stakeRewards, stakeState.Stake.Stake.Delegation.StakeLamports)
+
+	epochRewards, err = readEpochRewardsSysvar(slotCtx)
+	require.NoError(t, err)
+	assert.False(t, epochRewards.Active)
+	assert.Equal(t, voteRewards+stakeRewards, epochRewards.DistributedRewards)
+	assert.Nil(t, replayCtx.epochRewards)
+
+	epochRewardsAcct, err := slotCtx.GetAccount(sealevel.SysvarEpochRewardsAddr)
+	require.NoError(t, err)
+	assert.Equal(t, 1000000000000+voteRewards+stakeRewards+epochRewardsAcct.Lamports, replayCtx.capitalization)
+
+	// a distribution in progress that replay doesn't know of can't be resumed
+	epochRewards.Active = true
+	require.NoError(t, replayCtx.writeEpochRewardsSysvar(slotCtx, epochRewards))
+	require.ErrorIs(t, replayCtx.distributeEpochRewards(slotCtx, 102), ErrEpochRewardsUnknown)
+}
@@ -11,7 +11,6 @@ import (
 	"go.firedancer.io/radiance/pkg/accounts"
 	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sealevel"
-	"k8s.io/klog/v2"
 )
 
 type acctHash struct {
@@ -104,6 +103,62 @@ func computeMerkleRootLoop(acctHashes [][]byte) []byte {
 	}
 }
 
+// merkleTree computes the same root as computeMerkleRootLoop over hashes added one at a
+// time, holding at most merkleFanout pending hashes per level of the tree.
+type merkleTree struct {
+	levels [][][32]byte
+	counts []uint64
+}
+
+func (tree *merkleTree) add(hash [32]byte) {
+	tree.addAtLevel(0, hash)
+}
+
+func (tree *merkleTree) addAtLevel(level int, hash [32]byte) {
+	if level == len(tree.levels) {
+		tree.levels = append(tree.levels, make([][32]byte, 0, merkleFanout))
+		tree.counts = append(tree.counts, 0)
+	}
+
+	tree.levels[level] = append(tree.levels[level], hash)
+	tree.counts[level]++
+
+	if len(tree.levels[level]) == merkleFanout {
+		tree.addAtLevel(level+1, hashMerkleChunk(tree.levels[level]))
+		tree.levels[level] = tree.levels[level][:0]
+	}
+}
+
+// root hashes the partially filled chunks at each level into the level above, until
+// reaching the level holding a single hash. A tree with no hashes has a nil root.
+func (tree *merkleTree) root() []byte {
+	if len(tree.levels) == 0 {
+		return nil
+	}
+
+	for level := 0; ; level++ {
+		if level > 0 && tree.counts[level] == 1 {
+			return tree.levels[level][0][:]
+		}
+
+		if len(tree.levels[level]) != 0 {
+			tree.addAtLevel(level+1, hashMerkleChunk(tree.levels[level]))
+			tree.levels[level] = tree.levels[level][:0]
+		}
+	}
+}
+
+func hashMerkleChunk(chunk [][32]byte) [32]byte {
+	hasher := sha256.New()
+	for idx := range chunk {
+		hasher.Write(chunk[idx][:])
+	}
+
+	var hash [32]byte
+	hasher.Sum(hash[:0])
+	return hash
+}
+
 func pubkeyCmp(a solana.PublicKey, b solana.PublicKey) bool {
 	for i := uint64(0); i < 4; i++ {
 		a1 := binary.BigEndian.Uint64(a[8*i:])
@@ -145,20 +200,37 @@ func isEnabledThisEpoch(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint6
 	return calculationInterval >= minimumCalculationInterval
 }
 
-func shouldIncludeEah(epochSchedule *sealevel.SysvarEpochSchedule, slotCtx *sealevel.SlotCtx) bool {
+func eahStartSlot(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint64) uint64 {
+	calculationOffsetStart := epochSchedule.SlotsInEpoch(epoch) / 4
+	return safemath.SaturatingAddU64(epochSchedule.FirstSlotInEpoch(epoch), calculationOffsetStart)
+}
+
+func eahStopSlot(epochSchedule *sealevel.SysvarEpochSchedule, epoch uint64) uint64 {
+	calculationOffsetStop := (epochSchedule.SlotsInEpoch(epoch) / 4) * 3
+	return safemath.SaturatingAddU64(epochSchedule.FirstSlotInEpoch(epoch), calculationOffsetStop)
+}
+
+// shouldStartEahCalculation returns true if the slot is the first slot at or beyond the
+// EAH start slot, in which case the EAH is calculated over the accounts state as of this slot.
+func shouldStartEahCalculation(epochSchedule *sealevel.SysvarEpochSchedule, slotCtx *sealevel.SlotCtx) bool {
 	if !isEnabledThisEpoch(epochSchedule, slotCtx.Epoch) {
 		return false
 	}
 
-	slotsPerEpoch := epochSchedule.SlotsInEpoch(slotCtx.Epoch)
-	calculationOffsetStop := (slotsPerEpoch / 4) * 3
-	firstSlotInEpoch := epochSchedule.FirstSlotInEpoch(slotCtx.Epoch)
-	stopSlot := safemath.SaturatingAddU64(firstSlotInEpoch, calculationOffsetStop)
+	startSlot := eahStartSlot(epochSchedule, slotCtx.Epoch)
+	return slotCtx.ParentSlot < startSlot && slotCtx.Slot >= startSlot
+}
 
+func shouldIncludeEah(epochSchedule *sealevel.SysvarEpochSchedule, slotCtx *sealevel.SlotCtx) bool {
+	if !isEnabledThisEpoch(epochSchedule, slotCtx.Epoch) {
+		return false
+	}
+
+	stopSlot := eahStopSlot(epochSchedule, slotCtx.Epoch)
 	return slotCtx.ParentSlot < stopSlot && slotCtx.Slot >= stopSlot
 }
 
-func calculateBankHash(slotCtx *sealevel.SlotCtx, acctsDeltaHash []byte, parentBankHash [32]byte, numSigs uint64, blockHash [32]byte) []byte {
+func calculateBankHash(acctsDeltaHash []byte, parentBankHash [32]byte, numSigs uint64, blockHash [32]byte) []byte {
 	hasher := sha256.New()
 	hasher.Write(parentBankHash[:])
 	hasher.Write(acctsDeltaHash[:])
@@ -169,28 +241,28 @@ func calculateBankHash(slotCtx *sealevel.SlotCtx, acctsDeltaHash []byte, parentB
 	hasher.Write(numSigsBytes[:])
 	hasher.Write(blockHash[:])
 
-	maybeBankhash := hasher.Sum(nil)
+	return hasher.Sum(nil)
+}
+
+func mixInEpochAccountsHash(bankHash []byte, eah [32]byte) []byte {
+	hasher := sha256.New()
+	hasher.Write(bankHash)
+	hasher.Write(eah[:])
+	return hasher.Sum(nil)
+}
 
-	epochScheduleAcct, err := slotCtx.Accounts.GetAccount(&sealevel.SysvarEpochScheduleAddr)
+func readEpochScheduleSysvar(accts accounts.Accounts) (*sealevel.SysvarEpochSchedule, error) {
+	epochScheduleAcct, err := accts.GetAccount(&sealevel.SysvarEpochScheduleAddr)
 	if err != nil {
-		panic("unable to get epochschedule sysvar acct")
+		return nil, err
 	}
 
 	dec := bin.NewBinDecoder(epochScheduleAcct.Data)
-	var epochSchedule sealevel.SysvarEpochSchedule
+	epochSchedule := new(sealevel.SysvarEpochSchedule)
 	err = epochSchedule.UnmarshalWithDecoder(dec)
 	if err != nil {
-		panic("unable to deserialize epochschedule sysvar")
-	}
-
-	var bankHash []byte
-	if shouldIncludeEah(&epochSchedule, slotCtx) {
-		klog.Infof("**** EAH required for this bankhash")
-		bankHash = maybeBankhash
-	} else {
-		klog.Infof("**** EAH *NOT* required for this bankhash")
-		bankHash = maybeBankhash
+		return nil, err
 	}
 
-	return bankHash
+	return epochSchedule, nil
 }
@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// durableNonceAccount returns the index of the tx's nonce account if it is a durable nonce
+// tx, being one of which the first instruction advances a writable nonce account. Such a tx
+// may use the nonce account's durable nonce as its recent blockhash in place of a recent
+// blockhash.
+func durableNonceAccount(tx *solana.Transaction) (uint64, bool) {
+	if len(tx.Message.Instructions) == 0 {
+		return 0, false
+	}
+	instr := tx.Message.Instructions[0]
+
+	programId, err := tx.ResolveProgramIDIndex(instr.ProgramIDIndex)
+	if err != nil || programId != sealevel.SystemProgramAddr {
+		return 0, false
+	}
+
+	if len(instr.Data) < 4 || binary.LittleEndian.Uint32(instr.Data) != sealevel.SystemProgramInstrTypeAdvanceNonceAccount {
+		return 0, false
+	}
+
+	if len(instr.Accounts) == 0 || int(instr.Accounts[0]) >= len(tx.Message.AccountKeys) {
+		return 0, false
+	}
+
+	nonceIdx := uint64(instr.Accounts[0])
+	isWritable, err := tx.Message.IsWritable(tx.Message.AccountKeys[nonceIdx])
+	if err != nil || !isWritable {
+		return 0, false
+	}
+
+	return nonceIdx, true
+}
+
+// isAdvanceableNonceTx returns whether the tx is a durable nonce tx of which the nonce could
+// be advanced in a slot of which the most recent blockhash is blockhash, i.e. whether it was
+// not already advanced to that blockhash's durable nonce.
+func isAdvanceableNonceTx(tx *solana.Transaction, blockhash [32]byte) bool {
+	_, isNonceTx := durableNonceAccount(tx)
+	return isNonceTx && [32]byte(tx.Message.RecentBlockhash) != sealevel.DurableNonceFromBlockhash(blockhash)
+}
+
+// loadNonceAccount validates the nonce account of a durable nonce tx, which must be an
+// initialized nonce account owned by the system program, of which the durable nonce is the
+// tx's recent blockhash and the authority a signer of the tx's first instruction. It returns
+// the index of the nonce account amongst the tx's accounts, and the account as it is to be
+// committed should the tx fail, with its nonce advanced to the slot's durable nonce.
+func loadNonceAccount(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txAccts *sealevel.TransactionAccounts) (uint64, *accounts.Account, bool) {
+	nonceIdx, isNonceTx := durableNonceAccount(tx)
+	if !isNonceTx || nonceIdx >= uint64(len(txAccts.Accounts)) {
+		return 0, nil, false
+	}
+
+	nonceAcct := txAccts.Accounts[nonceIdx]
+	if nonceAcct.Owner != sealevel.SystemProgramAddr {
+		return 0, nil, false
+	}
+
+	nonceStateVersions, err := sealevel.UnmarshalNonceStateVersions(nonceAcct.Data)
+	if err != nil || nonceStateVersions.Type != sealevel.NonceVersionCurrent {
+		return 0, nil, false
+	}
+
+	nonceData := nonceStateVersions.Current
+	if !nonceData.IsInitialized || nonceData.DurableNonce != [32]byte(tx.Message.RecentBlockhash) {
+		return 0, nil, false
+	}
+
+	var isAuthorized bool
+	for _, acctIdx := range tx.Message.Instructions[0].Accounts {
+		if int(acctIdx) < len(tx.Message.AccountKeys) && tx.Message.AccountKeys[acctIdx] == nonceData.Authority {
+			isAuthorized = tx.Message.IsSigner(nonceData.Authority)
+			break
+		}
+	}
+	if !isAuthorized {
+		return 0, nil, false
+	}
+
+	nonceData.DurableNonce = sealevel.DurableNonceFromBlockhash(slotCtx.Blockhash)
+	nonceData.FeeCalculator.LamportsPerSignature = slotCtx.LamportsPerSignature
+	advancedState, err := (&sealevel.NonceStateVersions{Type: sealevel.NonceVersionCurrent, Current: nonceData}).Marshal()
+	if err != nil || len(advancedState) > len(nonceAcct.Data) {
+		return 0, nil, false
+	}
+
+	advancedAcct := *nonceAcct
+	advancedAcct.Data = append([]byte{}, nonceAcct.Data...)
+	copy(advancedAcct.Data, advancedState)
+
+	return nonceIdx, &advancedAcct, true
+}
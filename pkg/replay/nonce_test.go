@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func newTestNonceTx(t *testing.T, nonceAddr solana.PublicKey, authority solana.PublicKey, durableNonce [32]byte) *solana.Transaction {
+	advanceNonce := solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{solana.Meta(nonceAddr).WRITE(),
+		solana.Meta(solana.SysVarRecentBlockHashesPubkey), solana.Meta(authority).SIGNER()}, []byte{4, 0, 0, 0})
+
+	tx, err := solana.NewTransaction([]solana.Instruction{advanceNonce}, durableNonce, solana.TransactionPayer(authority))
+	require.NoError(t, err)
+	return tx
+}
+
+func newTestNonceAcct(t *testing.T, nonceAddr solana.PublicKey, authority solana.PublicKey, durableNonce [32]byte) accounts.Account {
+	nonceState := &sealevel.NonceStateVersions{Type: sealevel.NonceVersionCurrent, Current: sealevel.NonceData{IsInitialized: true,
+		Authority: authority, DurableNonce: durableNonce, FeeCalculator: sealevel.FeeCalculator{LamportsPerSignature: 5000}}}
+	data, err := nonceState.Marshal()
+	require.NoError(t, err)
+
+	return accounts.Account{Key: nonceAddr, Lamports: 1500000, Owner: sealevel.SystemProgramAddr, Data: data}
+}
+
+func TestDurableNonceAccount(t *testing.T) {
+	nonceAddr, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	tx := newTestNonceTx(t, nonceAddr, authority, [32]byte{1})
+	nonceIdx, isNonceTx := durableNonceAccount(tx)
+	require.True(t, isNonceTx)
+	assert.Equal(t, nonceAddr, tx.Message.AccountKeys[nonceIdx])
+
+	_, isNonceTx = durableNonceAccount(newTestSignedTx(t))
+	assert.False(t, isNonceTx)
+
+	// the nonce account must be writable
+	tx.Message.Header.NumReadonlyUnsignedAccounts = uint8(len(tx.Message.AccountKeys) - 1)
+	_, isNonceTx = durableNonceAccount(tx)
+	assert.False(t, isNonceTx)
+
+	// already advanced to the durable nonce of the slot's blockhash
+	tx = newTestNonceTx(t, nonceAddr, authority, sealevel.DurableNonceFromBlockhash([32]byte{2}))
+	assert.True(t, isAdvanceableNonceTx(tx, [32]byte{3}))
+	assert.False(t, isAdvanceableNonceTx(tx, [32]byte{2}))
+}
+
+func TestLoadNonceAccount(t *testing.T) {
+	nonceAddr, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	durableNonce := sealevel.DurableNonceFromBlockhash([32]byte{1})
+	slotCtx := &sealevel.SlotCtx{Blockhash: [32]byte{2}, LamportsPerSignature: 10000}
+
+	txAccts := func(tx *solana.Transaction, nonceAcct accounts.Account) *sealevel.TransactionAccounts {
+		accts := make([]accounts.Account, len(tx.Message.AccountKeys))
+		for idx, pubkey := range tx.Message.AccountKeys {
+			accts[idx] = accounts.Account{Key: pubkey, Owner: sealevel.SystemProgramAddr}
+			if pubkey == nonceAddr {
+				accts[idx] = nonceAcct
+			}
+		}
+		return sealevel.NewTransactionAccounts(accts)
+	}
+
+	tx := newTestNonceTx(t, nonceAddr, authority, durableNonce)
+	nonceIdx, advancedAcct, ok := loadNonceAccount(slotCtx, tx, txAccts(tx, newTestNonceAcct(t, nonceAddr, authority, durableNonce)))
+	require.True(t, ok)
+	assert.Equal(t, nonceAddr, tx.Message.AccountKeys[nonceIdx])
+
+	advancedState, err := sealevel.UnmarshalNonceStateVersions(advancedAcct.Data)
+	require.NoError(t, err)
+	assert.Equal(t, sealevel.DurableNonceFromBlockhash([32]byte{2}), advancedState.Current.DurableNonce)
+	assert.Equal(t, uint64(10000), advancedState.Current.FeeCalculator.LamportsPerSignature)
+	assert.Equal(t, authority, advancedState.Current.Authority)
+	assert.Equal(t, uint64(1500000), advancedAcct.Lamports)
+
+	// the durable nonce must match the tx's recent blockhash
+	_, _, ok = loadNonceAccount(slotCtx, tx, txAccts(tx, newTestNonceAcct(t, nonceAddr, authority, [32]byte{3})))
+	assert.False(t, ok)
+
+	// the nonce authority must sign the tx
+	_, _, ok = loadNonceAccount(slotCtx, tx, txAccts(tx, newTestNonceAcct(t, nonceAddr, solana.NewWallet().PublicKey(), durableNonce)))
+	assert.False(t, ok)
+
+	// the nonce account must be owned by the system program
+	nonceAcct := newTestNonceAcct(t, nonceAddr, authority, durableNonce)
+	nonceAcct.Owner = sealevel.StakeProgramAddr
+	_, _, ok = loadNonceAccount(slotCtx, tx, txAccts(tx, nonceAcct))
+	assert.False(t, ok)
+}
+
+func TestCheckTransactions_DurableNonce(t *testing.T) {
+	queue := newBlockhashQueue(&snapshot.BlockHashVec{MaxAge: 300})
+	queue.registerHash([32]byte{1}, 5000)
+	replayCtx := &ReplayCtx{blockhashQueue: queue, statusCache: newStatusCache()}
+
+	nonceAddr, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	nonceTx := newTestNonceTx(t, nonceAddr, authority, [32]byte{7})
+	advancedTx := newTestNonceTx(t, nonceAddr, authority, sealevel.DurableNonceFromBlockhash([32]byte{1}))
+
+	txErrs := make([]*sealevel.TransactionError, 2)
+	_, nonceTxs := replayCtx.checkTransactions(&Block{Transactions: []*solana.Transaction{nonceTx, advancedTx}}, txErrs)
+
+	assert.Equal(t, []bool{true, false}, nonceTxs)
+	assert.Nil(t, txErrs[0])
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeBlockhashNotFound), txErrs[1])
+}
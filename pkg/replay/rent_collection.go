@@ -0,0 +1,241 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/fees"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+const (
+	defaultTicksPerSecond = 160
+	secondsPerDay         = 24 * 60 * 60
+)
+
+// rentPartition is the inclusive range [start, end] of the partitions into which the pubkey
+// space is divided for the purposes of rent collection, of which each slot visits those
+// since its parent slot's. The partitions are swept once per rent collection cycle.
+type rentPartition struct {
+	start uint64
+	end   uint64
+	count uint64
+}
+
+// rentCycleParams describes a rent collection cycle, which spans one epoch, or several if
+// epochs are shorter than two days.
+type rentCycleParams struct {
+	epoch             uint64
+	slotsPerEpoch     uint64
+	inMultiEpochCycle bool
+	baseEpoch         uint64
+	epochsPerCycle    uint64
+	partitionCount    uint64
+}
+
+func slotCountInTwoDays(ticksPerSlot uint64) uint64 {
+	return 2 * defaultTicksPerSecond * secondsPerDay / ticksPerSlot
+}
+
+// useMultiEpochCollectionCycle returns true if rent collection in the given epoch is part of a
+// cycle spanning several epochs. Warmup epochs are each swept in their own cycle, as are the
+// epochs of clusters with the minimum epoch length, which are used for local testing.
+func useMultiEpochCollectionCycle(epochSchedule *sealevel.SysvarEpochSchedule, ticksPerSlot uint64, epoch uint64) bool {
+	if epochSchedule.SlotsPerEpoch == sealevel.MinimumSlotsPerEpoch {
+		return false
+	}
+	return ticksPerSlot != 0 && epoch >= epochSchedule.FirstNormalEpoch && epochSchedule.SlotsPerEpoch < slotCountInTwoDays(ticksPerSlot)
+}
+
+// rentCollectionPartitions returns the partitions visited by rent collection in the given
+// slot, splitting the range since the parent slot at the epoch boundary, should the slot be
+// the first of its epoch. During warmup, each epoch's partition count is the epoch's length.
+//
+// Clusters other than mainnet-beta with short epochs use a fixed collection cycle instead,
+// which isn't supported.
+func rentCollectionPartitions(epochSchedule *sealevel.SysvarEpochSchedule, ticksPerSlot uint64, slot uint64, parentSlot uint64) []rentPartition {
+	epoch, slotIndex := epochSchedule.GetEpochAndSlotIndex(slot)
+	parentEpoch, parentSlotIndex := epochSchedule.GetEpochAndSlotIndex(parentSlot)
+
+	var partitions []rentPartition
+	if parentEpoch < epoch {
+		// slots skipped across the epoch boundary visit the remainder of the parent's epoch,
+		// and the first partition of the new epoch
+		if slot-parentSlot > 1 {
+			parentLastSlotIndex := epochSchedule.SlotsInEpoch(parentEpoch) - 1
+			partitions = append(partitions, partitionFromSlotIndexes(epochSchedule, ticksPerSlot, parentSlotIndex, parentLastSlotIndex, parentEpoch, true))
+
+			if slotIndex > 0 {
+				partitions = append(partitions, partitionFromSlotIndexes(epochSchedule, ticksPerSlot, 0, 0, epoch, true))
+			}
+		}
+		parentSlotIndex = 0
+	}
+
+	return append(partitions, partitionFromSlotIndexes(epochSchedule, ticksPerSlot, parentSlotIndex, slotIndex, epoch, false))
+}
+
+func partitionFromSlotIndexes(epochSchedule *sealevel.SysvarEpochSchedule, ticksPerSlot uint64, startSlotIndex uint64, endSlotIndex uint64, epoch uint64, forGappedEpochs bool) rentPartition {
+	slotsPerEpoch := epochSchedule.SlotsInEpoch(epoch)
+
+	params := rentCycleParams{epoch: epoch, slotsPerEpoch: slotsPerEpoch, epochsPerCycle: 1, partitionCount: slotsPerEpoch}
+	if useMultiEpochCollectionCycle(epochSchedule, ticksPerSlot, epoch) {
+		epochsPerCycle := slotCountInTwoDays(ticksPerSlot) / slotsPerEpoch
+		params = rentCycleParams{epoch: epoch, slotsPerEpoch: slotsPerEpoch, inMultiEpochCycle: true,
+			baseEpoch: epochSchedule.FirstNormalEpoch, epochsPerCycle: epochsPerCycle, partitionCount: slotsPerEpoch * epochsPerCycle}
+	}
+
+	partition := rentPartition{start: params.partitionIndex(startSlotIndex), end: params.partitionIndex(endSlotIndex),
+		count: params.partitionCount}
+
+	// the slot indexes given at epoch boundaries are off by one, which is only of consequence
+	// where the boundary falls in the middle of a multi-epoch cycle, in which case the
+	// partitions are adjusted to remain contiguous with those of the previous epoch.
+	isSpecialNewEpoch := startSlotIndex == 0 && endSlotIndex != 1
+	inMiddleOfCycle := partition.start > 0
+	if params.inMultiEpochCycle && isSpecialNewEpoch && inMiddleOfCycle {
+		partition.start--
+		if forGappedEpochs {
+			partition.end--
+		}
+	}
+
+	return partition
+}
+
+func (params *rentCycleParams) partitionIndex(slotIndex uint64) uint64 {
+	epochIndexInCycle := (params.epoch - params.baseEpoch) % params.epochsPerCycle
+	return slotIndex + epochIndexInCycle*params.slotsPerEpoch
+}
+
+// pubkeyRange returns the inclusive range of pubkeys covered by the partitions, which
+// divide the pubkey space evenly by the pubkeys' first 8 bytes.
+func (partition rentPartition) pubkeyRange() (solana.PublicKey, solana.PublicKey) {
+	var start, end solana.PublicKey
+	for idx := range end {
+		end[idx] = 0xff
+	}
+
+	if partition.count == 1 {
+		return start, end
+	}
+
+	// (2^64)/count, without overflowing
+	partitionWidth := (math.MaxUint64-partition.count+1)/partition.count + 1
+
+	var startPrefix uint64
+	if partition.start == 0 && partition.end == 0 {
+		startPrefix = 0
+	} else if partition.start+1 == partition.count {
+		startPrefix = math.MaxUint64
+	} else {
+		startPrefix = (partition.start + 1) * partitionWidth
+	}
+
+	var endPrefix uint64
+	if partition.end+1 == partition.count {
+		endPrefix = math.MaxUint64
+	} else {
+		endPrefix = (partition.end+1)*partitionWidth - 1
+	}
+
+	// n..=n for n > 0 is a no-op partition at an epoch boundary, and is nullified
+	if partition.start != 0 && partition.start == partition.end {
+		if endPrefix == math.MaxUint64 {
+			startPrefix = endPrefix
+			start = end
+		} else {
+			endPrefix = startPrefix
+			end = start
+		}
+	}
+
+	binary.BigEndian.PutUint64(start[:8], startPrefix)
+	binary.BigEndian.PutUint64(end[:8], endPrefix)
+	return start, end
+}
+
+// collectRent runs the slot's rent collection pass over the accounts in the partitions due
+// for the slot, as of the end of the slot, including those created earlier in the slot. The accounts changed by rent collection are
+// included in the accounts delta hash, as are those left unchanged unless rent rewrites are
+// skipped. It returns the total rent collected.
+//
+// Collected rent is meant to be distributed to the epoch's staked validators, which isn't
+// implemented, since rent fees collection has long been disabled on mainnet-beta.
+func collectRent(slotCtx *sealevel.SlotCtx, rentCollector *snapshot.RentCollector, ticksPerSlot uint64) (uint64, error) {
+	skipRewrites := slotCtx.Features.IsActive(features.SkipRentRewrites)
+
+	var totalCollected uint64
+	for _, partition := range rentCollectionPartitions(&rentCollector.EpochSchedule, ticksPerSlot, slotCtx.Slot, slotCtx.ParentSlot) {
+		start, end := partition.pubkeyRange()
+		pubkeys, err := rentCollectionPubkeys(slotCtx, start, end)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, pubkey := range pubkeys {
+			acct, err := loadAccount(slotCtx, pubkey)
+			if errors.Is(err, accountsdb.ErrNoAccount) {
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+
+			if acct.Lamports == 0 {
+				continue
+			}
+
+			rentEpochPre := acct.RentEpoch
+			collected := fees.CollectRentFromAccount(rentCollector, slotCtx.Features, acct)
+			totalCollected += collected
+
+			if collected != 0 || acct.RentEpoch != rentEpochPre || !skipRewrites {
+				err = slotCtx.SetAccount(pubkey, acct)
+				if err != nil {
+					return 0, err
+				}
+				slotCtx.ModifiedAccts[pubkey] = true
+			}
+		}
+	}
+
+	return totalCollected, nil
+}
+
+// rentCollectionPubkeys returns the pubkeys of the accounts within the inclusive range
+// [start, end] as of the end of the slot, in ascending order: those as of the parent slot,
+// along with those modified in the slot so far.
+func rentCollectionPubkeys(slotCtx *sealevel.SlotCtx, start solana.PublicKey, end solana.PublicKey) ([]solana.PublicKey, error) {
+	pubkeys, err := slotCtx.AccountsDb.PubkeysInRangeAtSlot(slotCtx.ParentSlot, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	inParent := make(map[solana.PublicKey]bool, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		inParent[pubkey] = true
+	}
+
+	var modified []solana.PublicKey
+	for pubkey := range slotCtx.ModifiedAccts {
+		if !inParent[pubkey] && bytes.Compare(pubkey[:], start[:]) >= 0 && bytes.Compare(pubkey[:], end[:]) <= 0 {
+			modified = append(modified, pubkey)
+		}
+	}
+	if len(modified) == 0 {
+		return pubkeys, nil
+	}
+
+	pubkeys = append(pubkeys, modified...)
+	sort.Slice(pubkeys, func(i, j int) bool {
+		return bytes.Compare(pubkeys[i][:], pubkeys[j][:]) < 0
+	})
+	return pubkeys, nil
+}
@@ -0,0 +1,231 @@
+package replay
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb/accountsdbtest"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/fees"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func TestRentCollectionPartitions(t *testing.T) {
+	epochSchedule := &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000}
+
+	assert.Equal(t, []rentPartition{{0, 1, 432000}}, rentCollectionPartitions(epochSchedule, 64, 1, 0))
+	assert.Equal(t, []rentPartition{{10, 20, 432000}}, rentCollectionPartitions(epochSchedule, 64, 432020, 432010))
+
+	// the first slot of an epoch visits the epoch's first partition
+	assert.Equal(t, []rentPartition{{0, 0, 432000}}, rentCollectionPartitions(epochSchedule, 64, 432000, 431999))
+
+	// slots skipped across an epoch boundary visit the remainder of the previous epoch
+	assert.Equal(t, []rentPartition{{431990, 431999, 432000}, {0, 0, 432000}, {0, 5, 432000}},
+		rentCollectionPartitions(epochSchedule, 64, 432005, 431990))
+	assert.Equal(t, []rentPartition{{431990, 431999, 432000}, {0, 0, 432000}},
+		rentCollectionPartitions(epochSchedule, 64, 432000, 431990))
+
+	// epochs shorter than two days' worth of slots are swept in a multi-epoch cycle
+	epochSchedule = &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 64}
+	assert.Equal(t, []rentPartition{{0, 0, 432000}}, rentCollectionPartitions(epochSchedule, 64, 0, 0))
+	assert.Equal(t, []rentPartition{{62, 63, 432000}}, rentCollectionPartitions(epochSchedule, 64, 63, 62))
+	assert.Equal(t, []rentPartition{{63, 64, 432000}}, rentCollectionPartitions(epochSchedule, 64, 64, 63))
+	assert.Equal(t, []rentPartition{{64, 65, 432000}}, rentCollectionPartitions(epochSchedule, 64, 65, 64))
+	assert.Equal(t, []rentPartition{{1000, 1001, 432000}}, rentCollectionPartitions(epochSchedule, 64, 1001, 1000))
+	assert.Equal(t, []rentPartition{{431998, 431999, 432000}}, rentCollectionPartitions(epochSchedule, 64, 431999, 431998))
+	assert.Equal(t, []rentPartition{{0, 0, 432000}}, rentCollectionPartitions(epochSchedule, 64, 432000, 431999))
+
+	// except for epochs of the minimum length, which are each swept in their own cycle
+	epochSchedule = &sealevel.SysvarEpochSchedule{SlotsPerEpoch: sealevel.MinimumSlotsPerEpoch}
+	assert.Equal(t, []rentPartition{{30, 31, 32}}, rentCollectionPartitions(epochSchedule, 64, 31, 30))
+	assert.Equal(t, []rentPartition{{0, 0, 32}}, rentCollectionPartitions(epochSchedule, 64, 32, 31))
+	assert.Equal(t, []rentPartition{{0, 1, 32}}, rentCollectionPartitions(epochSchedule, 64, 33, 32))
+}
+
+func TestRentCollectionPartitions_Warmup(t *testing.T) {
+	epochSchedule := &sealevel.SysvarEpochSchedule{SlotsPerEpoch: 8192, LeaderScheduleSlotOffset: 8192, Warmup: true,
+		FirstNormalEpoch: 8, FirstNormalSlot: 8160}
+
+	// each warmup epoch is swept in its own cycle, of as many partitions as the epoch has slots
+	assert.Equal(t, []rentPartition{{0, 1, 32}}, rentCollectionPartitions(epochSchedule, 64, 1, 0))
+	assert.Equal(t, []rentPartition{{30, 31, 32}}, rentCollectionPartitions(epochSchedule, 64, 31, 30))
+	assert.Equal(t, []rentPartition{{0, 0, 64}}, rentCollectionPartitions(epochSchedule, 64, 32, 31))
+	assert.Equal(t, []rentPartition{{0, 1, 64}}, rentCollectionPartitions(epochSchedule, 64, 33, 32))
+	assert.Equal(t, []rentPartition{{4094, 4095, 4096}}, rentCollectionPartitions(epochSchedule, 64, 8159, 8158))
+
+	// slots skipped across a warmup epoch boundary
+	assert.Equal(t, []rentPartition{{58, 63, 64}, {0, 0, 128}, {0, 4, 128}}, rentCollectionPartitions(epochSchedule, 64, 100, 90))
+
+	// the normal epochs, being shorter than two days, are swept in a multi-epoch cycle of
+	// as many whole epochs as fit in two days, which begins with the first normal epoch
+	assert.Equal(t, []rentPartition{{0, 0, 425984}}, rentCollectionPartitions(epochSchedule, 64, 8160, 8159))
+	assert.Equal(t, []rentPartition{{0, 1, 425984}}, rentCollectionPartitions(epochSchedule, 64, 8161, 8160))
+	assert.Equal(t, []rentPartition{{8191, 8192, 425984}}, rentCollectionPartitions(epochSchedule, 64, 16352, 16351))
+}
+
+func TestRentPartitionPubkeyRange(t *testing.T) {
+	pubkeyWithPrefix := func(prefix uint64, fill byte) solana.PublicKey {
+		var pubkey solana.PublicKey
+		for idx := range pubkey {
+			pubkey[idx] = fill
+		}
+		for idx := 0; idx < 8; idx++ {
+			pubkey[idx] = byte(prefix >> (56 - 8*idx))
+		}
+		return pubkey
+	}
+
+	for _, test := range []struct {
+		partition  rentPartition
+		start, end solana.PublicKey
+	}{
+		{rentPartition{0, 0, 1}, pubkeyWithPrefix(0, 0), pubkeyWithPrefix(math.MaxUint64, 0xff)},
+		{rentPartition{0, 0, 3}, pubkeyWithPrefix(0, 0), pubkeyWithPrefix(0x5555555555555554, 0xff)},
+		{rentPartition{0, 1, 3}, pubkeyWithPrefix(0x5555555555555555, 0), pubkeyWithPrefix(0xaaaaaaaaaaaaaaa9, 0xff)},
+		{rentPartition{1, 2, 3}, pubkeyWithPrefix(0xaaaaaaaaaaaaaaaa, 0), pubkeyWithPrefix(math.MaxUint64, 0xff)},
+		{rentPartition{0, 1, math.MaxUint64}, pubkeyWithPrefix(1, 0), pubkeyWithPrefix(1, 0xff)},
+
+		// no-op partitions at epoch boundaries
+		{rentPartition{1, 1, 3}, pubkeyWithPrefix(0xaaaaaaaaaaaaaaaa, 0), pubkeyWithPrefix(0xaaaaaaaaaaaaaaaa, 0)},
+		{rentPartition{2, 2, 3}, pubkeyWithPrefix(math.MaxUint64, 0xff), pubkeyWithPrefix(math.MaxUint64, 0xff)},
+	} {
+		start, end := test.partition.pubkeyRange()
+		assert.Equal(t, test.start, start, "partition %v", test.partition)
+		assert.Equal(t, test.end, end, "partition %v", test.partition)
+	}
+}
+
+func newTestRentCollector() *snapshot.RentCollector {
+	return &snapshot.RentCollector{EpochSchedule: sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000},
+		SlotsPerYear: 78892314.984, Rent: sealevel.SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2}}
+}
+
+func TestCollectRentFromAccount(t *testing.T) {
+	rentCollector := newTestRentCollector()
+	rentCollector.Epoch = 10
+	f := features.NewFeaturesDefault()
+
+	// one epoch's rent for an account without data, being (128 * 3480) * 432000 / 78892314.984
+	rentPerEpoch := uint64(2439)
+
+	acct := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100000, RentEpoch: 10}
+	assert.Equal(t, rentPerEpoch, fees.CollectRentFromAccount(rentCollector, f, acct))
+	assert.Equal(t, 100000-rentPerEpoch, acct.Lamports)
+	assert.Equal(t, uint64(11), acct.RentEpoch)
+
+	// rent is not collected again within the epoch
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, acct))
+
+	// rent is due for every epoch since the account's rent epoch
+	acct = &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100000, RentEpoch: 9}
+	assert.Equal(t, 2*rentPerEpoch, fees.CollectRentFromAccount(rentCollector, f, acct))
+
+	// accounts that can't pay are emptied
+	acct = &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100, Data: []byte{1}, RentEpoch: 10}
+	assert.Equal(t, uint64(100), fees.CollectRentFromAccount(rentCollector, f, acct))
+	assert.Equal(t, accounts.Account{Key: acct.Key}, *acct)
+
+	// rent-exempt accounts are left alone, until their rent epoch is set to the maximum
+	exemptAcct := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 890880, RentEpoch: 3}
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, exemptAcct))
+	assert.Equal(t, uint64(3), exemptAcct.RentEpoch)
+
+	f.EnableFeature(features.SetExemptRentEpochMax, 0)
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, exemptAcct))
+	assert.Equal(t, uint64(fees.RentExemptRentEpoch), exemptAcct.RentEpoch)
+
+	executableAcct := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1, Executable: true, RentEpoch: 3}
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, executableAcct))
+	assert.Equal(t, uint64(fees.RentExemptRentEpoch), executableAcct.RentEpoch)
+
+	// once rent fees collection is disabled, rent-paying accounts are left alone
+	f.EnableFeature(features.DisableRentFeesCollection, 0)
+	acct = &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 100000, RentEpoch: 10}
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, acct))
+	assert.Equal(t, accounts.Account{Key: acct.Key, Lamports: 100000, RentEpoch: 10}, *acct)
+
+	exemptAcct = &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 890880, RentEpoch: 3}
+	assert.Zero(t, fees.CollectRentFromAccount(rentCollector, f, exemptAcct))
+	assert.Equal(t, uint64(fees.RentExemptRentEpoch), exemptAcct.RentEpoch)
+}
+
+func TestCollectRent(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+	require.NoError(t, acctsDb.SetRootSlot(0))
+
+	// slot 1 visits the accounts in the second of the epoch's partitions
+	start, _ := rentPartition{0, 1, 432000}.pubkeyRange()
+	newAcct := func(idx byte, lamports uint64, rentEpoch uint64) *accounts.Account {
+		acct := &accounts.Account{Key: start, Lamports: lamports, Owner: sealevel.SystemProgramAddr, RentEpoch: rentEpoch}
+		acct.Key[31] = idx
+		return acct
+	}
+
+	payingAcct := newAcct(1, 100000, 0)
+	exemptAcct := newAcct(2, 890880, 0)
+	collectedAcct := newAcct(3, 890880, fees.RentExemptRentEpoch)
+	loadedAcct := newAcct(4, 100000, 0)
+	otherAcct := &accounts.Account{Key: solana.PublicKey{0xff}, Lamports: 100000, Owner: sealevel.SystemProgramAddr}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{payingAcct, exemptAcct, collectedAcct, loadedAcct, otherAcct}, 0))
+
+	newSlotCtx := func(f *features.Features) *sealevel.SlotCtx {
+		slotCtx := &sealevel.SlotCtx{Slot: 1, ParentSlot: 0, Accounts: accounts.NewMemAccounts(), AccountsDb: acctsDb, Features: f,
+			ModifiedAccts: make(map[solana.PublicKey]bool)}
+
+		// accounts loaded for the block are collected from as of the end of the slot
+		loaded := newAcct(4, 200000, 0)
+		require.NoError(t, slotCtx.SetAccount(loaded.Key, loaded))
+
+		// as are accounts created earlier in the slot
+		created := newAcct(5, 100000, 0)
+		require.NoError(t, slotCtx.SetAccount(created.Key, created))
+		slotCtx.ModifiedAccts[created.Key] = true
+		return slotCtx
+	}
+
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.SetExemptRentEpochMax, 0)
+	f.EnableFeature(features.SkipRentRewrites, 0)
+	slotCtx := newSlotCtx(f)
+
+	rentPerEpoch := uint64(2439)
+	collected, err := collectRent(slotCtx, newTestRentCollector(), 64)
+	require.NoError(t, err)
+	assert.Equal(t, 3*rentPerEpoch, collected)
+	createdKey := newAcct(5, 0, 0).Key
+	assert.Equal(t, map[solana.PublicKey]bool{payingAcct.Key: true, exemptAcct.Key: true, loadedAcct.Key: true, createdKey: true},
+		slotCtx.ModifiedAccts)
+
+	acct, err := slotCtx.GetAccount(payingAcct.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 100000-rentPerEpoch, acct.Lamports)
+	assert.Equal(t, uint64(1), acct.RentEpoch)
+
+	acct, err = slotCtx.GetAccount(exemptAcct.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(fees.RentExemptRentEpoch), acct.RentEpoch)
+
+	acct, err = slotCtx.GetAccount(loadedAcct.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 200000-rentPerEpoch, acct.Lamports)
+
+	acct, err = slotCtx.GetAccount(createdKey)
+	require.NoError(t, err)
+	assert.Equal(t, 100000-rentPerEpoch, acct.Lamports)
+
+	// without skipping rewrites, every account visited is included in the delta hash
+	f = features.NewFeaturesDefault()
+	f.EnableFeature(features.DisableRentFeesCollection, 0)
+	slotCtx = newSlotCtx(f)
+
+	collected, err = collectRent(slotCtx, newTestRentCollector(), 64)
+	require.NoError(t, err)
+	assert.Zero(t, collected)
+	assert.Equal(t, map[solana.PublicKey]bool{payingAcct.Key: true, exemptAcct.Key: true, collectedAcct.Key: true, loadedAcct.Key: true,
+		createdKey: true}, slotCtx.ModifiedAccts)
+}
@@ -0,0 +1,123 @@
+package replay
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/snapshot"
+	"k8s.io/klog/v2"
+)
+
+// ReplayCtx holds the state that is carried over from one replayed block to the next.
+type ReplayCtx struct {
+	AccountsDb *accountsdb.AccountsDb
+	Manifest   *snapshot.SnapshotManifest
+
+	// TxParallelism is the maximum number of a block's transactions executed concurrently,
+	// with transactions executed serially if it is at most one. CheckSerialEquivalence
+	// additionally executes each block serially, failing replay if the outcomes differ.
+	TxParallelism          int
+	CheckSerialEquivalence bool
+
+	// SkipSigVerify skips the verification of transaction signatures and precompile
+	// instructions, which may be done when replaying trusted blocks. Transactions are
+	// sanitized regardless.
+	SkipSigVerify bool
+
+	// Divergences, if set, collects the divergences between replayed transactions and their
+	// on-chain metadata.
+	Divergences *DivergenceCollector
+
+	eah *epochAccountsHash
+
+	// the recent blockhashes against which transactions' ages are checked, and the
+	// transactions processed in recently replayed slots, which are nil if the recent
+	// blockhashes are unknown
+	blockhashQueue *blockhashQueue
+	statusCache    *statusCache
+
+	// the stake delegations and vote accounts, the epoch stakes, the capitalization and the
+	// partitioned epoch rewards distribution in progress, if any, which are only known if
+	// replay began from the manifest's slot, with stakes being nil otherwise
+	stakes         *stakesCache
+	epochStakes    []snapshot.EpochStakesPair
+	capitalization uint64
+	epochRewards   *epochRewardStatus
+
+	// the number of txs committed as of the most recently replayed bank, which is known
+	// alongside the stakes, and whether an inflation feature has since been activated,
+	// changing the bank's inflation parameters
+	transactionCount uint64
+	inflationChanged bool
+
+	// the most recently replayed bank, and the number of blocks replayed on top of the snapshot
+	lastBank          *replayedBank
+	numBlocksReplayed uint64
+}
+
+// replayedBank records the fields of a replayed bank that differ from those of its parent,
+// for the purposes of creating a snapshot at the bank's slot.
+type replayedBank struct {
+	slot           uint64
+	parentSlot     uint64
+	epoch          uint64
+	bankHash       [32]byte
+	parentBankHash [32]byte
+	acctsDeltaHash [32]byte
+	numSignatures  uint64
+	collectorId    solana.PublicKey
+	collectorFees  uint64
+	collectedRent  uint64
+}
+
+func NewReplayCtx(acctsDb *accountsdb.AccountsDb, manifest *snapshot.SnapshotManifest) (*ReplayCtx, error) {
+	replayCtx := &ReplayCtx{AccountsDb: acctsDb, Manifest: manifest}
+
+	// an AccountsDB that has not had any blocks replayed on top of it is rooted at the snapshot slot
+	lastSlot, hasLastSlot := acctsDb.LastSlot()
+	if !hasLastSlot {
+		err := acctsDb.SetRootSlot(manifest.Bank.Slot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the manifest's blockhash queue lacks the blockhashes of blocks since replayed on top of
+	// the snapshot, in which case transactions' ages cannot be checked
+	if hasLastSlot && lastSlot != manifest.Bank.Slot {
+		klog.Warningf("AccountsDB at slot %d is ahead of its manifest at slot %d, so blockhash age and duplicate transaction checks are disabled, nonce instructions are refused, and epoch boundaries cannot be crossed",
+			lastSlot, manifest.Bank.Slot)
+	} else {
+		replayCtx.blockhashQueue = newBlockhashQueue(&manifest.Bank.BlockhashQueue)
+		replayCtx.statusCache = newStatusCache()
+		replayCtx.stakes = newStakesCache(&manifest.Bank.Stakes)
+		replayCtx.capitalization = manifest.Bank.Capitalization
+		replayCtx.transactionCount = manifest.Bank.TransactionCount
+	}
+	replayCtx.epochStakes = manifest.Bank.EpochStakes
+
+	// a snapshot taken whilst an EAH calculation is in flight carries the EAH for its epoch
+	if manifest.EpochAccountHash != [32]byte{} {
+		replayCtx.eah = newCompletedEpochAccountsHash(manifest.Bank.Epoch, manifest.Bank.Slot, manifest.EpochAccountHash)
+	}
+
+	// whereas one started whilst replaying on top of the snapshot was persisted in the AccountsDB
+	if hasLastSlot {
+		err := replayCtx.restoreEpochAccountsHash(lastSlot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return replayCtx, nil
+}
+
+// CleanAccountsDb garbage collects account versions in the AccountsDB that are no longer
+// needed as of maxCleanSlot. An in-flight EAH calculation relies upon the accounts state as
+// of its slot, so cleaning is limited to that slot until the calculation has completed.
+func (replayCtx *ReplayCtx) CleanAccountsDb(maxCleanSlot uint64) (*accountsdb.CleanStats, error) {
+	if eahSlot, inFlight := replayCtx.inFlightEpochAccountsHashSlot(); inFlight {
+		maxCleanSlot = min(maxCleanSlot, eahSlot)
+	}
+
+	return replayCtx.AccountsDb.Clean(maxCleanSlot)
+}
@@ -0,0 +1,228 @@
+package replay
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/tpu"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxTxAccounts              = 256
+	maxTxAccountLocks          = 64
+	maxTxAccountLocksIncreased = 128
+)
+
+// verifyTransactions sanitizes each of the block's transactions and validates their account
+// locks and, if verifySigs is set, verifies their signatures and precompile instructions, as
+// the runtime does before executing transactions. It returns the error with which each
+// transaction failed verification, or nil where it passed. Transactions are verified
+// concurrently by numWorkers workers.
+func verifyTransactions(slotCtx *sealevel.SlotCtx, block *Block, numWorkers int, verifySigs bool) []*sealevel.TransactionError {
+	txErrs := make([]*sealevel.TransactionError, len(block.Transactions))
+
+	var group errgroup.Group
+	group.SetLimit(max(numWorkers, 1))
+
+	for idx, tx := range block.Transactions {
+		group.Go(func() error {
+			txErrs[idx] = verifyTransaction(slotCtx.Features, tx, verifySigs)
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+	return txErrs
+}
+
+func verifyTransaction(f *features.Features, tx *solana.Transaction, verifySigs bool) *sealevel.TransactionError {
+	txErr := sanitizeTx(tx)
+	if txErr != nil {
+		return txErr
+	}
+
+	txErr = validateAccountLocks(f, tx)
+	if txErr != nil {
+		return txErr
+	}
+
+	if !verifySigs {
+		return nil
+	}
+
+	if !tpu.VerifyTxSig(tx) {
+		return sealevel.NewTransactionError(sealevel.TxErrCodeSignatureFailure)
+	}
+
+	if !f.IsActive(features.MovePrecompileVerificationToSvm) {
+		instrs, err := instrsFromTx(tx)
+		if err != nil {
+			return sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+		}
+		return sealevel.VerifyPrecompiles(instrs, f)
+	}
+
+	return nil
+}
+
+// checkTransactions rejects those of the block's transactions that passed verification but
+// of which the recent blockhash is no longer valid, with BlockhashNotFound, or which have
+// already been processed, whether in a recently replayed slot or earlier in the block, with
+// AlreadyProcessed, as the runtime does before executing transactions. The errors are set in
+// txErrs. It returns the message hash of each transaction, by which it is recorded in the
+// status cache, and which of the transactions are durable nonce transactions, of which the
+// nonce accounts are instead validated upon execution. Where the recent blockhashes are
+// unknown, transactions are not checked.
+func (replayCtx *ReplayCtx) checkTransactions(block *Block, txErrs []*sealevel.TransactionError) ([][32]byte, []bool) {
+	msgHashes := make([][32]byte, len(block.Transactions))
+	nonceTxs := make([]bool, len(block.Transactions))
+	processed := make(map[[32]byte]bool)
+
+	for idx, tx := range block.Transactions {
+		msgHash, err := txMessageHash(tx)
+		if err != nil {
+			if txErrs[idx] == nil {
+				txErrs[idx] = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+			}
+			continue
+		}
+		msgHashes[idx] = msgHash
+
+		if txErrs[idx] != nil || replayCtx.blockhashQueue == nil {
+			continue
+		}
+
+		recentBlockhash := [32]byte(tx.Message.RecentBlockhash)
+		if !replayCtx.blockhashQueue.isHashValidForAge(recentBlockhash, maxProcessingAge) {
+			// a durable nonce tx that is processed again fails upon execution, as its nonce
+			// will have been advanced, so it needs no status cache check
+			lastHash := replayCtx.blockhashQueue.lastHash
+			if lastHash != nil && isAdvanceableNonceTx(tx, *lastHash) {
+				nonceTxs[idx] = true
+			} else {
+				txErrs[idx] = sealevel.NewTransactionError(sealevel.TxErrCodeBlockhashNotFound)
+			}
+			continue
+		}
+
+		if processed[msgHash] || replayCtx.statusCache.contains(recentBlockhash, msgHash) {
+			txErrs[idx] = sealevel.NewTransactionError(sealevel.TxErrCodeAlreadyProcessed)
+			continue
+		}
+		processed[msgHash] = true
+	}
+
+	return msgHashes, nonceTxs
+}
+
+// numLookupAccounts returns the number of accounts that the tx loads via address lookup tables.
+func numLookupAccounts(tx *solana.Transaction) int {
+	var numAccts int
+	for _, lookup := range tx.Message.GetAddressTableLookups() {
+		numAccts += len(lookup.WritableIndexes) + len(lookup.ReadonlyIndexes)
+	}
+	return numAccts
+}
+
+// sanitizeTx checks the consistency of the tx's signatures, message header, instructions and
+// address lookups, as the runtime does when sanitizing transactions, returning
+// SanitizeFailure for transactions that are malformed, or a lookup table error for those
+// of which the address lookups could not be resolved.
+func sanitizeTx(tx *solana.Transaction) *sealevel.TransactionError {
+	sanitizeFailure := sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+	header := tx.Message.Header
+
+	// the lookup table accounts are appended to the account keys once resolved
+	numLookupAccts := numLookupAccounts(tx)
+	numStaticAccts := len(tx.Message.AccountKeys)
+	if tx.Message.IsResolved() {
+		numStaticAccts -= numLookupAccts
+	}
+	numAccts := numStaticAccts + numLookupAccts
+
+	if numStaticAccts <= 0 || int(header.NumRequiredSignatures)+int(header.NumReadonlyUnsignedAccounts) > numStaticAccts {
+		return sanitizeFailure
+	}
+
+	// the fee payer must be writable
+	if header.NumReadonlySignedAccounts >= header.NumRequiredSignatures {
+		return sanitizeFailure
+	}
+
+	if numAccts > maxTxAccounts {
+		return sanitizeFailure
+	}
+
+	for _, lookup := range tx.Message.GetAddressTableLookups() {
+		if len(lookup.WritableIndexes) == 0 && len(lookup.ReadonlyIndexes) == 0 {
+			return sanitizeFailure
+		}
+	}
+
+	for _, instr := range tx.Message.Instructions {
+		// programs must be amongst the static accounts, and the fee payer cannot be a program
+		if int(instr.ProgramIDIndex) >= numStaticAccts || instr.ProgramIDIndex == 0 {
+			return sanitizeFailure
+		}
+		for _, acctIdx := range instr.Accounts {
+			if int(acctIdx) >= numAccts {
+				return sanitizeFailure
+			}
+		}
+	}
+
+	// signers must be amongst the static accounts, since signatures are verified before
+	// the address lookups are resolved
+	if len(tx.Signatures) != int(header.NumRequiredSignatures) || len(tx.Signatures) > numStaticAccts {
+		return sanitizeFailure
+	}
+
+	if numLookupAccts != 0 && !tx.Message.IsResolved() {
+		return lookupTableErr(tx)
+	}
+
+	return nil
+}
+
+// lookupTableErr returns the error with which the tx's address lookups failed to resolve.
+func lookupTableErr(tx *solana.Transaction) *sealevel.TransactionError {
+	tables := tx.Message.GetAddressTables()
+	for _, lookup := range tx.Message.GetAddressTableLookups() {
+		table, ok := tables[lookup.AccountKey]
+		if !ok {
+			return sealevel.NewTransactionError(sealevel.TxErrCodeAddressLookupTableNotFound)
+		}
+
+		for _, idx := range append(append([]uint8{}, lookup.WritableIndexes...), lookup.ReadonlyIndexes...) {
+			if int(idx) >= len(table) {
+				return sealevel.NewTransactionError(sealevel.TxErrCodeInvalidAddressLookupTableIndex)
+			}
+		}
+	}
+
+	return sealevel.NewTransactionError(sealevel.TxErrCodeAddressLookupTableNotFound)
+}
+
+// validateAccountLocks checks that the tx loads no account more than once, and does not
+// lock more accounts than permitted.
+func validateAccountLocks(f *features.Features, tx *solana.Transaction) *sealevel.TransactionError {
+	seen := make(map[solana.PublicKey]bool, len(tx.Message.AccountKeys))
+	for _, pubkey := range tx.Message.AccountKeys {
+		if seen[pubkey] {
+			return sealevel.NewTransactionError(sealevel.TxErrCodeAccountLoadedTwice)
+		}
+		seen[pubkey] = true
+	}
+
+	lockLimit := maxTxAccountLocks
+	if f.IsActive(features.IncreaseTxAccountLockLimit) {
+		lockLimit = maxTxAccountLocksIncreased
+	}
+
+	if len(tx.Message.AccountKeys) > lockLimit {
+		return sealevel.NewTransactionError(sealevel.TxErrCodeTooManyAccountLocks)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func newTestSignedTx(t *testing.T, instrs ...solana.Instruction) *solana.Transaction {
+	payer := solana.NewWallet()
+	if len(instrs) == 0 {
+		instrs = []solana.Instruction{solana.NewInstruction(solana.SystemProgramID,
+			solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).WRITE().SIGNER(), solana.Meta(solana.NewWallet().PublicKey()).WRITE()}, []byte{2})}
+	}
+
+	tx, err := solana.NewTransaction(instrs, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	require.NoError(t, err)
+
+	_, err = tx.Sign(func(pubkey solana.PublicKey) *solana.PrivateKey {
+		if pubkey == payer.PublicKey() {
+			return &payer.PrivateKey
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	return tx
+}
+
+func TestSanitizeTx(t *testing.T) {
+	assert.Nil(t, sanitizeTx(newTestSignedTx(t)))
+
+	sanitizeFailure := sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+
+	// the fee payer must be writable
+	tx := newTestSignedTx(t)
+	tx.Message.Header.NumReadonlySignedAccounts = 1
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	tx = newTestSignedTx(t)
+	tx.Message.Header.NumReadonlyUnsignedAccounts = 3
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	// the fee payer cannot be a program
+	tx = newTestSignedTx(t)
+	tx.Message.Instructions[0].ProgramIDIndex = 0
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	tx = newTestSignedTx(t)
+	tx.Message.Instructions[0].ProgramIDIndex = 3
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	tx = newTestSignedTx(t)
+	tx.Message.Instructions[0].Accounts = append(tx.Message.Instructions[0].Accounts, 3)
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	tx = newTestSignedTx(t)
+	tx.Signatures = append(tx.Signatures, solana.Signature{})
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+
+	// address lookups that could not be resolved
+	tx = newTestSignedTx(t)
+	tx.Message.SetVersion(solana.MessageVersionV0)
+	tx.Message.AddAddressTableLookup(solana.MessageAddressTableLookup{AccountKey: solana.NewWallet().PublicKey(), ReadonlyIndexes: []uint8{0}})
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeAddressLookupTableNotFound), sanitizeTx(tx))
+
+	tx.Message.GetAddressTableLookups()[0].ReadonlyIndexes = nil
+	assert.Equal(t, sanitizeFailure, sanitizeTx(tx))
+}
+
+func TestValidateAccountLocks(t *testing.T) {
+	f := features.NewFeaturesDefault()
+
+	tx := newTestSignedTx(t)
+	assert.Nil(t, validateAccountLocks(f, tx))
+
+	tx.Message.AccountKeys[1] = tx.Message.AccountKeys[0]
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeAccountLoadedTwice), validateAccountLocks(f, tx))
+
+	tx = newTestSignedTx(t)
+	for len(tx.Message.AccountKeys) <= maxTxAccountLocks {
+		tx.Message.AccountKeys = append(tx.Message.AccountKeys, solana.NewWallet().PublicKey())
+	}
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeTooManyAccountLocks), validateAccountLocks(f, tx))
+
+	f.EnableFeature(features.IncreaseTxAccountLockLimit, 0)
+	assert.Nil(t, validateAccountLocks(f, tx))
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	f := features.NewFeaturesDefault()
+
+	tx := newTestSignedTx(t)
+	assert.Nil(t, verifyTransaction(f, tx, true))
+
+	tx.Signatures[0][0] ^= 0xff
+	assert.Equal(t, sealevel.NewTransactionError(sealevel.TxErrCodeSignatureFailure), verifyTransaction(f, tx, true))
+	assert.Nil(t, verifyTransaction(f, tx, false))
+
+	// an ed25519 precompile instruction with too little data for its signature offsets
+	precompileInstr := solana.NewInstruction(solana.PublicKeyFromBytes(sealevel.Ed25519PrecompileAddr[:]), nil, []byte{1, 0})
+	tx = newTestSignedTx(t, precompileInstr)
+	assert.Equal(t, sealevel.NewTxErrInstructionError(0, sealevel.PrecompileErrInstrDataSize), verifyTransaction(f, tx, true))
+	txErrJson, err := json.Marshal(verifyTransaction(f, tx, true))
+	require.NoError(t, err)
+	assert.Equal(t, `{"InstructionError":[0,{"Custom":4}]}`, string(txErrJson))
+
+	// verified upon execution instead
+	f.EnableFeature(features.MovePrecompileVerificationToSvm, 0)
+	assert.Nil(t, verifyTransaction(f, tx, true))
+}
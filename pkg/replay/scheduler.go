@@ -0,0 +1,236 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/klog/v2"
+)
+
+var (
+	ErrSerialEquivalence = errors.New("ErrSerialEquivalence")
+)
+
+// txAcctLocks are the accounts read and written by a transaction, which determine the
+// transactions that it may be executed concurrently with.
+type txAcctLocks struct {
+	writable []solana.PublicKey
+	readonly []solana.PublicKey
+}
+
+// txAccountLocks returns the accounts read and written by a transaction, including those
+// referenced via address lookup tables, which must already have been resolved.
+func txAccountLocks(slotCtx *sealevel.SlotCtx, tx *solana.Transaction) (*txAcctLocks, error) {
+	acctMetas, err := tx.AccountMetaList()
+	if err != nil {
+		return nil, err
+	}
+
+	locks := new(txAcctLocks)
+	for _, acctMeta := range acctMetas {
+		if acctMeta.IsWritable {
+			locks.writable = append(locks.writable, acctMeta.PublicKey)
+		} else {
+			locks.readonly = append(locks.readonly, acctMeta.PublicKey)
+		}
+
+		// programs deployed by the upgradeable loader are executed from their program data
+		// account, which is not necessarily amongst the tx's accounts, but is written by
+		// upgrades and redeployments.
+		acct, err := slotCtx.GetAccount(acctMeta.PublicKey)
+		if err != nil {
+			continue
+		}
+		if programDataAddr, ok := sealevel.UpgradeableProgramDataAddress(acct); ok {
+			locks.readonly = append(locks.readonly, programDataAddr)
+		}
+	}
+
+	return locks, nil
+}
+
+// scheduleTxBatches partitions transactions into batches within which no two transactions
+// conflict, where transactions conflict if either writes an account that the other reads or
+// writes. Each transaction is placed in the batch following the last batch holding an earlier
+// transaction that it conflicts with, so executing the batches in order, with the transactions
+// of each batch executed concurrently, is equivalent to executing them serially in order.
+// The batches hold transaction indices, in ascending order.
+func scheduleTxBatches(locks []*txAcctLocks) [][]int {
+	// the number of the batch following the last that writes or reads each account
+	nextAfterWrite := make(map[solana.PublicKey]int)
+	nextAfterRead := make(map[solana.PublicKey]int)
+
+	var batches [][]int
+	for idx, txLocks := range locks {
+		var batch int
+		for _, pubkey := range txLocks.writable {
+			batch = max(batch, nextAfterWrite[pubkey], nextAfterRead[pubkey])
+		}
+		for _, pubkey := range txLocks.readonly {
+			batch = max(batch, nextAfterWrite[pubkey])
+		}
+
+		for _, pubkey := range txLocks.writable {
+			nextAfterWrite[pubkey] = batch + 1
+		}
+		for _, pubkey := range txLocks.readonly {
+			nextAfterRead[pubkey] = max(nextAfterRead[pubkey], batch+1)
+		}
+
+		if batch == len(batches) {
+			batches = append(batches, nil)
+		}
+		batches[batch] = append(batches[batch], idx)
+	}
+
+	return batches
+}
+
+// executeTransactions executes the block's transactions against the slot's accounts,
+// returning the status metadata of each. Transactions that failed verification, as given by
+// verifyErrs, are not executed, and their status is the verification error. nonceTxs marks
+// the durable nonce transactions, as determined by checkTransactions. With more than
+// one worker, transactions are scheduled into batches of non-conflicting transactions, which
+// are executed concurrently.
+func executeTransactions(slotCtx *sealevel.SlotCtx, block *Block, verifyErrs []*sealevel.TransactionError, nonceTxs []bool, numWorkers int) ([]*TransactionStatusMeta, error) {
+	statuses := make([]*TransactionStatusMeta, len(block.Transactions))
+
+	executeTx := func(idx int) {
+		tx := block.Transactions[idx]
+		if verifyErrs[idx] != nil {
+			klog.Infof("[+] not executing transaction %d, %s, which failed verification: %s", idx+1, tx.Signatures[0], verifyErrs[idx])
+			statuses[idx] = &TransactionStatusMeta{Err: verifyErrs[idx]}
+			return
+		}
+
+		klog.Infof("[+] executing transaction %d, %s", idx+1, tx.Signatures[0])
+		statuses[idx] = ProcessTransaction(slotCtx, tx, nonceTxs[idx])
+	}
+
+	if numWorkers <= 1 {
+		for idx := range block.Transactions {
+			executeTx(idx)
+		}
+		return statuses, nil
+	}
+
+	locks := make([]*txAcctLocks, len(block.Transactions))
+	for idx, tx := range block.Transactions {
+		// txs that are not executed take no locks
+		if verifyErrs[idx] != nil {
+			locks[idx] = new(txAcctLocks)
+			continue
+		}
+
+		txLocks, err := txAccountLocks(slotCtx, tx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine accounts of tx %s: %w", tx.Signatures[0], err)
+		}
+		locks[idx] = txLocks
+	}
+
+	batches := scheduleTxBatches(locks)
+	klog.Infof("executing %d transactions in %d batches with %d workers", len(block.Transactions), len(batches), numWorkers)
+
+	for _, batch := range batches {
+		var group errgroup.Group
+		group.SetLimit(numWorkers)
+
+		for _, idx := range batch {
+			group.Go(func() error {
+				executeTx(idx)
+				return nil
+			})
+		}
+
+		_ = group.Wait()
+	}
+
+	return statuses, nil
+}
+
+// cloneSlotCtx returns a copy of the slot context with its own copy of the slot's accounts
+// and set of modified accounts, such that transactions may be executed against it without
+// affecting the original.
+func cloneSlotCtx(slotCtx *sealevel.SlotCtx) *sealevel.SlotCtx {
+	clone := &sealevel.SlotCtx{Slot: slotCtx.Slot, Epoch: slotCtx.Epoch, ParentSlot: slotCtx.ParentSlot,
+		Accounts: slotCtx.Accounts.(accounts.MemAccounts).Clone(), AccountsDb: slotCtx.AccountsDb,
+		Blockhash: slotCtx.Blockhash, LamportsPerSignature: slotCtx.LamportsPerSignature, Replay: slotCtx.Replay, Features: slotCtx.Features,
+		EpochTotalStake: slotCtx.EpochTotalStake, EpochVoteAccountStakes: slotCtx.EpochVoteAccountStakes}
+
+	clone.ModifiedAccts = make(map[solana.PublicKey]bool, len(slotCtx.ModifiedAccts))
+	for pubkey := range slotCtx.ModifiedAccts {
+		clone.ModifiedAccts[pubkey] = true
+	}
+
+	return clone
+}
+
+// checkSerialEquivalence compares the outcome of executing a block's transactions in parallel
+// with that of executing them serially, returning ErrSerialEquivalence if any transaction's
+// fee, error, compute units consumed or balances, or the state of any modified account,
+// differs. The accounts delta hash, and hence the bankhash, is derived from the modified
+// accounts, so is identical if they are.
+func checkSerialEquivalence(block *Block, parallelSlotCtx *sealevel.SlotCtx, parallelStatuses []*TransactionStatusMeta,
+	serialSlotCtx *sealevel.SlotCtx, serialStatuses []*TransactionStatusMeta) error {
+	for idx, tx := range block.Transactions {
+		parallel, serial := parallelStatuses[idx], serialStatuses[idx]
+		if parallel.Fee != serial.Fee {
+			return fmt.Errorf("%w: slot %d tx %s fee was %d in parallel, but %d serially", ErrSerialEquivalence,
+				block.Slot, tx.Signatures[0], parallel.Fee, serial.Fee)
+		}
+		if (parallel.Err == nil) != (serial.Err == nil) || (parallel.Err != nil && *parallel.Err != *serial.Err) {
+			return fmt.Errorf("%w: slot %d tx %s err was %v in parallel, but %v serially", ErrSerialEquivalence,
+				block.Slot, tx.Signatures[0], parallel.Err, serial.Err)
+		}
+		if parallel.ComputeUnitsConsumed != serial.ComputeUnitsConsumed {
+			return fmt.Errorf("%w: slot %d tx %s consumed %d CUs in parallel, but %d serially", ErrSerialEquivalence,
+				block.Slot, tx.Signatures[0], parallel.ComputeUnitsConsumed, serial.ComputeUnitsConsumed)
+		}
+		if !slices.Equal(parallel.PreBalances, serial.PreBalances) || !slices.Equal(parallel.PostBalances, serial.PostBalances) {
+			return fmt.Errorf("%w: slot %d tx %s balances were %v -> %v in parallel, but %v -> %v serially", ErrSerialEquivalence,
+				block.Slot, tx.Signatures[0], parallel.PreBalances, parallel.PostBalances, serial.PreBalances, serial.PostBalances)
+		}
+	}
+
+	if len(parallelSlotCtx.ModifiedAccts) != len(serialSlotCtx.ModifiedAccts) {
+		return fmt.Errorf("%w: slot %d had %d modified accounts in parallel, but %d serially", ErrSerialEquivalence,
+			block.Slot, len(parallelSlotCtx.ModifiedAccts), len(serialSlotCtx.ModifiedAccts))
+	}
+
+	for pubkey := range parallelSlotCtx.ModifiedAccts {
+		if !serialSlotCtx.ModifiedAccts[pubkey] {
+			return fmt.Errorf("%w: slot %d account %s modified in parallel, but not serially", ErrSerialEquivalence, block.Slot, pubkey)
+		}
+
+		parallelAcct, err := parallelSlotCtx.GetAccount(pubkey)
+		if err != nil {
+			return err
+		}
+		serialAcct, err := serialSlotCtx.GetAccount(pubkey)
+		if err != nil {
+			return err
+		}
+
+		if !accountStatesEqual(parallelAcct, serialAcct) {
+			return fmt.Errorf("%w: slot %d account %s state differs (lamports %d, owner %s, %d bytes of data in parallel; lamports %d, owner %s, %d bytes of data serially)",
+				ErrSerialEquivalence, block.Slot, pubkey, parallelAcct.Lamports, base58.Encode(parallelAcct.Owner[:]), len(parallelAcct.Data),
+				serialAcct.Lamports, base58.Encode(serialAcct.Owner[:]), len(serialAcct.Data))
+		}
+	}
+
+	return nil
+}
+
+// accountStatesEqual returns true if the accounts are identical in the fields included in the
+// accounts delta hash.
+func accountStatesEqual(a *accounts.Account, b *accounts.Account) bool {
+	return a.Key == b.Key && a.Lamports == b.Lamports && a.Owner == b.Owner && a.Executable == b.Executable &&
+		a.RentEpoch == b.RentEpoch && string(a.Data) == string(b.Data)
+}
@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"bytes"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestScheduleTxBatches(t *testing.T) {
+	a, b, c, d := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	locks := []*txAcctLocks{
+		{writable: []solana.PublicKey{a}, readonly: []solana.PublicKey{c}},
+		{writable: []solana.PublicKey{b}, readonly: []solana.PublicKey{c}},
+		// reads an account written by tx 0
+		{readonly: []solana.PublicKey{a}},
+		// writes an account read by txs 0 and 1, but not tx 2
+		{writable: []solana.PublicKey{c}},
+		// writes an account read by tx 2
+		{writable: []solana.PublicKey{a}},
+		// conflicts with no other tx
+		{writable: []solana.PublicKey{d}},
+		// reads an account written by tx 4 and read by tx 2
+		{readonly: []solana.PublicKey{a, c}},
+	}
+
+	batches := scheduleTxBatches(locks)
+	assert.Equal(t, [][]int{{0, 1, 5}, {2, 3}, {4}, {6}}, batches)
+
+	// every pair of conflicting txs is placed in batches in block order
+	batchOf := make(map[int]int)
+	for batchIdx, batch := range batches {
+		for _, txIdx := range batch {
+			batchOf[txIdx] = batchIdx
+		}
+	}
+	for i := range locks {
+		for j := i + 1; j < len(locks); j++ {
+			if txLocksConflict(locks[i], locks[j]) {
+				assert.Less(t, batchOf[i], batchOf[j], "txs %d and %d conflict", i, j)
+			}
+		}
+	}
+}
+
+func txLocksConflict(x *txAcctLocks, y *txAcctLocks) bool {
+	for _, w := range x.writable {
+		for _, other := range append(append([]solana.PublicKey{}, y.writable...), y.readonly...) {
+			if w == other {
+				return true
+			}
+		}
+	}
+	for _, r := range x.readonly {
+		for _, other := range y.writable {
+			if r == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestTxAccountLocksProgramData(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	programId := solana.NewWallet().PublicKey()
+	programDataAddr := solana.NewWallet().PublicKey()
+	acct := solana.NewWallet().PublicKey()
+
+	programState := sealevel.UpgradeableLoaderState{Type: sealevel.UpgradeableLoaderStateTypeProgram,
+		Program: sealevel.UpgradeableLoaderStateProgram{ProgramDataAddress: programDataAddr}}
+	buf := new(bytes.Buffer)
+	require.NoError(t, programState.MarshalWithEncoder(bin.NewBinEncoder(buf)))
+
+	slotAccts := accounts.NewMemAccounts()
+	slotAccts.Map[programId] = &accounts.Account{Key: programId, Owner: sealevel.BpfLoaderUpgradeableAddr, Executable: true, Data: buf.Bytes()}
+	slotCtx := &sealevel.SlotCtx{Accounts: slotAccts}
+
+	instr := solana.NewInstruction(programId, solana.AccountMetaSlice{solana.Meta(acct).WRITE()}, []byte{})
+	tx, err := solana.NewTransaction([]solana.Instruction{instr}, solana.Hash{}, solana.TransactionPayer(payer))
+	require.NoError(t, err)
+
+	locks, err := txAccountLocks(slotCtx, tx)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []solana.PublicKey{payer, acct}, locks.writable)
+	assert.ElementsMatch(t, []solana.PublicKey{programId, programDataAddr}, locks.readonly)
+}
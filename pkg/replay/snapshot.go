@@ -0,0 +1,148 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+var ErrBankStateUnknown = errors.New("ErrBankStateUnknown")
+
+// snapshotManifest returns a manifest describing the bank at the most recently replayed slot.
+//
+// A manifest can only be described once blocks have been replayed from the manifest's slot,
+// since the blockhash queue, stakes and transaction count are otherwise unknown. Nor can one be
+// described whilst a partitioned epoch rewards distribution is in progress, or after an
+// inflation feature was activated, since the rewards and inflation parameters are not tracked.
+func (replayCtx *ReplayCtx) snapshotManifest() (*snapshot.SnapshotManifest, error) {
+	manifest := *replayCtx.Manifest
+	manifest.BankIncrementalSnapshotPersistence = snapshot.BankIncrementalSnapshotPersistence{}
+
+	// the status cache of the snapshot that replay began from is not loaded, and so cannot
+	// be carried over into a snapshot at the same slot
+	bank := replayCtx.lastBank
+	if bank == nil {
+		return nil, fmt.Errorf("%w: no blocks have been replayed since slot %d", ErrBankStateUnknown, replayCtx.Manifest.Bank.Slot)
+	}
+
+	if replayCtx.blockhashQueue == nil || replayCtx.stakes == nil {
+		return nil, fmt.Errorf("%w: replay did not begin from the manifest's slot %d", ErrBankStateUnknown, replayCtx.Manifest.Bank.Slot)
+	}
+	if replayCtx.epochRewards != nil {
+		return nil, fmt.Errorf("%w: a partitioned epoch rewards distribution is in progress", ErrBankStateUnknown)
+	}
+	if replayCtx.inflationChanged {
+		return nil, fmt.Errorf("%w: an inflation feature was activated since slot %d", ErrBankStateUnknown, replayCtx.Manifest.Bank.Slot)
+	}
+
+	manifest.Bank.Slot = bank.slot
+	manifest.Bank.ParentSlot = bank.parentSlot
+	manifest.Bank.Epoch = bank.epoch
+	manifest.Bank.Hash = bank.bankHash
+	manifest.Bank.ParentHash = bank.parentBankHash
+	manifest.Bank.SignatureCount = bank.numSignatures
+	manifest.Bank.TransactionCount = replayCtx.transactionCount
+	manifest.Bank.BlockhashQueue = replayCtx.blockhashQueue.blockHashVec()
+	manifest.Bank.Ancestors = []snapshot.SlotPair{{Slot: bank.slot}}
+	manifest.Bank.BlockHeight = replayCtx.Manifest.Bank.BlockHeight + replayCtx.numBlocksReplayed
+	manifest.Bank.TickHeight = (bank.slot + 1) * manifest.Bank.TicksPerSlot
+	manifest.Bank.MaxTickHeight = manifest.Bank.TickHeight
+	manifest.Bank.CollectorId = bank.collectorId
+	manifest.Bank.CollectorFees = bank.collectorFees
+	manifest.Bank.CollectedRent = bank.collectedRent
+	manifest.Bank.FeeRateGovernor = deriveFeeRateGovernor(&replayCtx.Manifest.Bank.FeeRateGovernor)
+	manifest.AccountsDb.BankHashInfo.Hash = bank.acctsDeltaHash
+
+	// the inflation parameters are unchanged in the absence of inflation feature activations,
+	// and the slot duration (ns_per_slot) is fixed at genesis
+
+	manifest.Bank.EpochStakes = replayCtx.epochStakes
+	manifest.Bank.Stakes = replayCtx.stakes.stakes()
+	manifest.Bank.Capitalization = replayCtx.capitalization
+	manifest.EpochRewardStatus = snapshot.SerializableEpochRewardStatus{}
+
+	// the EAH is included in snapshots taken between the EAH start and stop slots
+	manifest.EpochAccountHash = [32]byte{}
+	epochSchedule := &manifest.Bank.EpochSchedule
+	if replayCtx.eah != nil && replayCtx.eah.epoch == bank.epoch && bank.slot < eahStopSlot(epochSchedule, bank.epoch) {
+		eah, err := replayCtx.epochAccountsHash(bank.epoch)
+		if err != nil {
+			return nil, err
+		}
+		manifest.EpochAccountHash = eah
+	}
+
+	return &manifest, nil
+}
+
+// deriveFeeRateGovernor returns the fee rate governor of a bank derived from that of its
+// parent, of which the fee bounds are derived from the target fee.
+func deriveFeeRateGovernor(parent *snapshot.FeeRateGovernor) snapshot.FeeRateGovernor {
+	governor := *parent
+	if governor.TargetSignaturesPerSlot > 0 {
+		governor.MinLamportsPerSignature = max(1, governor.TargetLamportsPerSignature/2)
+		governor.MaxLamportsPerSignature = governor.TargetLamportsPerSignature * 10
+	} else {
+		governor.MinLamportsPerSignature = governor.TargetLamportsPerSignature
+		governor.MaxLamportsPerSignature = governor.TargetLamportsPerSignature
+	}
+	return governor
+}
+
+// CreateFullSnapshot writes a full snapshot archive of the AccountsDB as of the most recently
+// replayed slot into outputDir, returning the path of the archive.
+func (replayCtx *ReplayCtx) CreateFullSnapshot(outputDir string) (string, error) {
+	manifest, err := replayCtx.snapshotManifest()
+	if err != nil {
+		return "", err
+	}
+
+	accountsHash, capitalization, err := CalculateAccountsHash(replayCtx.AccountsDb, manifest.Bank.Slot)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate accounts hash: %w", err)
+	}
+
+	manifest.AccountsDb.BankHashInfo.SnapshotHash = accountsHash
+	manifest.Bank.Capitalization = capitalization
+
+	return snapshot.WriteFullSnapshot(replayCtx.AccountsDb, manifest, replayCtx.statusCache.bankSlotDeltas(), outputDir)
+}
+
+// CreateIncrementalSnapshot writes an incremental snapshot archive of the accounts modified
+// between fullSlot and the most recently replayed slot into outputDir, returning the path
+// of the archive. A full snapshot at fullSlot is required in order to load it.
+func (replayCtx *ReplayCtx) CreateIncrementalSnapshot(fullSlot uint64, outputDir string) (string, error) {
+	manifest, err := replayCtx.snapshotManifest()
+	if err != nil {
+		return "", err
+	}
+
+	slot := manifest.Bank.Slot
+	if fullSlot >= slot {
+		return "", fmt.Errorf("full snapshot slot %d must precede the replayed slot %d", fullSlot, slot)
+	}
+
+	fullHash, fullCapitalization, err := CalculateAccountsHash(replayCtx.AccountsDb, fullSlot)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate full accounts hash: %w", err)
+	}
+
+	incrementalHash, incrementalCapitalization, err := CalculateIncrementalAccountsHash(replayCtx.AccountsDb, fullSlot, slot)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate incremental accounts hash: %w", err)
+	}
+
+	// the bank's capitalization is over all accounts, rather than just those modified since the full snapshot
+	_, capitalization, err := CalculateAccountsHash(replayCtx.AccountsDb, slot)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate capitalization: %w", err)
+	}
+
+	manifest.Bank.Capitalization = capitalization
+	manifest.AccountsDb.BankHashInfo.SnapshotHash = [32]byte{}
+	manifest.BankIncrementalSnapshotPersistence = snapshot.BankIncrementalSnapshotPersistence{FullSlot: fullSlot, FullHash: fullHash,
+		FullCapitalization: fullCapitalization, IncrementalHash: incrementalHash, IncrementalCapitalization: incrementalCapitalization}
+
+	return snapshot.WriteIncrementalSnapshot(replayCtx.AccountsDb, manifest, replayCtx.statusCache.bankSlotDeltas(), outputDir)
+}
@@ -0,0 +1,311 @@
+package replay
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// the number of epochs of stake history retained in the StakeHistory sysvar
+const maxStakeHistoryEntries = 512
+
+// the number of epochs prior to the leader schedule epoch for which epoch stakes are retained
+const maxLeaderScheduleStakes = 5
+
+// stakedVoteAccount is a vote account along with the stake delegated to it, which is
+// effective as of the stakes cache's epoch.
+type stakedVoteAccount struct {
+	stake   uint64
+	account snapshot.VoteAccount
+}
+
+// stakesCache tracks the stake delegations and vote accounts of the cluster as of the most
+// recently replayed slot, as the runtime's stakes cache does. It is initialized from the
+// stakes in the snapshot manifest, and kept up to date with the stake and vote accounts
+// modified by each replayed block.
+type stakesCache struct {
+	voteAccounts map[solana.PublicKey]*stakedVoteAccount
+	delegations  map[solana.PublicKey]sealevel.Delegation
+	stakeHistory sealevel.SysvarStakeHistory
+	epoch        uint64
+}
+
+func newStakesCache(stakes *snapshot.Stakes) *stakesCache {
+	cache := &stakesCache{voteAccounts: make(map[solana.PublicKey]*stakedVoteAccount, len(stakes.VoteAccounts)),
+		delegations:  make(map[solana.PublicKey]sealevel.Delegation, len(stakes.StakeDelegations)),
+		stakeHistory: append(sealevel.SysvarStakeHistory{}, stakes.StakeHistory...), epoch: stakes.Epoch}
+
+	for _, voteAcct := range stakes.VoteAccounts {
+		cache.voteAccounts[voteAcct.Key] = &stakedVoteAccount{stake: voteAcct.Stake, account: voteAcct.Value}
+	}
+
+	for _, delegation := range stakes.StakeDelegations {
+		cache.delegations[delegation.Account] = sealevel.Delegation{VoterPubkey: delegation.Delegation.VoterPubkey,
+			StakeLamports: delegation.Delegation.Stake, ActivationEpoch: delegation.Delegation.ActivationEpoch,
+			DeactivationEpoch: delegation.Delegation.DeactivationEpoch, WarmupCooldownRate: delegation.Delegation.WarmupCooldownRate}
+	}
+
+	return cache
+}
+
+// clone returns a copy of the stakes cache that is unaffected by changes to the original.
+func (cache *stakesCache) clone() *stakesCache {
+	clone := &stakesCache{voteAccounts: make(map[solana.PublicKey]*stakedVoteAccount, len(cache.voteAccounts)),
+		delegations:  make(map[solana.PublicKey]sealevel.Delegation, len(cache.delegations)),
+		stakeHistory: append(sealevel.SysvarStakeHistory{}, cache.stakeHistory...), epoch: cache.epoch}
+
+	for pubkey, voteAcct := range cache.voteAccounts {
+		clonedVoteAcct := *voteAcct
+		clone.voteAccounts[pubkey] = &clonedVoteAcct
+	}
+	for pubkey, delegation := range cache.delegations {
+		clone.delegations[pubkey] = delegation
+	}
+
+	return clone
+}
+
+// newWarmupCooldownRateEpoch returns the epoch from which the reduced stake warmup and
+// cooldown rate applies, if it has been activated.
+func newWarmupCooldownRateEpoch(epochSchedule *sealevel.SysvarEpochSchedule, f *features.Features) *uint64 {
+	slot, activated := f.ActivationSlot(features.ReduceStakeWarmupCooldown)
+	if !activated {
+		return nil
+	}
+
+	epoch := epochSchedule.GetEpoch(slot)
+	return &epoch
+}
+
+func (cache *stakesCache) delegationStake(delegation *sealevel.Delegation, newRateActivationEpoch *uint64) uint64 {
+	return delegation.Stake(cache.epoch, cache.stakeHistory, newRateActivationEpoch)
+}
+
+func (cache *stakesCache) addStake(voterPubkey solana.PublicKey, stake uint64) {
+	if voteAcct, exists := cache.voteAccounts[voterPubkey]; exists {
+		voteAcct.stake += stake
+	}
+}
+
+func (cache *stakesCache) subStake(voterPubkey solana.PublicKey, stake uint64) {
+	if voteAcct, exists := cache.voteAccounts[voterPubkey]; exists {
+		voteAcct.stake -= min(voteAcct.stake, stake)
+	}
+}
+
+// storeAccount updates the stakes cache with a stake or vote account modified in a slot.
+// Accounts owned by other programs are ignored.
+func (cache *stakesCache) storeAccount(acct *accounts.Account, newRateActivationEpoch *uint64) {
+	switch acct.Owner {
+	case sealevel.VoteProgramAddr:
+		if acct.Lamports == 0 {
+			delete(cache.voteAccounts, acct.Key)
+			return
+		}
+
+		voteStateVersions, err := sealevel.UnmarshalVersionedVoteState(acct.Data)
+		if err != nil || !voteStateVersions.IsInitialized() {
+			delete(cache.voteAccounts, acct.Key)
+			return
+		}
+		voteState := voteStateVersions.ConvertToCurrent()
+
+		voteAcct := snapshot.VoteAccount{Lamports: acct.Lamports, Data: bytes.Clone(acct.Data), NodePubkey: voteState.NodePubkey,
+			LastTimestampTs: voteState.LastTimestamp.Timestamp, LastTimestampSlot: voteState.LastTimestamp.Slot, Owner: solana.PublicKey(acct.Owner),
+			RentEpoch: acct.RentEpoch}
+		if acct.Executable {
+			voteAcct.Executable = 1
+		}
+
+		// the stake of a vote account new to the cache is that of the delegations to it
+		if existing, exists := cache.voteAccounts[acct.Key]; exists {
+			existing.account = voteAcct
+			return
+		}
+
+		var stake uint64
+		for _, delegation := range cache.delegations {
+			if delegation.VoterPubkey == acct.Key {
+				stake += cache.delegationStake(&delegation, newRateActivationEpoch)
+			}
+		}
+		cache.voteAccounts[acct.Key] = &stakedVoteAccount{stake: stake, account: voteAcct}
+
+	case sealevel.StakeProgramAddr:
+		var stakeState *sealevel.StakeStateV2
+		if acct.Lamports != 0 {
+			stakeState, _ = sealevel.UnmarshalStakeState(acct.Data)
+		}
+
+		oldDelegation, existed := cache.delegations[acct.Key]
+		if existed {
+			cache.subStake(oldDelegation.VoterPubkey, cache.delegationStake(&oldDelegation, newRateActivationEpoch))
+		}
+
+		if stakeState == nil || stakeState.Status != sealevel.StakeStateV2StatusStake {
+			delete(cache.delegations, acct.Key)
+			return
+		}
+
+		delegation := stakeState.Stake.Stake.Delegation
+		cache.delegations[acct.Key] = delegation
+		cache.addStake(delegation.VoterPubkey, cache.delegationStake(&delegation, newRateActivationEpoch))
+	}
+}
+
+// addStakeHistoryEntry adds the entry for the epoch to the stake history, which is ordered
+// from the most recent epoch and limited to maxStakeHistoryEntries epochs.
+func addStakeHistoryEntry(stakeHistory sealevel.SysvarStakeHistory, epoch uint64, entry sealevel.StakeHistoryEntry) sealevel.SysvarStakeHistory {
+	idx := sort.Search(len(stakeHistory), func(idx int) bool {
+		return stakeHistory[idx].Epoch <= epoch
+	})
+
+	if idx < len(stakeHistory) && stakeHistory[idx].Epoch == epoch {
+		stakeHistory[idx].Entry = entry
+	} else {
+		stakeHistory = append(stakeHistory, sealevel.StakeHistoryPair{})
+		copy(stakeHistory[idx+1:], stakeHistory[idx:])
+		stakeHistory[idx] = sealevel.StakeHistoryPair{Epoch: epoch, Entry: entry}
+	}
+
+	return stakeHistory[:min(len(stakeHistory), maxStakeHistoryEntries)]
+}
+
+// activateEpoch records the stake activating, deactivating and in effect during the cache's
+// epoch in the stake history, and moves the cache on to the next epoch, with the stake of
+// each vote account being that which is effective as of the next epoch.
+func (cache *stakesCache) activateEpoch(nextEpoch uint64, newRateActivationEpoch *uint64) {
+	var entry sealevel.StakeHistoryEntry
+	for _, delegation := range cache.delegations {
+		status := delegation.StakeActivatingAndDeactivating(cache.epoch, cache.stakeHistory, newRateActivationEpoch)
+		entry.Effective += status.Effective
+		entry.Activating += status.Activating
+		entry.Deactivating += status.Deactivating
+	}
+
+	cache.stakeHistory = addStakeHistoryEntry(cache.stakeHistory, cache.epoch, entry)
+	cache.epoch = nextEpoch
+
+	for _, voteAcct := range cache.voteAccounts {
+		voteAcct.stake = 0
+	}
+	for _, delegation := range cache.delegations {
+		cache.addStake(delegation.VoterPubkey, cache.delegationStake(&delegation, newRateActivationEpoch))
+	}
+}
+
+func sortedPubkeys[V any](m map[solana.PublicKey]V) []solana.PublicKey {
+	pubkeys := make([]solana.PublicKey, 0, len(m))
+	for pubkey := range m {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Slice(pubkeys, func(i, j int) bool {
+		return bytes.Compare(pubkeys[i][:], pubkeys[j][:]) < 0
+	})
+	return pubkeys
+}
+
+// stakes returns the contents of the stakes cache in the form of the manifest's stakes.
+func (cache *stakesCache) stakes() snapshot.Stakes {
+	stakes := snapshot.Stakes{Epoch: cache.epoch, StakeHistory: append(sealevel.SysvarStakeHistory{}, cache.stakeHistory...)}
+
+	for _, pubkey := range sortedPubkeys(cache.voteAccounts) {
+		voteAcct := cache.voteAccounts[pubkey]
+		stakes.VoteAccounts = append(stakes.VoteAccounts, snapshot.VoteAccountsPair{Key: pubkey, Stake: voteAcct.stake, Value: voteAcct.account})
+	}
+
+	for _, pubkey := range sortedPubkeys(cache.delegations) {
+		delegation := cache.delegations[pubkey]
+		stakes.StakeDelegations = append(stakes.StakeDelegations, snapshot.DelegationPair{Account: pubkey,
+			Delegation: snapshot.Delegation{VoterPubkey: delegation.VoterPubkey, Stake: delegation.StakeLamports,
+				ActivationEpoch: delegation.ActivationEpoch, DeactivationEpoch: delegation.DeactivationEpoch,
+				WarmupCooldownRate: delegation.WarmupCooldownRate}})
+	}
+
+	return stakes
+}
+
+// epochStakes returns the stakes of the cache as the epoch stakes for the given leader
+// schedule epoch, with the staked vote accounts grouped by node, and their authorized
+// voters for the epoch.
+func (cache *stakesCache) epochStakes(leaderScheduleEpoch uint64) snapshot.EpochStakes {
+	epochStakes := snapshot.EpochStakes{Stakes: cache.stakes()}
+
+	nodeVoteAccts := make(map[solana.PublicKey]*snapshot.NodeVoteAccounts)
+	for _, voteAcct := range epochStakes.Stakes.VoteAccounts {
+		epochStakes.TotalStake += voteAcct.Stake
+		if voteAcct.Stake == 0 {
+			continue
+		}
+
+		voteStateVersions, err := sealevel.UnmarshalVersionedVoteState(voteAcct.Value.Data)
+		if err != nil {
+			continue
+		}
+		voteState := voteStateVersions.ConvertToCurrent()
+
+		authorizedVoter, _, err := voteState.AuthorizedVoters.GetOrCalculateAuthorizedVoterForEpoch(leaderScheduleEpoch)
+		if err != nil {
+			continue
+		}
+
+		nodeVoteAcct, exists := nodeVoteAccts[voteState.NodePubkey]
+		if !exists {
+			nodeVoteAcct = &snapshot.NodeVoteAccounts{}
+			nodeVoteAccts[voteState.NodePubkey] = nodeVoteAcct
+		}
+		nodeVoteAcct.VoteAccounts = append(nodeVoteAcct.VoteAccounts, voteAcct.Key)
+		nodeVoteAcct.TotalStake += voteAcct.Stake
+
+		epochStakes.EpochAuthorizedVoters = append(epochStakes.EpochAuthorizedVoters, snapshot.PubkeyPair{Key: voteAcct.Key, Val: authorizedVoter})
+	}
+
+	for _, nodePubkey := range sortedPubkeys(nodeVoteAccts) {
+		epochStakes.NodeIdToVoteAccounts = append(epochStakes.NodeIdToVoteAccounts,
+			snapshot.NodeVoteAccountsPair{Key: nodePubkey, Val: *nodeVoteAccts[nodePubkey]})
+	}
+
+	return epochStakes
+}
+
+// updateEpochStakes adds the epoch stakes for the leader schedule epoch from the stakes
+// cache, if they have not already been added, dropping those of epochs no longer needed.
+func updateEpochStakes(epochStakes []snapshot.EpochStakesPair, cache *stakesCache, leaderScheduleEpoch uint64) []snapshot.EpochStakesPair {
+	for _, pair := range epochStakes {
+		if pair.Key == leaderScheduleEpoch {
+			return epochStakes
+		}
+	}
+
+	var retained []snapshot.EpochStakesPair
+	for _, pair := range epochStakes {
+		if pair.Key+maxLeaderScheduleStakes >= leaderScheduleEpoch {
+			retained = append(retained, pair)
+		}
+	}
+
+	return append(retained, snapshot.EpochStakesPair{Key: leaderScheduleEpoch, Val: cache.epochStakes(leaderScheduleEpoch)})
+}
+
+// setEpochStakes records the total stake of the slot's epoch, and the stake delegated to each
+// vote account during the epoch, from the epoch stakes, for use by the sol_get_epoch_stake
+// syscall.
+func setEpochStakes(slotCtx *sealevel.SlotCtx, epochStakes []snapshot.EpochStakesPair) {
+	for _, pair := range epochStakes {
+		if pair.Key != slotCtx.Epoch {
+			continue
+		}
+
+		slotCtx.EpochTotalStake = pair.Val.TotalStake
+		slotCtx.EpochVoteAccountStakes = make(map[solana.PublicKey]uint64, len(pair.Val.Stakes.VoteAccounts))
+		for _, voteAcct := range pair.Val.Stakes.VoteAccounts {
+			slotCtx.EpochVoteAccountStakes[voteAcct.Key] = voteAcct.Stake
+		}
+		return
+	}
+}
@@ -0,0 +1,187 @@
+package replay
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+func newTestVoteAccount(t *testing.T, pubkey solana.PublicKey, nodePubkey solana.PublicKey, commission byte,
+	epochCredits []sealevel.EpochCredits) *accounts.Account {
+	voteState := sealevel.VoteStateVersions{Type: sealevel.VoteStateVersionCurrent,
+		Current: sealevel.VoteState{NodePubkey: nodePubkey, Commission: commission, EpochCredits: epochCredits}}
+	voteState.Current.AuthorizedVoters.AuthorizedVoters.Set(0, nodePubkey)
+
+	data := new(bytes.Buffer)
+	require.NoError(t, voteState.MarshalWithEncoder(bin.NewBinEncoder(data)))
+
+	return &accounts.Account{Key: pubkey, Lamports: 1000000, Data: data.Bytes(), Owner: sealevel.VoteProgramAddr}
+}
+
+func newTestStakeAccount(t *testing.T, pubkey solana.PublicKey, voterPubkey solana.PublicKey, stake uint64, activationEpoch uint64,
+	creditsObserved uint64) *accounts.Account {
+	stakeState := &sealevel.StakeStateV2{Status: sealevel.StakeStateV2StatusStake}
+	stakeState.Stake.Stake = sealevel.Stake{CreditsObserved: creditsObserved, Delegation: sealevel.Delegation{VoterPubkey: voterPubkey,
+		StakeLamports: stake, ActivationEpoch: activationEpoch, DeactivationEpoch: math.MaxUint64, WarmupCooldownRate: 0.25}}
+
+	stakeStateBytes, err := sealevel.MarshalStakeState(stakeState)
+	require.NoError(t, err)
+	data := make([]byte, 200)
+	copy(data, stakeStateBytes)
+
+	return &accounts.Account{Key: pubkey, Lamports: stake + 2282880, Data: data, Owner: sealevel.StakeProgramAddr}
+}
+
+func TestAddStakeHistoryEntry(t *testing.T) {
+	var stakeHistory sealevel.SysvarStakeHistory
+	stakeHistory = addStakeHistoryEntry(stakeHistory, 5, sealevel.StakeHistoryEntry{Effective: 5})
+	stakeHistory = addStakeHistoryEntry(stakeHistory, 7, sealevel.StakeHistoryEntry{Effective: 7})
+	stakeHistory = addStakeHistoryEntry(stakeHistory, 6, sealevel.StakeHistoryEntry{Effective: 6})
+	stakeHistory = addStakeHistoryEntry(stakeHistory, 7, sealevel.StakeHistoryEntry{Effective: 8})
+
+	assert.Equal(t, sealevel.SysvarStakeHistory{{Epoch: 7, Entry: sealevel.StakeHistoryEntry{Effective: 8}},
+		{Epoch: 6, Entry: sealevel.StakeHistoryEntry{Effective: 6}}, {Epoch: 5, Entry: sealevel.StakeHistoryEntry{Effective: 5}}}, stakeHistory)
+
+	// the oldest entries are dropped
+	for epoch := uint64(8); epoch < 8+maxStakeHistoryEntries; epoch++ {
+		stakeHistory = addStakeHistoryEntry(stakeHistory, epoch, sealevel.StakeHistoryEntry{})
+	}
+	assert.Len(t, stakeHistory, maxStakeHistoryEntries)
+	assert.Equal(t, uint64(7+maxStakeHistoryEntries), stakeHistory[0].Epoch)
+	assert.Equal(t, uint64(8), stakeHistory[len(stakeHistory)-1].Epoch)
+}
+
+func TestStakesCacheStoreAccount(t *testing.T) {
+	cache := newStakesCache(&snapshot.Stakes{Epoch: 10})
+
+	votePubkey := solana.NewWallet().PublicKey()
+	nodePubkey := solana.NewWallet().PublicKey()
+	cache.storeAccount(newTestVoteAccount(t, votePubkey, nodePubkey, 10, nil), nil)
+	require.Contains(t, cache.voteAccounts, votePubkey)
+	assert.Zero(t, cache.voteAccounts[votePubkey].stake)
+	assert.Equal(t, nodePubkey, cache.voteAccounts[votePubkey].account.NodePubkey)
+
+	// stake delegated prior to the stake history is fully effective
+	stakePubkey := solana.NewWallet().PublicKey()
+	cache.storeAccount(newTestStakeAccount(t, stakePubkey, votePubkey, 1000, 0, 0), nil)
+	assert.Equal(t, uint64(1000), cache.voteAccounts[votePubkey].stake)
+
+	// stake delegated in the cache's epoch is yet to activate
+	activatingPubkey := solana.NewWallet().PublicKey()
+	cache.storeAccount(newTestStakeAccount(t, activatingPubkey, votePubkey, 1000, 10, 0), nil)
+	assert.Equal(t, uint64(1000), cache.voteAccounts[votePubkey].stake)
+
+	// accounts owned by other programs are ignored
+	otherAcct := newTestStakeAccount(t, solana.NewWallet().PublicKey(), votePubkey, 1000, 0, 0)
+	otherAcct.Owner = sealevel.SystemProgramAddr
+	cache.storeAccount(otherAcct, nil)
+	assert.Len(t, cache.delegations, 2)
+
+	// a vote account re-added to the cache has the stake of the delegations to it
+	closedVoteAcct := newTestVoteAccount(t, votePubkey, nodePubkey, 10, nil)
+	closedVoteAcct.Lamports = 0
+	cache.storeAccount(closedVoteAcct, nil)
+	assert.NotContains(t, cache.voteAccounts, votePubkey)
+
+	cache.storeAccount(newTestVoteAccount(t, votePubkey, nodePubkey, 10, nil), nil)
+	assert.Equal(t, uint64(1000), cache.voteAccounts[votePubkey].stake)
+
+	closedStakeAcct := newTestStakeAccount(t, stakePubkey, votePubkey, 1000, 0, 0)
+	closedStakeAcct.Lamports = 0
+	cache.storeAccount(closedStakeAcct, nil)
+	assert.NotContains(t, cache.delegations, stakePubkey)
+	assert.Zero(t, cache.voteAccounts[votePubkey].stake)
+}
+
+func TestStakesCacheActivateEpoch(t *testing.T) {
+	cache := newStakesCache(&snapshot.Stakes{Epoch: 10})
+
+	votePubkey := solana.NewWallet().PublicKey()
+	cache.storeAccount(newTestVoteAccount(t, votePubkey, solana.NewWallet().PublicKey(), 10, nil), nil)
+	cache.storeAccount(newTestStakeAccount(t, solana.NewWallet().PublicKey(), votePubkey, 1000, 0, 0), nil)
+	cache.storeAccount(newTestStakeAccount(t, solana.NewWallet().PublicKey(), votePubkey, 1000, 10, 0), nil)
+
+	// activating the epoch leaves a saved epoch state unaffected, such that it can be restored
+	replayCtx := &ReplayCtx{stakes: cache, capitalization: 100}
+	saved := replayCtx.saveEpochState(true)
+
+	cache.activateEpoch(11, nil)
+	assert.Equal(t, uint64(11), cache.epoch)
+	assert.Equal(t, sealevel.SysvarStakeHistory{{Epoch: 10, Entry: sealevel.StakeHistoryEntry{Effective: 1000, Activating: 1000}}},
+		cache.stakeHistory)
+
+	// a quarter of the cluster's effective stake may warm up in an epoch
+	assert.Equal(t, uint64(1250), cache.voteAccounts[votePubkey].stake)
+
+	stakes := cache.stakes()
+	assert.Equal(t, uint64(11), stakes.Epoch)
+	assert.Len(t, stakes.StakeDelegations, 2)
+	assert.Equal(t, []snapshot.VoteAccountsPair{{Key: votePubkey, Stake: 1250, Value: cache.voteAccounts[votePubkey].account}},
+		stakes.VoteAccounts)
+
+	replayCtx.capitalization = 200
+	replayCtx.restoreEpochState(saved)
+	assert.Equal(t, uint64(100), replayCtx.capitalization)
+	assert.Equal(t, uint64(10), replayCtx.stakes.epoch)
+	assert.Empty(t, replayCtx.stakes.stakeHistory)
+	assert.Equal(t, uint64(1000), replayCtx.stakes.voteAccounts[votePubkey].stake)
+}
+
+func TestUpdateEpochStakes(t *testing.T) {
+	cache := newStakesCache(&snapshot.Stakes{Epoch: 10})
+
+	nodePubkey := solana.NewWallet().PublicKey()
+	stakedPubkey := solana.NewWallet().PublicKey()
+	unstakedPubkey := solana.NewWallet().PublicKey()
+	cache.storeAccount(newTestVoteAccount(t, stakedPubkey, nodePubkey, 10, nil), nil)
+	cache.storeAccount(newTestVoteAccount(t, unstakedPubkey, nodePubkey, 10, nil), nil)
+	cache.storeAccount(newTestStakeAccount(t, solana.NewWallet().PublicKey(), stakedPubkey, 1000, 0, 0), nil)
+
+	var epochStakes []snapshot.EpochStakesPair
+	for epoch := uint64(1); epoch <= 6; epoch++ {
+		epochStakes = append(epochStakes, snapshot.EpochStakesPair{Key: epoch})
+	}
+
+	epochStakes = updateEpochStakes(epochStakes, cache, 11)
+	require.Len(t, epochStakes, 2)
+	assert.Equal(t, uint64(6), epochStakes[0].Key)
+	assert.Equal(t, uint64(11), epochStakes[1].Key)
+
+	newEpochStakes := epochStakes[1].Val
+	assert.Equal(t, uint64(1000), newEpochStakes.TotalStake)
+	assert.Equal(t, []snapshot.NodeVoteAccountsPair{{Key: nodePubkey,
+		Val: snapshot.NodeVoteAccounts{VoteAccounts: []solana.PublicKey{stakedPubkey}, TotalStake: 1000}}}, newEpochStakes.NodeIdToVoteAccounts)
+	assert.Equal(t, []snapshot.PubkeyPair{{Key: stakedPubkey, Val: nodePubkey}}, newEpochStakes.EpochAuthorizedVoters)
+
+	// the epoch stakes of an epoch are only added once
+	assert.Equal(t, epochStakes, updateEpochStakes(epochStakes, cache, 11))
+}
+
+func TestSetEpochStakes(t *testing.T) {
+	votePubkey := solana.NewWallet().PublicKey()
+	epochStakes := []snapshot.EpochStakesPair{
+		{Key: 11, Val: snapshot.EpochStakes{TotalStake: 1000,
+			Stakes: snapshot.Stakes{VoteAccounts: []snapshot.VoteAccountsPair{{Key: votePubkey, Stake: 1000}}}}},
+		{Key: 12, Val: snapshot.EpochStakes{TotalStake: 3000,
+			Stakes: snapshot.Stakes{VoteAccounts: []snapshot.VoteAccountsPair{{Key: votePubkey, Stake: 3000}}}}},
+	}
+
+	slotCtx := &sealevel.SlotCtx{Epoch: 11}
+	setEpochStakes(slotCtx, epochStakes)
+	assert.Equal(t, uint64(1000), slotCtx.EpochTotalStake)
+	assert.Equal(t, map[solana.PublicKey]uint64{votePubkey: 1000}, slotCtx.EpochVoteAccountStakes)
+
+	// the stakes of an epoch without epoch stakes are left unset
+	slotCtx = &sealevel.SlotCtx{Epoch: 13}
+	setEpochStakes(slotCtx, epochStakes)
+	assert.Equal(t, uint64(0), slotCtx.EpochTotalStake)
+	assert.Nil(t, slotCtx.EpochVoteAccountStakes)
+}
@@ -0,0 +1,137 @@
+package replay
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/zeebo/blake3"
+	"go.firedancer.io/radiance/pkg/sealevel"
+	"go.firedancer.io/radiance/pkg/snapshot"
+)
+
+// statusCacheMaxAge is the number of slots for which processed transactions are retained in
+// the status cache, which exceeds the age at which their recent blockhashes become invalid.
+const statusCacheMaxAge = 300
+
+// statusCache records the transactions processed in recently replayed slots, keyed by their
+// recent blockhash, such that a transaction processed more than once can be rejected with
+// AlreadyProcessed. As in the runtime, transactions are identified by the hash of their
+// message, rather than by their signatures, which are malleable.
+//
+// The statuses of the transactions processed in each of the most recent statusCacheMaxAge
+// rooted slots are also retained, keyed by both message hash and signature, for inclusion in
+// snapshots. The status cache carried in snapshots is not loaded, so transactions processed
+// before the snapshot slot are neither detected nor carried into snapshots taken by replay.
+type statusCache struct {
+	entries map[[32]byte]*statusCacheEntry
+
+	roots      []uint64
+	slotDeltas map[uint64]map[[32]byte][]snapshot.TxStatus
+}
+
+type statusCacheEntry struct {
+	// the most recent slot in which a transaction with this recent blockhash was processed
+	maxSlot uint64
+
+	// the slot in which each transaction was processed, keyed by message hash
+	msgHashes map[[32]byte]uint64
+}
+
+func newStatusCache() *statusCache {
+	return &statusCache{entries: make(map[[32]byte]*statusCacheEntry), slotDeltas: make(map[uint64]map[[32]byte][]snapshot.TxStatus)}
+}
+
+// txMessageHash returns the hash that identifies the tx's message in the status cache.
+func txMessageHash(tx *solana.Transaction) ([32]byte, error) {
+	msgBytes, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	hasher := blake3.New()
+	hasher.Write([]byte("solana-tx-message-v1"))
+	hasher.Write(msgBytes)
+
+	var msgHash [32]byte
+	copy(msgHash[:], hasher.Sum(nil))
+	return msgHash, nil
+}
+
+func (cache *statusCache) contains(blockhash [32]byte, msgHash [32]byte) bool {
+	entry, ok := cache.entries[blockhash]
+	if !ok {
+		return false
+	}
+	_, ok = entry.msgHashes[msgHash]
+	return ok
+}
+
+// insert records that the tx with the given message hash and signature was processed in the
+// slot with the given outcome.
+func (cache *statusCache) insert(slot uint64, blockhash [32]byte, msgHash [32]byte, signature solana.Signature, txErr *sealevel.TransactionError) {
+	entry, ok := cache.entries[blockhash]
+	if !ok {
+		entry = &statusCacheEntry{msgHashes: make(map[[32]byte]uint64)}
+		cache.entries[blockhash] = entry
+	}
+
+	entry.maxSlot = max(entry.maxSlot, slot)
+	entry.msgHashes[msgHash] = slot
+
+	slotDelta, ok := cache.slotDeltas[slot]
+	if !ok {
+		slotDelta = make(map[[32]byte][]snapshot.TxStatus)
+		cache.slotDeltas[slot] = slotDelta
+	}
+
+	// the keys are recorded from a key index of zero
+	msgHashStatus := snapshot.TxStatus{Err: txErr}
+	copy(msgHashStatus.Key[:], msgHash[:])
+	signatureStatus := snapshot.TxStatus{Err: txErr}
+	copy(signatureStatus.Key[:], signature[:])
+	slotDelta[blockhash] = append(slotDelta[blockhash], msgHashStatus, signatureStatus)
+}
+
+// addRoot records that the slot was rooted, evicting the statuses of the oldest rooted slot
+// once more than statusCacheMaxAge slots have been rooted.
+func (cache *statusCache) addRoot(slot uint64) {
+	cache.roots = append(cache.roots, slot)
+	if len(cache.roots) > statusCacheMaxAge {
+		delete(cache.slotDeltas, cache.roots[0])
+		cache.roots = cache.roots[1:]
+	}
+}
+
+// bankSlotDeltas returns the statuses of the txs processed in each of the rooted slots, in
+// slot order, as carried in snapshots.
+func (cache *statusCache) bankSlotDeltas() []snapshot.BankSlotDelta {
+	slotDeltas := make([]snapshot.BankSlotDelta, 0, len(cache.roots))
+	for _, slot := range cache.roots {
+		slotDelta := snapshot.BankSlotDelta{Slot: slot, IsRoot: true}
+		for blockhash, txs := range cache.slotDeltas[slot] {
+			slotDelta.Statuses = append(slotDelta.Statuses, snapshot.BlockhashStatuses{Blockhash: blockhash, Txs: txs})
+		}
+		slices.SortFunc(slotDelta.Statuses, func(a, b snapshot.BlockhashStatuses) int {
+			return bytes.Compare(a.Blockhash[:], b.Blockhash[:])
+		})
+		slotDeltas = append(slotDeltas, slotDelta)
+	}
+
+	return slotDeltas
+}
+
+// purge evicts the transactions of which the recent blockhash was last used more than
+// statusCacheMaxAge slots before the given slot.
+func (cache *statusCache) purge(slot uint64) {
+	if slot < statusCacheMaxAge {
+		return
+	}
+
+	minSlot := slot - statusCacheMaxAge
+	for blockhash, entry := range cache.entries {
+		if entry.maxSlot <= minSlot {
+			delete(cache.entries, blockhash)
+		}
+	}
+}
@@ -107,7 +107,7 @@ func calculateStakeWeightedTimestamp(clock *sealevel.SysvarClock, epochSchedule
 }
 
 func updateClockSysvar(clock *sealevel.SysvarClock, accountsDb *accountsdb.AccountsDb, block *Block) error {
-	epochScheduleAcct, err := accountsDb.GetAccount(sealevel.SysvarEpochScheduleAddr)
+	epochScheduleAcct, err := accountsDb.GetAccountAtSlot(block.ParentSlot, sealevel.SysvarEpochScheduleAddr)
 	if err != nil {
 		panic("unable to retrieve epoch schedule sysvar acct when updating clock sysvar")
 	}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 	"go.firedancer.io/radiance/pkg/accounts"
 	"go.firedancer.io/radiance/pkg/cu"
 	"go.firedancer.io/radiance/pkg/fees"
@@ -14,23 +13,17 @@ import (
 	"k8s.io/klog/v2"
 )
 
-type TxErrInvalidSignature struct {
-	msg string
-}
-
-func NewTxErrInvalidSignature(msg string) error {
-	return &TxErrInvalidSignature{msg: msg}
-}
-
-func (err *TxErrInvalidSignature) Error() string {
-	return err.msg
+// txErrFromErr returns err if it is a TransactionError, and otherwise a TransactionError of
+// the given code.
+func txErrFromErr(err error, code sealevel.TransactionErrorCode) *sealevel.TransactionError {
+	var txErr *sealevel.TransactionError
+	if errors.As(err, &txErr) {
+		return txErr
+	}
+	return sealevel.NewTransactionError(code)
 }
 
-var (
-	TxErrInsufficientFundsForRent = errors.New("TxErrInsufficientFundsForRent")
-)
-
-func transactionAcctsFromTx(slotCtx *sealevel.SlotCtx, tx *solana.Transaction) (*sealevel.TransactionAccounts, error) {
+func transactionAcctsFromTx(slotCtx *sealevel.SlotCtx, instrsSysvarAcct *accounts.Account, tx *solana.Transaction) (*sealevel.TransactionAccounts, error) {
 	acctsForTx := make([]accounts.Account, 0)
 
 	txAcctMetas, err := tx.AccountMetaList()
@@ -39,6 +32,11 @@ func transactionAcctsFromTx(slotCtx *sealevel.SlotCtx, tx *solana.Transaction) (
 	}
 
 	for _, acctMeta := range txAcctMetas {
+		if acctMeta.PublicKey == sealevel.SysvarInstructionsAddr {
+			acctsForTx = append(acctsForTx, *instrsSysvarAcct)
+			continue
+		}
+
 		acct, err := slotCtx.GetAccount(acctMeta.PublicKey)
 		if err != nil {
 			return nil, err
@@ -62,6 +60,8 @@ func newExecCtx(slotCtx *sealevel.SlotCtx, transactionAccts *sealevel.Transactio
 	execCtx.GlobalCtx.Features = *slotCtx.Features
 	execCtx.Accounts = accounts.NewMemAccounts()
 	execCtx.SlotCtx = slotCtx
+	execCtx.Blockhash = slotCtx.Blockhash
+	execCtx.LamportsPerSignature = slotCtx.LamportsPerSignature
 	execCtx.TransactionContext.ComputeBudgetLimits = computeBudgetLimits
 
 	return execCtx
@@ -108,57 +108,71 @@ func fixupInstructionsSysvarAcct(execCtx *sealevel.ExecutionCtx, instrIdx uint16
 	return nil
 }
 
-func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txMeta *rpc.TransactionMeta) (uint64, error) {
-	/*err := tx.VerifySignatures()
-	if err != nil {
-		return NewTxErrInvalidSignature(err.Error())
-	}*/
+// ProcessTransaction executes the transaction, which must already have been verified by
+// verifyTransactions, against the slot's accounts, returning its status metadata. Where the
+// transaction fails, only the fee is deducted from the payer, and the returned metadata's Err
+// is set. For a durable nonce transaction, the nonce account stands in for the recent
+// blockhash, and its nonce is advanced even if the transaction fails.
+func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, isNonceTx bool) *TransactionStatusMeta {
+	status := new(TransactionStatusMeta)
 
 	instrs, err := instrsFromTx(tx)
 	if err != nil {
-		return 0, err
+		status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+		return status
 	}
 
-	err = sealevel.WriteInstructionsSysvar(&slotCtx.Accounts, instrs)
+	// the instructions sysvar is specific to each tx, and so is kept apart from the slot's
+	// accounts, which are shared by transactions executing concurrently.
+	instrsSysvarAccts := accounts.Accounts(accounts.NewMemAccounts())
+	err = sealevel.WriteInstructionsSysvar(&instrsSysvarAccts, instrs)
 	if err != nil {
-		return 0, err
+		status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+		return status
 	}
 
-	transactionAccts, err := transactionAcctsFromTx(slotCtx, tx)
+	instrsSysvarAcct, err := instrsSysvarAccts.GetAccount(&sealevel.SysvarInstructionsAddr)
 	if err != nil {
-		return 0, err
+		status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+		return status
+	}
+
+	transactionAccts, err := transactionAcctsFromTx(slotCtx, instrsSysvarAcct, tx)
+	if err != nil {
+		status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeAccountNotFound)
+		return status
+	}
+
+	var nonceIdx uint64
+	var advancedNonceAcct *accounts.Account
+	if isNonceTx {
+		var ok bool
+		nonceIdx, advancedNonceAcct, ok = loadNonceAccount(slotCtx, tx, transactionAccts)
+		if !ok {
+			status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeBlockhashNotFound)
+			return status
+		}
 	}
 
 	computeBudgetLimits, err := sealevel.ComputeBudgetExecuteInstructions(instrs)
 	if err != nil {
-		return 0, err
+		status.Err = txErrFromErr(err, sealevel.TxErrCodeSanitizeFailure)
+		return status
 	}
 
 	var log sealevel.LogRecorder
 	execCtx := newExecCtx(slotCtx, transactionAccts, computeBudgetLimits, &log)
 	execCtx.TransactionContext.AllInstructions = instrs
 
-	// check for pre-balance divergences
-	for count := uint64(0); count < uint64(len(tx.Message.AccountKeys)); count++ {
-		txAcct, err := execCtx.TransactionContext.Accounts.GetAccount(count)
-		if err != nil {
-			panic(fmt.Sprintf("unable to get tx acct %d whilst checking for pre-balances divergences", count))
-		}
-		if txAcct.Lamports != txMeta.PreBalances[count] {
-			klog.Infof("tx %s pre-balance divergence: lamport balance for %s was %d but onchain lamport balance was %d (acct slot %d)", tx.Signatures[0], txAcct.Key, txAcct.Lamports, txMeta.PreBalances[count], txAcct.Slot)
-		}
-		execCtx.TransactionContext.Accounts.Unlock(count)
-	}
+	status.PreBalances = balances(&execCtx.TransactionContext.Accounts)
+	status.PreTokenBalances = tokenBalances(slotCtx, &execCtx.TransactionContext.Accounts)
 
 	totalFee, payerNewLamports, err := fees.ApplyTxFees(tx, instrs, &execCtx.TransactionContext.Accounts, computeBudgetLimits)
 	if err != nil {
-		return 0, err
-	}
-
-	// check for fee divergences
-	if totalFee != txMeta.Fee {
-		klog.Infof("tx %s fee divergence: totalFee was %d, but onchain fee was %d", tx.Signatures[0], totalFee, txMeta.Fee)
+		status.Err = txErrFromErr(err, sealevel.TxErrCodeInsufficientFundsForFee)
+		return status
 	}
+	status.Fee = totalFee
 
 	rent, err := sealevel.ReadRentSysvar(execCtx)
 	if err != nil {
@@ -167,17 +181,19 @@ func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txMet
 
 	preTxRentStates := fees.NewRentStateInfo(&rent, execCtx.TransactionContext, tx)
 
-	var instrErr error
+	var instrErr *sealevel.TransactionError
 
 	for instrIdx, instr := range tx.Message.Instructions {
 		err = fixupInstructionsSysvarAcct(execCtx, uint16(instrIdx))
 		if err != nil {
-			return totalFee, err
+			status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+			return status
 		}
 
 		resolvedAccountMetas, err := instr.ResolveInstructionAccounts(&tx.Message)
 		if err != nil {
-			return totalFee, err
+			status.Err = sealevel.NewTransactionError(sealevel.TxErrCodeSanitizeFailure)
+			return status
 		}
 
 		var acctMetas []sealevel.AccountMeta
@@ -189,40 +205,29 @@ func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txMet
 		instructionAccts := sealevel.InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
 
 		err = execCtx.ProcessInstruction(instr.Data, instructionAccts, programIndices(tx, instrIdx))
-		for _, l := range log.Logs {
-			klog.Infof("%s", l)
-		}
 		if err != nil {
 			klog.Infof("%+v", tx)
-			instrErr = err
+			instrErr = sealevel.NewTxErrInstructionError(instrIdx, err)
 			break
 		}
 	}
 
-	klog.Infof("[+] tx %s - compute units consumed: %d", tx.Signatures[0], execCtx.ComputeMeter.Used())
+	for _, l := range log.Logs {
+		klog.Infof("%s", l)
+	}
 
-	// check for CU consumed divergences
-	if instrErr == nil && *txMeta.ComputeUnitsConsumed != execCtx.ComputeMeter.Used() {
-		klog.Infof("tx %s CU divergence: used was %d but onchain CU consumed was %d", tx.Signatures[0], execCtx.ComputeMeter.Used(), *txMeta.ComputeUnitsConsumed)
+	status.LogMessages = log.Logs
+	status.InnerInstructions = innerInstructions(execCtx.TransactionContext)
+	status.ComputeUnitsConsumed = execCtx.ComputeMeter.Used()
+	if returnDataProgramId, returnData := execCtx.TransactionContext.ReturnData(); len(returnData) != 0 {
+		status.ReturnData = &TransactionReturnData{ProgramId: returnDataProgramId, Data: returnData}
 	}
 
+	klog.Infof("[+] tx %s - compute units consumed: %d", tx.Signatures[0], status.ComputeUnitsConsumed)
+
 	postTxRentStates := fees.NewRentStateInfo(&rent, execCtx.TransactionContext, tx)
 	rentStateErr := fees.VerifyRentStateChanges(preTxRentStates, postTxRentStates, execCtx.TransactionContext)
 
-	// check for post-balances divergences (but only if the tx succeeded)
-	if instrErr == nil && rentStateErr == nil {
-		for count := uint64(0); count < uint64(len(tx.Message.AccountKeys)); count++ {
-			txAcct, err := execCtx.TransactionContext.Accounts.GetAccount(count)
-			if err != nil {
-				panic(fmt.Sprintf("unable to get tx acct %d whilst checking for post-balances divergences", count))
-			}
-			if txAcct.Lamports != txMeta.PostBalances[count] {
-				klog.Infof("tx %s post-balance divergence: lamport balance for %s was %d but onchain lamport balance was %d", tx.Signatures[0], txAcct.Key, txAcct.Lamports, txMeta.PostBalances[count])
-			}
-			execCtx.TransactionContext.Accounts.Unlock(count)
-		}
-	}
-
 	// if there was an error in the tx, do not update account states, except for deducting the tx fee
 	// from the payer account
 	if instrErr != nil || rentStateErr != nil {
@@ -242,20 +247,40 @@ func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txMet
 			panic(fmt.Sprintf("unable to set slot account to update state of payer acct after failed t: %s", err))
 		}
 
-		slotCtx.ModifiedAccts[payerAcct.Key] = true
+		slotCtx.SetAccountModified(payerAcct.Key)
 
 		execCtx.TransactionContext.Accounts.Unlock(0)
 
-		var txErr error
-		if rentStateErr != nil {
-			txErr = rentStateErr
-		} else {
-			txErr = instrErr
+		status.PostBalances = append([]uint64{}, status.PreBalances...)
+		status.PostBalances[0] = payerNewLamports
+
+		// the nonce is advanced regardless, such that the tx cannot be processed again
+		if advancedNonceAcct != nil {
+			if nonceIdx == 0 {
+				advancedNonceAcct.Lamports = payerNewLamports
+			}
+
+			err = slotCtx.SetAccount(advancedNonceAcct.Key, advancedNonceAcct)
+			if err != nil {
+				panic(fmt.Sprintf("unable to set slot account to advance nonce acct after failed tx: %s", err))
+			}
+
+			slotCtx.SetAccountModified(advancedNonceAcct.Key)
+			execCtx.TransactionContext.Accounts.Unlock(nonceIdx)
 		}
+		status.PostTokenBalances = status.PreTokenBalances
 
-		return totalFee, fmt.Errorf("tx err: %s", txErr)
+		if instrErr != nil {
+			status.Err = instrErr
+		} else {
+			status.Err = txErrFromErr(rentStateErr, sealevel.TxErrCodeInvalidRentPayingAccount)
+		}
+		return status
 	}
 
+	status.PostBalances = balances(&execCtx.TransactionContext.Accounts)
+	status.PostTokenBalances = tokenBalances(slotCtx, &execCtx.TransactionContext.Accounts)
+
 	// update account states in slotCtx for all accounts 'touched' during the tx's execution
 	for idx, wasTouched := range execCtx.TransactionContext.Accounts.Touched {
 		if wasTouched {
@@ -269,11 +294,11 @@ func ProcessTransaction(slotCtx *sealevel.SlotCtx, tx *solana.Transaction, txMet
 				panic(fmt.Sprintf("unable to set slot account for %s to update state: %s", newAcctState.Key, err))
 			}
 
-			slotCtx.ModifiedAccts[newAcctState.Key] = true
+			slotCtx.SetAccountModified(newAcctState.Key)
 			klog.Infof("modified account %s after tx", newAcctState.Key)
 			execCtx.TransactionContext.Accounts.Unlock(uint64(idx))
 		}
 	}
 
-	return totalFee, nil
+	return status
 }
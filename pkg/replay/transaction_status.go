@@ -0,0 +1,189 @@
+package replay
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// TransactionStatusMeta is the outcome of replaying a transaction, in the form of the
+// transaction status metadata served by RPC nodes (see rpc.TransactionMeta), such that it
+// may be compared field by field against that of the transaction's on-chain execution.
+type TransactionStatusMeta struct {
+	Err                  *sealevel.TransactionError
+	Fee                  uint64
+	PreBalances          []uint64
+	PostBalances         []uint64
+	InnerInstructions    []rpc.InnerInstruction
+	PreTokenBalances     []rpc.TokenBalance
+	PostTokenBalances    []rpc.TokenBalance
+	LogMessages          []string
+	ReturnData           *TransactionReturnData
+	ComputeUnitsConsumed uint64
+}
+
+// TransactionReturnData is the data most recently returned by a program in a transaction via
+// sol_set_return_data, and the program that returned it.
+type TransactionReturnData struct {
+	ProgramId solana.PublicKey
+	Data      []byte
+}
+
+// SPL token account and mint layouts, common to the token and token-2022 programs. Token-2022
+// accounts with extensions are longer, and carry their account type after the base layout.
+const (
+	tokenAcctLen            = 165
+	tokenAcctStateOffset    = 108
+	tokenAcctTypeOffset     = tokenAcctLen
+	tokenAcctTypeAccount    = 2
+	tokenMintLen            = 82
+	tokenMintDecimalsOffset = 44
+	tokenMintInitOffset     = 45
+)
+
+func isTokenProgram(pubkey solana.PublicKey) bool {
+	return pubkey == solana.TokenProgramID || pubkey == solana.Token2022ProgramID
+}
+
+// balances returns the lamport balances of the tx's accounts.
+func balances(txAccts *sealevel.TransactionAccounts) []uint64 {
+	lamports := make([]uint64, len(txAccts.Accounts))
+	for idx, acct := range txAccts.Accounts {
+		lamports[idx] = acct.Lamports
+	}
+	return lamports
+}
+
+// innerInstructions returns the instructions invoked via CPI during the tx's execution, grouped
+// by the top-level instruction from which they were invoked. Top-level instructions that
+// invoked no others are omitted, as they are by RPC nodes.
+func innerInstructions(txCtx *sealevel.TransactionCtx) []rpc.InnerInstruction {
+	var inner []rpc.InnerInstruction
+	topLevelIdx := -1
+
+	// the last entry in the trace is that of the next instruction to be executed
+	for traceIdx := uint64(0); traceIdx < txCtx.InstructionTraceLength(); traceIdx++ {
+		instrCtx := &txCtx.InstructionTrace[traceIdx]
+		if instrCtx.NestingLevel == 0 {
+			topLevelIdx++
+			continue
+		}
+
+		if len(instrCtx.ProgramAccounts) == 0 {
+			continue
+		}
+
+		compiledInstr := solana.CompiledInstruction{ProgramIDIndex: uint16(instrCtx.ProgramAccounts[len(instrCtx.ProgramAccounts)-1]),
+			Accounts: make([]uint16, len(instrCtx.InstructionAccounts)), Data: instrCtx.Data}
+		for idx, instrAcct := range instrCtx.InstructionAccounts {
+			compiledInstr.Accounts[idx] = uint16(instrAcct.IndexInTransaction)
+		}
+
+		if len(inner) == 0 || inner[len(inner)-1].Index != uint16(topLevelIdx) {
+			inner = append(inner, rpc.InnerInstruction{Index: uint16(topLevelIdx)})
+		}
+		inner[len(inner)-1].Instructions = append(inner[len(inner)-1].Instructions, compiledInstr)
+	}
+
+	return inner
+}
+
+// tokenBalances returns the balances of the tx's accounts that are initialized SPL token
+// accounts. The decimals of each token account's mint are taken from the tx's accounts
+// where the mint is amongst them, and otherwise from the slot's accounts state.
+func tokenBalances(slotCtx *sealevel.SlotCtx, txAccts *sealevel.TransactionAccounts) []rpc.TokenBalance {
+	var tokenBalances []rpc.TokenBalance
+
+	for idx, acct := range txAccts.Accounts {
+		owner := solana.PublicKeyFromBytes(acct.Owner[:])
+		if !isTokenProgram(owner) || !isTokenAccount(owner, acct.Data) {
+			continue
+		}
+
+		mint := solana.PublicKeyFromBytes(acct.Data[0:32])
+		tokenOwner := solana.PublicKeyFromBytes(acct.Data[32:64])
+		amount := binary.LittleEndian.Uint64(acct.Data[64:72])
+
+		decimals, ok := mintDecimals(slotCtx, txAccts, owner, mint)
+		if !ok {
+			continue
+		}
+
+		tokenBalances = append(tokenBalances, rpc.TokenBalance{AccountIndex: uint16(idx), Owner: &tokenOwner, Mint: mint,
+			UiTokenAmount: uiTokenAmount(amount, decimals)})
+	}
+
+	return tokenBalances
+}
+
+func isTokenAccount(tokenProgram solana.PublicKey, data []byte) bool {
+	if len(data) < tokenAcctLen || data[tokenAcctStateOffset] == 0 {
+		return false
+	}
+
+	if len(data) == tokenAcctLen {
+		return true
+	}
+
+	return tokenProgram == solana.Token2022ProgramID && data[tokenAcctTypeOffset] == tokenAcctTypeAccount
+}
+
+func mintDecimals(slotCtx *sealevel.SlotCtx, txAccts *sealevel.TransactionAccounts, tokenProgram solana.PublicKey, mint solana.PublicKey) (uint8, bool) {
+	var mintAcct *accounts.Account
+	for _, acct := range txAccts.Accounts {
+		if acct.Key == mint {
+			mintAcct = acct
+			break
+		}
+	}
+
+	if mintAcct == nil {
+		acct, err := slotCtx.GetAccount(mint)
+		if err != nil && slotCtx.AccountsDb != nil {
+			acct, err = slotCtx.GetAccountFromAccountsDb(mint)
+		}
+		if err != nil {
+			return 0, false
+		}
+		mintAcct = acct
+	}
+
+	if solana.PublicKey(mintAcct.Owner) != tokenProgram || len(mintAcct.Data) < tokenMintLen || mintAcct.Data[tokenMintInitOffset] == 0 {
+		return 0, false
+	}
+
+	return mintAcct.Data[tokenMintDecimalsOffset], true
+}
+
+// uiTokenAmount returns the token amount in the form served by RPC nodes, i.e. as both the raw
+// amount and the amount accounting for the mint's decimals.
+func uiTokenAmount(amount uint64, decimals uint8) *rpc.UiTokenAmount {
+	uiAmount := float64(amount) / math.Pow10(int(decimals))
+	return &rpc.UiTokenAmount{Amount: strconv.FormatUint(amount, 10), Decimals: decimals, UiAmount: &uiAmount,
+		UiAmountString: uiAmountString(amount, decimals)}
+}
+
+// uiAmountString formats the amount as a decimal number with the given number of decimal
+// places, with trailing zeros in the fractional part removed.
+func uiAmountString(amount uint64, decimals uint8) string {
+	digits := strconv.FormatUint(amount, 10)
+	if decimals == 0 {
+		return digits
+	}
+
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	whole, fraction := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
@@ -0,0 +1,104 @@
+package replay
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func TestUiAmountString(t *testing.T) {
+	assert.Equal(t, "0", uiAmountString(0, 0))
+	assert.Equal(t, "0", uiAmountString(0, 6))
+	assert.Equal(t, "1234", uiAmountString(1234, 0))
+	assert.Equal(t, "0.001234", uiAmountString(1234, 6))
+	assert.Equal(t, "1.5", uiAmountString(1500000, 6))
+	assert.Equal(t, "12", uiAmountString(12000000, 6))
+	assert.Equal(t, "18446744073.709551615", uiAmountString(18446744073709551615, 9))
+}
+
+func TestInnerInstructions(t *testing.T) {
+	txCtx := &sealevel.TransactionCtx{InstructionTrace: []sealevel.InstructionCtx{
+		{NestingLevel: 0, ProgramAccounts: []uint64{3}},
+		{NestingLevel: 1, ProgramAccounts: []uint64{4}, InstructionAccounts: []sealevel.InstructionAccount{{IndexInTransaction: 1}, {IndexInTransaction: 2}}, Data: []byte{1, 2}},
+		{NestingLevel: 2, ProgramAccounts: []uint64{5}, InstructionAccounts: []sealevel.InstructionAccount{{IndexInTransaction: 2}}, Data: []byte{3}},
+		// invokes no other instructions
+		{NestingLevel: 0, ProgramAccounts: []uint64{3}},
+		{NestingLevel: 0, ProgramAccounts: []uint64{4}},
+		{NestingLevel: 1, ProgramAccounts: []uint64{5}, Data: []byte{4}},
+		// the next instruction, yet to be executed
+		{},
+	}}
+
+	expected := []rpc.InnerInstruction{
+		{Index: 0, Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 4, Accounts: []uint16{1, 2}, Data: []byte{1, 2}},
+			{ProgramIDIndex: 5, Accounts: []uint16{2}, Data: []byte{3}},
+		}},
+		{Index: 2, Instructions: []solana.CompiledInstruction{
+			{ProgramIDIndex: 5, Accounts: []uint16{}, Data: []byte{4}},
+		}},
+	}
+
+	assert.Equal(t, expected, innerInstructions(txCtx))
+}
+
+func TestTokenBalances(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+	otherMint := solana.NewWallet().PublicKey()
+	tokenOwner := solana.NewWallet().PublicKey()
+
+	newTokenAcct := func(mint solana.PublicKey, amount uint64) *accounts.Account {
+		data := make([]byte, tokenAcctLen)
+		copy(data[0:32], mint[:])
+		copy(data[32:64], tokenOwner[:])
+		binary.LittleEndian.PutUint64(data[64:72], amount)
+		data[tokenAcctStateOffset] = 1
+		return &accounts.Account{Key: solana.NewWallet().PublicKey(), Owner: solana.TokenProgramID, Data: data}
+	}
+
+	newMintAcct := func(pubkey solana.PublicKey, decimals uint8) *accounts.Account {
+		data := make([]byte, tokenMintLen)
+		data[tokenMintDecimalsOffset] = decimals
+		data[tokenMintInitOffset] = 1
+		return &accounts.Account{Key: pubkey, Owner: solana.TokenProgramID, Data: data}
+	}
+
+	uninitialized := newTokenAcct(mint, 5)
+	uninitialized.Data[tokenAcctStateOffset] = 0
+
+	// the first mint is amongst the tx's accounts, whilst the other is only in the slot's accounts
+	slotAccts := accounts.NewMemAccounts()
+	slotAccts.Map[otherMint] = newMintAcct(otherMint, 2)
+	slotCtx := &sealevel.SlotCtx{Accounts: slotAccts}
+
+	txAccts := sealevel.NewTransactionAccounts([]accounts.Account{
+		{Key: solana.NewWallet().PublicKey(), Owner: sealevel.SystemProgramAddr},
+		*newTokenAcct(mint, 1500000),
+		*newMintAcct(mint, 6),
+		*uninitialized,
+		*newTokenAcct(otherMint, 12345),
+		// the mint of which is unknown
+		*newTokenAcct(solana.NewWallet().PublicKey(), 1),
+	})
+
+	balances := tokenBalances(slotCtx, txAccts)
+	require.Len(t, balances, 2)
+
+	assert.Equal(t, uint16(1), balances[0].AccountIndex)
+	assert.Equal(t, mint, balances[0].Mint)
+	assert.Equal(t, tokenOwner, *balances[0].Owner)
+	assert.Equal(t, "1500000", balances[0].UiTokenAmount.Amount)
+	assert.Equal(t, uint8(6), balances[0].UiTokenAmount.Decimals)
+	assert.Equal(t, "1.5", balances[0].UiTokenAmount.UiAmountString)
+	assert.Equal(t, 1.5, *balances[0].UiTokenAmount.UiAmount)
+
+	assert.Equal(t, uint16(4), balances[1].AccountIndex)
+	assert.Equal(t, otherMint, balances[1].Mint)
+	assert.Equal(t, "123.45", balances[1].UiTokenAmount.UiAmountString)
+}
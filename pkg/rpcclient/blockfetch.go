@@ -2,11 +2,42 @@ package rpcclient
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
+// JSON-RPC error codes returned by the Solana RPC server for getBlock requests
+const (
+	rpcErrCodeBlockNotAvailable          = -32004
+	rpcErrCodeSlotSkipped                = -32007
+	rpcErrCodeLongTermStorageSlotSkipped = -32009
+	rpcErrCodeBlockStatusNotAvailableYet = -32014
+)
+
+func rpcErrCode(err error) (int, bool) {
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code, true
+	}
+	return 0, false
+}
+
+// IsSlotSkippedErr returns true if the error indicates that no block was produced for the slot.
+func IsSlotSkippedErr(err error) bool {
+	code, ok := rpcErrCode(err)
+	return ok && (code == rpcErrCodeSlotSkipped || code == rpcErrCodeLongTermStorageSlotSkipped)
+}
+
+// IsBlockNotAvailableErr returns true if the error indicates that the block for the slot
+// has not (yet) been confirmed at the requested commitment level.
+func IsBlockNotAvailableErr(err error) bool {
+	code, ok := rpcErrCode(err)
+	return ok && (code == rpcErrCodeBlockNotAvailable || code == rpcErrCodeBlockStatusNotAvailableYet)
+}
+
 func (fetcher *RpcClient) GetBlock(slot uint64) (*rpc.GetBlockResult, error) {
 	return fetcher.client.GetBlock(context.TODO(), slot)
 }
@@ -8,6 +8,7 @@ import (
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/accounts"
 	"go.firedancer.io/radiance/pkg/features"
 	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
@@ -326,6 +327,21 @@ func unmarshalUpgradeableLoaderState(data []byte) (*UpgradeableLoaderState, erro
 	}
 }
 
+// UpgradeableProgramDataAddress returns the address of the program data account of a program
+// deployed by the upgradeable loader, from which the program is executed.
+func UpgradeableProgramDataAddress(programAcct *accounts.Account) (solana.PublicKey, bool) {
+	if programAcct.Owner != BpfLoaderUpgradeableAddr || !programAcct.Executable {
+		return solana.PublicKey{}, false
+	}
+
+	state, err := unmarshalUpgradeableLoaderState(programAcct.Data)
+	if err != nil || state.Type != UpgradeableLoaderStateTypeProgram {
+		return solana.PublicKey{}, false
+	}
+
+	return state.Program.ProgramDataAddress, true
+}
+
 func marshalUpgradeableLoaderState(state *UpgradeableLoaderState) ([]byte, error) {
 	buffer := new(bytes.Buffer)
 	encoder := bin.NewBinEncoder(buffer)
@@ -935,21 +951,9 @@ func deserializeParametersUnaligned(execCtx *ExecutionCtx, parameterBytes []byte
 	return nil
 }
 
-func executeProgram(execCtx *ExecutionCtx, programData []byte) error {
+func executeProgram(execCtx *ExecutionCtx, deploySlot uint64, programData []byte) error {
 	klog.Infof("bpf loader - executeProgram")
 
-	syscallRegistry := Syscalls(&execCtx.GlobalCtx.Features, false)
-
-	loader, err := loader.NewLoaderWithSyscalls(programData, &syscallRegistry, false)
-	if err != nil {
-		return err
-	}
-
-	program, err := loader.Load()
-	if err != nil {
-		return err
-	}
-
 	txCtx := execCtx.TransactionContext
 	instrCtx, err := txCtx.CurrentInstructionCtx()
 	if err != nil {
@@ -965,6 +969,11 @@ func executeProgram(execCtx *ExecutionCtx, programData []byte) error {
 
 	programAcct.Drop()
 
+	program, syscallRegistry, err := loadedPrograms.load(programId, deploySlot, &execCtx.GlobalCtx.Features, programData)
+	if err != nil {
+		return err
+	}
+
 	heapSize := execCtx.TransactionContext.ComputeBudgetLimits.UpdatedHeapBytes
 	heapCostResult := calculateHeapCost(heapSize, CUHeapCostDefault)
 	err = execCtx.ComputeMeter.Consume(heapCostResult)
@@ -1002,11 +1011,12 @@ func executeProgram(execCtx *ExecutionCtx, programData []byte) error {
 	ret, computeUnitsConsumed, runErr := interpreter.Run()
 
 	klog.Infof("Program %s consumed %d of %d compute units", programId, computeUnitsConsumed, computeRemainingPrev)
+	execCtx.logProgramConsumed(programId, uint64(computeUnitsConsumed), computeRemainingPrev)
 
 	if runErr != nil {
 		klog.Infof("program execution result: %s", runErr)
 	} else if ret != 0 {
-		runErr = fmt.Errorf("program execution (%s) returned failure: %d", programId, ret)
+		runErr = programReturnErr(ret)
 		klog.Infof("program execution (%s) returned failure: %d", programId, ret)
 	} else {
 		klog.Infof("program execution (%s) returned success", programId)
@@ -1016,6 +1026,7 @@ func executeProgram(execCtx *ExecutionCtx, programData []byte) error {
 	if len(returnData) != 0 {
 		encodedStr := base64.StdEncoding.EncodeToString(returnData)
 		klog.Infof("Program return %s %s", returnedDataProgId, encodedStr)
+		execCtx.logProgramReturn(returnedDataProgId, returnData)
 	}
 
 	// deserialize data
@@ -1090,6 +1101,9 @@ func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
 
 	var programBytes []byte
 
+	// programs owned by the non-upgradeable loaders cannot be redeployed
+	var deploySlot uint64
+
 	programOwner := programAcct.Owner()
 
 	if programOwner == BpfLoader2Addr || programOwner == BpfLoaderDeprecatedAddr {
@@ -1103,7 +1117,7 @@ func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
 
 		programAcct.Drop()
 
-		programDataAcct, err := execCtx.SlotCtx.GetAccountFromAccountsDb(programAcctState.Program.ProgramDataAddress)
+		programDataAcct, err := getProgramDataAcct(execCtx.SlotCtx, programAcctState.Program.ProgramDataAddress)
 		if err != nil {
 			klog.Infof("unable to get account %s as program data: %s", programAcctState.Program.ProgramDataAddress, err)
 			return InstrErrUnsupportedProgramId
@@ -1118,22 +1132,42 @@ func BpfLoaderProgramExecute(execCtx *ExecutionCtx) error {
 			return InstrErrInvalidAccountData
 		}
 
+		// programs deployed or upgraded in a slot only become visible in the next slot
 		programDataSlot := programDataAcctState.ProgramData.Slot
 		if programDataSlot >= execCtx.SlotCtx.Slot {
 			klog.Infof("programDataSlot (%d) >= execCtx.SlotCtx.Slot (%d)", programDataSlot, execCtx.SlotCtx.Slot)
 			return InstrErrInvalidAccountData
 		}
 
+		deploySlot = programDataSlot
 		programBytes = programDataAcct.Data[upgradeableLoaderSizeOfProgramDataMetaData:]
 	} else {
 		return InstrErrUnsupportedProgramId
 	}
 
-	err = executeProgram(execCtx, programBytes)
+	err = executeProgram(execCtx, deploySlot, programBytes)
 
 	return err
 }
 
+// getProgramDataAcct returns the program data account at the given address. Program data
+// accounts modified earlier in the slot, such as by an upgrade, are only found amongst the
+// slot's accounts, and otherwise are read from the AccountsDB.
+func getProgramDataAcct(slotCtx *SlotCtx, programDataAddr solana.PublicKey) (*accounts.Account, error) {
+	if slotCtx.Accounts != nil {
+		programDataAcct, err := slotCtx.GetAccount(programDataAddr)
+		if err == nil {
+			return programDataAcct, nil
+		}
+	}
+
+	if slotCtx.AccountsDb == nil {
+		return nil, fmt.Errorf("program data account %s not found", programDataAddr)
+	}
+
+	return slotCtx.GetAccountFromAccountsDb(programDataAddr)
+}
+
 func UpgradeableLoaderInitializeBuffer(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
 	klog.Infof("InitializeBuffer instr")
 	err := instrCtx.CheckNumOfInstructionAccounts(2)
@@ -1513,6 +1547,8 @@ func UpgradeableLoaderDeployWithMaxDataLen(execCtx *ExecutionCtx, txCtx *Transac
 		}
 	}
 
+	loadedPrograms.invalidate(newProgramId)
+
 	klog.Infof("deployed program: %s", newProgramId)
 
 	return nil
@@ -1764,6 +1800,8 @@ func UpgradeableLoaderUpgrade(execCtx *ExecutionCtx, txCtx *TransactionCtx, inst
 		return err
 	}
 
+	loadedPrograms.invalidate(program.Key())
+
 	klog.Infof("upgraded program %s", program.Key())
 	return nil
 }
@@ -2227,16 +2265,7 @@ func UpgradeableLoaderClose(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrC
 						return err
 					}
 
-					/* TODO?:
-						let clock = invoke_context.get_sysvar_cache().get_clock()?;
-					    invoke_context.programs_modified_by_tx.replenish(
-					            program_key,
-					            Arc::new(LoadedProgram::new_tombstone(
-					                            clock.slot,
-					                            LoadedProgramType::Closed,
-					            )),
-					    );
-					*/
+					loadedPrograms.invalidate(programKey)
 				}
 
 			default:
@@ -2310,7 +2339,7 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return InstrErrInvalidAccountOwner
 	}
 
-	//programKey := programAcct.Key()
+	programKey := programAcct.Key()
 
 	programAcctState, err := unmarshalUpgradeableLoaderState(programAcct.Data())
 	if err != nil {
@@ -2425,6 +2454,8 @@ func UpgradeableLoaderExtendProgram(execCtx *ExecutionCtx, txCtx *TransactionCtx
 		return err
 	}
 
+	loadedPrograms.invalidate(programKey)
+
 	klog.Infof("Extended ProgramData account by %d bytes", additionalBytes)
 
 	return nil
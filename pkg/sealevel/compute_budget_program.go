@@ -1,8 +1,6 @@
 package sealevel
 
 import (
-	"fmt"
-
 	bin "github.com/gagliardetto/binary"
 	"go.firedancer.io/radiance/pkg/safemath"
 	"k8s.io/klog/v2"
@@ -124,11 +122,11 @@ func sanitizeRequestedHeapSize(len uint32) bool {
 }
 
 func invalidInstructionDataErr(idx int) error {
-	return fmt.Errorf("Error processing Instruction %d: %w", idx, InstrErrInvalidInstructionData)
+	return NewTxErrInstructionError(idx, InstrErrInvalidInstructionData)
 }
 
 func duplicateInstructionErr(idx int) error {
-	return fmt.Errorf("Transaction contains a duplicate instruction (%d) that is not allowed", idx)
+	return NewTxErrDuplicateInstruction(idx)
 }
 
 func ComputeBudgetExecuteInstructions(instructions []Instruction) (*ComputeBudgetLimits, error) {
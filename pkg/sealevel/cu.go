@@ -20,6 +20,7 @@ const (
 	CUUpgradeableLoaderComputeUnits           = 2370
 	CUDeprecatedLoaderComputeUnits            = 1140
 	CUDefaultLoaderComputeUnits               = 570
+	CULoaderV4DefaultComputeUnits             = 2000
 	CUHeapCostDefault                         = 8
 	CUAddressLookupTableDefaultComputeUnits   = 750
 	CUComputeBudgetProgramDefaultComputeUnits = 150
@@ -43,4 +44,25 @@ const (
 	CUBn128MultiplicationCost                 = 3840
 	CUBn128PairingOnePairCostFirst            = 36364
 	CUBn128PairingOnePairCostOther            = 12121
+	CUBigModularExponentiationBaseCost        = 190
+	CUBigModularExponentiationCostDivisor     = 2
+)
+
+const (
+	CUZkCloseContextStateComputeUnits                              = 3300
+	CUZkVerifyZeroCiphertextComputeUnits                           = 6000
+	CUZkVerifyCiphertextCiphertextEqualityComputeUnits             = 8000
+	CUZkVerifyCiphertextCommitmentEqualityComputeUnits             = 6400
+	CUZkVerifyPubkeyValidityComputeUnits                           = 2600
+	CUZkVerifyPercentageWithCapComputeUnits                        = 6500
+	CUZkVerifyBatchedRangeProofU64ComputeUnits                     = 111000
+	CUZkVerifyBatchedRangeProofU128ComputeUnits                    = 200000
+	CUZkVerifyBatchedRangeProofU256ComputeUnits                    = 368000
+	CUZkVerifyGroupedCiphertext2HandlesValidityComputeUnits        = 6400
+	CUZkVerifyBatchedGroupedCiphertext2HandlesValidityComputeUnits = 13000
+	CUZkVerifyGroupedCiphertext3HandlesValidityComputeUnits        = 8100
+	CUZkVerifyBatchedGroupedCiphertext3HandlesValidityComputeUnits = 16400
+	CUZkVerifyWithdrawComputeUnits                                 = 110000
+	CUZkVerifyTransferComputeUnits                                 = 219000
+	CUZkVerifyRangeProofU64ComputeUnits                            = 105000
 )
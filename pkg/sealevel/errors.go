@@ -1,6 +1,9 @@
 package sealevel
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // instruction errors
 var (
@@ -96,6 +99,17 @@ var (
 	PrecompileErrInstrDataSize = errors.New("PrecompileErrInstrDataSize")
 )
 
+// zk elgamal proof verification errors
+var (
+	ZkProofErrDeserialization         = errors.New("ZkProofErrDeserialization")
+	ZkProofErrIdentityPoint           = errors.New("ZkProofErrIdentityPoint")
+	ZkProofErrAlgebraicRelation       = errors.New("ZkProofErrAlgebraicRelation")
+	ZkProofErrInvalidBitSize          = errors.New("ZkProofErrInvalidBitSize")
+	ZkProofErrInvalidGeneratorsLength = errors.New("ZkProofErrInvalidGeneratorsLength")
+	ZkProofErrIllegalCommitmentLength = errors.New("ZkProofErrIllegalCommitmentLength")
+	ZkProofErrIllegalAmountBitLength  = errors.New("ZkProofErrIllegalAmountBitLength")
+)
+
 // instruction errors - Solana numerical error codes
 const (
 	InstrErrCodeSuccess                                = 0
@@ -330,7 +344,67 @@ var customErrs = map[error]bool{
 	PrecompileErrInstrDataSize:                                             true,
 }
 
+// CustomProgramErr is the error of a program that returned a custom program error code.
+type CustomProgramErr struct {
+	Code uint32
+}
+
+func (err *CustomProgramErr) Error() string {
+	return fmt.Sprintf("custom program error: 0x%x", err.Code)
+}
+
+// builtin program errors, returned by programs as the error's code shifted left by 32 bits,
+// with 1 denoting custom program error 0
+var builtinProgramErrs = map[uint64]error{
+	2:  InstrErrInvalidArgument,
+	3:  InstrErrInvalidInstructionData,
+	4:  InstrErrInvalidAccountData,
+	5:  InstrErrAccountDataTooSmall,
+	6:  InstrErrInsufficientFunds,
+	7:  InstrErrIncorrectProgramId,
+	8:  InstrErrMissingRequiredSignature,
+	9:  InstrErrAccountAlreadyInitialized,
+	10: InstrErrUninitializedAccount,
+	11: InstrErrNotEnoughAccountKeys,
+	12: InstrErrAccountBorrowFailed,
+	13: InstrErrMaxSeedLengthExceeded,
+	14: InstrErrInvalidSeeds,
+	15: InstrErrBorshIoError,
+	16: InstrErrAccountNotRentExempt,
+	17: InstrErrUnsupportedSysvar,
+	18: InstrErrIllegalOwner,
+	19: InstrErrMaxAccountsDataAllocationsExceeded,
+	20: InstrErrInvalidRealloc,
+	21: InstrErrMaxInstructionTraceLengthExceeded,
+	22: InstrErrBuiltinProgramsMustConsumeComputeUnits,
+	23: InstrErrInvalidAccountOwner,
+	24: InstrErrArithmeticOverflow,
+	25: InstrErrImmutable,
+	26: InstrErrIncorrectAuthority,
+}
+
+// programReturnErr returns the error denoted by a non-zero value returned by a program, being
+// either a builtin program error or a custom program error.
+func programReturnErr(ret uint64) error {
+	if ret>>32 == 0 {
+		return &CustomProgramErr{Code: uint32(ret)}
+	}
+
+	if ret == 1<<32 {
+		return &CustomProgramErr{Code: 0}
+	}
+
+	if err, ok := builtinProgramErrs[ret>>32]; ok && ret&0xffffffff == 0 {
+		return err
+	}
+
+	return InstrErrInvalidError
+}
+
 func IsCustomErr(err error) bool {
+	if _, ok := err.(*CustomProgramErr); ok {
+		return true
+	}
 	return customErrs[err]
 }
 
@@ -340,5 +414,9 @@ func TranslateErrToErrCode(err error) int {
 		return InstrErrCodeSuccess
 	}
 
+	if customErr, ok := err.(*CustomProgramErr); ok {
+		return int(customErr.Code)
+	}
+
 	return solanaNumericalErrCodes[err]
 }
@@ -1,6 +1,8 @@
 package sealevel
 
 import (
+	"sync"
+
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/accounts"
 	"go.firedancer.io/radiance/pkg/accountsdb"
@@ -33,6 +35,7 @@ type SlotCtx struct {
 	Slot                 uint64
 	ParentSlot           uint64
 	Epoch                uint64
+	Blockhash            [32]byte
 	LamportsPerSignature uint64
 	ModifiedAccts        map[solana.PublicKey]bool
 	// TODO: use sysvar cache instead of deserializing from accounts each time
@@ -40,6 +43,13 @@ type SlotCtx struct {
 	SlotBank    SlotBank
 	Features    *features.Features
 	Replay      bool
+
+	// the total stake of the slot's epoch, and the stake delegated to each vote account
+	// during the epoch
+	EpochTotalStake        uint64
+	EpochVoteAccountStakes map[solana.PublicKey]uint64
+
+	modifiedAcctsLock sync.Mutex
 }
 
 func (execCtx *ExecutionCtx) PrepareInstruction(ix Instruction, signers []solana.PublicKey) ([]InstructionAccount, []uint64, error) {
@@ -209,9 +219,22 @@ func (execCtx *ExecutionCtx) ExecuteInstruction() error {
 		return err
 	}
 
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return err
+	}
+
+	execCtx.logProgramInvoke(programId, execCtx.StackHeight())
+
 	klog.Infof("calling native program %s", builtinId)
 	err = nativeProgramFn(execCtx)
 
+	if err != nil {
+		execCtx.logProgramFailure(programId, err)
+	} else {
+		execCtx.logProgramSuccess(programId)
+	}
+
 	return err
 }
 
@@ -317,7 +340,7 @@ func (slotCtx *SlotCtx) GetAccount(pubkey solana.PublicKey) (*accounts.Account,
 }
 
 func (slotCtx *SlotCtx) GetAccountFromAccountsDb(pubkey solana.PublicKey) (*accounts.Account, error) {
-	acct, err := slotCtx.AccountsDb.GetAccount(pubkey)
+	acct, err := slotCtx.AccountsDb.GetAccountAtSlot(slotCtx.ParentSlot, pubkey)
 	if err != nil {
 		return nil, err
 	} else {
@@ -331,5 +354,13 @@ func (slotCtx *SlotCtx) SetAccount(pubkey solana.PublicKey, acct *accounts.Accou
 	return err
 }
 
+// SetAccountModified records that the account was modified in the slot, such that it is
+// included in the accounts delta hash. Transactions executing concurrently may call it.
+func (slotCtx *SlotCtx) SetAccountModified(pubkey solana.PublicKey) {
+	slotCtx.modifiedAcctsLock.Lock()
+	slotCtx.ModifiedAccts[pubkey] = true
+	slotCtx.modifiedAcctsLock.Unlock()
+}
+
 func (slotCtx *SlotCtx) SetupSysvarCache(slot uint64) {
 }
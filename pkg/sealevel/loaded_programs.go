@@ -0,0 +1,199 @@
+package sealevel
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/sbpf"
+	"go.firedancer.io/radiance/pkg/sbpf/loader"
+)
+
+// DefaultLoadedProgramCacheSize is the default maximum size in bytes of the programs held in
+// the loaded-program cache.
+const DefaultLoadedProgramCacheSize = 512 * 1024 * 1024
+
+// loadedProgramFeatureGates are the feature gates that affect how a program is loaded, i.e.
+// those gating the syscalls registered by Syscalls, since a program's calls are resolved
+// against the syscall registry when it is loaded.
+var loadedProgramFeatureGates = []features.FeatureGate{
+	features.Curve25519SyscallEnabled,
+	features.EnableAltbn128CompressionSyscall,
+	features.EnableAltBn128Syscall,
+	features.EnablePartitionedEpochReward,
+	features.LastRestartSlotSysvar,
+	features.EnableBigModExpSyscall,
+	features.RemainingComputeUnitsSyscallEnabled,
+	features.DisableFeesSysvar,
+	features.GetSysvarSyscallEnabled,
+	features.EnableGetEpochStakeSyscall,
+}
+
+// LoadedProgramCacheStats describes the state and effectiveness of the loaded-program cache.
+type LoadedProgramCacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64
+	Invalidations uint64
+	NumPrograms   uint64
+	SizeBytes     uint64
+}
+
+type loadedProgramKey struct {
+	programId   solana.PublicKey
+	deploySlot  uint64
+	featuresKey uint64
+}
+
+type loadedProgram struct {
+	key      loadedProgramKey
+	program  *sbpf.Program
+	syscalls sbpf.SyscallRegistry
+	size     uint64
+}
+
+// loadedProgramCache is a bounded LRU cache of loaded and verified programs, such that
+// programs need not be parsed, relocated and verified on every invocation.
+//
+// Programs are keyed by program ID and the slot in which they were deployed, so that a
+// redeployed program is never served from its previous version's entry, and by the
+// features affecting program loading.
+type loadedProgramCache struct {
+	lock     sync.Mutex
+	maxBytes uint64
+	size     uint64
+	entries  map[loadedProgramKey]*list.Element
+	lru      *list.List
+
+	hits          uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+}
+
+var loadedPrograms = newLoadedProgramCache(DefaultLoadedProgramCacheSize)
+
+func newLoadedProgramCache(maxBytes uint64) *loadedProgramCache {
+	return &loadedProgramCache{maxBytes: maxBytes, entries: make(map[loadedProgramKey]*list.Element), lru: list.New()}
+}
+
+// LoadedProgramsStats returns the hit, miss, eviction and invalidation counts and current
+// size of the process-wide loaded-program cache.
+func LoadedProgramsStats() LoadedProgramCacheStats {
+	return loadedPrograms.stats()
+}
+
+// SetLoadedProgramCacheSize sets the maximum size in bytes of the programs held in the
+// process-wide loaded-program cache. A size of zero disables the cache.
+func SetLoadedProgramCacheSize(maxBytes uint64) {
+	loadedPrograms.resize(maxBytes)
+}
+
+func loadedProgramFeaturesKey(f *features.Features) uint64 {
+	var key uint64
+	for idx, gate := range loadedProgramFeatureGates {
+		if f.IsActive(gate) {
+			key |= 1 << idx
+		}
+	}
+	return key
+}
+
+// load returns the loaded program for the given program ID and deploy slot, along with the
+// syscall registry it was loaded against, loading and caching it from programData if it
+// is not already cached.
+func (cache *loadedProgramCache) load(programId solana.PublicKey, deploySlot uint64, f *features.Features, programData []byte) (*sbpf.Program, sbpf.SyscallRegistry, error) {
+	key := loadedProgramKey{programId: programId, deploySlot: deploySlot, featuresKey: loadedProgramFeaturesKey(f)}
+
+	cache.lock.Lock()
+	elem, exists := cache.entries[key]
+	if exists {
+		cache.hits++
+		cache.lru.MoveToFront(elem)
+		entry := elem.Value.(*loadedProgram)
+		cache.lock.Unlock()
+		return entry.program, entry.syscalls, nil
+	}
+	cache.misses++
+	cache.lock.Unlock()
+
+	syscallRegistry := Syscalls(f, false)
+
+	l, err := loader.NewLoaderWithSyscalls(programData, &syscallRegistry, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	program, err := l.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cache.put(&loadedProgram{key: key, program: program, syscalls: syscallRegistry, size: uint64(len(program.RO))})
+
+	return program, syscallRegistry, nil
+}
+
+func (cache *loadedProgramCache) put(entry *loadedProgram) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if entry.size > cache.maxBytes {
+		return
+	}
+
+	// another invocation may have loaded the same program concurrently
+	if _, exists := cache.entries[entry.key]; exists {
+		return
+	}
+
+	cache.entries[entry.key] = cache.lru.PushFront(entry)
+	cache.size += entry.size
+
+	cache.evictLocked()
+}
+
+// invalidate drops all cached versions of the given program, such as when it is upgraded,
+// redeployed or closed.
+func (cache *loadedProgramCache) invalidate(programId solana.PublicKey) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	for key, elem := range cache.entries {
+		if key.programId == programId {
+			cache.removeLocked(elem)
+			cache.invalidations++
+		}
+	}
+}
+
+func (cache *loadedProgramCache) resize(maxBytes uint64) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.maxBytes = maxBytes
+	cache.evictLocked()
+}
+
+func (cache *loadedProgramCache) evictLocked() {
+	for cache.size > cache.maxBytes {
+		cache.removeLocked(cache.lru.Back())
+		cache.evictions++
+	}
+}
+
+func (cache *loadedProgramCache) removeLocked(elem *list.Element) {
+	entry := elem.Value.(*loadedProgram)
+	delete(cache.entries, entry.key)
+	cache.lru.Remove(elem)
+	cache.size -= entry.size
+}
+
+func (cache *loadedProgramCache) stats() LoadedProgramCacheStats {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	return LoadedProgramCacheStats{Hits: cache.hits, Misses: cache.misses, Evictions: cache.evictions,
+		Invalidations: cache.invalidations, NumPrograms: uint64(len(cache.entries)), SizeBytes: cache.size}
+}
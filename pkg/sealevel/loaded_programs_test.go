@@ -0,0 +1,56 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+func TestLoadedProgramCache(t *testing.T) {
+	programData := fixtures.Load(t, "sbpf", "noop.so")
+	programId := solana.NewWallet().PublicKey()
+	f := features.NewFeaturesDefault()
+
+	cache := newLoadedProgramCache(DefaultLoadedProgramCacheSize)
+
+	program, _, err := cache.load(programId, 10, f, programData)
+	require.NoError(t, err)
+
+	cached, _, err := cache.load(programId, 10, f, programData)
+	require.NoError(t, err)
+	assert.Same(t, program, cached)
+
+	// a redeployed program, or a change in the features affecting loading, is loaded afresh
+	_, _, err = cache.load(programId, 12, f, programData)
+	require.NoError(t, err)
+
+	f.EnableFeature(features.EnableAltBn128Syscall, 0)
+	_, _, err = cache.load(programId, 12, f, programData)
+	require.NoError(t, err)
+
+	assert.Equal(t, LoadedProgramCacheStats{Hits: 1, Misses: 3, NumPrograms: 3, SizeBytes: 3 * uint64(len(program.RO))}, cache.stats())
+
+	cache.invalidate(programId)
+	stats := cache.stats()
+	assert.Equal(t, uint64(3), stats.Invalidations)
+	assert.Equal(t, uint64(0), stats.NumPrograms)
+
+	// only one program fits once the cache is shrunk
+	_, _, err = cache.load(programId, 10, f, programData)
+	require.NoError(t, err)
+	_, _, err = cache.load(programId, 12, f, programData)
+	require.NoError(t, err)
+
+	cache.resize(uint64(len(program.RO)))
+	stats = cache.stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, uint64(1), stats.NumPrograms)
+
+	_, _, err = cache.load(programId, 12, f, programData)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), cache.stats().Hits)
+}
@@ -0,0 +1,772 @@
+package sealevel
+
+import (
+	"bytes"
+	"errors"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/features"
+	"go.firedancer.io/radiance/pkg/safemath"
+	"k8s.io/klog/v2"
+)
+
+const (
+	LoaderV4InstrTypeWrite = iota
+	LoaderV4InstrTypeTruncate
+	LoaderV4InstrTypeDeploy
+	LoaderV4InstrTypeRetract
+	LoaderV4InstrTypeTransferAuthority
+	LoaderV4InstrTypeFinalize
+)
+
+const (
+	LoaderV4StatusRetracted = iota
+	LoaderV4StatusDeployed
+	LoaderV4StatusFinalized
+)
+
+// programs can only be redeployed or retracted once this many slots have passed since their deployment
+const LoaderV4DeploymentCooldownInSlots = 750
+
+// the program bytes of a loader-v4 program account follow its LoaderV4State
+const loaderV4ProgramDataOffset = 48
+
+// ErrNoSlotCtx is returned when executing a loader-v4 program without a slot context, which
+// is needed to determine whether the program's deployment is visible in the current slot.
+var ErrNoSlotCtx = errors.New("ErrNoSlotCtx")
+
+// instructions
+type LoaderV4InstrWrite struct {
+	Offset uint32
+	Bytes  []byte
+}
+
+type LoaderV4InstrTruncate struct {
+	NewSize uint32
+}
+
+// LoaderV4State is the state at the start of a loader-v4 program account. Once a program is
+// finalized, AuthorityAddressOrNextVersion is the address of the program's next version.
+type LoaderV4State struct {
+	Slot                          uint64
+	AuthorityAddressOrNextVersion solana.PublicKey
+	Status                        uint64
+}
+
+func (write *LoaderV4InstrWrite) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	var err error
+	write.Offset, err = decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	write.Bytes, err = decoder.ReadByteSlice()
+	return err
+}
+
+func (write *LoaderV4InstrWrite) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint32(LoaderV4InstrTypeWrite, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint32(write.Offset, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(write.Bytes, true)
+	return err
+}
+
+func (truncate *LoaderV4InstrTruncate) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	var err error
+	truncate.NewSize, err = decoder.ReadUint32(bin.LE)
+	return err
+}
+
+func (truncate *LoaderV4InstrTruncate) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint32(LoaderV4InstrTypeTruncate, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint32(truncate.NewSize, bin.LE)
+	return err
+}
+
+func (state *LoaderV4State) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	var err error
+	state.Slot, err = decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	authority, err := decoder.ReadBytes(solana.PublicKeyLength)
+	if err != nil {
+		return err
+	}
+	copy(state.AuthorityAddressOrNextVersion[:], authority)
+
+	state.Status, err = decoder.ReadUint64(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	if state.Status > LoaderV4StatusFinalized {
+		return invalidEnumValue
+	}
+
+	return nil
+}
+
+func (state *LoaderV4State) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(state.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(state.AuthorityAddressOrNextVersion[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(state.Status, bin.LE)
+	return err
+}
+
+func unmarshalLoaderV4State(data []byte) (*LoaderV4State, error) {
+	if len(data) < loaderV4ProgramDataOffset {
+		return nil, InstrErrAccountDataTooSmall
+	}
+
+	state := new(LoaderV4State)
+	decoder := bin.NewBinDecoder(data)
+
+	err := state.UnmarshalWithDecoder(decoder)
+	if err != nil {
+		return nil, InstrErrInvalidAccountData
+	}
+
+	return state, nil
+}
+
+func setLoaderV4AccountState(acct *BorrowedAccount, state *LoaderV4State, f features.Features) error {
+	buffer := new(bytes.Buffer)
+	encoder := bin.NewBinEncoder(buffer)
+
+	err := state.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	return acct.SetState(f, buffer.Bytes())
+}
+
+// checkLoaderV4ProgramAccount checks that the program account is a writable, non-finalized
+// loader-v4 program whose authority is the signing instruction account at index 1.
+func checkLoaderV4ProgramAccount(instrCtx *InstructionCtx, program *BorrowedAccount, authorityAddress solana.PublicKey) (*LoaderV4State, error) {
+	if program.Owner() != LoaderV4Addr {
+		klog.Infof("Program not owned by loader")
+		return nil, InstrErrInvalidAccountOwner
+	}
+
+	state, err := unmarshalLoaderV4State(program.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	if !program.IsWritable() {
+		klog.Infof("Program is not writeable")
+		return nil, InstrErrInvalidArgument
+	}
+
+	isSigner, err := instrCtx.IsInstructionAccountSigner(1)
+	if err != nil {
+		return nil, err
+	}
+	if !isSigner {
+		klog.Infof("Authority did not sign")
+		return nil, InstrErrMissingRequiredSignature
+	}
+
+	if state.AuthorityAddressOrNextVersion != authorityAddress {
+		klog.Infof("Incorrect authority provided")
+		return nil, InstrErrIncorrectAuthority
+	}
+
+	if state.Status == LoaderV4StatusFinalized {
+		klog.Infof("Program is finalized")
+		return nil, InstrErrImmutable
+	}
+
+	return state, nil
+}
+
+func loaderV4InstructionAccountKey(txCtx *TransactionCtx, instrCtx *InstructionCtx, instrAcctIdx uint64) (solana.PublicKey, error) {
+	idx, err := instrCtx.IndexOfInstructionAccountInTransaction(instrAcctIdx)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return txCtx.KeyOfAccountAtIndex(idx)
+}
+
+func LoaderV4Write(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, write LoaderV4InstrWrite) error {
+	klog.Infof("Write instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		return err
+	}
+
+	state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+	if err != nil {
+		return err
+	}
+
+	if state.Status != LoaderV4StatusRetracted {
+		klog.Infof("Program is not retracted")
+		return InstrErrInvalidArgument
+	}
+
+	startOffset := safemath.SaturatingAddU64(loaderV4ProgramDataOffset, uint64(write.Offset))
+	endOffset := safemath.SaturatingAddU64(startOffset, uint64(len(write.Bytes)))
+	if uint64(len(program.Data())) < endOffset {
+		klog.Infof("Write out of bounds")
+		return InstrErrAccountDataTooSmall
+	}
+
+	data, err := program.DataMutable(execCtx.GlobalCtx.Features)
+	if err != nil {
+		return err
+	}
+
+	copy(data[startOffset:endOffset], write.Bytes)
+	return nil
+}
+
+func LoaderV4Truncate(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx, truncate LoaderV4InstrTruncate) error {
+	klog.Infof("Truncate instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		return err
+	}
+
+	isInitialization := truncate.NewSize > 0 && len(program.Data()) < loaderV4ProgramDataOffset
+
+	if isInitialization {
+		if program.Owner() != LoaderV4Addr {
+			klog.Infof("Program not owned by loader")
+			return InstrErrInvalidAccountOwner
+		}
+
+		if !program.IsWritable() {
+			klog.Infof("Program is not writeable")
+			return InstrErrInvalidArgument
+		}
+
+		if !program.IsSigner() {
+			klog.Infof("Program did not sign")
+			return InstrErrMissingRequiredSignature
+		}
+
+		isSigner, err := instrCtx.IsInstructionAccountSigner(1)
+		if err != nil {
+			return err
+		}
+		if !isSigner {
+			klog.Infof("Authority did not sign")
+			return InstrErrMissingRequiredSignature
+		}
+	} else {
+		state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+		if err != nil {
+			return err
+		}
+
+		if state.Status != LoaderV4StatusRetracted {
+			klog.Infof("Program is not retracted")
+			return InstrErrInvalidArgument
+		}
+	}
+
+	newLen := safemath.SaturatingAddU64(loaderV4ProgramDataOffset, uint64(truncate.NewSize))
+
+	var requiredLamports uint64
+	if truncate.NewSize != 0 {
+		rent, err := ReadRentSysvar(execCtx)
+		if err != nil {
+			return err
+		}
+		requiredLamports = rent.MinimumBalance(newLen)
+	}
+
+	if program.Lamports() < requiredLamports {
+		klog.Infof("Insufficient lamports, %d are required", requiredLamports)
+		return InstrErrInsufficientFunds
+	} else if program.Lamports() > requiredLamports {
+		// the recipient is optional, unless the program is being closed
+		recipient, err := instrCtx.BorrowInstructionAccount(txCtx, 2)
+		if err == nil {
+			defer recipient.Drop()
+
+			isWritable, err := instrCtx.IsInstructionAccountWritable(2)
+			if err != nil {
+				return err
+			}
+			if !isWritable {
+				klog.Infof("Recipient is not writeable")
+				return InstrErrInvalidArgument
+			}
+
+			lamportsToReceive := safemath.SaturatingSubU64(program.Lamports(), requiredLamports)
+			err = program.CheckedSubLamports(lamportsToReceive, execCtx.GlobalCtx.Features)
+			if err != nil {
+				return err
+			}
+			err = recipient.CheckedAddLamports(lamportsToReceive, execCtx.GlobalCtx.Features)
+			if err != nil {
+				return err
+			}
+		} else if truncate.NewSize == 0 {
+			klog.Infof("Closing a program requires a recipient account")
+			return InstrErrInvalidArgument
+		}
+	}
+
+	if truncate.NewSize == 0 {
+		return program.SetDataLength(0, execCtx.GlobalCtx.Features)
+	}
+
+	err = program.SetDataLength(newLen, execCtx.GlobalCtx.Features)
+	if err != nil {
+		return err
+	}
+
+	if isInitialization {
+		state := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAddress, Status: LoaderV4StatusRetracted}
+		err = setLoaderV4AccountState(program, &state, execCtx.GlobalCtx.Features)
+	}
+
+	return err
+}
+
+func LoaderV4Deploy(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
+	klog.Infof("Deploy instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		return err
+	}
+
+	// the program bytes are optionally taken from a source program account
+	sourceProgram, err := instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		sourceProgram = nil
+	} else {
+		defer sourceProgram.Drop()
+	}
+
+	state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+	if err != nil {
+		return err
+	}
+
+	clock, err := ReadClockSysvar(execCtx)
+	if err != nil {
+		return err
+	}
+
+	// a slot of zero means the program has never been deployed, so there is no cooldown
+	if state.Slot != 0 && safemath.SaturatingAddU64(state.Slot, LoaderV4DeploymentCooldownInSlots) > clock.Slot {
+		klog.Infof("Program was deployed recently, cooldown still in effect")
+		return InstrErrInvalidArgument
+	}
+
+	if state.Status != LoaderV4StatusRetracted {
+		klog.Infof("Destination program is not retracted")
+		return InstrErrInvalidArgument
+	}
+
+	buffer := program
+	if sourceProgram != nil {
+		sourceState, err := checkLoaderV4ProgramAccount(instrCtx, sourceProgram, authorityAddress)
+		if err != nil {
+			return err
+		}
+
+		if sourceState.Status != LoaderV4StatusRetracted {
+			klog.Infof("Source program is not retracted")
+			return InstrErrInvalidArgument
+		}
+		buffer = sourceProgram
+	}
+
+	err = deployProgram(execCtx, buffer.Data()[loaderV4ProgramDataOffset:])
+	if err != nil {
+		return InstrErrInvalidAccountData
+	}
+
+	if sourceProgram != nil {
+		rent, err := ReadRentSysvar(execCtx)
+		if err != nil {
+			return err
+		}
+
+		requiredLamports := rent.MinimumBalance(uint64(len(sourceProgram.Data())))
+		transferLamports := safemath.SaturatingSubU64(requiredLamports, program.Lamports())
+
+		sourceData := make([]byte, len(sourceProgram.Data()))
+		copy(sourceData, sourceProgram.Data())
+
+		err = program.SetData(execCtx.GlobalCtx.Features, sourceData)
+		if err != nil {
+			return err
+		}
+		err = sourceProgram.SetDataLength(0, execCtx.GlobalCtx.Features)
+		if err != nil {
+			return err
+		}
+		err = sourceProgram.CheckedSubLamports(transferLamports, execCtx.GlobalCtx.Features)
+		if err != nil {
+			return err
+		}
+		err = program.CheckedAddLamports(transferLamports, execCtx.GlobalCtx.Features)
+		if err != nil {
+			return err
+		}
+	}
+
+	state, err = unmarshalLoaderV4State(program.Data())
+	if err != nil {
+		return err
+	}
+
+	state.Slot = clock.Slot
+	state.Status = LoaderV4StatusDeployed
+
+	return setLoaderV4AccountState(program, state, execCtx.GlobalCtx.Features)
+}
+
+func LoaderV4Retract(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
+	klog.Infof("Retract instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		return err
+	}
+
+	state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+	if err != nil {
+		return err
+	}
+
+	clock, err := ReadClockSysvar(execCtx)
+	if err != nil {
+		return err
+	}
+
+	if safemath.SaturatingAddU64(state.Slot, LoaderV4DeploymentCooldownInSlots) > clock.Slot {
+		klog.Infof("Program was deployed recently, cooldown still in effect")
+		return InstrErrInvalidArgument
+	}
+
+	if state.Status != LoaderV4StatusDeployed {
+		klog.Infof("Program is not deployed")
+		return InstrErrInvalidArgument
+	}
+
+	state.Status = LoaderV4StatusRetracted
+
+	return setLoaderV4AccountState(program, state, execCtx.GlobalCtx.Features)
+}
+
+func LoaderV4TransferAuthority(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
+	klog.Infof("TransferAuthority instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		return err
+	}
+
+	newAuthorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 2)
+	if err != nil {
+		return err
+	}
+
+	state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+	if err != nil {
+		return err
+	}
+
+	isSigner, err := instrCtx.IsInstructionAccountSigner(2)
+	if err != nil {
+		return err
+	}
+	if !isSigner {
+		klog.Infof("New authority did not sign")
+		return InstrErrMissingRequiredSignature
+	}
+
+	if state.AuthorityAddressOrNextVersion == newAuthorityAddress {
+		klog.Infof("No change")
+		return InstrErrInvalidArgument
+	}
+
+	state.AuthorityAddressOrNextVersion = newAuthorityAddress
+
+	return setLoaderV4AccountState(program, state, execCtx.GlobalCtx.Features)
+}
+
+func LoaderV4Finalize(execCtx *ExecutionCtx, txCtx *TransactionCtx, instrCtx *InstructionCtx) error {
+	klog.Infof("Finalize instr")
+
+	program, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+
+	authorityAddress, err := loaderV4InstructionAccountKey(txCtx, instrCtx, 1)
+	if err != nil {
+		program.Drop()
+		return err
+	}
+
+	state, err := checkLoaderV4ProgramAccount(instrCtx, program, authorityAddress)
+	if err != nil {
+		program.Drop()
+		return err
+	}
+
+	if state.Status != LoaderV4StatusDeployed {
+		klog.Infof("Program must be deployed to be finalized")
+		program.Drop()
+		return InstrErrInvalidArgument
+	}
+
+	program.Drop()
+
+	nextVersion, err := instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+
+	if nextVersion.Owner() != LoaderV4Addr {
+		klog.Infof("Next version is not owned by loader")
+		nextVersion.Drop()
+		return InstrErrInvalidAccountOwner
+	}
+
+	nextVersionState, err := unmarshalLoaderV4State(nextVersion.Data())
+	if err != nil {
+		nextVersion.Drop()
+		return err
+	}
+
+	if nextVersionState.AuthorityAddressOrNextVersion != authorityAddress {
+		klog.Infof("Next version has a different authority")
+		nextVersion.Drop()
+		return InstrErrIncorrectAuthority
+	}
+
+	if nextVersionState.Status == LoaderV4StatusFinalized {
+		klog.Infof("Next version is finalized")
+		nextVersion.Drop()
+		return InstrErrImmutable
+	}
+
+	nextVersionAddress := nextVersion.Key()
+	nextVersion.Drop()
+
+	program, err = instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer program.Drop()
+
+	state.AuthorityAddressOrNextVersion = nextVersionAddress
+	state.Status = LoaderV4StatusFinalized
+
+	return setLoaderV4AccountState(program, state, execCtx.GlobalCtx.Features)
+}
+
+func ProcessLoaderV4Instruction(execCtx *ExecutionCtx) error {
+	klog.Infof("loader v4 program mgmt")
+
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	decoder := bin.NewBinDecoder(instrCtx.Data)
+
+	instrType, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return InstrErrInvalidInstructionData
+	}
+
+	switch instrType {
+	case LoaderV4InstrTypeWrite:
+		{
+			var write LoaderV4InstrWrite
+			err = write.UnmarshalWithDecoder(decoder)
+			if err != nil {
+				return InstrErrInvalidInstructionData
+			}
+
+			err = LoaderV4Write(execCtx, txCtx, instrCtx, write)
+		}
+
+	case LoaderV4InstrTypeTruncate:
+		{
+			var truncate LoaderV4InstrTruncate
+			err = truncate.UnmarshalWithDecoder(decoder)
+			if err != nil {
+				return InstrErrInvalidInstructionData
+			}
+
+			err = LoaderV4Truncate(execCtx, txCtx, instrCtx, truncate)
+		}
+
+	case LoaderV4InstrTypeDeploy:
+		{
+			err = LoaderV4Deploy(execCtx, txCtx, instrCtx)
+		}
+
+	case LoaderV4InstrTypeRetract:
+		{
+			err = LoaderV4Retract(execCtx, txCtx, instrCtx)
+		}
+
+	case LoaderV4InstrTypeTransferAuthority:
+		{
+			err = LoaderV4TransferAuthority(execCtx, txCtx, instrCtx)
+		}
+
+	case LoaderV4InstrTypeFinalize:
+		{
+			err = LoaderV4Finalize(execCtx, txCtx, instrCtx)
+		}
+
+	default:
+		{
+			err = InstrErrInvalidInstructionData
+		}
+	}
+
+	return err
+}
+
+// LoaderV4ProgramExecute processes instructions to the loader-v4 program itself, and executes
+// the programs owned by it.
+func LoaderV4ProgramExecute(execCtx *ExecutionCtx) error {
+	klog.Infof("LoaderV4ProgramExecute")
+
+	if !execCtx.GlobalCtx.Features.IsActive(features.EnableProgramRuntimeV2AndLoaderV4) {
+		return InstrErrUnsupportedProgramId
+	}
+
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	programId, err := instrCtx.LastProgramKey(txCtx)
+	if err != nil {
+		return err
+	}
+
+	if programId == LoaderV4Addr {
+		err = execCtx.ComputeMeter.Consume(CULoaderV4DefaultComputeUnits)
+		if err != nil {
+			return err
+		}
+		return ProcessLoaderV4Instruction(execCtx)
+	}
+
+	programAcct, err := instrCtx.BorrowLastProgramAccount(txCtx)
+	if err != nil {
+		return err
+	}
+
+	if programAcct.Owner() != LoaderV4Addr {
+		klog.Infof("Program not owned by loader")
+		programAcct.Drop()
+		return InstrErrUnsupportedProgramId
+	}
+
+	if len(programAcct.Data()) == 0 {
+		klog.Infof("Program is uninitialized")
+		programAcct.Drop()
+		return InstrErrUnsupportedProgramId
+	}
+
+	state, err := unmarshalLoaderV4State(programAcct.Data())
+	if err != nil {
+		programAcct.Drop()
+		return err
+	}
+
+	if state.Status == LoaderV4StatusRetracted {
+		klog.Infof("Program is not deployed")
+		programAcct.Drop()
+		return InstrErrUnsupportedProgramId
+	}
+
+	if execCtx.SlotCtx == nil {
+		programAcct.Drop()
+		return ErrNoSlotCtx
+	}
+
+	// programs deployed in a slot only become visible in the next slot
+	if state.Slot >= execCtx.SlotCtx.Slot {
+		klog.Infof("Program is not deployed")
+		programAcct.Drop()
+		return InstrErrUnsupportedProgramId
+	}
+
+	programBytes := programAcct.Data()[loaderV4ProgramDataOffset:]
+	programAcct.Drop()
+
+	return executeProgram(execCtx, state.Slot, programBytes)
+}
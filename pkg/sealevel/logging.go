@@ -1,5 +1,12 @@
 package sealevel
 
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
 type Logger interface {
 	Log(s string)
 }
@@ -11,3 +18,39 @@ type LogRecorder struct {
 func (r *LogRecorder) Log(s string) {
 	r.Logs = append(r.Logs, s)
 }
+
+// The following record the program invocation, completion and return data log messages in
+// the format used by the Solana Labs validator, such that the logs recorded for a transaction
+// may be compared to those returned by RPC nodes.
+
+func (execCtx *ExecutionCtx) logProgramInvoke(programId solana.PublicKey, stackHeight uint64) {
+	if execCtx.Log != nil {
+		execCtx.Log.Log(fmt.Sprintf("Program %s invoke [%d]", programId, stackHeight))
+	}
+}
+
+func (execCtx *ExecutionCtx) logProgramSuccess(programId solana.PublicKey) {
+	if execCtx.Log != nil {
+		execCtx.Log.Log(fmt.Sprintf("Program %s success", programId))
+	}
+}
+
+// logProgramFailure logs the instruction error corresponding to err, as the runtime
+// displays it.
+func (execCtx *ExecutionCtx) logProgramFailure(programId solana.PublicKey, err error) {
+	if execCtx.Log != nil {
+		execCtx.Log.Log(fmt.Sprintf("Program %s failed: %s", programId, InstructionErrorFromErr(err).Message()))
+	}
+}
+
+func (execCtx *ExecutionCtx) logProgramConsumed(programId solana.PublicKey, consumed uint64, limit uint64) {
+	if execCtx.Log != nil {
+		execCtx.Log.Log(fmt.Sprintf("Program %s consumed %d of %d compute units", programId, consumed, limit))
+	}
+}
+
+func (execCtx *ExecutionCtx) logProgramReturn(programId solana.PublicKey, data []byte) {
+	if execCtx.Log != nil {
+		execCtx.Log.Log(fmt.Sprintf("Program return: %s %s", programId, base64.StdEncoding.EncodeToString(data)))
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gagliardetto/solana-go"
 	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
 )
 
 const BpfLoaderUpgradeableAddrStr = "BPFLoaderUpgradeab1e11111111111111111111111"
@@ -19,6 +20,10 @@ const BpfLoaderDeprecatedAddrStr = "BPFLoader1111111111111111111111111111111111"
 
 var BpfLoaderDeprecatedAddr = base58.MustDecodeFromString(BpfLoaderDeprecatedAddrStr)
 
+const LoaderV4AddrStr = "LoaderV411111111111111111111111111111111111"
+
+var LoaderV4Addr = base58.MustDecodeFromString(LoaderV4AddrStr)
+
 const NativeLoaderAddrStr = "NativeLoader1111111111111111111111111111111"
 
 var NativeLoaderAddr = base58.MustDecodeFromString(NativeLoaderAddrStr)
@@ -88,6 +93,12 @@ func resolveNativeProgramById(programId [32]byte) (func(ctx *ExecutionCtx) error
 		return BpfLoaderProgramExecute, nil
 	case BpfLoaderUpgradeableAddr:
 		return BpfLoaderProgramExecute, nil
+	case LoaderV4Addr:
+		return LoaderV4ProgramExecute, nil
+	case ZkElGamalProofProgramAddr:
+		return ZkElGamalProofProgramExecute, nil
+	case ZkTokenProofProgramAddr:
+		return ZkTokenProofProgramExecute, nil
 	case Ed25519PrecompileAddr:
 		return Ed25519ProgramExecute, nil
 	case Secp256kPrecompileAddr:
@@ -97,6 +108,35 @@ func resolveNativeProgramById(programId [32]byte) (func(ctx *ExecutionCtx) error
 	return nil, InstrErrUnsupportedProgramId
 }
 
+// VerifyPrecompiles verifies the signatures of a tx's ed25519 and secp256k1 precompile
+// instructions ahead of its execution, as the runtime does prior to the activation of
+// MovePrecompileVerificationToSvm, returning the TransactionError for the first instruction
+// that fails verification.
+func VerifyPrecompiles(instrs []Instruction, f *features.Features) *TransactionError {
+	for idx, instr := range instrs {
+		var verify func(execCtx *ExecutionCtx) error
+		switch instr.ProgramId {
+		case Ed25519PrecompileAddr:
+			verify = Ed25519ProgramExecute
+		case Secp256kPrecompileAddr:
+			verify = Secp256k1ProgramExecute
+		default:
+			continue
+		}
+
+		txCtx := &TransactionCtx{InstructionTrace: []InstructionCtx{{Data: instr.Data}}, InstructionStack: []uint64{0}, AllInstructions: instrs}
+		execCtx := &ExecutionCtx{TransactionContext: txCtx}
+		execCtx.GlobalCtx.Features = *f
+
+		err := verify(execCtx)
+		if err != nil {
+			return NewTxErrInstructionError(idx, err)
+		}
+	}
+
+	return nil
+}
+
 func verifySigner(authorized solana.PublicKey, signers []solana.PublicKey) error {
 	for _, signer := range signers {
 		if signer == authorized {
@@ -0,0 +1,858 @@
+package sealevel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/fixtures"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// loader-v4 tests
+
+func loaderV4AcctData(t *testing.T, state LoaderV4State, programBytes []byte) []byte {
+	writer := new(bytes.Buffer)
+	encoder := bin.NewBinEncoder(writer)
+	err := state.MarshalWithEncoder(encoder)
+	assert.NoError(t, err)
+	writer.Write(programBytes)
+	return writer.Bytes()
+}
+
+func newLoaderV4TestExecCtx(txCtx *TransactionCtx, slot uint64) *ExecutionCtx {
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.EnableProgramRuntimeV2AndLoaderV4, 0)
+	execCtx.GlobalCtx.Features = *f
+
+	execCtx.Accounts = accounts.NewMemAccounts()
+	var clock SysvarClock
+	clock.Slot = slot
+	clockAcct := accounts.Account{}
+	clockAcct.Lamports = 1
+	execCtx.Accounts.SetAccount(&SysvarClockAddr, &clockAcct)
+	WriteClockSysvar(&execCtx.Accounts, clock)
+
+	var rent SysvarRent
+	rent.LamportsPerUint8Year = 1
+	rent.ExemptionThreshold = 1
+	rent.BurnPercent = 0
+
+	rentAcct := accounts.Account{}
+	rentAcct.Lamports = 1
+	execCtx.Accounts.SetAccount(&SysvarRentAddr, &rentAcct)
+	WriteRentSysvar(&execCtx.Accounts, rent)
+
+	execCtx.SlotCtx = new(SlotCtx)
+	execCtx.SlotCtx.Slot = slot
+
+	return execCtx
+}
+
+func TestExecute_Tx_LoaderV4_Truncate_Initialize_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	// uninitialized program account, funded beyond its rent exempt minimum
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: make([]byte, 0), Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	recipientPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	recipientAcct := accounts.Account{Key: recipientPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, recipientAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: true, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: recipientAcct.Key, IsSigner: false, IsWritable: true}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	truncate := LoaderV4InstrTruncate{NewSize: 100}
+	err = truncate.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, loaderV4ProgramDataOffset+100, len(programAcctPost.Data))
+
+	// the rent exempt minimum stays with the program and the surplus goes to the recipient
+	requiredLamports := uint64(loaderV4ProgramDataOffset + 100 + rentAccountStorageOverhead)
+	assert.Equal(t, requiredLamports, programAcctPost.Lamports)
+
+	recipientAcctPost, err := txCtx.Accounts.GetAccount(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 10000-requiredLamports, recipientAcctPost.Lamports)
+
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), state.Slot)
+	assert.Equal(t, uint64(LoaderV4StatusRetracted), state.Status)
+	assert.Equal(t, authorityAcct.Key, state.AuthorityAddressOrNextVersion)
+}
+
+func TestExecute_Tx_LoaderV4_Truncate_Initialize_Program_Didnt_Sign_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: make([]byte, 0), Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true}, // program acct didn't sign
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	truncate := LoaderV4InstrTruncate{NewSize: 100}
+	err = truncate.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrMissingRequiredSignature, err)
+}
+
+func TestExecute_Tx_LoaderV4_Truncate_Insufficient_Lamports_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, make([]byte, 100))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 500, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	truncate := LoaderV4InstrTruncate{NewSize: 1000} // too large to be rent exempt with 500 lamports
+	err = truncate.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInsufficientFunds, err)
+}
+
+func TestExecute_Tx_LoaderV4_Truncate_Close_Without_Recipient_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, make([]byte, 100))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 500, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	truncate := LoaderV4InstrTruncate{NewSize: 0}
+	err = truncate.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidArgument, err)
+}
+
+func TestExecute_Tx_LoaderV4_Write_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	var writeInstr LoaderV4InstrWrite
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	writeInstr.Offset = 20
+	writeInstr.Bytes = make([]byte, 100)
+	for count := 0; count < 100; count++ {
+		writeInstr.Bytes[count] = 0x61
+	}
+	err = writeInstr.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	// check the new account state for presence of the newly written data ('a' x 100)
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	startingOffset := loaderV4ProgramDataOffset + uint64(writeInstr.Offset)
+	programBytesPost := programAcctPost.Data[startingOffset : startingOffset+uint64(len(writeInstr.Bytes))]
+	assert.Equal(t, writeInstr.Bytes, programBytesPost)
+}
+
+func TestExecute_Tx_LoaderV4_Write_Out_Of_Bounds_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	var writeInstr LoaderV4InstrWrite
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	writeInstr.Offset = 450 // write extends past the end of the program acct data
+	writeInstr.Bytes = make([]byte, 100)
+	err = writeInstr.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrAccountDataTooSmall, err)
+}
+
+func TestExecute_Tx_LoaderV4_Write_Incorrect_Authority_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: solana.NewWallet().PublicKey(), Status: LoaderV4StatusRetracted} // different authority
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	var writeInstr LoaderV4InstrWrite
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	writeInstr.Bytes = make([]byte, 100)
+	err = writeInstr.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrIncorrectAuthority, err)
+}
+
+func TestExecute_Tx_LoaderV4_Write_Program_Deployed_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed} // not retracted
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	var writeInstr LoaderV4InstrWrite
+	instrWriter := new(bytes.Buffer)
+	instrEncoder := bin.NewBinEncoder(instrWriter)
+	writeInstr.Bytes = make([]byte, 100)
+	err = writeInstr.MarshalWithEncoder(instrEncoder)
+	assert.NoError(t, err)
+	instrData := instrWriter.Bytes()
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidArgument, err)
+}
+
+func TestExecute_Tx_LoaderV4_Deploy_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	validProgramBytes := fixtures.Load(t, "sbpf", "noop_aligned.so")
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, validProgramBytes)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeDeploy)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), state.Slot)
+	assert.Equal(t, uint64(LoaderV4StatusDeployed), state.Status)
+	assert.Equal(t, authorityAcct.Key, state.AuthorityAddressOrNextVersion)
+}
+
+func TestExecute_Tx_LoaderV4_Deploy_From_Source_Program_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	// empty program account
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, nil)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 0, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	// source program account containing the program bytes
+	validProgramBytes := fixtures.Load(t, "sbpf", "noop_aligned.so")
+	sourcePrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	sourceData := loaderV4AcctData(t, programState, validProgramBytes)
+	sourceAcct := accounts.Account{Key: sourcePrivKey.PublicKey(), Lamports: 1000000, Data: sourceData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, sourceAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: sourceAcct.Key, IsSigner: false, IsWritable: true}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeDeploy)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	// the program bytes and the rent exempt minimum are moved from the source to the program
+	requiredLamports := uint64(len(sourceData) + rentAccountStorageOverhead)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, validProgramBytes, programAcctPost.Data[loaderV4ProgramDataOffset:])
+	assert.Equal(t, requiredLamports, programAcctPost.Lamports)
+
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), state.Slot)
+	assert.Equal(t, uint64(LoaderV4StatusDeployed), state.Status)
+
+	sourceAcctPost, err := txCtx.Accounts.GetAccount(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(sourceAcctPost.Data))
+	assert.Equal(t, 1000000-requiredLamports, sourceAcctPost.Lamports)
+}
+
+func TestExecute_Tx_LoaderV4_Deploy_Cooldown_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	validProgramBytes := fixtures.Load(t, "sbpf", "noop_aligned.so")
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 1000, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted} // deployed within the cooldown period
+	programData := loaderV4AcctData(t, programState, validProgramBytes)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeDeploy)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidArgument, err)
+}
+
+func TestExecute_Tx_LoaderV4_Deploy_Invalid_Program_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500)) // not an ELF
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeDeploy)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidAccountData, err)
+}
+
+func TestExecute_Tx_LoaderV4_Retract_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeRetract)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), state.Slot)
+	assert.Equal(t, uint64(LoaderV4StatusRetracted), state.Status)
+}
+
+func TestExecute_Tx_LoaderV4_Retract_Not_Deployed_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted} // already retracted
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeRetract)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidArgument, err)
+}
+
+func TestExecute_Tx_LoaderV4_TransferAuthority_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	newAuthorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	newAuthorityAcct := accounts.Account{Key: newAuthorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, newAuthorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: newAuthorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeTransferAuthority)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, newAuthorityAcct.Key, state.AuthorityAddressOrNextVersion)
+	assert.Equal(t, uint64(LoaderV4StatusDeployed), state.Status)
+}
+
+func TestExecute_Tx_LoaderV4_TransferAuthority_New_Authority_Didnt_Sign_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	newAuthorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	newAuthorityAcct := accounts.Account{Key: newAuthorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, newAuthorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: newAuthorityAcct.Key, IsSigner: false, IsWritable: false}} // new authority didn't sign
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeTransferAuthority)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrMissingRequiredSignature, err)
+}
+
+func TestExecute_Tx_LoaderV4_TransferAuthority_No_Change_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}} // same authority
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeTransferAuthority)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidArgument, err)
+}
+
+func TestExecute_Tx_LoaderV4_Finalize_Success(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	nextVersionPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	nextVersionState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusRetracted}
+	nextVersionData := loaderV4AcctData(t, nextVersionState, nil)
+	nextVersionAcct := accounts.Account{Key: nextVersionPrivKey.PublicKey(), Lamports: 10000, Data: nextVersionData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, nextVersionAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: nextVersionAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeFinalize)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	programAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	state, err := unmarshalLoaderV4State(programAcctPost.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, nextVersionAcct.Key, state.AuthorityAddressOrNextVersion)
+	assert.Equal(t, uint64(LoaderV4StatusFinalized), state.Status)
+}
+
+func TestExecute_Tx_LoaderV4_Finalize_Next_Version_Wrong_Authority_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	nextVersionPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	nextVersionState := LoaderV4State{Slot: 0, AuthorityAddressOrNextVersion: solana.NewWallet().PublicKey(), Status: LoaderV4StatusRetracted} // different authority
+	nextVersionData := loaderV4AcctData(t, nextVersionState, nil)
+	nextVersionAcct := accounts.Account{Key: nextVersionPrivKey.PublicKey(), Lamports: 10000, Data: nextVersionData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct, nextVersionAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false},
+		{Pubkey: nextVersionAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeFinalize)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrIncorrectAuthority, err)
+}
+
+func TestExecute_Tx_LoaderV4_Finalized_Program_Immutable_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusFinalized}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeRetract)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrImmutable, err)
+}
+
+func TestExecute_Tx_LoaderV4_Feature_Inactive_Failure(t *testing.T) {
+	loaderAcct := accounts.Account{Key: LoaderV4Addr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: authorityAcct.Key, Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, make([]byte, 500))
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{loaderAcct, programAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	instrData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(instrData, LoaderV4InstrTypeRetract)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1234)
+	execCtx.GlobalCtx.Features.DisableFeature(features.EnableProgramRuntimeV2AndLoaderV4)
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func TestExecute_Tx_LoaderV4_Invoke_Program_Success(t *testing.T) {
+	validProgramBytes := fixtures.Load(t, "sbpf", "get_stack_height.so")
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: solana.NewWallet().PublicKey(), Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, validProgramBytes)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: true, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{programAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	txCtx.ComputeBudgetLimits = &ComputeBudgetLimits{UpdatedHeapBytes: MinHeapFrameBytes, ComputeUnitLimit: 1400000}
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1337)
+	err = execCtx.ProcessInstruction(make([]byte, 0), instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+}
+
+func TestExecute_Tx_LoaderV4_Invoke_Retracted_Program_Failure(t *testing.T) {
+	validProgramBytes := fixtures.Load(t, "sbpf", "get_stack_height.so")
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: solana.NewWallet().PublicKey(), Status: LoaderV4StatusRetracted}
+	programData := loaderV4AcctData(t, programState, validProgramBytes)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: true, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{programAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	txCtx.ComputeBudgetLimits = &ComputeBudgetLimits{UpdatedHeapBytes: MinHeapFrameBytes, ComputeUnitLimit: 1400000}
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1337)
+	err = execCtx.ProcessInstruction(make([]byte, 0), instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func TestExecute_Tx_LoaderV4_Invoke_Program_No_SlotCtx_Failure(t *testing.T) {
+	validProgramBytes := fixtures.Load(t, "sbpf", "get_stack_height.so")
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	programState := LoaderV4State{Slot: 100, AuthorityAddressOrNextVersion: solana.NewWallet().PublicKey(), Status: LoaderV4StatusDeployed}
+	programData := loaderV4AcctData(t, programState, validProgramBytes)
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: LoaderV4Addr, Executable: true, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{programAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: programAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	txCtx.ComputeBudgetLimits = &ComputeBudgetLimits{UpdatedHeapBytes: MinHeapFrameBytes, ComputeUnitLimit: 1400000}
+	execCtx := newLoaderV4TestExecCtx(txCtx, 1337)
+	execCtx.SlotCtx = nil
+	err = execCtx.ProcessInstruction(make([]byte, 0), instructionAccts, []uint64{0})
+	assert.ErrorIs(t, err, ErrNoSlotCtx)
+}
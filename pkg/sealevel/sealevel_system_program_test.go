@@ -1050,3 +1050,71 @@ func TestExecute_Tx_System_Program_AssignWithSeed_Base_Not_Signer_Failure(t *tes
 	err = execCtx.ProcessInstruction(instrBytes, instructionAccts, []uint64{0})
 	assert.Equal(t, InstrErrMissingRequiredSignature, err)
 }
+
+func TestExecute_Tx_System_Program_InitializeNonceAccount_Blockhash_Unknown_Failure(t *testing.T) {
+
+	// system program acct
+	systemProgramAcct := accounts.Account{Key: SystemProgramAddr, Lamports: 100000000, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+
+	// nonce acct
+	nonceAcctPrivateKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	noncePubkey := nonceAcctPrivateKey.PublicKey()
+	nonceAcct := accounts.Account{Key: noncePubkey, Lamports: 10000, Data: make([]byte, 80), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	recentBlockhashesAcct := accounts.Account{Key: SysvarRecentBlockHashesAddr, Lamports: 1, Data: make([]byte, 0), Owner: SysvarOwnerAddr, Executable: false, RentEpoch: 100}
+	rentSysvarAcct := accounts.Account{Key: SysvarRentAddr, Lamports: 1, Data: make([]byte, 0), Owner: SysvarOwnerAddr, Executable: false, RentEpoch: 100}
+
+	initNonceInstrWriter := new(bytes.Buffer)
+	initNonceEncoder := bin.NewBinEncoder(initNonceInstrWriter)
+
+	err = initNonceEncoder.WriteUint32(SystemProgramInstrTypeInitializeNonceAccount, bin.LE)
+	assert.NoError(t, err)
+	err = initNonceEncoder.WriteBytes(noncePubkey[:], false)
+	assert.NoError(t, err)
+	instrBytes := initNonceInstrWriter.Bytes()
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{systemProgramAcct, nonceAcct, recentBlockhashesAcct, rentSysvarAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: nonceAcct.Key, IsSigner: true, IsWritable: true},
+		{Pubkey: recentBlockhashesAcct.Key, IsSigner: false, IsWritable: false},
+		{Pubkey: rentSysvarAcct.Key, IsSigner: false, IsWritable: false}}
+
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+
+	execCtx.Accounts = accounts.NewMemAccounts()
+
+	recentBlockhashes := SysvarRecentBlockhashes{{Blockhash: [32]byte{1}, FeeCalculator: FeeCalculator{LamportsPerSignature: 5000}}}
+	recentBlockhashesWriter := new(bytes.Buffer)
+	err = recentBlockhashes.MarshalWithEncoder(bin.NewBinEncoder(recentBlockhashesWriter))
+	assert.NoError(t, err)
+	recentBlockhashesSysvarAcct := accounts.Account{Lamports: 1, Data: recentBlockhashesWriter.Bytes()}
+	execCtx.Accounts.SetAccount(&SysvarRecentBlockHashesAddr, &recentBlockhashesSysvarAcct)
+
+	var rent SysvarRent
+	rent.LamportsPerUint8Year = 1
+	rent.ExemptionThreshold = 1
+	rent.BurnPercent = 0
+
+	rentAcct := accounts.Account{}
+	rentAcct.Lamports = 1
+	execCtx.Accounts.SetAccount(&SysvarRentAddr, &rentAcct)
+	WriteRentSysvar(&execCtx.Accounts, rent)
+
+	// the durable nonce cannot be derived without the slot's blockhash
+	err = execCtx.ProcessInstruction(instrBytes, instructionAccts, []uint64{0})
+	assert.ErrorIs(t, err, ErrBlockhashUnknown)
+
+	execCtx.Blockhash = [32]byte{1}
+	err = execCtx.ProcessInstruction(instrBytes, instructionAccts, []uint64{0})
+	assert.NoError(t, err)
+
+	nonceAcctAfter, err := execCtx.TransactionContext.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	nonceStateVersions, err := UnmarshalNonceStateVersions(nonceAcctAfter.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, DurableNonceFromBlockhash([32]byte{1}), nonceStateVersions.State().DurableNonce)
+}
@@ -3,9 +3,12 @@ package sealevel
 import (
 	"bytes"
 	_ "embed"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"math"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
@@ -2069,6 +2072,242 @@ func (e *executeCase) run(t *testing.T) {
 	assert.Equal(t, logs, e.Logs)
 }
 
+// runSyscallTestProgram executes the given fixture program with the instruction data and no
+// instruction accounts, after setting up the execution context with the clock and rent
+// sysvars and the given features, and returns the execution context and the error, if any.
+func runSyscallTestProgram(t *testing.T, programFile string, f *features.Features, instrData []byte, setup func(execCtx *ExecutionCtx)) (*ExecutionCtx, error) {
+	// program data account
+	programDataPrivKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	programDataPubkey := programDataPrivKey.PublicKey()
+	programDataAcctState := UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgramData, ProgramData: UpgradeableLoaderStateProgramData{Slot: 0, UpgradeAuthorityAddress: nil}}
+	validProgramBytes := fixtures.Load(t, "sbpf", programFile)
+	programDataStateWriter := new(bytes.Buffer)
+	programDataStateEncoder := bin.NewBinEncoder(programDataStateWriter)
+	err = programDataAcctState.MarshalWithEncoder(programDataStateEncoder)
+	require.NoError(t, err)
+	programDataStateBytes := make([]byte, len(validProgramBytes)+upgradeableLoaderSizeOfProgramDataMetaData)
+	copy(programDataStateBytes, programDataStateWriter.Bytes())
+	copy(programDataStateBytes[upgradeableLoaderSizeOfProgramDataMetaData:], validProgramBytes)
+
+	programDataAcct := accounts.Account{Key: programDataPubkey, Lamports: 0, Data: programDataStateBytes, Owner: BpfLoaderUpgradeableAddr, Executable: false, RentEpoch: 100}
+
+	// program account
+	programAcctState := UpgradeableLoaderState{Type: UpgradeableLoaderStateTypeProgram, Program: UpgradeableLoaderStateProgram{ProgramDataAddress: programDataAcct.Key}}
+	programWriter := new(bytes.Buffer)
+	programEncoder := bin.NewBinEncoder(programWriter)
+	err = programAcctState.MarshalWithEncoder(programEncoder)
+	require.NoError(t, err)
+	programPrivKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	programData := make([]byte, 5000)
+	copy(programData, programWriter.Bytes())
+	programAcct := accounts.Account{Key: programPrivKey.PublicKey(), Lamports: 10000, Data: programData, Owner: BpfLoaderUpgradeableAddr, Executable: true, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{programAcct})
+	instructionAccts := InstructionAcctsFromAccountMetas([]AccountMeta{}, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	txCtx.ComputeBudgetLimits = &ComputeBudgetLimits{UpdatedHeapBytes: MinHeapFrameBytes, ComputeUnitLimit: 1400000}
+	var log LogRecorder
+	execCtx := &ExecutionCtx{Log: &log, TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(1400000)}
+
+	execCtx.Accounts = accounts.NewMemAccounts()
+	clockAcct := accounts.Account{Lamports: 1}
+	execCtx.Accounts.SetAccount(&SysvarClockAddr, &clockAcct)
+	WriteClockSysvar(&execCtx.Accounts, SysvarClock{Slot: 1337, Epoch: 12})
+
+	rentAcct := accounts.Account{Lamports: 1}
+	execCtx.Accounts.SetAccount(&SysvarRentAddr, &rentAcct)
+	WriteRentSysvar(&execCtx.Accounts, SysvarRent{LamportsPerUint8Year: 3480, ExemptionThreshold: 2, BurnPercent: 50})
+
+	pk := [32]byte(programDataAcct.Key)
+	err = execCtx.Accounts.SetAccount(&pk, &programDataAcct)
+	require.NoError(t, err)
+
+	execCtx.GlobalCtx.Features = *f
+	execCtx.SlotCtx = new(SlotCtx)
+	execCtx.SlotCtx.Slot = 1337
+	execCtx.SlotCtx.Accounts = execCtx.Accounts
+
+	if setup != nil {
+		setup(execCtx)
+	}
+
+	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	return execCtx, err
+}
+
+func TestInterpreter_Remaining_Compute_Units_Syscall(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.RemainingComputeUnitsSyscallEnabled, 0)
+
+	execCtx, err := runSyscallTestProgram(t, "remaining_compute_units.so", f, nil, nil)
+	require.NoError(t, err)
+
+	_, returnData := execCtx.TransactionContext.ReturnData()
+	require.Len(t, returnData, 16)
+	first := binary.LittleEndian.Uint64(returnData[:8])
+	second := binary.LittleEndian.Uint64(returnData[8:])
+
+	// the second call is charged the syscall base cost, plus the cost of the two instructions
+	// executed since the first call
+	assert.Less(t, first, uint64(1400000))
+	assert.Equal(t, uint64(CUSyscallBaseCost+2), first-second)
+
+	// the syscall is not registered without its feature gate
+	_, err = runSyscallTestProgram(t, "remaining_compute_units.so", features.NewFeaturesDefault(), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Big_Mod_Exp_Syscall(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.EnableBigModExpSyscall, 0)
+
+	bigModExpInstrData := func(base, exponent, modulus []byte) []byte {
+		data := binary.LittleEndian.AppendUint64(nil, uint64(len(base)))
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(exponent)))
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(modulus)))
+		data = append(data, base...)
+		data = append(data, exponent...)
+		return append(data, modulus...)
+	}
+
+	largeModulus := bytes.Repeat([]byte{0xff}, 64)
+	largeModulus[63] = 0xc5
+	largeBase := bytes.Repeat([]byte{0x12, 0x34}, 32)
+
+	cases := []struct {
+		name     string
+		base     []byte
+		exponent []byte
+		modulus  []byte
+		expected []byte
+	}{
+		{name: "small", base: []byte{3}, exponent: []byte{5}, modulus: []byte{0, 7}, expected: []byte{0, 5}},
+		{name: "zero exponent", base: []byte{9}, exponent: nil, modulus: []byte{13}, expected: []byte{1}},
+		{name: "zero modulus", base: []byte{9}, exponent: []byte{2}, modulus: []byte{0, 0}, expected: []byte{0, 0}},
+		{name: "modulus one", base: []byte{9}, exponent: []byte{2}, modulus: []byte{1}, expected: []byte{0}},
+		{name: "large", base: largeBase, exponent: []byte{0x01, 0x00, 0x01}, modulus: largeModulus,
+			expected: new(big.Int).Exp(new(big.Int).SetBytes(largeBase), big.NewInt(0x10001), new(big.Int).SetBytes(largeModulus)).FillBytes(make([]byte, 64))},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			execCtx, err := runSyscallTestProgram(t, "big_mod_exp.so", f, bigModExpInstrData(c.base, c.exponent, c.modulus), nil)
+			require.NoError(t, err)
+
+			_, returnData := execCtx.TransactionContext.ReturnData()
+			assert.Equal(t, c.expected, returnData)
+		})
+	}
+
+	// inputs longer than 512 bytes are rejected
+	_, err := runSyscallTestProgram(t, "big_mod_exp.so", f, bigModExpInstrData([]byte{2}, []byte{2}, make([]byte, 513)), nil)
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Get_Sysvar_Syscall(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.GetSysvarSyscallEnabled, 0)
+
+	getSysvarInstrData := func(sysvarId [32]byte, offset, length uint64) []byte {
+		data := append([]byte{}, sysvarId[:]...)
+		data = binary.LittleEndian.AppendUint64(data, offset)
+		return binary.LittleEndian.AppendUint64(data, length)
+	}
+
+	// the clock sysvar set up by runSyscallTestProgram
+	clockBytes := make([]byte, SysvarClockStructLen)
+	binary.LittleEndian.PutUint64(clockBytes[:8], 1337)
+	binary.LittleEndian.PutUint64(clockBytes[16:24], 12)
+
+	cases := []struct {
+		name     string
+		sysvarId [32]byte
+		offset   uint64
+		length   uint64
+		result   uint64
+		expected []byte
+	}{
+		{name: "whole clock", sysvarId: SysvarClockAddr, offset: 0, length: SysvarClockStructLen, result: GetSysvarSuccess, expected: clockBytes},
+		{name: "clock epoch", sysvarId: SysvarClockAddr, offset: 16, length: 8, result: GetSysvarSuccess, expected: clockBytes[16:24]},
+		{name: "past end of clock", sysvarId: SysvarClockAddr, offset: 8, length: SysvarClockStructLen, result: GetSysvarOffsetLengthExceedsSysvar, expected: make([]byte, SysvarClockStructLen)},
+		{name: "unsupported sysvar", sysvarId: SysvarFeesAddr, offset: 0, length: 8, result: GetSysvarSysvarNotFound, expected: make([]byte, 8)},
+		{name: "missing sysvar", sysvarId: SysvarLastRestartSlotAddr, offset: 0, length: 8, result: GetSysvarSysvarNotFound, expected: make([]byte, 8)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			execCtx, err := runSyscallTestProgram(t, "get_sysvar.so", f, getSysvarInstrData(c.sysvarId, c.offset, c.length), nil)
+			require.NoError(t, err)
+
+			_, returnData := execCtx.TransactionContext.ReturnData()
+			require.Len(t, returnData, 8+int(c.length))
+			assert.Equal(t, c.result, binary.LittleEndian.Uint64(returnData[:8]))
+			assert.Equal(t, c.expected, returnData[8:])
+		})
+	}
+
+	// an offset and length overflowing a u64 aborts the program
+	_, err := runSyscallTestProgram(t, "get_sysvar.so", f, getSysvarInstrData(SysvarClockAddr, math.MaxUint64, 8), nil)
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Get_Epoch_Stake_Syscall(t *testing.T) {
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.EnableGetEpochStakeSyscall, 0)
+
+	voteAcct := solana.MustPublicKeyFromBase58("EGsvbNQWLy1KJnTpFTPUC3hdAaRPSbNCvvf4ZGtSxXEX")
+	unknownVoteAcct := solana.MustPublicKeyFromBase58("5ZWgXcyqrrNpQHCme5SdC5hCeYb2o3fEJhF7Gok3bTVN")
+	setup := func(execCtx *ExecutionCtx) {
+		execCtx.SlotCtx.EpochTotalStake = 1000000
+		execCtx.SlotCtx.EpochVoteAccountStakes = map[solana.PublicKey]uint64{voteAcct: 250000}
+	}
+
+	cases := []struct {
+		name      string
+		instrData []byte
+		expected  uint64
+	}{
+		{name: "total stake", instrData: nil, expected: 1000000},
+		{name: "vote account stake", instrData: voteAcct[:], expected: 250000},
+		{name: "unknown vote account", instrData: unknownVoteAcct[:], expected: 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			execCtx, err := runSyscallTestProgram(t, "get_epoch_stake.so", f, c.instrData, setup)
+			require.NoError(t, err)
+
+			_, returnData := execCtx.TransactionContext.ReturnData()
+			require.Len(t, returnData, 8)
+			assert.Equal(t, c.expected, binary.LittleEndian.Uint64(returnData))
+		})
+	}
+}
+
+func TestInterpreter_Get_Fees_Sysvar_Syscall(t *testing.T) {
+	setup := func(execCtx *ExecutionCtx) {
+		feesAcct := accounts.Account{Lamports: 1}
+		execCtx.Accounts.SetAccount(&SysvarFeesAddr, &feesAcct)
+		WriteFeesSysvar(&execCtx.Accounts, SysvarFees{FeeCalculator: FeeCalculator{LamportsPerSignature: 5000}})
+	}
+
+	execCtx, err := runSyscallTestProgram(t, "get_fees_sysvar.so", features.NewFeaturesDefault(), nil, setup)
+	require.NoError(t, err)
+
+	_, returnData := execCtx.TransactionContext.ReturnData()
+	require.Len(t, returnData, 8)
+	assert.Equal(t, uint64(5000), binary.LittleEndian.Uint64(returnData))
+
+	// the syscall is no longer registered once the fees sysvar is disabled
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.DisableFeesSysvar, 0)
+	_, err = runSyscallTestProgram(t, "get_fees_sysvar.so", f, nil, setup)
+	assert.Error(t, err)
+}
+
 func TestExecute(t *testing.T) {
 	// Collect test cases
 	var cases []executeCase
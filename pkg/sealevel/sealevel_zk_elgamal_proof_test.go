@@ -0,0 +1,1051 @@
+package sealevel
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gtank/ristretto255"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// zk elgamal proof program tests. the proofs are generated by the provers below, which follow
+// the zk-sdk provers for each proof type.
+
+func zkTestRandomScalar() *ristretto255.Scalar {
+	buf := make([]byte, 64)
+	_, _ = rand.Read(buf)
+	return ristretto255.NewScalar().FromUniformBytes(buf)
+}
+
+func zkTestMulPoint(s *ristretto255.Scalar, p *ristretto255.Element) *ristretto255.Element {
+	return ristretto255.NewElement().ScalarMult(s, p)
+}
+
+func zkTestAddPoints(points ...*ristretto255.Element) *ristretto255.Element {
+	sum := ristretto255.NewElement().Zero()
+	for _, p := range points {
+		sum.Add(sum, p)
+	}
+	return sum
+}
+
+func zkTestAdd(x, y *ristretto255.Scalar) *ristretto255.Scalar {
+	return ristretto255.NewScalar().Add(x, y)
+}
+
+// zkTestKeypair returns an ElGamal secret key s and its pubkey P = s^-1 * H.
+func zkTestKeypair() (*ristretto255.Scalar, *ristretto255.Element) {
+	s := zkTestRandomScalar()
+	return s, zkTestMulPoint(ristretto255.NewScalar().Invert(s), zkPedersenH)
+}
+
+// zkTestCommit returns the Pedersen commitment x*G + r*H.
+func zkTestCommit(x uint64, r *ristretto255.Scalar) *ristretto255.Element {
+	return zkTestAddPoints(zkTestMulPoint(zkScalarFromU64(x), zkPedersenG), zkTestMulPoint(r, zkPedersenH))
+}
+
+func zkTestEncode(points ...*ristretto255.Element) []byte {
+	var out []byte
+	for _, p := range points {
+		out = p.Encode(out)
+	}
+	return out
+}
+
+func zkTestEncodeScalars(scalars ...*ristretto255.Scalar) []byte {
+	var out []byte
+	for _, s := range scalars {
+		out = s.Encode(out)
+	}
+	return out
+}
+
+func zkTestProveZeroCiphertext(s *ristretto255.Scalar, P, D *ristretto255.Element, transcript *zkTranscript) []byte {
+	transcript.zeroCiphertextProofDomainSeparator()
+
+	y := zkTestRandomScalar()
+	YP := zkTestMulPoint(y, P)
+	YD := zkTestMulPoint(y, D)
+	transcript.AppendMessage("Y_P", YP.Encode(nil))
+	transcript.AppendMessage("Y_D", YD.Encode(nil))
+
+	c := transcript.challengeScalar("c")
+	z := zkTestAdd(zkMul(c, s), y)
+
+	return append(zkTestEncode(YP, YD), zkTestEncodeScalars(z)...)
+}
+
+func zkTestProveCiphertextCiphertextEquality(s *ristretto255.Scalar, P1, D1, P2 *ristretto255.Element, x uint64, r *ristretto255.Scalar, transcript *zkTranscript) []byte {
+	transcript.ciphertextCiphertextEqualityProofDomainSeparator()
+
+	ys, yx, yr := zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar()
+	Y0 := zkTestMulPoint(ys, P1)
+	Y1 := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(ys, D1))
+	Y2 := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(yr, zkPedersenH))
+	Y3 := zkTestMulPoint(yr, P2)
+	for i, Y := range []*ristretto255.Element{Y0, Y1, Y2, Y3} {
+		transcript.AppendMessage([]string{"Y_0", "Y_1", "Y_2", "Y_3"}[i], Y.Encode(nil))
+	}
+
+	c := transcript.challengeScalar("c")
+	zs := zkTestAdd(zkMul(c, s), ys)
+	zx := zkTestAdd(zkMul(c, zkScalarFromU64(x)), yx)
+	zr := zkTestAdd(zkMul(c, r), yr)
+
+	return append(zkTestEncode(Y0, Y1, Y2, Y3), zkTestEncodeScalars(zs, zx, zr)...)
+}
+
+func zkTestProveCiphertextCommitmentEquality(s *ristretto255.Scalar, P, D *ristretto255.Element, x uint64, r *ristretto255.Scalar, transcript *zkTranscript) []byte {
+	transcript.ciphertextCommitmentEqualityProofDomainSeparator()
+
+	ys, yx, yr := zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar()
+	Y0 := zkTestMulPoint(ys, P)
+	Y1 := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(ys, D))
+	Y2 := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(yr, zkPedersenH))
+	for i, Y := range []*ristretto255.Element{Y0, Y1, Y2} {
+		transcript.AppendMessage([]string{"Y_0", "Y_1", "Y_2"}[i], Y.Encode(nil))
+	}
+
+	c := transcript.challengeScalar("c")
+	zs := zkTestAdd(zkMul(c, s), ys)
+	zx := zkTestAdd(zkMul(c, zkScalarFromU64(x)), yx)
+	zr := zkTestAdd(zkMul(c, r), yr)
+
+	// the verifier's transcript is followed, so that proofs composed with it verify
+	transcript.appendScalar("z_s", zs)
+	transcript.appendScalar("z_x", zx)
+	transcript.appendScalar("z_r", zr)
+	_ = transcript.challengeScalar("w")
+
+	return append(zkTestEncode(Y0, Y1, Y2), zkTestEncodeScalars(zs, zx, zr)...)
+}
+
+func zkTestProvePubkeyValidity(s *ristretto255.Scalar, P *ristretto255.Element, transcript *zkTranscript) []byte {
+	transcript.appendDomainSeparator("pubkey-proof")
+
+	y := zkTestRandomScalar()
+	Y := zkTestMulPoint(y, P)
+	transcript.AppendMessage("Y", Y.Encode(nil))
+
+	c := transcript.challengeScalar("c")
+	z := zkTestAdd(zkMul(c, s), y)
+
+	return append(zkTestEncode(Y), zkTestEncodeScalars(z)...)
+}
+
+// zkTestProvePercentageWithCap proves that the delta and claimed commitments commit to the
+// same amount, simulating the proof that the percentage commitment commits to the max value.
+func zkTestProvePercentageWithCap(CMax *ristretto255.Element, maxValue uint64, x uint64, rDelta, rClaimed *ristretto255.Scalar, transcript *zkTranscript) []byte {
+	transcript.appendDomainSeparator("percentage-with-cap-proof")
+
+	zMax, cMax := zkTestRandomScalar(), zkTestRandomScalar()
+	CMaxMinusM := zkTestAddPoints(CMax, zkTestMulPoint(zkNeg(zkScalarFromU64(maxValue)), zkPedersenG))
+	YMax := zkTestAddPoints(zkTestMulPoint(zMax, zkPedersenH), zkTestMulPoint(zkNeg(cMax), CMaxMinusM))
+
+	yx, yDelta, yClaimed := zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar()
+	YDelta := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(yDelta, zkPedersenH))
+	YClaimed := zkTestAddPoints(zkTestMulPoint(yx, zkPedersenG), zkTestMulPoint(yClaimed, zkPedersenH))
+
+	transcript.AppendMessage("Y_max_proof", YMax.Encode(nil))
+	transcript.AppendMessage("Y_delta", YDelta.Encode(nil))
+	transcript.AppendMessage("Y_claimed", YClaimed.Encode(nil))
+
+	c := transcript.challengeScalar("c")
+	cEquality := ristretto255.NewScalar().Subtract(c, cMax)
+
+	zx := zkTestAdd(zkMul(cEquality, zkScalarFromU64(x)), yx)
+	zDelta := zkTestAdd(zkMul(cEquality, rDelta), yDelta)
+	zClaimed := zkTestAdd(zkMul(cEquality, rClaimed), yClaimed)
+
+	proof := append(zkTestEncode(YMax), zkTestEncodeScalars(zMax, cMax)...)
+	proof = append(proof, zkTestEncode(YDelta, YClaimed)...)
+	return append(proof, zkTestEncodeScalars(zx, zDelta, zClaimed)...)
+}
+
+func zkTestProveGroupedCiphertextValidity(pubkeys []*ristretto255.Element, x uint64, r *ristretto255.Scalar, transcript *zkTranscript) []byte {
+	yr, yx := zkTestRandomScalar(), zkTestRandomScalar()
+	Y := []*ristretto255.Element{zkTestAddPoints(zkTestMulPoint(yr, zkPedersenH), zkTestMulPoint(yx, zkPedersenG))}
+	for _, P := range pubkeys {
+		Y = append(Y, zkTestMulPoint(yr, P))
+	}
+	for i := range Y {
+		transcript.AppendMessage([]string{"Y_0", "Y_1", "Y_2", "Y_3"}[i], Y[i].Encode(nil))
+	}
+
+	c := transcript.challengeScalar("c")
+	zr := zkTestAdd(zkMul(c, r), yr)
+	zx := zkTestAdd(zkMul(c, zkScalarFromU64(x)), yx)
+
+	return append(zkTestEncode(Y...), zkTestEncodeScalars(zr, zx)...)
+}
+
+// zkTestGroupedCiphertext encrypts x under each of the pubkeys with the same opening r.
+func zkTestGroupedCiphertext(pubkeys []*ristretto255.Element, x uint64, r *ristretto255.Scalar) []byte {
+	points := []*ristretto255.Element{zkTestCommit(x, r)}
+	for _, P := range pubkeys {
+		points = append(points, zkTestMulPoint(r, P))
+	}
+	return zkTestEncode(points...)
+}
+
+func zkTestInnerProduct(a, b []*ristretto255.Scalar) *ristretto255.Scalar {
+	sum := ristretto255.NewScalar()
+	for i := range a {
+		sum.Add(sum, zkMul(a[i], b[i]))
+	}
+	return sum
+}
+
+// zkTestProveInnerProduct proves <a, b> for the generators G and H, returning the interleaved
+// L and R points followed by a and b.
+func zkTestProveInnerProduct(Q *ristretto255.Element, G, H []*ristretto255.Element, a, b []*ristretto255.Scalar, transcript *zkTranscript) []byte {
+	n := len(G)
+	transcript.appendDomainSeparator("inner-product")
+	transcript.appendU64("n", uint64(n))
+
+	var out []byte
+	for n > 1 {
+		n = n / 2
+		aL, aR, bL, bR := a[:n], a[n:], b[:n], b[n:]
+		GL, GR, HL, HR := G[:n], G[n:], H[:n], H[n:]
+
+		cL := zkTestInnerProduct(aL, bR)
+		cR := zkTestInnerProduct(aR, bL)
+
+		L := zkTestMulPoint(cL, Q)
+		R := zkTestMulPoint(cR, Q)
+		for i := 0; i < n; i++ {
+			L = zkTestAddPoints(L, zkTestMulPoint(aL[i], GR[i]), zkTestMulPoint(bR[i], HL[i]))
+			R = zkTestAddPoints(R, zkTestMulPoint(aR[i], GL[i]), zkTestMulPoint(bL[i], HR[i]))
+		}
+
+		transcript.AppendMessage("L", L.Encode(nil))
+		transcript.AppendMessage("R", R.Encode(nil))
+		out = append(out, zkTestEncode(L, R)...)
+
+		u := transcript.challengeScalar("u")
+		uInv := ristretto255.NewScalar().Invert(u)
+
+		newA, newB := make([]*ristretto255.Scalar, n), make([]*ristretto255.Scalar, n)
+		newG, newH := make([]*ristretto255.Element, n), make([]*ristretto255.Element, n)
+		for i := 0; i < n; i++ {
+			newA[i] = zkTestAdd(zkMul(aL[i], u), zkMul(uInv, aR[i]))
+			newB[i] = zkTestAdd(zkMul(bL[i], uInv), zkMul(u, bR[i]))
+			newG[i] = zkTestAddPoints(zkTestMulPoint(uInv, GL[i]), zkTestMulPoint(u, GR[i]))
+			newH[i] = zkTestAddPoints(zkTestMulPoint(u, HL[i]), zkTestMulPoint(uInv, HR[i]))
+		}
+		a, b, G, H = newA, newB, newG, newH
+	}
+
+	return append(out, zkTestEncodeScalars(a[0], b[0])...)
+}
+
+// zkTestProveRange proves that each amount fits within its bit length, given the openings
+// of the commitments to the amounts.
+func zkTestProveRange(amounts []uint64, bitLengths []int, openings []*ristretto255.Scalar, transcript *zkTranscript) []byte {
+	var nm int
+	for _, n := range bitLengths {
+		nm += n
+	}
+
+	transcript.appendDomainSeparator("range-proof")
+	transcript.appendU64("n", uint64(nm))
+
+	one := zkScalarOne()
+	G, H := zkBulletproofGensG[:nm], zkBulletproofGensH[:nm]
+
+	aBlinding := zkTestRandomScalar()
+	A := zkTestMulPoint(aBlinding, zkPedersenH)
+	var aL []*ristretto255.Scalar
+	for j, amount := range amounts {
+		for i := 0; i < bitLengths[j]; i++ {
+			bit := zkScalarFromU64((amount >> i) & 1)
+			aL = append(aL, bit)
+			A = zkTestAddPoints(A, zkTestMulPoint(bit, G[len(aL)-1]), zkTestMulPoint(ristretto255.NewScalar().Subtract(bit, one), H[len(aL)-1]))
+		}
+	}
+
+	sBlinding := zkTestRandomScalar()
+	S := zkTestMulPoint(sBlinding, zkPedersenH)
+	sL, sR := make([]*ristretto255.Scalar, nm), make([]*ristretto255.Scalar, nm)
+	for i := 0; i < nm; i++ {
+		sL[i], sR[i] = zkTestRandomScalar(), zkTestRandomScalar()
+		S = zkTestAddPoints(S, zkTestMulPoint(sL[i], G[i]), zkTestMulPoint(sR[i], H[i]))
+	}
+
+	transcript.AppendMessage("A", A.Encode(nil))
+	transcript.AppendMessage("S", S.Encode(nil))
+
+	y := transcript.challengeScalar("y")
+	z := transcript.challengeScalar("z")
+
+	// l(X) = l0 + l1*X and r(X) = r0 + r1*X
+	l0, l1 := make([]*ristretto255.Scalar, nm), make([]*ristretto255.Scalar, nm)
+	r0, r1 := make([]*ristretto255.Scalar, nm), make([]*ristretto255.Scalar, nm)
+	i := 0
+	expZ := zkMul(z, z)
+	expY := zkScalarOne()
+	for j := range amounts {
+		exp2 := zkScalarOne()
+		for k := 0; k < bitLengths[j]; k++ {
+			aR := ristretto255.NewScalar().Subtract(aL[i], one)
+			l0[i] = ristretto255.NewScalar().Subtract(aL[i], z)
+			l1[i] = sL[i]
+			r0[i] = zkTestAdd(zkMul(expY, zkTestAdd(aR, z)), zkMul(expZ, exp2))
+			r1[i] = zkMul(expY, sR[i])
+			expY = zkMul(expY, y)
+			exp2 = zkTestAdd(exp2, exp2)
+			i++
+		}
+		expZ = zkMul(expZ, z)
+	}
+
+	t1 := zkTestAdd(zkTestInnerProduct(l0, r1), zkTestInnerProduct(l1, r0))
+	t2 := zkTestInnerProduct(l1, r1)
+	t0 := zkTestInnerProduct(l0, r0)
+
+	t1Blinding, t2Blinding := zkTestRandomScalar(), zkTestRandomScalar()
+	T1 := zkTestAddPoints(zkTestMulPoint(t1, zkPedersenG), zkTestMulPoint(t1Blinding, zkPedersenH))
+	T2 := zkTestAddPoints(zkTestMulPoint(t2, zkPedersenG), zkTestMulPoint(t2Blinding, zkPedersenH))
+
+	transcript.AppendMessage("T_1", T1.Encode(nil))
+	transcript.AppendMessage("T_2", T2.Encode(nil))
+
+	x := transcript.challengeScalar("x")
+
+	aggOpening := ristretto255.NewScalar()
+	expZ = zkMul(z, z)
+	for _, opening := range openings {
+		aggOpening.Add(aggOpening, zkMul(expZ, opening))
+		expZ = zkMul(expZ, z)
+	}
+
+	xx := zkMul(x, x)
+	tx := zkTestAdd(t0, zkTestAdd(zkMul(t1, x), zkMul(t2, xx)))
+	txBlinding := zkTestAdd(aggOpening, zkTestAdd(zkMul(t1Blinding, x), zkMul(t2Blinding, xx)))
+	eBlinding := zkTestAdd(aBlinding, zkMul(sBlinding, x))
+
+	transcript.appendScalar("t_x", tx)
+	transcript.appendScalar("t_x_blinding", txBlinding)
+	transcript.appendScalar("e_blinding", eBlinding)
+
+	w := transcript.challengeScalar("w")
+	_ = transcript.challengeScalar("c")
+	Q := zkTestMulPoint(w, zkPedersenG)
+
+	lVec, rVec := make([]*ristretto255.Scalar, nm), make([]*ristretto255.Scalar, nm)
+	hPrime := make([]*ristretto255.Element, nm)
+	yInv := ristretto255.NewScalar().Invert(y)
+	expYInv := zkScalarOne()
+	for i := 0; i < nm; i++ {
+		lVec[i] = zkTestAdd(l0[i], zkMul(l1[i], x))
+		rVec[i] = zkTestAdd(r0[i], zkMul(r1[i], x))
+		hPrime[i] = zkTestMulPoint(expYInv, H[i])
+		expYInv = zkMul(expYInv, yInv)
+	}
+
+	ipp := zkTestProveInnerProduct(Q, G, hPrime, lVec, rVec, transcript)
+
+	proof := zkTestEncode(A, S, T1, T2)
+	proof = append(proof, zkTestEncodeScalars(tx, txBlinding, eBlinding)...)
+	return append(proof, ipp...)
+}
+
+// zkTestRangeProofInstrData builds the instruction data of a batched range proof over the
+// commitments to the amounts.
+func zkTestRangeProofInstrData(instrType byte, amounts []uint64, bitLengths []int) []byte {
+	context := make([]byte, ZkBatchedRangeProofCtxLen)
+	openings := make([]*ristretto255.Scalar, len(amounts))
+	for i, amount := range amounts {
+		openings[i] = zkTestRandomScalar()
+		copy(context[i*32:], zkTestCommit(amount, openings[i]).Encode(nil))
+		context[ZkRangeProofMaxCommitments*32+i] = byte(bitLengths[i])
+	}
+
+	transcript := newZkTranscript("batched-range-proof-instruction")
+	transcript.AppendMessage("commitments", context[:ZkRangeProofMaxCommitments*32])
+	transcript.AppendMessage("bit-lengths", context[ZkRangeProofMaxCommitments*32:])
+
+	proof := zkTestProveRange(amounts, bitLengths, openings, transcript)
+
+	return append(append([]byte{instrType}, context...), proof...)
+}
+
+func zkTestZeroCiphertextInstrData() []byte {
+	s, P := zkTestKeypair()
+	r := zkTestRandomScalar()
+	C, D := zkTestCommit(0, r), zkTestMulPoint(r, P)
+
+	context := zkTestEncode(P, C, D)
+	transcript := newZkTranscript("zero-ciphertext-instruction")
+	transcript.AppendMessage("pubkey", context[0:32])
+	transcript.AppendMessage("ciphertext", context[32:96])
+
+	proof := zkTestProveZeroCiphertext(s, P, D, transcript)
+	return append(append([]byte{ZkElGamalProofInstrTypeVerifyZeroCiphertext}, context...), proof...)
+}
+
+func newZkElGamalProofTestExecCtx(txCtx *TransactionCtx) *ExecutionCtx {
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.ZkElgamalProofProgramEnabled, 0)
+	execCtx.GlobalCtx.Features = *f
+	return execCtx
+}
+
+func zkElGamalProofProgramAcct() accounts.Account {
+	return accounts.Account{Key: ZkElGamalProofProgramAddr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+}
+
+// zkTestVerify executes a proof verification instruction without a context state account.
+func zkTestVerify(t *testing.T, instrData []byte) error {
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct()})
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	return execCtx.ProcessInstruction(instrData, []InstructionAccount{}, []uint64{0})
+}
+
+// zkTestTamper flips a bit in the last scalar of the proof in the instruction data.
+func zkTestTamper(instrData []byte) []byte {
+	tampered := make([]byte, len(instrData))
+	copy(tampered, instrData)
+	tampered[len(tampered)-32] ^= 1
+	return tampered
+}
+
+func TestExecute_Tx_ZkElGamalProof_ZeroCiphertext_Success(t *testing.T) {
+	err := zkTestVerify(t, zkTestZeroCiphertextInstrData())
+	assert.Equal(t, nil, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_ZeroCiphertext_Tampered_Failure(t *testing.T) {
+	err := zkTestVerify(t, zkTestTamper(zkTestZeroCiphertextInstrData()))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_ZeroCiphertext_Nonzero_Amount_Failure(t *testing.T) {
+	s, P := zkTestKeypair()
+	r := zkTestRandomScalar()
+	C, D := zkTestCommit(1, r), zkTestMulPoint(r, P)
+
+	context := zkTestEncode(P, C, D)
+	transcript := newZkTranscript("zero-ciphertext-instruction")
+	transcript.AppendMessage("pubkey", context[0:32])
+	transcript.AppendMessage("ciphertext", context[32:96])
+
+	proof := zkTestProveZeroCiphertext(s, P, D, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyZeroCiphertext}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_CiphertextCiphertextEquality_Success(t *testing.T) {
+	s1, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	r1, r2 := zkTestRandomScalar(), zkTestRandomScalar()
+	const amount = 55
+
+	context := zkTestEncode(P1, P2, zkTestCommit(amount, r1), zkTestMulPoint(r1, P1), zkTestCommit(amount, r2), zkTestMulPoint(r2, P2))
+	transcript := newZkTranscript("ciphertext-ciphertext-equality-instruction")
+	transcript.AppendMessage("first-pubkey", context[0:32])
+	transcript.AppendMessage("second-pubkey", context[32:64])
+	transcript.AppendMessage("first-ciphertext", context[64:128])
+	transcript.AppendMessage("second-ciphertext", context[128:192])
+
+	proof := zkTestProveCiphertextCiphertextEquality(s1, P1, zkTestMulPoint(r1, P1), P2, amount, r2, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyCiphertextCiphertextEquality}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_CiphertextCommitmentEquality_Success(t *testing.T) {
+	s, P := zkTestKeypair()
+	rCiphertext, rCommitment := zkTestRandomScalar(), zkTestRandomScalar()
+	const amount = 1234
+	D := zkTestMulPoint(rCiphertext, P)
+
+	context := zkTestEncode(P, zkTestCommit(amount, rCiphertext), D, zkTestCommit(amount, rCommitment))
+	transcript := newZkTranscript("ciphertext-commitment-equality-instruction")
+	transcript.AppendMessage("pubkey", context[0:32])
+	transcript.AppendMessage("ciphertext", context[32:96])
+	transcript.AppendMessage("commitment", context[96:128])
+
+	proof := zkTestProveCiphertextCommitmentEquality(s, P, D, amount, rCommitment, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyCiphertextCommitmentEquality}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_PubkeyValidity_Success(t *testing.T) {
+	s, P := zkTestKeypair()
+
+	context := zkTestEncode(P)
+	transcript := newZkTranscript("pubkey-validity-instruction")
+	transcript.AppendMessage("pubkey", context)
+
+	proof := zkTestProvePubkeyValidity(s, P, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyPubkeyValidity}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_PubkeyValidity_Identity_Point_Failure(t *testing.T) {
+	_, P := zkTestKeypair()
+
+	// a proof with Y as the identity is rejected before the algebraic relation is checked
+	instrData := append([]byte{ZkElGamalProofInstrTypeVerifyPubkeyValidity}, zkTestEncode(P)...)
+	instrData = append(instrData, make([]byte, ZkPubkeyValidityProofLen)...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_PercentageWithCap_Success(t *testing.T) {
+	const maxValue = 1000
+	const delta = 42
+	rMax, rDelta, rClaimed := zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar()
+	CMax := zkTestCommit(500, rMax)
+
+	context := zkTestEncode(CMax, zkTestCommit(delta, rDelta), zkTestCommit(delta, rClaimed))
+	context = binary.LittleEndian.AppendUint64(context, maxValue)
+	transcript := newZkTranscript("percentage-with-cap-instruction")
+	transcript.AppendMessage("percentage-commitment", context[0:32])
+	transcript.AppendMessage("delta-commitment", context[32:64])
+	transcript.AppendMessage("claimed-commitment", context[64:96])
+	transcript.appendU64("max-value", maxValue)
+
+	proof := zkTestProvePercentageWithCap(CMax, maxValue, delta, rDelta, rClaimed, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyPercentageWithCap}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_GroupedCiphertext2HandlesValidity_Success(t *testing.T) {
+	_, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	r := zkTestRandomScalar()
+	const amount = 77
+
+	context := append(zkTestEncode(P1, P2), zkTestGroupedCiphertext([]*ristretto255.Element{P1, P2}, amount, r)...)
+	transcript := newZkTranscript("grouped-ciphertext-validity-2-handles-instruction")
+	transcript.AppendMessage("first-pubkey", context[0:32])
+	transcript.AppendMessage("second-pubkey", context[32:64])
+	transcript.AppendMessage("grouped-ciphertext", context[64:160])
+	transcript.groupedCiphertextValidityProofDomainSeparator(2)
+
+	proof := zkTestProveGroupedCiphertextValidity([]*ristretto255.Element{P1, P2}, amount, r, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyGroupedCiphertext2HandlesValidity}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedGroupedCiphertext2HandlesValidity_Success(t *testing.T) {
+	_, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	pubkeys := []*ristretto255.Element{P1, P2}
+	rLo, rHi := zkTestRandomScalar(), zkTestRandomScalar()
+	const amountLo, amountHi = 11, 22
+
+	context := zkTestEncode(P1, P2)
+	context = append(context, zkTestGroupedCiphertext(pubkeys, amountLo, rLo)...)
+	context = append(context, zkTestGroupedCiphertext(pubkeys, amountHi, rHi)...)
+	transcript := newZkTranscript("batched-grouped-ciphertext-validity-2-handles-instruction")
+	transcript.AppendMessage("first-pubkey", context[0:32])
+	transcript.AppendMessage("second-pubkey", context[32:64])
+	transcript.AppendMessage("grouped-ciphertext-lo", context[64:160])
+	transcript.AppendMessage("grouped-ciphertext-hi", context[160:256])
+
+	transcript.batchedGroupedCiphertextValidityProofDomainSeparator(2)
+	tScalar := transcript.challengeScalar("t")
+	transcript.groupedCiphertextValidityProofDomainSeparator(2)
+
+	// the batched amount overflows a u64 in general, so prove with the batched scalars directly
+	rBatched := zkTestAdd(rLo, zkMul(tScalar, rHi))
+	xBatched := zkTestAdd(zkScalarFromU64(amountLo), zkMul(tScalar, zkScalarFromU64(amountHi)))
+	yr, yx := zkTestRandomScalar(), zkTestRandomScalar()
+	Y := []*ristretto255.Element{zkTestAddPoints(zkTestMulPoint(yr, zkPedersenH), zkTestMulPoint(yx, zkPedersenG)), zkTestMulPoint(yr, P1), zkTestMulPoint(yr, P2)}
+	transcript.AppendMessage("Y_0", Y[0].Encode(nil))
+	transcript.AppendMessage("Y_1", Y[1].Encode(nil))
+	transcript.AppendMessage("Y_2", Y[2].Encode(nil))
+	c := transcript.challengeScalar("c")
+	proof := append(zkTestEncode(Y...), zkTestEncodeScalars(zkTestAdd(zkMul(c, rBatched), yr), zkTestAdd(zkMul(c, xBatched), yx))...)
+
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext2HandlesValidity}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_GroupedCiphertext3HandlesValidity_Success(t *testing.T) {
+	_, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	_, P3 := zkTestKeypair()
+	pubkeys := []*ristretto255.Element{P1, P2, P3}
+	r := zkTestRandomScalar()
+	const amount = 99
+
+	context := append(zkTestEncode(P1, P2, P3), zkTestGroupedCiphertext(pubkeys, amount, r)...)
+	transcript := newZkTranscript("grouped-ciphertext-validity-3-handles-instruction")
+	transcript.AppendMessage("first-pubkey", context[0:32])
+	transcript.AppendMessage("second-pubkey", context[32:64])
+	transcript.AppendMessage("third-pubkey", context[64:96])
+	transcript.AppendMessage("grouped-ciphertext", context[96:224])
+	transcript.groupedCiphertextValidityProofDomainSeparator(3)
+
+	proof := zkTestProveGroupedCiphertextValidity(pubkeys, amount, r, transcript)
+	instrData := append(append([]byte{ZkElGamalProofInstrTypeVerifyGroupedCiphertext3HandlesValidity}, context...), proof...)
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+
+	// a ciphertext encrypted under a different third pubkey fails to verify
+	_, POther := zkTestKeypair()
+	context = append(zkTestEncode(P1, P2, POther), zkTestGroupedCiphertext(pubkeys, amount, r)...)
+	instrData = append(append([]byte{ZkElGamalProofInstrTypeVerifyGroupedCiphertext3HandlesValidity}, context...), proof...)
+	err = zkTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedRangeProofU64_Success(t *testing.T) {
+	instrData := zkTestRangeProofInstrData(ZkElGamalProofInstrTypeVerifyBatchedRangeProofU64, []uint64{55, 1 << 20}, []int{32, 32})
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedRangeProofU128_Success(t *testing.T) {
+	instrData := zkTestRangeProofInstrData(ZkElGamalProofInstrTypeVerifyBatchedRangeProofU128, []uint64{1 << 63, 7, 8}, []int{64, 32, 32})
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedRangeProofU256_Success(t *testing.T) {
+	instrData := zkTestRangeProofInstrData(ZkElGamalProofInstrTypeVerifyBatchedRangeProofU256, []uint64{1, 2, 3, 4}, []int{64, 64, 64, 64})
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedRangeProof_Amount_Out_Of_Range_Failure(t *testing.T) {
+	// the amount doesn't fit in its 32 bit length, so its upper bits aren't proven
+	instrData := zkTestRangeProofInstrData(ZkElGamalProofInstrTypeVerifyBatchedRangeProofU64, []uint64{1 << 40, 3}, []int{32, 32})
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_BatchedRangeProof_Wrong_Bit_Length_Failure(t *testing.T) {
+	// bit lengths summing to 128 don't make a u64 batched range proof
+	instrData := zkTestRangeProofInstrData(ZkElGamalProofInstrTypeVerifyBatchedRangeProofU128, []uint64{1, 2}, []int{64, 64})
+	instrData[0] = ZkElGamalProofInstrTypeVerifyBatchedRangeProofU64
+	instrData = instrData[:1+ZkBatchedRangeProofCtxLen+ZkRangeProofU64Len]
+
+	err := zkTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Invalid_Length_Failure(t *testing.T) {
+	instrData := zkTestZeroCiphertextInstrData()
+
+	err := zkTestVerify(t, instrData[:len(instrData)-1])
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+
+	// proofs read from accounts aren't supported
+	err = zkTestVerify(t, []byte{ZkElGamalProofInstrTypeVerifyZeroCiphertext, 0, 0, 0, 0})
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Feature_Inactive_Failure(t *testing.T) {
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct()})
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+	execCtx.GlobalCtx.Features = *features.NewFeaturesDefault()
+
+	err := execCtx.ProcessInstruction(zkTestZeroCiphertextInstrData(), []InstructionAccount{}, []uint64{0})
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func zkTestContextStateAccts(t *testing.T, contextData []byte) (accounts.Account, accounts.Account) {
+	contextPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	contextAcct := accounts.Account{Key: contextPrivKey.PublicKey(), Lamports: 5000, Data: contextData, Owner: ZkElGamalProofProgramAddr, Executable: false, RentEpoch: 100}
+
+	authorityPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	authorityAcct := accounts.Account{Key: authorityPrivKey.PublicKey(), Lamports: 0, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	return contextAcct, authorityAcct
+}
+
+func TestExecute_Tx_ZkElGamalProof_Create_Context_State_Success(t *testing.T) {
+	instrData := zkTestZeroCiphertextInstrData()
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, make([]byte, ZkProofContextStateMetaLen+96))
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	contextAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, authorityAcct.Key[:], contextAcctPost.Data[:32])
+	assert.Equal(t, byte(ZkElGamalProofInstrTypeVerifyZeroCiphertext), contextAcctPost.Data[32])
+	assert.Equal(t, instrData[1:97], contextAcctPost.Data[33:])
+}
+
+func TestExecute_Tx_ZkElGamalProof_Create_Context_State_Already_Initialized_Failure(t *testing.T) {
+	contextData := make([]byte, ZkProofContextStateMetaLen+96)
+	contextData[32] = ZkElGamalProofInstrTypeVerifyZeroCiphertext
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, contextData)
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction(zkTestZeroCiphertextInstrData(), instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrAccountAlreadyInitialized, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Create_Context_State_Wrong_Size_Failure(t *testing.T) {
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, make([]byte, ZkProofContextStateMetaLen+32))
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction(zkTestZeroCiphertextInstrData(), instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidAccountData, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Close_Context_State_Success(t *testing.T) {
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, nil)
+	contextAcct.Data = append(append(authorityAcct.Key[:], ZkElGamalProofInstrTypeVerifyZeroCiphertext), make([]byte, 96)...)
+
+	destinationPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	destinationAcct := accounts.Account{Key: destinationPrivKey.PublicKey(), Lamports: 100, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, destinationAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: destinationAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: true, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err = execCtx.ProcessInstruction([]byte{ZkElGamalProofInstrTypeCloseContextState}, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	contextAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), contextAcctPost.Lamports)
+	assert.Equal(t, 0, len(contextAcctPost.Data))
+	assert.Equal(t, SystemProgramAddr, contextAcctPost.Owner)
+
+	destinationAcctPost, err := txCtx.Accounts.GetAccount(2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5100), destinationAcctPost.Lamports)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Close_Context_State_Wrong_Authority_Failure(t *testing.T) {
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, nil)
+	contextAcct.Data = append(append(authorityAcct.Key[:], ZkElGamalProofInstrTypeVerifyZeroCiphertext), make([]byte, 96)...)
+
+	otherPrivKey, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	otherAcct := accounts.Account{Key: otherPrivKey.PublicKey(), Lamports: 100, Data: make([]byte, 0), Owner: SystemProgramAddr, Executable: false, RentEpoch: 100}
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, otherAcct})
+
+	// the destination signs in place of the context state authority
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: otherAcct.Key, IsSigner: true, IsWritable: true},
+		{Pubkey: otherAcct.Key, IsSigner: true, IsWritable: true}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err = execCtx.ProcessInstruction([]byte{ZkElGamalProofInstrTypeCloseContextState}, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidAccountOwner, err)
+}
+
+func TestExecute_Tx_ZkElGamalProof_Close_Context_State_Authority_Didnt_Sign_Failure(t *testing.T) {
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, nil)
+	contextAcct.Data = append(append(authorityAcct.Key[:], ZkElGamalProofInstrTypeVerifyZeroCiphertext), make([]byte, 96)...)
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkElGamalProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: true}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkElGamalProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction([]byte{ZkElGamalProofInstrTypeCloseContextState}, instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrMissingRequiredSignature, err)
+}
+
+// known answers for the Pedersen generator H, which the zk-sdk derives by hashing the
+// encoding of the ristretto basepoint, and for the merlin transcript underlying the proofs'
+// Fiat-Shamir challenges.
+func TestZkElGamalProof_Known_Answers(t *testing.T) {
+	assert.Equal(t, "8c9240b456a9e6dc65c377a1048d745f94a08cdb7f44cbcd7b46f34048871134", hex.EncodeToString(zkPedersenH.Encode(nil)))
+
+	transcript := newZkTranscript("test protocol")
+	transcript.AppendMessage("some label", []byte("some data"))
+	challenge := make([]byte, 32)
+	transcript.ExtractBytes(challenge, "challenge")
+	assert.Equal(t, "d5a21972d0d5fe320c0d263fac7fffb8145aa640af6e9bca177c03c7efcf0615", hex.EncodeToString(challenge))
+}
+
+// fixed instruction data for each proof type, generated with the provers above and pinned
+// such that changes to the verifiers that alter which proofs they accept are caught, along
+// with variations of them that must be rejected. these are not proofs made by the zk-sdk
+// itself, so they don't establish compatibility with it.
+
+const zkTestVectorZeroCiphertext = "01a612aa43beeccedfae037524bfbbce38c3753c8a66543b28833eca3c79c0ad1eb21ae42aa4c76ae4ccc04225d1e84404bb1e25e85312522c6bd13d704a0663" +
+	"66e40529fcb05a38abe3d620208696fac5477d96948f4b5b88710022e1570fe21a1cc452a6180fc578e6bd32e4aca394ba42eb7bd7d73f7816696f5b092e9de8" +
+	"195ab3ccfe97428621ac757b7ce712f3ab74f72257812ee6ee4120ab1d833def6b9e02c4eaad8c81f9dddda40d940a3f357f104a5607b8db32c11d90eb5ccd3c" +
+	"02"
+
+const zkTestVectorPubkeyValidity = "0402b6bd4d129bad0e1cd9845911adddd4aeffd54ba39a1f2b12c4af64c09a8c4d0e6fd6251f1dbd8232ecd96ab49673b5d1066a057b61c5b057c439bbf67262" +
+	"56e1664925f08519e29b80079bd8180fe718826a0e445607ff556a0feb3a87160b"
+
+const zkTestVectorCiphertextCommitmentEquality = "0302b6bd4d129bad0e1cd9845911adddd4aeffd54ba39a1f2b12c4af64c09a8c4d28a67df062763dcc77457d0bd70c96a06d9b760f905dc2e2f30c47b79d746c" +
+	"4470b06b20d2eae70db57d776930d182db9d8b0728bcc78949ca2f0fe683cda87f38508ad16201dab1136cef22f3a3c9b8f06f7392d6fd555bcc96aea80911ad" +
+	"294cefb6c2d1a4ed39fbd7c9f096c0e5cf1d6644e40ed21ec0855e84fcc02c8b4c2c70739f40fc4a69e6cffff0f1664776edc502edaee8d926f16d9478831c1d" +
+	"4f7c738aad2c40b6d52ffe82a60a48cc5258c3078300bc0b98cb53d5794496925668e8d1645c142865e376cba7f73fc46a22de943ca59699301b556de2d8dcad" +
+	"0d19b3a19ea277e7914ca94c9a5054139d537ea5b2e17f239f821bf5de15ef6b02f415bfab4b650ce00b3d26898940153f59ba5cea23617e535ef5475b432447" +
+	"08"
+
+const zkTestVectorBatchedRangeProofU64 = "064c489a359fa9baf3612871b890d322d67bfbe93a2c54d9b31b8754cb8eb5053cec93fcce4353d6d8224698b23b73b37ff8bacff837e821b469e8f5196c4129" +
+	"36000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"002020000000000000201ab20c6b3f231e823b14fe64a15db768a343fb8ca1dd0418548c358bafeb4878e72e55b0b0f407079d06009392416aff183156332629" +
+	"a36f6f73eb808967084cf65e1eba3c9500b9344af2a7a1d805084163a76d1ad94456190e0cb05fef48ace6a852e9b54271fc51a49f6d516850944d1344579cd9" +
+	"73672e139c2d8d7f76cf8a413c1aab24c75b5001280189b64cf85d6c46b42763df95b90ff49b8f4700af02a9e080062e4f39b7c2dd6cd99485b14be310ee9c8d" +
+	"8acb389edd5ff73103d4c6709e56e1c572d6a13d748387785284faed980f46273cfcedac6cf26cbd078642b52ac9ecb14b0cc38307b9ea283b2add52aa052e56" +
+	"e692ca101412ac966fb612d16fa49df085c1f9624268dd857330ac24bc03c5d3a66600aebf23402e6f02da0644c0584d8e1821193c0c436a9686b8302d2907fc" +
+	"a09c263d6c31283629d2a7d9d2440c0777d882b8cfca85a489af3431858734eb70dbf6209451aa8a050cceeeffddf358242dc37beb3d82cf463024d9bb080014" +
+	"abbf6b6a1dd5b228697092b1c40b0177b71b1a574d93a3a7c53b410b176cd3c1d55cbdac53291b017a7208611a37ba35a8879f9b86051d2abb185750a26467e5" +
+	"343efc058f1917e81f2cc982a427f939b5a7d4805bee89c0fbc7f40975a78c1bb2dc4c17fd8f5e04764a7894986668a2280c846d20b4fd12ab80acfbeaeea4fe" +
+	"64a6b2d19ec15b9d4f7e9c0e011a6c84b31da51b9634154242769985c6d163596fc081a1aedf51be0b9cc4679a843eb1d5bd6f1d451373027c54933215ed17d4" +
+	"cfa055f63e7a4b050838c8a9299fd565cd6e9eff76428873fe750673b05037e0a97d8a8891e50c1265bff0632ce02b9afd5d302a4c913815eab3fedcabcc2d02" +
+	"74b59e4b1ed0f0b9082f84a3509c627372f4a5f5f398e2684e5e05894c9b3763900935456cb867ba08"
+
+const zkTestVectorCiphertextCiphertextEquality = "0242f8fa5132c44882e14130050cb13dac009839ae38f886ce04c938d9c933c36f160dfbbe5e6c3171b025664e684811cdfb40968bee6f0f58cb7ed0df7b5c5f" +
+	"04ae97f8a9a27f72280941fdc8ddec0b0d3d64e08214fc20dc14d93647a66fee045a292ace49ccdce2b2ef47c2e4b70753677488331f120e81a576f71c355ede" +
+	"2a663224917917186c3d8a51003c12fb8485a75282916e27e23bc917e6ea909836cec7db6704d2e8afb1f62a8b77c7c9933dcf760ad7da3a17cd084cba743660" +
+	"6a5252685dc7a10a247994f80e04307c9a71709f07675a71378b672fb9f884d2211a4cb8487b9ec2915a229c99f7cc02b23164496ec503d3ef8b989e43358f8c" +
+	"204cc148064ef327471dd20cd5f33f0b739c0e906ad298b432201a7aa3e1e1df210ab4a9f6f84c4734418bc75209dc78678846315cdec372dc971485e29c7444" +
+	"38d695cc355b99696a0f5283f632d4d781edc9aa0b653952e5bc7aa28bbad64e0d9fc0094b20652f60ecc93f57a040d22e3b0b4cb7d51c49b08961ebd4786429" +
+	"066619190abe3b48240accbc0be379be7c82ad15a8dc262ebfb29f6a9b6422b408"
+
+const zkTestVectorPercentageWithCap = "050ae399f5a18f819bf6decfefca685b79c7741af0c00e63d07d8a17604eab3449a0f53ef67d0d9253b1d38fa2c6225143a77e21b7ac338b2b02e3fd768499ce" +
+	"4faa5eebacbcb1e3e5b5239a78fcbbcb06f0a9e522b20a05acdfb64a3d86b8042ae80300000000000096e047e3b64f09704532e6f9efa6fe59794484c407165e" +
+	"a92b9a1e39044d397cc67c959c0b9a2d532e71a200a7f068999c1f7e21b2abf85743294d1d1b6cc20f73cf9bd168b82a57da065edb3588e121f6c4473cca7ade" +
+	"985fe77e7e59b7b803de6fd0cb0d45c43dbca8dde981c0522b2b11e448a402d28ef6972df769a127111427a34415ce658a00042649bbb8395bb67d82ef395702" +
+	"661611134fc2e476761b08f8054d808d8cc6592056e79512d6d064963d9e2a627f5d9db6a8ed7ad106b85e315d2f643cdd86831e8801f4e331c75f0eca79a3b1" +
+	"a8185254698f0b900353eeeb52ee80543f396f7943bc4b96ef259499eb3b43cef9470b3dd22968f00d"
+
+const zkTestVectorBatchedRangeProofU128 = "07b8ca1857ab11c8f62cb9e4b73034b31268d1c07493bdf91e0752067e7c6b6c04e2422c8be69637c271dc17c08fe0ed581fb4202ebf641e7b670613967efd3f" +
+	"2506709f5c200bff0158aff74a0c24a9d57e12e88b7ea3e9ed0248e47211717e6e00000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"004020200000000000387d12cb733dcac1b2b2f4c4ac481256d7dc39f7bcd7754d02d8af17c3babc3e249fe7ca18fb153b66e40619ae5b39d3b9e15d46cae023" +
+	"f8535f4b39cdb1d445b6c78d1ff4a25ca7fa1ee4f08e532b0ad367f1ee150251e3031680de2120892c300c693b983cee03a1d03004d9c633cc637625050e3442" +
+	"0e9d8ff18e57537f75b7b21b13401f2025a2427852cb37455a4d31396dcca63ce11a710f113974dd056a785d6d8e649f68493fd9a40aff98d7ab7c1c31baf855" +
+	"baeab46e4739534203f4584d6f45e69ea61915eb90c5c41b3d1dbbde704aeb59dd8d25333e41b89d00820bb1441b1265275ad9f8e73c812e3a027287d19ec7ad" +
+	"c9aade8168c9c06710e0a553b435378aa550d1db2c917a92ebcd5f15ba7d80230801f5e1b13a31514a4813ddac0be5cb66921e73695d5d39c20be585dc3083a6" +
+	"390bd4dcb91a8a103af25c8240a181ab925ec4f4615dc779e4b1eb6f27320311bb735172eb5a70fb76aa88271c3312fa90678a5ca6a8e514735cbce9d7f52052" +
+	"097590119a009db80c5c77d91928d6540835b30937c7d7ea9beca5ef7772df71a5138ccea3663d876e4ec3aaccbbaabe5b07c296233f197688e84e358e5c2f08" +
+	"30d32c56aac4d60e41c85a0cb56dbe55a887ecc297dac34c6b7b51d660727c2d563b3bebc27107092ac2ab499aa52583e8e015382cd5cedbf1481520b621878d" +
+	"c5350295021ddca61eb82ef1305055fcee8594ae01ebcaba63a1af8abe0d59c70ebcfcbf16e5ea323156fa075d5396643d5065634f65c569247a4606b5ab3028" +
+	"cf8a24ad3ff01a8c27b4052da07fb27fd957c201c0d0bbb2fe20dff82f5eddc0f7fbe4c32955df184da235f6e856128ff742e90bde3cc5dc7d76e6f1f0e1f4dc" +
+	"5bec8df470cba99325e825976ae2cfdefe560617c81bd1a4a7fc383fe295a8ae214f7d01cdc3f5201e996a3f5e1d946f38588252a863353af79b9c50cff27891" +
+	"2858f14c5f1944570cace46e6bfc1194887ad21d24896ec3811567ad47974b5aa76b9e71b6b870fc0e"
+
+const zkTestVectorBatchedRangeProofU256 = "0816fc5bf1632b640dba3ddfa5f41d6ce955f48d78a4b94840f56aedde83a41b5b8e210752b2456fad16f85ad25bdeb0e44e1c1704a2723b3db9567f0a6fc87b" +
+	"276a5f2f4f6b408b78f403f03fb4961d2e0e8f35f160db2e711d8f2a3a4924e130e24ce6ce60a613a85ba9eeebca7b514ac3fc41e5c74156d0ae7c14668db854" +
+	"6a000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+	"004040404000000000a4541db5127368330757831a2f7c64b620f090ecb2feb3d05b721e69c77d7b66ecd595732e57a51907fdf38a73566659ef7d9a17b44b17" +
+	"47c89d8ae0d59ef73b9ea17b9f7d2e2f582b856b073a506fe064fc524b246b72d1ffc2dd6106d396796af9441acc9c9eeff2e0ab4ad1a565086f32a71e0a9943" +
+	"0da01adaf1ff98b85b4362cfd3776ae6a8294f2808f5055d5a3a9b125caaa0b59d07bf979c0815ae09ff7df31a27f5a298e086aef697d009d8d54f384b285fde" +
+	"777cfdf82fe6ec610b467dab4b05e08971252a291ac55ce3f043baf807833c0b807839cb6ac3505f0426fc9d9af1fe289b2b346f92503518f05ab782c3560823" +
+	"3ec6b8f54df25cd50ad62ec003dafe3da37e48300ee6249a8cf39909311b9b4bbf7677953c949c7d0ee878f930e5f3a0d5e59576324bbc7e09113254a1a859e5" +
+	"127e511cc7338657075a4d4805021f23d3e388484d4443b876f21dc76bc9e422ec4c8ccae4b689ea7642cc59e6cf060448a076f3692f71d5747130d28f2d712c" +
+	"b489a2c72e469489489c713c2aebc5ebb55bc6593053a046cc3d1754a92c5a5117f9e0fcfe3f6d612d96fede6a0aff38df87a83ea5f163c972467de1ca41847d" +
+	"1197469e2c9345a472f0da40534f9a074c61aa324a540bde42d13e8f687c5a2496fd43bea099128153ee721a65cc122003b6133de1287ad216cfce050a47011c" +
+	"5f72c9b6c719d692366c1e7a29f79e6dc9abf6252af4c5dc3e7c6dc0ee98b0135aef539af62291476f9cf97bb4c3fb4843d3f29e29e3a52ee1a2151ab391ca82" +
+	"12c3f7502a3701e05ca6ca8033f8fd47eb79241169782150146f2637e59c2e97a9f41a00498ea83e79ce1777e1b6b4dbcf08da91ae4d0e47b8d355798748b177" +
+	"da4471767c2348a67edae1498f357e6a128c0839b371272c448f3dde6a5f3e590796871ebf4767d31abadb269cd988ebe5d552bbe6f9059cce33e190139d2ec5" +
+	"ae9841c7094d4dc05e9c8fe6eca89a8da76e12b6a35a312196b0bfb555a32a8fdaa70bf81dec96823190c6ce72bb9a0b4ae9e5c2f7b892d413c29abdf6f2d5a3" +
+	"5e3609fdff53ebb805294fccdb232f1bddf201d33d1d43a56fc3b1c4837b3c1c771bca3faf47434600"
+
+const zkTestVectorGroupedCiphertext2HandlesValidity = "09c003f355be3008e78036326319c9ce7f2e6a6e7f4b4d71d6a123d6f81891d3300afb4f1d2e2c7ba31fa50164088a2f9babcfadaaa55e832a97708a42ff1385" +
+	"620c501da33b33c63ad5289d90444f98c44fcff86eb65e60e147691422ab01423f92df97b2a78add6d88b9d7d676956b38cb5afe6f37b81b20f2b2a05b7ec42a" +
+	"19dc2f7f72e2762de7066725a683b75a34025887e2ac407613f0277653693cd60f88a4ee82fa39873bbccfbb253de9e20a8e9210ffbb1131105eb82e27c7a370" +
+	"3f94e9e658cf50205231806f3bcff99af7180a728894eb19c6b8fbb82aad89b165e6996fd247238260554e8586f3c604d7d50c997a965893e79fac375437553d" +
+	"4adab9e7ff09e3790dc5169061161fde2adf24406245c6fad0c9562f6b7b2afb05a9c269f9d96f7d2cf6d860b8c18a578a3e911071a38edd6cf11e48cd292fd0" +
+	"07"
+
+const zkTestVectorBatchedGroupedCiphertext2HandlesValidity = "0a54977178c14e4364ab72b82870825d05cf367160a0b42ac9f8040c0635d8f6437cc684be93aae4358e0f22d99f1a67d26303d3853cea8e83ddb38720cc523d" +
+	"6aca45e1130112b008300eaea5b787a9ad84b29c9715741976d266a2cf3ca78944e8ba57b5844102a98fba34b4858dc0fe10e21f7e2c34be7bf2841d7849dfc1" +
+	"3542ee2c8db2948e7301ff53d60763d975b59e32b64e38604e070c1debd6ed862f1c037877f7d07bb05bc6e1f4b391d6d6f80d2cd3269ba81a1c30a0c8cb5f85" +
+	"0a4ce453a9013eef44a42a3996a4c0bbeceac02024c103151ab8659c1a365de71904f05eb7c8e20ad7e8cbafe1de41b001ba2c0a1adaf89fe80e1e1a6ff1a72b" +
+	"1896dd04cadb263376a183a5a643403012437767f4d3ff646b9f95b9a4395736376cee55a6a4be40172d0558e806a8b7b72b3c1aaa1b292aedc11741f646caff" +
+	"0c52ba300ecfad15f428729a055687957389de49e01bed7ab4c0c2e74b40f93508d400c5f2de5dffe31e1141a2b58ae4e8409bde5b74ec51165df8cc4811574f" +
+	"0c8a378bfebaf3c9ba719ff84426f1cfd47234e4fb42ba15e7da57ffab891d780e"
+
+const zkTestVectorGroupedCiphertext3HandlesValidity = "0b781bf1de09f69660247748cc3676e0bb83a2c837f069151047a2e4d4b1b47869c41b3284ece196f3390367af85226e0622c17bbcd7b2a078b95e175ffbc377" +
+	"34c618f2994dab381c12f75694ab9bf6886d32af4eef1c19b39784823be91cd9604a08c235041a5e727d488b5e205295ca34ec30c4c718584c3f96864af4688f" +
+	"5ef4d5269842b68e6c8996f72ac270a80d496dc1a678026fa78a1a82536bc6f47eee0068b5f2b4c20c433a7b565fb2c0b337183b916519823fc134d32f4ac72c" +
+	"122402be4c43d45e12b96f661ef3e6a865dadb39353ec57b2b25ab7fdd6a729638f8dd65b320312da6dcb4b58bc6d72a1cb182ca8281c9e12d90f583c7116059" +
+	"5820be5e948a12f811a5074659d800c7bf269c3ec27e443388ce5d0723d9660f346e8ebc0afd5197f6e09e5250954933e71e1251761a9677210aab3451c46883" +
+	"3cba82847366886a433bbaa4642bb30e3041e5f65cf0ed0151862d771beb58f250945720283b369032fea440458996160a3e13f3391493833d3f80db2aa140f6" +
+	"0bb3476ca70e079b467811999b3c5acf25ffb3e5057a345f836d2576c3bf76ee0a"
+
+const zkTestVectorBatchedGroupedCiphertext3HandlesValidity = "0c72038b1851f30510a592fec50fac4c80291ff0a9c131436d5b82c14880ce4920b2a7d2eecdcb2365869ce32fbaaf42a0dd411e01f94bf8bd5f3493f21d1cfb" +
+	"6aa68f1a8332e31bc1140d152e0b3a477b6456544e19942c8a228ae01527b9fb216ea6034bfc02afed1b12c0880459cfec67bc174baebc18b5ffa8468335d929" +
+	"0a10987f98e7fec8ce78d0062ccb556a1526c044867e76cc61a65a0586e2aa993dd4c01e755009b2c5c4900b645a4637c549844febb18f931e8f84a9e066ae1e" +
+	"6992775e34f9cc4c864b73ad83bfb130b5472f59820cf8d4bf269b2f3f15d1ac6f6c80daa604ff81e8143833792ba3f27f8820115d6cb2fcbb1eaef9bdfe9f8e" +
+	"4f7af93e9ff0d867924a48a957fa1305c0f2a6d6e7bea455d85040ee9ff195204a86ac12a377b39ff8143d196d3457765f8dfeb4e0e797194a1a60908aa00a06" +
+	"6186923e3b1a97a1db006fdb017bf655ca143e9c99c67cc92c1f29c815562d3b5b0cdfbae90f22cf6add085ab410d999b5fef87ed7d2ce307c986fde44ff1975" +
+	"1100a85a7302a03d667afeb001361ab60fdd82333aaff293cd228adc956d94a417588ae649e1c3fe7cb5118f92426cefc48b29ec4e325f3c52222e2243a1a9ce" +
+	"2ccc77ca20b9456a811ca3b5769bbfc90aa5796c5ee2c3a37240ec52292fc6af6ebd7b334f061a5299dca0097a1f89e0ae4256dfb3a1f4369ab2ada946ae682b" +
+	"0cb2ce8394c0492884504983c23f80c11ffc16ff25dd6fbc1dc6f7885c0610d30d"
+
+// the encoding of the ristretto basepoint
+const zkTestBasepoint = "e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76"
+
+// the order of the ristretto255 group, which is not a canonical scalar encoding
+const zkTestGroupOrder = "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"
+
+func zkTestVectorVariant(t *testing.T, vector string, offset int, replacement string) []byte {
+	instrData, err := hex.DecodeString(vector)
+	assert.NoError(t, err)
+	if replacement != "" {
+		bytes, err := hex.DecodeString(replacement)
+		assert.NoError(t, err)
+		copy(instrData[offset:], bytes)
+	}
+	return instrData
+}
+
+func TestExecute_Tx_ZkElGamalProof_Test_Vectors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		vector      string
+		offset      int
+		replacement string
+		err         error
+	}{
+		{name: "zero ciphertext", vector: zkTestVectorZeroCiphertext},
+		{name: "pubkey validity", vector: zkTestVectorPubkeyValidity},
+		{name: "ciphertext commitment equality", vector: zkTestVectorCiphertextCommitmentEquality},
+		{name: "batched range proof u64", vector: zkTestVectorBatchedRangeProofU64},
+		{name: "ciphertext ciphertext equality", vector: zkTestVectorCiphertextCiphertextEquality},
+		{name: "percentage with cap", vector: zkTestVectorPercentageWithCap},
+		{name: "batched range proof u128", vector: zkTestVectorBatchedRangeProofU128},
+		{name: "batched range proof u256", vector: zkTestVectorBatchedRangeProofU256},
+		{name: "grouped ciphertext 2 handles validity", vector: zkTestVectorGroupedCiphertext2HandlesValidity},
+		{name: "batched grouped ciphertext 2 handles validity", vector: zkTestVectorBatchedGroupedCiphertext2HandlesValidity},
+		{name: "grouped ciphertext 3 handles validity", vector: zkTestVectorGroupedCiphertext3HandlesValidity},
+		{name: "batched grouped ciphertext 3 handles validity", vector: zkTestVectorBatchedGroupedCiphertext3HandlesValidity},
+
+		// proofs of different statements, made by altering the context
+		{name: "zero ciphertext, other pubkey", vector: zkTestVectorZeroCiphertext, offset: 1, replacement: zkTestBasepoint,
+			err: InstrErrInvalidInstructionData},
+		{name: "ciphertext commitment equality, other commitment", vector: zkTestVectorCiphertextCommitmentEquality, offset: 97,
+			replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+		{name: "batched range proof u64, other bit lengths", vector: zkTestVectorBatchedRangeProofU64,
+			offset: 1 + ZkRangeProofMaxCommitments*ZkPedersenCommitmentLen, replacement: "1830", err: InstrErrInvalidInstructionData},
+		{name: "ciphertext ciphertext equality, other second pubkey", vector: zkTestVectorCiphertextCiphertextEquality, offset: 33,
+			replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+		{name: "percentage with cap, other max value", vector: zkTestVectorPercentageWithCap, offset: 97, replacement: "e903000000000000",
+			err: InstrErrInvalidInstructionData},
+		{name: "batched range proof u256, other bit lengths", vector: zkTestVectorBatchedRangeProofU256,
+			offset: 1 + ZkRangeProofMaxCommitments*ZkPedersenCommitmentLen, replacement: "80204020", err: InstrErrInvalidInstructionData},
+		{name: "grouped ciphertext 2 handles validity, other first pubkey", vector: zkTestVectorGroupedCiphertext2HandlesValidity, offset: 1,
+			replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+		{name: "batched grouped ciphertext 2 handles validity, other hi ciphertext", vector: zkTestVectorBatchedGroupedCiphertext2HandlesValidity,
+			offset: 1 + 64 + 96, replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+		{name: "grouped ciphertext 3 handles validity, other third pubkey", vector: zkTestVectorGroupedCiphertext3HandlesValidity, offset: 65,
+			replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+		{name: "batched grouped ciphertext 3 handles validity, other lo ciphertext", vector: zkTestVectorBatchedGroupedCiphertext3HandlesValidity,
+			offset: 1 + 96, replacement: zkTestBasepoint, err: InstrErrInvalidInstructionData},
+
+		// non-canonical scalars and points
+		{name: "zero ciphertext, non-canonical scalar", vector: zkTestVectorZeroCiphertext, offset: 1 + 96 + 64,
+			replacement: zkTestGroupOrder, err: InstrErrInvalidInstructionData},
+		{name: "pubkey validity, non-canonical scalar", vector: zkTestVectorPubkeyValidity, offset: 1 + 32 + 32,
+			replacement: zkTestGroupOrder, err: InstrErrInvalidInstructionData},
+		{name: "pubkey validity, invalid point", vector: zkTestVectorPubkeyValidity, offset: 1 + 32,
+			replacement: strings.Repeat("ff", 32), err: InstrErrInvalidInstructionData},
+		{name: "batched range proof u64, non-canonical scalar", vector: zkTestVectorBatchedRangeProofU64,
+			offset: 1 + ZkBatchedRangeProofCtxLen + 4*32, replacement: zkTestGroupOrder, err: InstrErrInvalidInstructionData},
+		{name: "percentage with cap, non-canonical scalar", vector: zkTestVectorPercentageWithCap, offset: 1 + 104 + 32,
+			replacement: zkTestGroupOrder, err: InstrErrInvalidInstructionData},
+		{name: "grouped ciphertext 3 handles validity, invalid point", vector: zkTestVectorGroupedCiphertext3HandlesValidity,
+			offset: 1 + 224, replacement: strings.Repeat("ff", 32), err: InstrErrInvalidInstructionData},
+	} {
+		err := zkTestVerify(t, zkTestVectorVariant(t, test.vector, test.offset, test.replacement))
+		assert.Equal(t, test.err, err, test.name)
+	}
+}
@@ -0,0 +1,299 @@
+package sealevel
+
+import (
+	"testing"
+
+	"github.com/gtank/ristretto255"
+	"github.com/stretchr/testify/assert"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/features"
+)
+
+// zk-token proof program tests. the proofs are generated by the zk elgamal proof program's
+// test provers, over transcripts with the zk-token-sdk's domain separators.
+
+func newZkTokenProofTestExecCtx(txCtx *TransactionCtx) *ExecutionCtx {
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+	f := features.NewFeaturesDefault()
+	f.EnableFeature(features.ZkTokenSdkEnabled, 0)
+	execCtx.GlobalCtx.Features = *f
+	return execCtx
+}
+
+func zkTokenProofProgramAcct() accounts.Account {
+	return accounts.Account{Key: ZkTokenProofProgramAddr, Lamports: 0, Data: make([]byte, 0), Owner: NativeLoaderAddr, Executable: true, RentEpoch: 100}
+}
+
+// zkTokenTestVerify executes a proof verification instruction without a context state account.
+func zkTokenTestVerify(t *testing.T, instrData []byte) error {
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkTokenProofProgramAcct()})
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkTokenProofTestExecCtx(txCtx)
+	return execCtx.ProcessInstruction(instrData, []InstructionAccount{}, []uint64{0})
+}
+
+// zkTestProveBatchedGroupedCiphertextValidity proves the validity of a pair of grouped
+// ciphertexts of the amounts, as the combination lo + t*hi of them.
+func zkTestProveBatchedGroupedCiphertextValidity(pubkeys []*ristretto255.Element, amountLo uint64, rLo *ristretto255.Scalar, amountHi uint64, rHi *ristretto255.Scalar, transcript *zkTranscript) []byte {
+	transcript.batchedGroupedCiphertextValidityProofDomainSeparator(len(pubkeys))
+	tScalar := transcript.challengeScalar("t")
+	transcript.groupedCiphertextValidityProofDomainSeparator(len(pubkeys))
+
+	// the batched amount overflows a u64 in general, so prove with the batched scalars directly
+	rBatched := zkTestAdd(rLo, zkMul(tScalar, rHi))
+	xBatched := zkTestAdd(zkScalarFromU64(amountLo), zkMul(tScalar, zkScalarFromU64(amountHi)))
+	yr, yx := zkTestRandomScalar(), zkTestRandomScalar()
+	Y := []*ristretto255.Element{zkTestAddPoints(zkTestMulPoint(yr, zkPedersenH), zkTestMulPoint(yx, zkPedersenG))}
+	for _, P := range pubkeys {
+		Y = append(Y, zkTestMulPoint(yr, P))
+	}
+	for i := range Y {
+		transcript.AppendMessage([]string{"Y_0", "Y_1", "Y_2", "Y_3"}[i], Y[i].Encode(nil))
+	}
+
+	c := transcript.challengeScalar("c")
+	zr, zx := zkTestAdd(zkMul(c, rBatched), yr), zkTestAdd(zkMul(c, xBatched), yx)
+	transcript.appendScalar("z_r", zr)
+	transcript.appendScalar("z_x", zx)
+	_ = transcript.challengeScalar("w")
+
+	return append(zkTestEncode(Y...), zkTestEncodeScalars(zr, zx)...)
+}
+
+func zkTokenTestZeroBalanceInstrData(amount uint64) []byte {
+	s, P := zkTestKeypair()
+	r := zkTestRandomScalar()
+	C, D := zkTestCommit(amount, r), zkTestMulPoint(r, P)
+
+	context := zkTestEncode(P, C, D)
+	transcript := newZkTokenTranscript("ZeroBalanceProof")
+	transcript.AppendMessage("pubkey", context[0:32])
+	transcript.AppendMessage("ciphertext", context[32:96])
+
+	proof := zkTestProveZeroCiphertext(s, P, D, transcript)
+	return append(append([]byte{ZkTokenProofInstrTypeVerifyZeroBalance}, context...), proof...)
+}
+
+func zkTokenTestWithdrawInstrData(finalBalance uint64) []byte {
+	s, P := zkTestKeypair()
+	rCiphertext, rCommitment := zkTestRandomScalar(), zkTestRandomScalar()
+	D := zkTestMulPoint(rCiphertext, P)
+	commitment := zkTestCommit(finalBalance, rCommitment)
+
+	context := zkTestEncode(P, zkTestCommit(finalBalance, rCiphertext), D)
+	transcript := newZkTokenTranscript("WithdrawProof")
+	transcript.AppendMessage("pubkey", context[0:32])
+	transcript.AppendMessage("ciphertext", context[32:96])
+	transcript.AppendMessage("commitment", commitment.Encode(nil))
+
+	proof := zkTestEncode(commitment)
+	proof = append(proof, zkTestProveCiphertextCommitmentEquality(s, P, D, finalBalance, rCommitment, transcript)...)
+	proof = append(proof, zkTestProveRange([]uint64{finalBalance}, []int{64}, []*ristretto255.Scalar{rCommitment}, transcript)...)
+
+	return append(append([]byte{ZkTokenProofInstrTypeVerifyWithdraw}, context...), proof...)
+}
+
+// zkTokenTestTransferInstrData builds the instruction data of a transfer of the amount split
+// into its low 16 and high 32 bits, leaving the source with the new balance.
+func zkTokenTestTransferInstrData(amountLo, amountHi, newBalance uint64) []byte {
+	sSource, PSource := zkTestKeypair()
+	_, PDestination := zkTestKeypair()
+	_, PAuditor := zkTestKeypair()
+	pubkeys := []*ristretto255.Element{PSource, PDestination, PAuditor}
+	rLo, rHi, rNewSource, rCommitment := zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar(), zkTestRandomScalar()
+	DNewSource := zkTestMulPoint(rNewSource, PSource)
+	newSourceCommitment := zkTestCommit(newBalance, rCommitment)
+
+	context := zkTestGroupedCiphertext(pubkeys, amountLo, rLo)
+	context = append(context, zkTestGroupedCiphertext(pubkeys, amountHi, rHi)...)
+	context = append(context, zkTestEncode(pubkeys...)...)
+	context = append(context, zkTestEncode(zkTestCommit(newBalance, rNewSource), DNewSource)...)
+
+	transcript := newZkTokenTranscript("transfer-proof")
+	transcript.AppendMessage("ciphertext-lo", context[0:128])
+	transcript.AppendMessage("ciphertext-hi", context[128:256])
+	transcript.AppendMessage("transfer-pubkeys", context[256:352])
+	transcript.AppendMessage("ciphertext-new-source", context[352:416])
+	transcript.AppendMessage("commitment-new-source", newSourceCommitment.Encode(nil))
+
+	proof := zkTestEncode(newSourceCommitment)
+	proof = append(proof, zkTestProveCiphertextCommitmentEquality(sSource, PSource, DNewSource, newBalance, rCommitment, transcript)...)
+	proof = append(proof, zkTestProveBatchedGroupedCiphertextValidity([]*ristretto255.Element{PDestination, PAuditor}, amountLo, rLo, amountHi, rHi, transcript)...)
+	proof = append(proof, zkTestProveRange(
+		[]uint64{newBalance, amountLo, zkTransferAmountLoNegatedMaxVal - amountLo, amountHi},
+		[]int{64, 16, 16, 32},
+		[]*ristretto255.Scalar{rCommitment, rLo, zkNeg(rLo), rHi},
+		transcript)...)
+
+	return append(append([]byte{ZkTokenProofInstrTypeVerifyTransfer}, context...), proof...)
+}
+
+func TestExecute_Tx_ZkTokenProof_ZeroBalance_Success(t *testing.T) {
+	instrData := zkTokenTestZeroBalanceInstrData(0)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+
+	err = zkTokenTestVerify(t, zkTokenTestZeroBalanceInstrData(1))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_ZkElGamal_Proof_Failure(t *testing.T) {
+	// the zero-ciphertext proof of the zk elgamal proof program differs from the zero-balance
+	// proof in its transcript, and so isn't accepted in its place
+	instrData := zkTestZeroCiphertextInstrData()
+	instrData[0] = ZkTokenProofInstrTypeVerifyZeroBalance
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_Withdraw_Success(t *testing.T) {
+	instrData := zkTokenTestWithdrawInstrData(1 << 40)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_Transfer_Success(t *testing.T) {
+	instrData := zkTokenTestTransferInstrData(1234, 1<<20, 5000)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_Transfer_Amount_Out_Of_Range_Failure(t *testing.T) {
+	// the low bits of the amount don't fit in 16 bits
+	err := zkTokenTestVerify(t, zkTokenTestTransferInstrData(1<<16, 1, 5000))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_CiphertextCiphertextEquality_Success(t *testing.T) {
+	s1, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	r1, r2 := zkTestRandomScalar(), zkTestRandomScalar()
+	const amount = 55
+
+	context := zkTestEncode(P1, P2, zkTestCommit(amount, r1), zkTestMulPoint(r1, P1), zkTestCommit(amount, r2), zkTestMulPoint(r2, P2))
+	transcript := newZkTokenTranscript("CiphertextCiphertextEqualityProof")
+	transcript.AppendMessage("pubkey-source", context[0:32])
+	transcript.AppendMessage("pubkey-dest", context[32:64])
+	transcript.AppendMessage("ciphertext-source", context[64:128])
+	transcript.AppendMessage("ciphertext-dest", context[128:192])
+
+	proof := zkTestProveCiphertextCiphertextEquality(s1, P1, zkTestMulPoint(r1, P1), P2, amount, r2, transcript)
+	instrData := append(append([]byte{ZkTokenProofInstrTypeVerifyCiphertextCiphertextEquality}, context...), proof...)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_RangeProofU64_Success(t *testing.T) {
+	r := zkTestRandomScalar()
+	const amount = 1 << 63
+
+	context := zkTestEncode(zkTestCommit(amount, r))
+	transcript := newZkTokenTranscript("RangeProof")
+	transcript.AppendMessage("commitment", context)
+
+	proof := zkTestProveRange([]uint64{amount}, []int{64}, []*ristretto255.Scalar{r}, transcript)
+	instrData := append(append([]byte{ZkTokenProofInstrTypeVerifyRangeProofU64}, context...), proof...)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_GroupedCiphertext2HandlesValidity_Success(t *testing.T) {
+	_, P1 := zkTestKeypair()
+	_, P2 := zkTestKeypair()
+	r := zkTestRandomScalar()
+	const amount = 77
+
+	context := append(zkTestEncode(P1, P2), zkTestGroupedCiphertext([]*ristretto255.Element{P1, P2}, amount, r)...)
+	transcript := newZkTokenTranscript("CiphertextValidityProof")
+	transcript.AppendMessage("destination-pubkey", context[0:32])
+	transcript.AppendMessage("auditor-pubkey", context[32:64])
+	transcript.AppendMessage("grouped-ciphertext", context[64:160])
+	transcript.groupedCiphertextValidityProofDomainSeparator(2)
+
+	proof := zkTestProveGroupedCiphertextValidity([]*ristretto255.Element{P1, P2}, amount, r, transcript)
+	instrData := append(append([]byte{ZkTokenProofInstrTypeVerifyGroupedCiphertext2HandlesValidity}, context...), proof...)
+
+	err := zkTokenTestVerify(t, instrData)
+	assert.Equal(t, nil, err)
+
+	err = zkTokenTestVerify(t, zkTestTamper(instrData))
+	assert.Equal(t, InstrErrInvalidInstructionData, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_Transfer_With_Fee_Disabled_Failure(t *testing.T) {
+	for _, instrType := range []byte{ZkTokenProofInstrTypeVerifyTransferWithFee, ZkTokenProofInstrTypeVerifyBatchedRangeProofU256, ZkTokenProofInstrTypeVerifyFeeSigma} {
+		err := zkTokenTestVerify(t, []byte{instrType})
+		assert.Equal(t, InstrErrInvalidInstructionData, err)
+	}
+}
+
+func TestExecute_Tx_ZkTokenProof_Feature_Inactive_Failure(t *testing.T) {
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkTokenProofProgramAcct()})
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := &ExecutionCtx{TransactionContext: txCtx, ComputeMeter: cu.NewComputeMeter(10000000000)}
+	execCtx.GlobalCtx.Features = *features.NewFeaturesDefault()
+
+	err := execCtx.ProcessInstruction(zkTokenTestZeroBalanceInstrData(0), []InstructionAccount{}, []uint64{0})
+	assert.Equal(t, InstrErrUnsupportedProgramId, err)
+}
+
+func TestExecute_Tx_ZkTokenProof_Create_Context_State_Success(t *testing.T) {
+	instrData := zkTokenTestZeroBalanceInstrData(0)
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, make([]byte, ZkProofContextStateMetaLen+96))
+	contextAcct.Owner = ZkTokenProofProgramAddr
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkTokenProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkTokenProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
+	assert.Equal(t, nil, err)
+
+	contextAcctPost, err := txCtx.Accounts.GetAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, authorityAcct.Key[:], contextAcctPost.Data[:32])
+	assert.Equal(t, byte(ZkTokenProofInstrTypeVerifyZeroBalance), contextAcctPost.Data[32])
+	assert.Equal(t, instrData[1:97], contextAcctPost.Data[33:])
+}
+
+func TestExecute_Tx_ZkTokenProof_Create_Context_State_Wrong_Owner_Failure(t *testing.T) {
+	// context state accounts of the zk elgamal proof program aren't written to
+	contextAcct, authorityAcct := zkTestContextStateAccts(t, make([]byte, ZkProofContextStateMetaLen+96))
+
+	transactionAccts := NewTransactionAccounts([]accounts.Account{zkTokenProofProgramAcct(), contextAcct, authorityAcct})
+
+	acctMetas := []AccountMeta{{Pubkey: contextAcct.Key, IsSigner: false, IsWritable: true},
+		{Pubkey: authorityAcct.Key, IsSigner: false, IsWritable: false}}
+	instructionAccts := InstructionAcctsFromAccountMetas(acctMetas, *transactionAccts)
+
+	txCtx := NewTestTransactionCtx(*transactionAccts, 5, 64)
+	execCtx := newZkTokenProofTestExecCtx(txCtx)
+	err := execCtx.ProcessInstruction(zkTokenTestZeroBalanceInstrData(0), instructionAccts, []uint64{0})
+	assert.Equal(t, InstrErrInvalidAccountOwner, err)
+}
@@ -37,6 +37,10 @@ func Syscalls(f *features.Features, isDeploy bool) sbpf.SyscallRegistry {
 		reg.Register("sol_alt_bn128_group_op", SyscallAltBn128)
 	}
 
+	if f.IsActive(features.EnableBigModExpSyscall) {
+		reg.Register("sol_big_mod_exp", SyscallBigModExp)
+	}
+
 	reg.Register("sol_memcpy_", SyscallMemcpy)
 	reg.Register("sol_memcmp_", SyscallMemcmp)
 	reg.Register("sol_memset_", SyscallMemset)
@@ -54,6 +58,10 @@ func Syscalls(f *features.Features, isDeploy bool) sbpf.SyscallRegistry {
 	reg.Register("sol_set_return_data", SyscallSetReturnData)
 	reg.Register("sol_get_processed_sibling_instruction", SyscallGetProcessedSiblingInstruction)
 
+	if f.IsActive(features.RemainingComputeUnitsSyscallEnabled) {
+		reg.Register("sol_remaining_compute_units", SyscallRemainingComputeUnits)
+	}
+
 	reg.Register("sol_get_clock_sysvar", SyscallGetClockSysvar)
 	reg.Register("sol_get_rent_sysvar", SyscallGetRentSysvar)
 	reg.Register("sol_get_epoch_schedule_sysvar", SyscallGetEpochScheduleSysvar)
@@ -66,6 +74,18 @@ func Syscalls(f *features.Features, isDeploy bool) sbpf.SyscallRegistry {
 		reg.Register("sol_get_last_restart_slot", SyscallGetLastRestartSlotSysvar)
 	}
 
+	if !f.IsActive(features.DisableFeesSysvar) {
+		reg.Register("sol_get_fees_sysvar", SyscallGetFeesSysvar)
+	}
+
+	if f.IsActive(features.GetSysvarSyscallEnabled) {
+		reg.Register("sol_get_sysvar", SyscallGetSysvar)
+	}
+
+	if f.IsActive(features.EnableGetEpochStakeSyscall) {
+		reg.Register("sol_get_epoch_stake", SyscallGetEpochStake)
+	}
+
 	var SyscallInvokeSignedC = sbpf.SyscallFunc5(SyscallInvokeSignedCImpl)
 	var SyscallInvokeSignedRust = sbpf.SyscallFunc5(SyscallInvokeSignedRustImpl)
 
@@ -0,0 +1,106 @@
+package sealevel
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"go.firedancer.io/radiance/pkg/safemath"
+	"go.firedancer.io/radiance/pkg/sbpf"
+	"k8s.io/klog/v2"
+)
+
+const (
+	BigModExpParamsLen   = 48
+	BigModExpMaxInputLen = 512
+)
+
+// BigModExpParams are the parameters of the sol_big_mod_exp syscall, with each of the base,
+// exponent and modulus given by the VM address and length of its big-endian encoding.
+type BigModExpParams struct {
+	Base        uint64
+	BaseLen     uint64
+	Exponent    uint64
+	ExponentLen uint64
+	Modulus     uint64
+	ModulusLen  uint64
+}
+
+func (params *BigModExpParams) Unmarshal(data []byte) {
+	params.Base = binary.LittleEndian.Uint64(data[:8])
+	params.BaseLen = binary.LittleEndian.Uint64(data[8:16])
+	params.Exponent = binary.LittleEndian.Uint64(data[16:24])
+	params.ExponentLen = binary.LittleEndian.Uint64(data[24:32])
+	params.Modulus = binary.LittleEndian.Uint64(data[32:40])
+	params.ModulusLen = binary.LittleEndian.Uint64(data[40:48])
+}
+
+// bigModExp computes base^exponent mod modulus over big-endian encoded integers, returning
+// the result encoded as big-endian and left-padded to the length of the modulus. A modulus
+// of zero or one yields zero.
+func bigModExp(base, exponent, modulus []byte) []byte {
+	result := make([]byte, len(modulus))
+
+	m := new(big.Int).SetBytes(modulus)
+	if m.Cmp(big.NewInt(1)) <= 0 {
+		return result
+	}
+
+	b := new(big.Int).SetBytes(base)
+	e := new(big.Int).SetBytes(exponent)
+	r := new(big.Int).Exp(b, e, m)
+
+	return r.FillBytes(result)
+}
+
+// SyscallBigModExpImpl is an implementation of the sol_big_mod_exp syscall
+func SyscallBigModExpImpl(vm sbpf.VM, paramsAddr, returnValueAddr uint64) (uint64, error) {
+	klog.Infof("SyscallBigModExp")
+
+	execCtx := executionCtx(vm)
+
+	paramsBytes, err := vm.Translate(paramsAddr, BigModExpParamsLen, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	var params BigModExpParams
+	params.Unmarshal(paramsBytes)
+
+	if params.BaseLen > BigModExpMaxInputLen || params.ExponentLen > BigModExpMaxInputLen || params.ModulusLen > BigModExpMaxInputLen {
+		return syscallErr(SyscallErrInvalidLength)
+	}
+
+	inputLen := max(params.BaseLen, params.ExponentLen, params.ModulusLen)
+	cost := safemath.SaturatingAddU64(CUSyscallBaseCost, (inputLen*inputLen)/CUBigModularExponentiationCostDivisor+CUBigModularExponentiationBaseCost)
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return syscallCuErr()
+	}
+
+	base, err := vm.Translate(params.Base, params.BaseLen, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	exponent, err := vm.Translate(params.Exponent, params.ExponentLen, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	modulus, err := vm.Translate(params.Modulus, params.ModulusLen, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	result := bigModExp(base, exponent, modulus)
+
+	returnValue, err := vm.Translate(returnValueAddr, params.ModulusLen, true)
+	if err != nil {
+		return syscallErr(err)
+	}
+	copy(returnValue, result)
+
+	return syscallSuccess(0)
+}
+
+var SyscallBigModExp = sbpf.SyscallFunc2(SyscallBigModExpImpl)
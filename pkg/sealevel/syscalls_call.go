@@ -25,6 +25,21 @@ func SyscallGetStackHeightImpl(vm sbpf.VM) (uint64, error) {
 
 var SyscallGetStackHeight = sbpf.SyscallFunc0(SyscallGetStackHeightImpl)
 
+// SyscallRemainingComputeUnitsImpl is an implementation of the sol_remaining_compute_units syscall
+func SyscallRemainingComputeUnitsImpl(vm sbpf.VM) (uint64, error) {
+	klog.Infof("SyscallRemainingComputeUnits")
+
+	execCtx := executionCtx(vm)
+	err := execCtx.ComputeMeter.Consume(CUSyscallBaseCost)
+	if err != nil {
+		return syscallCuErr()
+	}
+
+	return syscallSuccess(execCtx.ComputeMeter.Remaining())
+}
+
+var SyscallRemainingComputeUnits = sbpf.SyscallFunc0(SyscallRemainingComputeUnitsImpl)
+
 // SyscallGetReturnDataImpl is an implementation of the sol_get_return_data syscall
 func SyscallGetReturnDataImpl(vm sbpf.VM, returnDataAddr, length, programIdAddr uint64) (uint64, error) {
 	klog.Infof("SyscallGetReturnData")
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/safemath"
 	"go.firedancer.io/radiance/pkg/sbpf"
 	"k8s.io/klog/v2"
 )
@@ -183,3 +185,145 @@ func SyscallGetLastRestartSlotSysvarImpl(vm sbpf.VM, addr uint64) (uint64, error
 }
 
 var SyscallGetLastRestartSlotSysvar = sbpf.SyscallFunc1(SyscallGetLastRestartSlotSysvarImpl)
+
+// SyscallGetFeesSysvarImpl is an implementation of the sol_get_fees_sysvar syscall
+func SyscallGetFeesSysvarImpl(vm sbpf.VM, addr uint64) (uint64, error) {
+	klog.Infof("SyscallGetFeesSysvar")
+
+	execCtx := executionCtx(vm)
+
+	cost := uint64(CUSyscallBaseCost + SysvarFeesStructLen)
+	err := execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return syscallCuErr()
+	}
+
+	feesDst, err := vm.Translate(addr, SysvarFeesStructLen, true)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	fees, err := ReadFeesSysvar(execCtx)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	binary.LittleEndian.PutUint64(feesDst[:8], fees.FeeCalculator.LamportsPerSignature)
+
+	return syscallSuccess(0)
+}
+
+var SyscallGetFeesSysvar = sbpf.SyscallFunc1(SyscallGetFeesSysvarImpl)
+
+// return values of the sol_get_sysvar syscall
+const (
+	GetSysvarSuccess                   = 0
+	GetSysvarOffsetLengthExceedsSysvar = 1
+	GetSysvarSysvarNotFound            = 2
+)
+
+// sysvars whose account data can be read via the sol_get_sysvar syscall
+var getSysvarAddrs = map[[32]byte]bool{
+	SysvarClockAddr:           true,
+	SysvarEpochScheduleAddr:   true,
+	SysvarEpochRewardsAddr:    true,
+	SysvarRentAddr:            true,
+	SysvarSlotHashesAddr:      true,
+	SysvarStakeHistoryAddr:    true,
+	SysvarLastRestartSlotAddr: true,
+}
+
+// SyscallGetSysvarImpl is an implementation of the sol_get_sysvar syscall, which copies length
+// bytes of a sysvar's account data from the given offset.
+func SyscallGetSysvarImpl(vm sbpf.VM, sysvarIdAddr, varAddr, offset, length uint64) (uint64, error) {
+	klog.Infof("SyscallGetSysvar")
+
+	execCtx := executionCtx(vm)
+
+	cost := safemath.SaturatingAddU64(CUSyscallBaseCost+solana.PublicKeyLength/CUCpiBytesPerUnit, max(length/CUCpiBytesPerUnit, CUMemOpBaseCost))
+	err := execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return syscallCuErr()
+	}
+
+	sysvarIdBytes, err := vm.Translate(sysvarIdAddr, solana.PublicKeyLength, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+	sysvarId := [32]byte(sysvarIdBytes)
+
+	varDst, err := vm.Translate(varAddr, length, true)
+	if err != nil {
+		return syscallErr(err)
+	}
+
+	offsetLength, err := safemath.CheckedAddU64(offset, length)
+	if err != nil {
+		return syscallErr(InstrErrArithmeticOverflow)
+	}
+
+	_, err = safemath.CheckedAddU64(varAddr, length)
+	if err != nil {
+		return syscallErr(InstrErrArithmeticOverflow)
+	}
+
+	if !getSysvarAddrs[sysvarId] {
+		return syscallSuccess(GetSysvarSysvarNotFound)
+	}
+
+	accts := addrObjectForLookup(execCtx)
+	sysvarAcct, err := (*accts).GetAccount(&sysvarId)
+	if err != nil || sysvarAcct.Lamports == 0 {
+		return syscallSuccess(GetSysvarSysvarNotFound)
+	}
+
+	if offsetLength > uint64(len(sysvarAcct.Data)) {
+		return syscallSuccess(GetSysvarOffsetLengthExceedsSysvar)
+	}
+
+	copy(varDst, sysvarAcct.Data[offset:offsetLength])
+
+	return syscallSuccess(GetSysvarSuccess)
+}
+
+var SyscallGetSysvar = sbpf.SyscallFunc4(SyscallGetSysvarImpl)
+
+// SyscallGetEpochStakeImpl is an implementation of the sol_get_epoch_stake syscall, which
+// returns the total stake of the current epoch if voteAddr is null, and otherwise the stake
+// delegated to the vote account at voteAddr during the epoch.
+func SyscallGetEpochStakeImpl(vm sbpf.VM, voteAddr uint64) (uint64, error) {
+	klog.Infof("SyscallGetEpochStake")
+
+	execCtx := executionCtx(vm)
+
+	if voteAddr == 0 {
+		err := execCtx.ComputeMeter.Consume(CUSyscallBaseCost)
+		if err != nil {
+			return syscallCuErr()
+		}
+
+		if execCtx.SlotCtx == nil {
+			return syscallSuccess(0)
+		}
+		return syscallSuccess(execCtx.SlotCtx.EpochTotalStake)
+	}
+
+	cost := uint64(CUSyscallBaseCost + solana.PublicKeyLength/CUCpiBytesPerUnit + CUMemOpBaseCost)
+	err := execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return syscallCuErr()
+	}
+
+	voteAcctBytes, err := vm.Translate(voteAddr, solana.PublicKeyLength, false)
+	if err != nil {
+		return syscallErr(err)
+	}
+	voteAcct := solana.PublicKeyFromBytes(voteAcctBytes)
+
+	if execCtx.SlotCtx == nil {
+		return syscallSuccess(0)
+	}
+	return syscallSuccess(execCtx.SlotCtx.EpochVoteAccountStakes[voteAcct])
+}
+
+var SyscallGetEpochStake = sbpf.SyscallFunc1(SyscallGetEpochStakeImpl)
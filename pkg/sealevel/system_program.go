@@ -38,6 +38,10 @@ var (
 	SystemProgErrAddressWithSeedMismatch    = errors.New("SystemProgErrAddressWithSeedMismatch")
 	SystemProgErrNonceNoRecentBlockhashes   = errors.New("SystemProgErrNonceNoRecentBlockhashes")
 	SystemProgErrNonceBlockhashNotExpired   = errors.New("SystemProgErrNonceBlockhashNotExpired")
+
+	// ErrBlockhashUnknown is returned by the nonce instructions when the execution context
+	// lacks the slot's blockhash, from which the durable nonce must be derived.
+	ErrBlockhashUnknown = errors.New("ErrBlockhashUnknown")
 )
 
 type SystemInstrCreateAccount struct {
@@ -551,7 +555,7 @@ func (nonceStateVersions *NonceStateVersions) Upgrade() bool {
 
 		nonceStateVersions.Current = nonceStateVersions.Legacy
 		nonceStateVersions.Type = NonceVersionCurrent
-		nonceStateVersions.Current.DurableNonce = durableNonce(nonceStateVersions.Current.DurableNonce)
+		nonceStateVersions.Current.DurableNonce = DurableNonceFromBlockhash(nonceStateVersions.Current.DurableNonce)
 		nonceStateVersions.Legacy = NonceData{}
 
 		return true
@@ -827,7 +831,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return SystemProgErrNonceNoRecentBlockhashes
 			}
 
-			err = SystemProgramAdvanceNonceAccount(execCtx, acct, signers, &recentBlockHashes)
+			err = SystemProgramAdvanceNonceAccount(execCtx, acct, signers)
 		}
 
 	case SystemProgramInstrTypeWithdrawNonceAccount:
@@ -848,8 +852,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			var recentBlockhashes SysvarRecentBlockhashes
-			recentBlockhashes, err = ReadRecentBlockHashesSysvar(execCtx)
+			_, err = ReadRecentBlockHashesSysvar(execCtx)
 			if err != nil {
 				return err
 			}
@@ -866,7 +869,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = SystemProgramWithdrawNonceAccount(execCtx, instrCtx, 0, withdrawNonceAcct.Lamports, 1, &rent, signers, &recentBlockhashes)
+			err = SystemProgramWithdrawNonceAccount(execCtx, instrCtx, 0, withdrawNonceAcct.Lamports, 1, &rent, signers)
 		}
 
 	case SystemProgramInstrTypeInitializeNonceAccount:
@@ -915,7 +918,7 @@ func SystemProgramExecute(execCtx *ExecutionCtx) error {
 				return err
 			}
 
-			err = SystemProgramInitializeNonceAccount(execCtx, acct, initNonceAcct.Pubkey, &rent, &recentBlockHashes)
+			err = SystemProgramInitializeNonceAccount(execCtx, acct, initNonceAcct.Pubkey, &rent)
 		}
 
 	case SystemProgramInstrTypeAuthorizeNonceAccount:
@@ -1269,7 +1272,9 @@ func transferInternal(execCtx *ExecutionCtx, fromAcctIdx uint64, toAcctIdx uint6
 	return err
 }
 
-func durableNonce(hash [32]byte) [32]byte {
+// DurableNonceFromBlockhash returns the durable nonce to which nonce accounts are advanced
+// in a slot of which the most recent blockhash is hash.
+func DurableNonceFromBlockhash(hash [32]byte) [32]byte {
 	prefix := "DURABLE_NONCE"
 	hasher := sha256.New()
 	hasher.Write([]byte(prefix))
@@ -1281,7 +1286,17 @@ func durableNonce(hash [32]byte) [32]byte {
 	return durableNonce
 }
 
-func SystemProgramInitializeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount, nonceAuthority solana.PublicKey, rent *SysvarRent, recentBlockhashes *SysvarRecentBlockhashes) error {
+// durableNonce returns the durable nonce to which nonce accounts are advanced in the slot
+// being executed, or ErrBlockhashUnknown if the slot's blockhash is unknown.
+func (execCtx *ExecutionCtx) durableNonce() ([32]byte, error) {
+	if execCtx.Blockhash == [32]byte{} {
+		klog.Errorf("nonce instruction: blockhash of slot unknown")
+		return [32]byte{}, ErrBlockhashUnknown
+	}
+	return DurableNonceFromBlockhash(execCtx.Blockhash), nil
+}
+
+func SystemProgramInitializeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount, nonceAuthority solana.PublicKey, rent *SysvarRent) error {
 	klog.Infof("InitializeNonceAccount: acct %s", acct.Key())
 
 	if !acct.IsWritable() {
@@ -1305,14 +1320,16 @@ func SystemProgramInitializeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAc
 		return InstrErrInsufficientFunds
 	}
 
-	rbh := recentBlockhashes.GetLatest()
-	durableNonce := durableNonce(rbh.Blockhash)
+	durableNonce, err := execCtx.durableNonce()
+	if err != nil {
+		return err
+	}
 
 	newNonceStateVersions := NonceStateVersions{Type: NonceVersionCurrent, Current: NonceData{
 		IsInitialized: true,
 		Authority:     nonceAuthority,
 		DurableNonce:  durableNonce,
-		FeeCalculator: FeeCalculator{LamportsPerSignature: rbh.FeeCalculator.LamportsPerSignature},
+		FeeCalculator: FeeCalculator{LamportsPerSignature: execCtx.LamportsPerSignature},
 	}}
 
 	newStateBytes, err := newNonceStateVersions.Marshal()
@@ -1383,7 +1400,7 @@ func SystemProgramUpgradeNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccou
 	return acct.SetState(execCtx.GlobalCtx.Features, newStateData)
 }
 
-func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *InstructionCtx, fromAcctIdx uint64, lamports uint64, toAcctIdx uint64, rent *SysvarRent, signers []solana.PublicKey, recentBlockhashes *SysvarRecentBlockhashes) error {
+func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *InstructionCtx, fromAcctIdx uint64, lamports uint64, toAcctIdx uint64, rent *SysvarRent, signers []solana.PublicKey) error {
 	klog.Infof("WithdrawNonceAccount")
 
 	from, err := instrCtx.BorrowInstructionAccount(execCtx.TransactionContext, fromAcctIdx)
@@ -1408,7 +1425,10 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 	if state.IsInitialized {
 		signer = state.Authority
 		if lamports == from.Lamports() {
-			durableNonce := durableNonce(recentBlockhashes.GetLatest().Blockhash)
+			durableNonce, err := execCtx.durableNonce()
+			if err != nil {
+				return err
+			}
 			if durableNonce == state.DurableNonce {
 				klog.Infof("Withdraw nonce account: nonce can only advance once per slot")
 				return SystemProgErrNonceBlockhashNotExpired
@@ -1475,7 +1495,7 @@ func SystemProgramWithdrawNonceAccount(execCtx *ExecutionCtx, instrCtx *Instruct
 	return nil
 }
 
-func SystemProgramAdvanceNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount, signers []solana.PublicKey, recentBlockhashes *SysvarRecentBlockhashes) error {
+func SystemProgramAdvanceNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccount, signers []solana.PublicKey) error {
 	klog.Infof("AdvanceNonceAccount")
 
 	if !acct.IsWritable() {
@@ -1501,8 +1521,10 @@ func SystemProgramAdvanceNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccou
 		return InstrErrMissingRequiredSignature
 	}
 
-	rbh := recentBlockhashes.GetLatest()
-	nextDurableNonce := durableNonce(rbh.Blockhash)
+	nextDurableNonce, err := execCtx.durableNonce()
+	if err != nil {
+		return err
+	}
 	if state.DurableNonce == nextDurableNonce {
 		klog.Errorf("Advance nonce account: nonce can only advance once per slot")
 		return SystemProgErrNonceBlockhashNotExpired
@@ -1510,12 +1532,12 @@ func SystemProgramAdvanceNonceAccount(execCtx *ExecutionCtx, acct *BorrowedAccou
 
 	if nonceStateVersions.Type == NonceVersionCurrent {
 		state.DurableNonce = nextDurableNonce
-		state.FeeCalculator.LamportsPerSignature = rbh.FeeCalculator.LamportsPerSignature
+		state.FeeCalculator.LamportsPerSignature = execCtx.LamportsPerSignature
 	} else {
 		nonceStateVersions.Upgrade()
 		upgradedState := nonceStateVersions.State()
 		upgradedState.DurableNonce = nextDurableNonce
-		upgradedState.FeeCalculator.LamportsPerSignature = rbh.FeeCalculator.LamportsPerSignature
+		upgradedState.FeeCalculator.LamportsPerSignature = execCtx.LamportsPerSignature
 	}
 
 	newData, err := nonceStateVersions.Marshal()
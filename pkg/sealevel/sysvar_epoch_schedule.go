@@ -62,6 +62,35 @@ func (ses *SysvarEpochSchedule) UnmarshalWithDecoder(decoder *bin.Decoder) (err
 	return
 }
 
+func (ses *SysvarEpochSchedule) MarshalWithEncoder(encoder *bin.Encoder) (err error) {
+	err = encoder.WriteUint64(ses.SlotsPerEpoch, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write SlotsPerEpoch when encoding SysvarEpochSchedule: %w", err)
+	}
+
+	err = encoder.WriteUint64(ses.LeaderScheduleSlotOffset, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write LeaderScheduleSlotOffset when encoding SysvarEpochSchedule: %w", err)
+	}
+
+	err = encoder.WriteBool(ses.Warmup)
+	if err != nil {
+		return fmt.Errorf("failed to write Warmup when encoding SysvarEpochSchedule: %w", err)
+	}
+
+	err = encoder.WriteUint64(ses.FirstNormalEpoch, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write FirstNormalEpoch when encoding SysvarEpochSchedule: %w", err)
+	}
+
+	err = encoder.WriteUint64(ses.FirstNormalSlot, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write FirstNormalSlot when encoding SysvarEpochSchedule: %w", err)
+	}
+
+	return
+}
+
 func (sr *SysvarEpochSchedule) MustUnmarshalWithDecoder(decoder *bin.Decoder) {
 	err := sr.UnmarshalWithDecoder(decoder)
 	if err != nil {
@@ -101,13 +130,13 @@ func (sr *SysvarEpochSchedule) Slot0(epoch uint64) uint64 {
 
 func (sr *SysvarEpochSchedule) GetEpochAndSlotIndex(slot uint64) (uint64, uint64) {
 	if slot < sr.FirstNormalSlot {
+		// the smallest power of two no less than n
 		nextPowerOfTwo := func(n uint64) uint64 {
-			shift := uint64(bits.Len(uint(n)))
-			return 1 << shift
+			return 1 << bits.Len64(n-1)
 		}
 
 		epoch := uint64(bits.TrailingZeros64(nextPowerOfTwo(slot+MinimumSlotsPerEpoch+1)) - bits.TrailingZeros64(MinimumSlotsPerEpoch) - 1)
-		epochLen := uint64(math.Pow(2, float64(bits.TrailingZeros64(uint64(epoch+MinimumSlotsPerEpoch)))))
+		epochLen := uint64(1) << (epoch + uint64(bits.TrailingZeros64(MinimumSlotsPerEpoch)))
 		return epoch, slot - (epochLen - MinimumSlotsPerEpoch)
 	} else {
 		normalSlotIndex := slot - sr.FirstNormalSlot
@@ -42,18 +42,26 @@ func (sf *SysvarFees) Update(lamportsPerSignature uint64) {
 	sf.FeeCalculator.LamportsPerSignature = lamportsPerSignature
 }
 
-func ReadFeesSysvar(accts *accounts.Accounts) SysvarFees {
+func ReadFeesSysvar(execCtx *ExecutionCtx) (SysvarFees, error) {
+	accts := addrObjectForLookup(execCtx)
+
 	feesSysvarAcct, err := (*accts).GetAccount(&SysvarFeesAddr)
 	if err != nil {
-		panic("failed to read fees sysvar account")
+		return SysvarFees{}, InstrErrUnsupportedSysvar
 	}
 
-	dec := bin.NewBinDecoder(feesSysvarAcct.Data)
+	if feesSysvarAcct.Lamports == 0 {
+		return SysvarFees{}, InstrErrUnsupportedSysvar
+	}
 
+	dec := bin.NewBinDecoder(feesSysvarAcct.Data)
 	var fees SysvarFees
-	fees.MustUnmarshalWithDecoder(dec)
+	err = fees.UnmarshalWithDecoder(dec)
+	if err != nil {
+		return SysvarFees{}, InstrErrUnsupportedSysvar
+	}
 
-	return fees
+	return fees, nil
 }
 
 func WriteFeesSysvar(accts *accounts.Accounts, fees SysvarFees) {
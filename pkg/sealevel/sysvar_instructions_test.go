@@ -114,49 +114,52 @@ func TestExecute_Tx_Sysvar_Instructions_Bpf_Test(t *testing.T) {
 	err = execCtx.ProcessInstruction(instrData, instructionAccts, []uint64{0})
 	assert.NoError(t, err)
 
-	// instruction 1 program id & instr data
-	expected := fmt.Sprintf("Program log: instruction1 program_id: %s", instr1.ProgramId)
+	expected := fmt.Sprintf("Program %s invoke [1]", programAcct.Key)
 	assert.Equal(t, expected, log.Logs[0])
-	expected = fmt.Sprintf("Program log: instruction1 data: %s", reformatHexBytes(instr1.Data))
+
+	// instruction 1 program id & instr data
+	expected = fmt.Sprintf("Program log: instruction1 program_id: %s", instr1.ProgramId)
 	assert.Equal(t, expected, log.Logs[1])
+	expected = fmt.Sprintf("Program log: instruction1 data: %s", reformatHexBytes(instr1.Data))
+	assert.Equal(t, expected, log.Logs[2])
 
 	// instruction 1, account 1
 	expected = fmt.Sprintf("Program log: instruction1 account 1: pubkey: %s", instr1.Accounts[0].Pubkey)
-	assert.Equal(t, expected, log.Logs[2])
-	expected = fmt.Sprintf("Program log: instruction1 account 1: is_writable: %t", instr1.Accounts[0].IsWritable)
 	assert.Equal(t, expected, log.Logs[3])
-	expected = fmt.Sprintf("Program log: instruction1 account 1: is_signer: %t", instr1.Accounts[0].IsSigner)
+	expected = fmt.Sprintf("Program log: instruction1 account 1: is_writable: %t", instr1.Accounts[0].IsWritable)
 	assert.Equal(t, expected, log.Logs[4])
+	expected = fmt.Sprintf("Program log: instruction1 account 1: is_signer: %t", instr1.Accounts[0].IsSigner)
+	assert.Equal(t, expected, log.Logs[5])
 
 	// instruction 1, account 2
 	expected = fmt.Sprintf("Program log: instruction1 account 2: pubkey: %s", instr1.Accounts[1].Pubkey)
-	assert.Equal(t, expected, log.Logs[5])
-	expected = fmt.Sprintf("Program log: instruction1 account 2: is_writable: %t", instr1.Accounts[1].IsWritable)
 	assert.Equal(t, expected, log.Logs[6])
-	expected = fmt.Sprintf("Program log: instruction1 account 2: is_signer: %t", instr1.Accounts[1].IsSigner)
+	expected = fmt.Sprintf("Program log: instruction1 account 2: is_writable: %t", instr1.Accounts[1].IsWritable)
 	assert.Equal(t, expected, log.Logs[7])
+	expected = fmt.Sprintf("Program log: instruction1 account 2: is_signer: %t", instr1.Accounts[1].IsSigner)
+	assert.Equal(t, expected, log.Logs[8])
 
 	// instruction 2 program id & instr data
 	expected = fmt.Sprintf("Program log: instruction2 program_id: %s", instr2.ProgramId)
-	assert.Equal(t, expected, log.Logs[8])
-	expected = fmt.Sprintf("Program log: instruction2 data: %s", reformatHexBytes(instr2.Data))
 	assert.Equal(t, expected, log.Logs[9])
+	expected = fmt.Sprintf("Program log: instruction2 data: %s", reformatHexBytes(instr2.Data))
+	assert.Equal(t, expected, log.Logs[10])
 
 	// instruction 2, account 1
 	expected = fmt.Sprintf("Program log: instruction2 account 1: pubkey: %s", instr2.Accounts[0].Pubkey)
-	assert.Equal(t, expected, log.Logs[10])
-	expected = fmt.Sprintf("Program log: instruction2 account 1: is_writable: %t", instr2.Accounts[0].IsWritable)
 	assert.Equal(t, expected, log.Logs[11])
-	expected = fmt.Sprintf("Program log: instruction2 account 1: is_signer: %t", instr2.Accounts[0].IsSigner)
+	expected = fmt.Sprintf("Program log: instruction2 account 1: is_writable: %t", instr2.Accounts[0].IsWritable)
 	assert.Equal(t, expected, log.Logs[12])
+	expected = fmt.Sprintf("Program log: instruction2 account 1: is_signer: %t", instr2.Accounts[0].IsSigner)
+	assert.Equal(t, expected, log.Logs[13])
 
 	// instruction 2, account 2
 	expected = fmt.Sprintf("Program log: instruction2 account 2: pubkey: %s", instr2.Accounts[1].Pubkey)
-	assert.Equal(t, expected, log.Logs[13])
-	expected = fmt.Sprintf("Program log: instruction2 account 2: is_writable: %t", instr2.Accounts[1].IsWritable)
 	assert.Equal(t, expected, log.Logs[14])
-	expected = fmt.Sprintf("Program log: instruction2 account 2: is_signer: %t", instr2.Accounts[1].IsSigner)
+	expected = fmt.Sprintf("Program log: instruction2 account 2: is_writable: %t", instr2.Accounts[1].IsWritable)
 	assert.Equal(t, expected, log.Logs[15])
+	expected = fmt.Sprintf("Program log: instruction2 account 2: is_signer: %t", instr2.Accounts[1].IsSigner)
+	assert.Equal(t, expected, log.Logs[16])
 
 	for _, l := range log.Logs {
 		fmt.Printf("log: %s\n", l)
@@ -45,6 +45,25 @@ func (sr *SysvarRent) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 	return
 }
 
+func (sr *SysvarRent) MarshalWithEncoder(encoder *bin.Encoder) (err error) {
+	err = encoder.WriteUint64(sr.LamportsPerUint8Year, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write LamportsPerUint8Year when encoding SysvarRent: %w", err)
+	}
+
+	err = encoder.WriteFloat64(sr.ExemptionThreshold, bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write ExemptionThreshold when encoding SysvarRent: %w", err)
+	}
+
+	err = encoder.WriteByte(sr.BurnPercent)
+	if err != nil {
+		return fmt.Errorf("failed to write BurnPercent when encoding SysvarRent: %w", err)
+	}
+
+	return
+}
+
 func (sr *SysvarRent) MustUnmarshalWithDecoder(decoder *bin.Decoder) {
 	err := sr.UnmarshalWithDecoder(decoder)
 	if err != nil {
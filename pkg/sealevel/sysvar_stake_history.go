@@ -65,6 +65,37 @@ func (sh *SysvarStakeHistory) UnmarshalWithDecoder(decoder *bin.Decoder) (err er
 	return
 }
 
+func (sh *SysvarStakeHistory) MarshalWithEncoder(encoder *bin.Encoder) (err error) {
+	err = encoder.WriteUint64(uint64(len(*sh)), bin.LE)
+	if err != nil {
+		return fmt.Errorf("failed to write length of entries when encoding SysvarStakeHistory: %w", err)
+	}
+
+	for _, stakeHistoryPair := range *sh {
+		err = encoder.WriteUint64(stakeHistoryPair.Epoch, bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to write Epoch when encoding SysvarStakeHistory: %w", err)
+		}
+
+		err = encoder.WriteUint64(stakeHistoryPair.Entry.Effective, bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to write Effective when encoding SysvarStakeHistory: %w", err)
+		}
+
+		err = encoder.WriteUint64(stakeHistoryPair.Entry.Activating, bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to write Activating when encoding SysvarStakeHistory: %w", err)
+		}
+
+		err = encoder.WriteUint64(stakeHistoryPair.Entry.Deactivating, bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to write Deactivating when encoding SysvarStakeHistory: %w", err)
+		}
+	}
+
+	return
+}
+
 func (sh *SysvarStakeHistory) MustUnmarshalWithDecoder(decoder *bin.Decoder) {
 	err := sh.UnmarshalWithDecoder(decoder)
 	if err != nil {
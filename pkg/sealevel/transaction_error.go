@@ -0,0 +1,564 @@
+package sealevel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/cu"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+// TransactionErrorCode identifies a variant of the runtime's TransactionError enum. The value
+// of each is the variant's index in the enum, which is also its bincode encoding.
+type TransactionErrorCode uint32
+
+const (
+	TxErrCodeAccountInUse TransactionErrorCode = iota
+	TxErrCodeAccountLoadedTwice
+	TxErrCodeAccountNotFound
+	TxErrCodeProgramAccountNotFound
+	TxErrCodeInsufficientFundsForFee
+	TxErrCodeInvalidAccountForFee
+	TxErrCodeAlreadyProcessed
+	TxErrCodeBlockhashNotFound
+	TxErrCodeInstructionError
+	TxErrCodeCallChainTooDeep
+	TxErrCodeMissingSignatureForFee
+	TxErrCodeInvalidAccountIndex
+	TxErrCodeSignatureFailure
+	TxErrCodeInvalidProgramForExecution
+	TxErrCodeSanitizeFailure
+	TxErrCodeClusterMaintenance
+	TxErrCodeAccountBorrowOutstanding
+	TxErrCodeWouldExceedMaxBlockCostLimit
+	TxErrCodeUnsupportedVersion
+	TxErrCodeInvalidWritableAccount
+	TxErrCodeWouldExceedMaxAccountCostLimit
+	TxErrCodeWouldExceedAccountDataBlockLimit
+	TxErrCodeTooManyAccountLocks
+	TxErrCodeAddressLookupTableNotFound
+	TxErrCodeInvalidAddressLookupTableOwner
+	TxErrCodeInvalidAddressLookupTableData
+	TxErrCodeInvalidAddressLookupTableIndex
+	TxErrCodeInvalidRentPayingAccount
+	TxErrCodeWouldExceedMaxVoteCostLimit
+	TxErrCodeWouldExceedAccountDataTotalLimit
+	TxErrCodeDuplicateInstruction
+	TxErrCodeInsufficientFundsForRent
+	TxErrCodeMaxLoadedAccountsDataSizeExceeded
+	TxErrCodeInvalidLoadedAccountsDataSizeLimit
+	TxErrCodeResanitizationNeeded
+	TxErrCodeProgramExecutionTemporarilyRestricted
+	TxErrCodeUnbalancedTransaction
+	TxErrCodeProgramCacheHitMaxLimit
+)
+
+var txErrNames = [...]string{
+	TxErrCodeAccountInUse:                          "AccountInUse",
+	TxErrCodeAccountLoadedTwice:                    "AccountLoadedTwice",
+	TxErrCodeAccountNotFound:                       "AccountNotFound",
+	TxErrCodeProgramAccountNotFound:                "ProgramAccountNotFound",
+	TxErrCodeInsufficientFundsForFee:               "InsufficientFundsForFee",
+	TxErrCodeInvalidAccountForFee:                  "InvalidAccountForFee",
+	TxErrCodeAlreadyProcessed:                      "AlreadyProcessed",
+	TxErrCodeBlockhashNotFound:                     "BlockhashNotFound",
+	TxErrCodeInstructionError:                      "InstructionError",
+	TxErrCodeCallChainTooDeep:                      "CallChainTooDeep",
+	TxErrCodeMissingSignatureForFee:                "MissingSignatureForFee",
+	TxErrCodeInvalidAccountIndex:                   "InvalidAccountIndex",
+	TxErrCodeSignatureFailure:                      "SignatureFailure",
+	TxErrCodeInvalidProgramForExecution:            "InvalidProgramForExecution",
+	TxErrCodeSanitizeFailure:                       "SanitizeFailure",
+	TxErrCodeClusterMaintenance:                    "ClusterMaintenance",
+	TxErrCodeAccountBorrowOutstanding:              "AccountBorrowOutstanding",
+	TxErrCodeWouldExceedMaxBlockCostLimit:          "WouldExceedMaxBlockCostLimit",
+	TxErrCodeUnsupportedVersion:                    "UnsupportedVersion",
+	TxErrCodeInvalidWritableAccount:                "InvalidWritableAccount",
+	TxErrCodeWouldExceedMaxAccountCostLimit:        "WouldExceedMaxAccountCostLimit",
+	TxErrCodeWouldExceedAccountDataBlockLimit:      "WouldExceedAccountDataBlockLimit",
+	TxErrCodeTooManyAccountLocks:                   "TooManyAccountLocks",
+	TxErrCodeAddressLookupTableNotFound:            "AddressLookupTableNotFound",
+	TxErrCodeInvalidAddressLookupTableOwner:        "InvalidAddressLookupTableOwner",
+	TxErrCodeInvalidAddressLookupTableData:         "InvalidAddressLookupTableData",
+	TxErrCodeInvalidAddressLookupTableIndex:        "InvalidAddressLookupTableIndex",
+	TxErrCodeInvalidRentPayingAccount:              "InvalidRentPayingAccount",
+	TxErrCodeWouldExceedMaxVoteCostLimit:           "WouldExceedMaxVoteCostLimit",
+	TxErrCodeWouldExceedAccountDataTotalLimit:      "WouldExceedAccountDataTotalLimit",
+	TxErrCodeDuplicateInstruction:                  "DuplicateInstruction",
+	TxErrCodeInsufficientFundsForRent:              "InsufficientFundsForRent",
+	TxErrCodeMaxLoadedAccountsDataSizeExceeded:     "MaxLoadedAccountsDataSizeExceeded",
+	TxErrCodeInvalidLoadedAccountsDataSizeLimit:    "InvalidLoadedAccountsDataSizeLimit",
+	TxErrCodeResanitizationNeeded:                  "ResanitizationNeeded",
+	TxErrCodeProgramExecutionTemporarilyRestricted: "ProgramExecutionTemporarilyRestricted",
+	TxErrCodeUnbalancedTransaction:                 "UnbalancedTransaction",
+	TxErrCodeProgramCacheHitMaxLimit:               "ProgramCacheHitMaxLimit",
+}
+
+// names of the runtime's InstructionError variants, indexed by their InstrErrCode* values
+var instrErrNames = [...]string{
+	InstrErrCodeGenericError:                           "GenericError",
+	InstrErrCodeInvalidArgument:                        "InvalidArgument",
+	InstrErrCodeInvalidInstructionData:                 "InvalidInstructionData",
+	InstrErrCodeInvalidAccountData:                     "InvalidAccountData",
+	InstrErrCodeAccountDataTooSmall:                    "AccountDataTooSmall",
+	InstrErrCodeInsufficientFunds:                      "InsufficientFunds",
+	InstrErrCodeIncorrectProgramId:                     "IncorrectProgramId",
+	InstrErrCodeMissingRequiredSignature:               "MissingRequiredSignature",
+	InstrErrCodeAccountAlreadyInitialized:              "AccountAlreadyInitialized",
+	InstrErrCodeUninitializedAccount:                   "UninitializedAccount",
+	InstrErrCodeUnbalancedInstruction:                  "UnbalancedInstruction",
+	InstrErrCodeModifiedProgramId:                      "ModifiedProgramId",
+	InstrErrCodeExternalAccountLamportSpend:            "ExternalAccountLamportSpend",
+	InstrErrCodeExternalAccountDataModified:            "ExternalAccountDataModified",
+	InstrErrCodeReadonlyLamportChange:                  "ReadonlyLamportChange",
+	InstrErrCodeReadonlyDataModified:                   "ReadonlyDataModified",
+	InstrErrCodeDuplicateAccountIndex:                  "DuplicateAccountIndex",
+	InstrErrCodeExecutableModified:                     "ExecutableModified",
+	InstrErrCodeRentEpochModified:                      "RentEpochModified",
+	InstrErrCodeNotEnoughAccountKeys:                   "NotEnoughAccountKeys",
+	InstrErrCodeAccountDataSizeChanged:                 "AccountDataSizeChanged",
+	InstrErrCodeAccountNotExecutable:                   "AccountNotExecutable",
+	InstrErrCodeAccountBorrowFailed:                    "AccountBorrowFailed",
+	InstrErrCodeAccountBorrowOutstanding:               "AccountBorrowOutstanding",
+	InstrErrCodeDuplicateAccountOutOfSync:              "DuplicateAccountOutOfSync",
+	InstrErrCodeCustom:                                 "Custom",
+	InstrErrCodeInvalidError:                           "InvalidError",
+	InstrErrCodeExecutableDataModified:                 "ExecutableDataModified",
+	InstrErrCodeExecutableLamportChange:                "ExecutableLamportChange",
+	InstrErrCodeExecutableAccountNotRentExempt:         "ExecutableAccountNotRentExempt",
+	InstrErrCodeUnsupportedProgramId:                   "UnsupportedProgramId",
+	InstrErrCodeCallDepth:                              "CallDepth",
+	InstrErrCodeMissingAccount:                         "MissingAccount",
+	InstrErrCodeReentrancyNotAllowed:                   "ReentrancyNotAllowed",
+	InstrErrCodeMaxSeedLengthExceeded:                  "MaxSeedLengthExceeded",
+	InstrErrCodeInvalidSeeds:                           "InvalidSeeds",
+	InstrErrCodeInvalidRealloc:                         "InvalidRealloc",
+	InstrErrCodeComputationalBudgetExceeded:            "ComputationalBudgetExceeded",
+	InstrErrCodePrivilegeEscalation:                    "PrivilegeEscalation",
+	InstrErrCodeProgramEnvironmentSetupFailure:         "ProgramEnvironmentSetupFailure",
+	InstrErrCodeProgramFailedToComplete:                "ProgramFailedToComplete",
+	InstrErrCodeProgramFailedToCompile:                 "ProgramFailedToCompile",
+	InstrErrCodeImmutable:                              "Immutable",
+	InstrErrCodeIncorrectAuthority:                     "IncorrectAuthority",
+	InstrErrCodeBorshIoError:                           "BorshIoError",
+	InstrErrCodeAccountNotRentExempt:                   "AccountNotRentExempt",
+	InstrErrCodeInvalidAccountOwner:                    "InvalidAccountOwner",
+	InstrErrCodeArithmeticOverflow:                     "ArithmeticOverflow",
+	InstrErrCodeUnsupportedSysvar:                      "UnsupportedSysvar",
+	InstrErrCodeIllegalOwner:                           "IllegalOwner",
+	InstrErrCodeMaxAccountsDataAllocationsExceeded:     "MaxAccountsDataAllocationsExceeded",
+	InstrErrCodeMaxAccountsExceeded:                    "MaxAccountsExceeded",
+	InstrErrCodeMaxInstructionTraceLengthExceeded:      "MaxInstructionTraceLengthExceeded",
+	InstrErrCodeBuiltinProgramsMustConsumeComputeUnits: "BuiltinProgramsMustConsumeComputeUnits",
+}
+
+// messages with which the runtime displays its InstructionError variants, indexed by their
+// InstrErrCode* values
+var instrErrMessages = [...]string{
+	InstrErrCodeGenericError:                           "generic instruction error",
+	InstrErrCodeInvalidArgument:                        "invalid program argument",
+	InstrErrCodeInvalidInstructionData:                 "invalid instruction data",
+	InstrErrCodeInvalidAccountData:                     "invalid account data for instruction",
+	InstrErrCodeAccountDataTooSmall:                    "account data too small for instruction",
+	InstrErrCodeInsufficientFunds:                      "insufficient funds for instruction",
+	InstrErrCodeIncorrectProgramId:                     "incorrect program id for instruction",
+	InstrErrCodeMissingRequiredSignature:               "missing required signature for instruction",
+	InstrErrCodeAccountAlreadyInitialized:              "instruction requires an uninitialized account",
+	InstrErrCodeUninitializedAccount:                   "instruction requires an initialized account",
+	InstrErrCodeUnbalancedInstruction:                  "sum of account balances before and after instruction do not match",
+	InstrErrCodeModifiedProgramId:                      "instruction illegally modified the program id of an account",
+	InstrErrCodeExternalAccountLamportSpend:            "instruction spent from the balance of an account it does not own",
+	InstrErrCodeExternalAccountDataModified:            "instruction modified data of an account it does not own",
+	InstrErrCodeReadonlyLamportChange:                  "instruction changed the balance of a read-only account",
+	InstrErrCodeReadonlyDataModified:                   "instruction modified data of a read-only account",
+	InstrErrCodeDuplicateAccountIndex:                  "instruction contains duplicate accounts",
+	InstrErrCodeExecutableModified:                     "instruction changed executable bit of an account",
+	InstrErrCodeRentEpochModified:                      "instruction modified rent epoch of an account",
+	InstrErrCodeNotEnoughAccountKeys:                   "insufficient account keys for instruction",
+	InstrErrCodeAccountDataSizeChanged:                 "program other than the account's owner changed the size of the account data",
+	InstrErrCodeAccountNotExecutable:                   "instruction expected an executable account",
+	InstrErrCodeAccountBorrowFailed:                    "instruction tries to borrow reference for an account which is already borrowed",
+	InstrErrCodeAccountBorrowOutstanding:               "instruction left account with an outstanding borrowed reference",
+	InstrErrCodeDuplicateAccountOutOfSync:              "instruction modifications of multiply-passed account differ",
+	InstrErrCodeCustom:                                 "custom program error",
+	InstrErrCodeInvalidError:                           "program returned invalid error code",
+	InstrErrCodeExecutableDataModified:                 "instruction changed executable accounts data",
+	InstrErrCodeExecutableLamportChange:                "instruction changed the balance of an executable account",
+	InstrErrCodeExecutableAccountNotRentExempt:         "executable accounts must be rent exempt",
+	InstrErrCodeUnsupportedProgramId:                   "Unsupported program id",
+	InstrErrCodeCallDepth:                              "Cross-program invocation call depth too deep",
+	InstrErrCodeMissingAccount:                         "An account required by the instruction is missing",
+	InstrErrCodeReentrancyNotAllowed:                   "Cross-program invocation reentrancy not allowed for this instruction",
+	InstrErrCodeMaxSeedLengthExceeded:                  "Length of the seed is too long for address generation",
+	InstrErrCodeInvalidSeeds:                           "Provided seeds do not result in a valid address",
+	InstrErrCodeInvalidRealloc:                         "Failed to reallocate account data",
+	InstrErrCodeComputationalBudgetExceeded:            "Computational budget exceeded",
+	InstrErrCodePrivilegeEscalation:                    "Cross-program invocation with unauthorized signer or writable account",
+	InstrErrCodeProgramEnvironmentSetupFailure:         "Failed to create program execution environment",
+	InstrErrCodeProgramFailedToComplete:                "Program failed to complete",
+	InstrErrCodeProgramFailedToCompile:                 "Program failed to compile",
+	InstrErrCodeImmutable:                              "Account is immutable",
+	InstrErrCodeIncorrectAuthority:                     "Incorrect authority provided",
+	InstrErrCodeBorshIoError:                           "Failed to serialize or deserialize account data",
+	InstrErrCodeAccountNotRentExempt:                   "An account does not have enough lamports to be rent-exempt",
+	InstrErrCodeInvalidAccountOwner:                    "Invalid account owner",
+	InstrErrCodeArithmeticOverflow:                     "Program arithmetic overflowed",
+	InstrErrCodeUnsupportedSysvar:                      "Unsupported sysvar",
+	InstrErrCodeIllegalOwner:                           "Provided owner is not allowed",
+	InstrErrCodeMaxAccountsDataAllocationsExceeded:     "Accounts data allocations exceeded the maximum allowed per transaction",
+	InstrErrCodeMaxAccountsExceeded:                    "Max accounts exceeded",
+	InstrErrCodeMaxInstructionTraceLengthExceeded:      "Max instruction trace length exceeded",
+	InstrErrCodeBuiltinProgramsMustConsumeComputeUnits: "Builtin programs must consume compute units",
+}
+
+// InstructionError is the runtime's InstructionError enum. Code is one of the InstrErrCode*
+// values, with Custom holding the program's error code for InstrErrCodeCustom, and
+// BorshIoError the error message for InstrErrCodeBorshIoError.
+type InstructionError struct {
+	Code         uint32
+	Custom       uint32
+	BorshIoError string
+}
+
+// TransactionError is the runtime's TransactionError enum, being the error recorded in a failed
+// transaction's status metadata. InstructionIndex is set for TxErrCodeInstructionError and
+// TxErrCodeDuplicateInstruction, InstructionError for TxErrCodeInstructionError, and
+// AccountIndex for TxErrCodeInsufficientFundsForRent and
+// TxErrCodeProgramExecutionTemporarilyRestricted. TransactionErrors may be compared with ==.
+type TransactionError struct {
+	Code             TransactionErrorCode
+	InstructionIndex uint8
+	InstructionError InstructionError
+	AccountIndex     uint8
+}
+
+func NewTransactionError(code TransactionErrorCode) *TransactionError {
+	return &TransactionError{Code: code}
+}
+
+// NewTxErrInstructionError returns the TransactionError for the failure of the instruction at
+// instrIdx with err, which is converted to an InstructionError via InstructionErrorFromErr.
+func NewTxErrInstructionError(instrIdx int, err error) *TransactionError {
+	return &TransactionError{Code: TxErrCodeInstructionError, InstructionIndex: uint8(instrIdx), InstructionError: InstructionErrorFromErr(err)}
+}
+
+func NewTxErrDuplicateInstruction(instrIdx int) *TransactionError {
+	return &TransactionError{Code: TxErrCodeDuplicateInstruction, InstructionIndex: uint8(instrIdx)}
+}
+
+func NewTxErrInsufficientFundsForRent(acctIdx uint64) *TransactionError {
+	return &TransactionError{Code: TxErrCodeInsufficientFundsForRent, AccountIndex: uint8(acctIdx)}
+}
+
+// InstructionErrorFromErr converts an error returned by instruction execution into the
+// corresponding InstructionError, searching the chain of wrapped errors for an instruction
+// error or custom program error. VM faults and other errors not corresponding to any
+// instruction error are reported as ProgramFailedToComplete, as they are by the runtime.
+func InstructionErrorFromErr(err error) InstructionError {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if customErr, ok := e.(*CustomProgramErr); ok {
+			return InstructionError{Code: InstrErrCodeCustom, Custom: customErr.Code}
+		}
+
+		if e == cu.ErrComputeExceeded || e == sbpf.ExcOutOfCU {
+			return InstructionError{Code: InstrErrCodeComputationalBudgetExceeded}
+		}
+
+		if IsCustomErr(e) {
+			return InstructionError{Code: InstrErrCodeCustom, Custom: uint32(TranslateErrToErrCode(e))}
+		}
+
+		if code, ok := solanaNumericalErrCodes[e]; ok {
+			instrErr := InstructionError{Code: uint32(code)}
+			if code == InstrErrCodeBorshIoError {
+				instrErr.BorshIoError = "Unknown"
+			}
+			return instrErr
+		}
+	}
+
+	return InstructionError{Code: InstrErrCodeProgramFailedToComplete}
+}
+
+func (instrErr InstructionError) name() string {
+	if int(instrErr.Code) < len(instrErrNames) {
+		return instrErrNames[instrErr.Code]
+	}
+	return fmt.Sprintf("InstructionError%d", instrErr.Code)
+}
+
+// String returns the instruction error in the form of the runtime's debug formatting,
+// e.g. "Custom(1)".
+func (instrErr InstructionError) String() string {
+	switch instrErr.Code {
+	case InstrErrCodeCustom:
+		return fmt.Sprintf("Custom(%d)", instrErr.Custom)
+	case InstrErrCodeBorshIoError:
+		return fmt.Sprintf("BorshIoError(%q)", instrErr.BorshIoError)
+	default:
+		return instrErr.name()
+	}
+}
+
+// Message returns the instruction error in the form of the runtime's display formatting,
+// e.g. "custom program error: 0x1", as used in program failure log messages.
+func (instrErr InstructionError) Message() string {
+	switch {
+	case instrErr.Code == InstrErrCodeCustom:
+		return fmt.Sprintf("custom program error: 0x%x", instrErr.Custom)
+	case instrErr.Code == InstrErrCodeBorshIoError:
+		return fmt.Sprintf("%s: %s", instrErrMessages[instrErr.Code], instrErr.BorshIoError)
+	case int(instrErr.Code) < len(instrErrMessages):
+		return instrErrMessages[instrErr.Code]
+	default:
+		return instrErr.name()
+	}
+}
+
+func (txErr *TransactionError) name() string {
+	if int(txErr.Code) < len(txErrNames) {
+		return txErrNames[txErr.Code]
+	}
+	return fmt.Sprintf("TransactionError%d", txErr.Code)
+}
+
+// Error returns the transaction error in the form of the runtime's debug formatting,
+// e.g. "InstructionError(0, Custom(1))".
+func (txErr *TransactionError) Error() string {
+	switch txErr.Code {
+	case TxErrCodeInstructionError:
+		return fmt.Sprintf("InstructionError(%d, %s)", txErr.InstructionIndex, txErr.InstructionError)
+	case TxErrCodeDuplicateInstruction:
+		return fmt.Sprintf("DuplicateInstruction(%d)", txErr.InstructionIndex)
+	case TxErrCodeInsufficientFundsForRent, TxErrCodeProgramExecutionTemporarilyRestricted:
+		return fmt.Sprintf("%s { account_index: %d }", txErr.name(), txErr.AccountIndex)
+	default:
+		return txErr.name()
+	}
+}
+
+type txErrAccountIndex struct {
+	AccountIndex uint8 `json:"account_index"`
+}
+
+// MarshalJSON encodes the instruction error as serde does, i.e. unit variants as strings,
+// and variants with data as single-entry objects keyed by the variant name.
+func (instrErr InstructionError) MarshalJSON() ([]byte, error) {
+	switch instrErr.Code {
+	case InstrErrCodeCustom:
+		return json.Marshal(map[string]uint32{"Custom": instrErr.Custom})
+	case InstrErrCodeBorshIoError:
+		return json.Marshal(map[string]string{"BorshIoError": instrErr.BorshIoError})
+	default:
+		return json.Marshal(instrErr.name())
+	}
+}
+
+func (instrErr *InstructionError) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		for code, instrErrName := range instrErrNames {
+			if name == instrErrName && code != InstrErrCodeCustom && code != InstrErrCodeBorshIoError {
+				*instrErr = InstructionError{Code: uint32(code)}
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown instruction error %q", name)
+	}
+
+	var variant map[string]json.RawMessage
+	if err := json.Unmarshal(data, &variant); err != nil {
+		return err
+	}
+
+	if custom, ok := variant["Custom"]; ok && len(variant) == 1 {
+		*instrErr = InstructionError{Code: InstrErrCodeCustom}
+		return json.Unmarshal(custom, &instrErr.Custom)
+	}
+	if borshIoError, ok := variant["BorshIoError"]; ok && len(variant) == 1 {
+		*instrErr = InstructionError{Code: InstrErrCodeBorshIoError}
+		return json.Unmarshal(borshIoError, &instrErr.BorshIoError)
+	}
+
+	return fmt.Errorf("unknown instruction error %s", data)
+}
+
+// MarshalJSON encodes the transaction error in the form served by RPC nodes, e.g.
+// "AccountNotFound", {"InstructionError":[0,{"Custom":1}]} or
+// {"InsufficientFundsForRent":{"account_index":2}}.
+func (txErr *TransactionError) MarshalJSON() ([]byte, error) {
+	switch txErr.Code {
+	case TxErrCodeInstructionError:
+		return json.Marshal(map[string][]interface{}{txErr.name(): {txErr.InstructionIndex, txErr.InstructionError}})
+	case TxErrCodeDuplicateInstruction:
+		return json.Marshal(map[string]uint8{txErr.name(): txErr.InstructionIndex})
+	case TxErrCodeInsufficientFundsForRent, TxErrCodeProgramExecutionTemporarilyRestricted:
+		return json.Marshal(map[string]txErrAccountIndex{txErr.name(): {AccountIndex: txErr.AccountIndex}})
+	default:
+		return json.Marshal(txErr.name())
+	}
+}
+
+func (txErr *TransactionError) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		code, ok := txErrCodeFromName(name)
+		if !ok {
+			return fmt.Errorf("unknown transaction error %q", name)
+		}
+		*txErr = TransactionError{Code: code}
+		return nil
+	}
+
+	var variant map[string]json.RawMessage
+	if err := json.Unmarshal(data, &variant); err != nil {
+		return err
+	}
+	if len(variant) != 1 {
+		return fmt.Errorf("unknown transaction error %s", data)
+	}
+
+	for name, value := range variant {
+		code, ok := txErrCodeFromName(name)
+		if !ok {
+			return fmt.Errorf("unknown transaction error %q", name)
+		}
+		*txErr = TransactionError{Code: code}
+
+		switch code {
+		case TxErrCodeInstructionError:
+			var fields []json.RawMessage
+			if err := json.Unmarshal(value, &fields); err != nil {
+				return err
+			}
+			if len(fields) != 2 {
+				return fmt.Errorf("malformed instruction error %s", value)
+			}
+			if err := json.Unmarshal(fields[0], &txErr.InstructionIndex); err != nil {
+				return err
+			}
+			return json.Unmarshal(fields[1], &txErr.InstructionError)
+
+		case TxErrCodeDuplicateInstruction:
+			return json.Unmarshal(value, &txErr.InstructionIndex)
+
+		case TxErrCodeInsufficientFundsForRent, TxErrCodeProgramExecutionTemporarilyRestricted:
+			var acctIdx txErrAccountIndex
+			if err := json.Unmarshal(value, &acctIdx); err != nil {
+				return err
+			}
+			txErr.AccountIndex = acctIdx.AccountIndex
+			return nil
+
+		default:
+			return fmt.Errorf("transaction error %q has no fields", name)
+		}
+	}
+
+	return nil
+}
+
+func txErrCodeFromName(name string) (TransactionErrorCode, bool) {
+	for code, txErrName := range txErrNames {
+		if name == txErrName {
+			return TransactionErrorCode(code), true
+		}
+	}
+	return 0, false
+}
+
+// MarshalWithEncoder encodes the instruction error as bincode does, i.e. as its u32 variant
+// index followed by the variant's fields.
+func (instrErr InstructionError) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint32(instrErr.Code, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	switch instrErr.Code {
+	case InstrErrCodeCustom:
+		err = encoder.WriteUint32(instrErr.Custom, bin.LE)
+	case InstrErrCodeBorshIoError:
+		err = encoder.WriteRustString(instrErr.BorshIoError)
+	}
+
+	return err
+}
+
+func (instrErr *InstructionError) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	var err error
+
+	*instrErr = InstructionError{}
+	instrErr.Code, err = decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	switch instrErr.Code {
+	case InstrErrCodeCustom:
+		instrErr.Custom, err = decoder.ReadUint32(bin.LE)
+	case InstrErrCodeBorshIoError:
+		instrErr.BorshIoError, err = decoder.ReadRustString()
+	default:
+		if int(instrErr.Code) >= len(instrErrNames) {
+			err = fmt.Errorf("unknown instruction error %d", instrErr.Code)
+		}
+	}
+
+	return err
+}
+
+// MarshalWithEncoder encodes the transaction error as bincode does, i.e. as its u32 variant
+// index followed by the variant's fields, as stored in the blockstore's transaction status
+// metadata.
+func (txErr *TransactionError) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint32(uint32(txErr.Code), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	switch txErr.Code {
+	case TxErrCodeInstructionError:
+		err = encoder.WriteUint8(txErr.InstructionIndex)
+		if err != nil {
+			return err
+		}
+		err = txErr.InstructionError.MarshalWithEncoder(encoder)
+	case TxErrCodeDuplicateInstruction:
+		err = encoder.WriteUint8(txErr.InstructionIndex)
+	case TxErrCodeInsufficientFundsForRent, TxErrCodeProgramExecutionTemporarilyRestricted:
+		err = encoder.WriteUint8(txErr.AccountIndex)
+	}
+
+	return err
+}
+
+func (txErr *TransactionError) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	code, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return err
+	}
+
+	*txErr = TransactionError{Code: TransactionErrorCode(code)}
+
+	switch txErr.Code {
+	case TxErrCodeInstructionError:
+		txErr.InstructionIndex, err = decoder.ReadUint8()
+		if err != nil {
+			return err
+		}
+		err = txErr.InstructionError.UnmarshalWithDecoder(decoder)
+	case TxErrCodeDuplicateInstruction:
+		txErr.InstructionIndex, err = decoder.ReadUint8()
+	case TxErrCodeInsufficientFundsForRent, TxErrCodeProgramExecutionTemporarilyRestricted:
+		txErr.AccountIndex, err = decoder.ReadUint8()
+	default:
+		if int(txErr.Code) >= len(txErrNames) {
+			err = fmt.Errorf("unknown transaction error %d", txErr.Code)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+package sealevel
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/sbpf"
+)
+
+func TestTransactionError_Encoding(t *testing.T) {
+	cases := []struct {
+		txErr   *TransactionError
+		str     string
+		json    string
+		bincode []byte
+	}{
+		{NewTransactionError(TxErrCodeAccountNotFound), "AccountNotFound", `"AccountNotFound"`, []byte{2, 0, 0, 0}},
+		{NewTransactionError(TxErrCodeBlockhashNotFound), "BlockhashNotFound", `"BlockhashNotFound"`, []byte{7, 0, 0, 0}},
+		{NewTxErrInstructionError(1, InstrErrInvalidArgument), "InstructionError(1, InvalidArgument)", `{"InstructionError":[1,"InvalidArgument"]}`,
+			[]byte{8, 0, 0, 0, 1, 1, 0, 0, 0}},
+		{NewTxErrInstructionError(0, &CustomProgramErr{Code: 6001}), "InstructionError(0, Custom(6001))", `{"InstructionError":[0,{"Custom":6001}]}`,
+			[]byte{8, 0, 0, 0, 0, 25, 0, 0, 0, 0x71, 0x17, 0, 0}},
+		{&TransactionError{Code: TxErrCodeInstructionError, InstructionError: InstructionError{Code: InstrErrCodeBorshIoError, BorshIoError: "eof"}},
+			`InstructionError(0, BorshIoError("eof"))`, `{"InstructionError":[0,{"BorshIoError":"eof"}]}`,
+			[]byte{8, 0, 0, 0, 0, 44, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 'e', 'o', 'f'}},
+		{NewTxErrDuplicateInstruction(2), "DuplicateInstruction(2)", `{"DuplicateInstruction":2}`, []byte{30, 0, 0, 0, 2}},
+		{NewTxErrInsufficientFundsForRent(3), "InsufficientFundsForRent { account_index: 3 }", `{"InsufficientFundsForRent":{"account_index":3}}`,
+			[]byte{31, 0, 0, 0, 3}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.str, func(t *testing.T) {
+			assert.Equal(t, c.str, c.txErr.Error())
+
+			jsonData, err := json.Marshal(c.txErr)
+			require.NoError(t, err)
+			assert.Equal(t, c.json, string(jsonData))
+
+			decoded := new(TransactionError)
+			require.NoError(t, json.Unmarshal(jsonData, decoded))
+			assert.Equal(t, *c.txErr, *decoded)
+
+			bincodeData, err := bin.MarshalBin(c.txErr)
+			require.NoError(t, err)
+			assert.Equal(t, c.bincode, bincodeData)
+
+			decoded = new(TransactionError)
+			require.NoError(t, bin.NewBinDecoder(bincodeData).Decode(decoded))
+			assert.Equal(t, *c.txErr, *decoded)
+		})
+	}
+
+	assert.Error(t, json.Unmarshal([]byte(`"NoSuchError"`), new(TransactionError)))
+	assert.Error(t, json.Unmarshal([]byte(`{"InstructionError":[0,"NoSuchError"]}`), new(TransactionError)))
+	assert.Error(t, bin.NewBinDecoder([]byte{200, 0, 0, 0}).Decode(new(TransactionError)))
+}
+
+func TestInstructionErrorFromErr(t *testing.T) {
+	assert.Equal(t, InstructionError{Code: InstrErrCodeInvalidAccountData}, InstructionErrorFromErr(InstrErrInvalidAccountData))
+	assert.Equal(t, InstructionError{Code: InstrErrCodeInvalidAccountData}, InstructionErrorFromErr(fmt.Errorf("failed: %w", InstrErrInvalidAccountData)))
+	assert.Equal(t, InstructionError{Code: InstrErrCodeCustom, Custom: 1}, InstructionErrorFromErr(SystemProgErrResultWithNegativeLamports))
+	assert.Equal(t, InstructionError{Code: InstrErrCodeCustom, Custom: 7}, InstructionErrorFromErr(&CustomProgramErr{Code: 7}))
+	assert.Equal(t, InstructionError{Code: InstrErrCodeComputationalBudgetExceeded}, InstructionErrorFromErr(&sbpf.Exception{PC: 3, Detail: sbpf.ExcOutOfCU}))
+	assert.Equal(t, InstructionError{Code: InstrErrCodeProgramFailedToComplete}, InstructionErrorFromErr(&sbpf.Exception{PC: 3, Detail: sbpf.ExcDivideByZero}))
+}
+
+func TestInstructionError_Message(t *testing.T) {
+	assert.Equal(t, "insufficient funds for instruction", InstructionErrorFromErr(InstrErrInsufficientFunds).Message())
+	assert.Equal(t, "invalid account data for instruction", InstructionErrorFromErr(fmt.Errorf("failed: %w", InstrErrInvalidAccountData)).Message())
+	assert.Equal(t, "custom program error: 0x1", InstructionErrorFromErr(SystemProgErrResultWithNegativeLamports).Message())
+	assert.Equal(t, "custom program error: 0x1771", InstructionErrorFromErr(&CustomProgramErr{Code: 6001}).Message())
+	assert.Equal(t, "Computational budget exceeded", InstructionErrorFromErr(&sbpf.Exception{PC: 3, Detail: sbpf.ExcOutOfCU}).Message())
+	assert.Equal(t, "Program failed to complete", InstructionErrorFromErr(&sbpf.Exception{PC: 3, Detail: sbpf.ExcDivideByZero}).Message())
+	assert.Equal(t, "Failed to serialize or deserialize account data: Unknown", InstructionErrorFromErr(InstrErrBorshIoError).Message())
+
+	log := new(LogRecorder)
+	execCtx := &ExecutionCtx{Log: log}
+	execCtx.logProgramFailure(SystemProgramAddr, InstrErrMissingRequiredSignature)
+	assert.Equal(t, []string{"Program 11111111111111111111111111111111 failed: missing required signature for instruction"}, log.Logs)
+}
+
+func TestProgramReturnErr(t *testing.T) {
+	assert.Equal(t, &CustomProgramErr{Code: 1}, programReturnErr(1))
+	assert.Equal(t, &CustomProgramErr{Code: 0xffffffff}, programReturnErr(0xffffffff))
+	assert.Equal(t, &CustomProgramErr{Code: 0}, programReturnErr(1<<32))
+	assert.Equal(t, InstrErrInvalidArgument, programReturnErr(2<<32))
+	assert.Equal(t, InstrErrIncorrectAuthority, programReturnErr(26<<32))
+	assert.Equal(t, InstrErrInvalidError, programReturnErr(27<<32))
+	assert.Equal(t, InstrErrInvalidError, programReturnErr(2<<32|1))
+	assert.Equal(t, "custom program error: 0x1771", programReturnErr(6001).Error())
+}
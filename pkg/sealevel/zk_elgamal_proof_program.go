@@ -0,0 +1,479 @@
+package sealevel
+
+import (
+	"encoding/binary"
+
+	"github.com/gagliardetto/solana-go"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
+	"k8s.io/klog/v2"
+)
+
+const ZkElGamalProofProgramAddrStr = "ZkE1Gama1Proof11111111111111111111111111111"
+
+var ZkElGamalProofProgramAddr = base58.MustDecodeFromString(ZkElGamalProofProgramAddrStr)
+
+// The instructions of the ZK ElGamal proof program. The zero-balance and withdraw proofs of
+// the zk-token proof program that it replaced are not among them: the zero-balance proof is
+// the zero-ciphertext proof under its former name, and withdrawals are instead proven with a
+// ciphertext-commitment equality proof and a batched range proof. The zk-token proof program
+// is in zk_token_proof_program.go.
+const (
+	ZkElGamalProofInstrTypeCloseContextState = iota
+	ZkElGamalProofInstrTypeVerifyZeroCiphertext
+	ZkElGamalProofInstrTypeVerifyCiphertextCiphertextEquality
+	ZkElGamalProofInstrTypeVerifyCiphertextCommitmentEquality
+	ZkElGamalProofInstrTypeVerifyPubkeyValidity
+	ZkElGamalProofInstrTypeVerifyPercentageWithCap
+	ZkElGamalProofInstrTypeVerifyBatchedRangeProofU64
+	ZkElGamalProofInstrTypeVerifyBatchedRangeProofU128
+	ZkElGamalProofInstrTypeVerifyBatchedRangeProofU256
+	ZkElGamalProofInstrTypeVerifyGroupedCiphertext2HandlesValidity
+	ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext2HandlesValidity
+	ZkElGamalProofInstrTypeVerifyGroupedCiphertext3HandlesValidity
+	ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity
+)
+
+// the proof type recorded in a proof context state account matches the type of the
+// instruction that verified the proof, with zero marking an uninitialized account
+const ZkProofTypeUninitialized = 0
+
+// a proof context state account holds the context state authority and the proof type,
+// followed by the context data of the verified proof
+const ZkProofContextStateMetaLen = 33
+
+// instruction data carrying only an account offset to read the proof from, which is not
+// yet enabled
+const zkInstrDataLenWithProofAccount = 5
+
+// zkProofVerifier verifies the proof of an instruction given its context data.
+type zkProofVerifier func(context []byte, proof []byte) error
+
+func verifyZeroCiphertextInstr(context []byte, proof []byte) error {
+	pubkey, ciphertext := context[0:32], context[32:96]
+
+	transcript := newZkTranscript("zero-ciphertext-instruction")
+	transcript.AppendMessage("pubkey", pubkey)
+	transcript.AppendMessage("ciphertext", ciphertext)
+
+	return verifyZeroCiphertextProof(pubkey, ciphertext, proof, transcript)
+}
+
+func verifyCiphertextCiphertextEqualityInstr(context []byte, proof []byte) error {
+	firstPubkey, secondPubkey := context[0:32], context[32:64]
+	firstCiphertext, secondCiphertext := context[64:128], context[128:192]
+
+	transcript := newZkTranscript("ciphertext-ciphertext-equality-instruction")
+	transcript.AppendMessage("first-pubkey", firstPubkey)
+	transcript.AppendMessage("second-pubkey", secondPubkey)
+	transcript.AppendMessage("first-ciphertext", firstCiphertext)
+	transcript.AppendMessage("second-ciphertext", secondCiphertext)
+
+	return verifyCiphertextCiphertextEqualityProof(firstPubkey, secondPubkey, firstCiphertext, secondCiphertext, proof, transcript)
+}
+
+func verifyCiphertextCommitmentEqualityInstr(context []byte, proof []byte) error {
+	pubkey, ciphertext, commitment := context[0:32], context[32:96], context[96:128]
+
+	transcript := newZkTranscript("ciphertext-commitment-equality-instruction")
+	transcript.AppendMessage("pubkey", pubkey)
+	transcript.AppendMessage("ciphertext", ciphertext)
+	transcript.AppendMessage("commitment", commitment)
+
+	return verifyCiphertextCommitmentEqualityProof(pubkey, ciphertext, commitment, proof, transcript)
+}
+
+func verifyPubkeyValidityInstr(context []byte, proof []byte) error {
+	transcript := newZkTranscript("pubkey-validity-instruction")
+	transcript.AppendMessage("pubkey", context)
+
+	return verifyPubkeyValidityProof(context, proof, transcript)
+}
+
+func verifyPercentageWithCapInstr(context []byte, proof []byte) error {
+	percentageCommitment, deltaCommitment, claimedCommitment := context[0:32], context[32:64], context[64:96]
+	maxValue := binary.LittleEndian.Uint64(context[96:104])
+
+	transcript := newZkTranscript("percentage-with-cap-instruction")
+	transcript.AppendMessage("percentage-commitment", percentageCommitment)
+	transcript.AppendMessage("delta-commitment", deltaCommitment)
+	transcript.AppendMessage("claimed-commitment", claimedCommitment)
+	transcript.appendU64("max-value", maxValue)
+
+	return verifyPercentageWithCapProof(percentageCommitment, deltaCommitment, claimedCommitment, maxValue, proof, transcript)
+}
+
+// zkBatchedRangeProofVerifier returns the verifier of batched range proofs whose bit lengths
+// sum to batchedBitLength.
+func zkBatchedRangeProofVerifier(batchedBitLength int) zkProofVerifier {
+	return func(context []byte, proof []byte) error {
+		return verifyBatchedRangeProofContext(context, proof, batchedBitLength, newZkTranscript("batched-range-proof-instruction"))
+	}
+}
+
+func verifyBatchedRangeProofContext(context []byte, proof []byte, batchedBitLength int, transcript *zkTranscript) error {
+	commitmentsBytes := context[:ZkRangeProofMaxCommitments*ZkPedersenCommitmentLen]
+	bitLengthsBytes := context[ZkRangeProofMaxCommitments*ZkPedersenCommitmentLen:]
+
+	// the commitments in use precede the zeroed unused ones
+	var commitments [][]byte
+	for i := 0; i < ZkRangeProofMaxCommitments; i++ {
+		commitment := commitmentsBytes[i*ZkPedersenCommitmentLen : (i+1)*ZkPedersenCommitmentLen]
+		if isZeroBytes(commitment) {
+			break
+		}
+		commitments = append(commitments, commitment)
+	}
+
+	var bitLengths []int
+	var sum int
+	for _, bitLength := range bitLengthsBytes[:len(commitments)] {
+		bitLengths = append(bitLengths, int(bitLength))
+		sum += int(bitLength)
+	}
+
+	if sum != batchedBitLength {
+		return ZkProofErrIllegalAmountBitLength
+	}
+
+	transcript.AppendMessage("commitments", commitmentsBytes)
+	transcript.AppendMessage("bit-lengths", bitLengthsBytes)
+
+	return verifyRangeProof(commitments, bitLengths, proof, transcript)
+}
+
+func verifyGroupedCiphertext2HandlesValidityInstr(context []byte, proof []byte) error {
+	firstPubkey, secondPubkey, groupedCiphertext := context[0:32], context[32:64], context[64:160]
+
+	transcript := newZkTranscript("grouped-ciphertext-validity-2-handles-instruction")
+	transcript.AppendMessage("first-pubkey", firstPubkey)
+	transcript.AppendMessage("second-pubkey", secondPubkey)
+	transcript.AppendMessage("grouped-ciphertext", groupedCiphertext)
+
+	return verifyGroupedCiphertextHandlesValidityProof([][]byte{firstPubkey, secondPubkey}, groupedCiphertext, proof, transcript)
+}
+
+func verifyBatchedGroupedCiphertext2HandlesValidityInstr(context []byte, proof []byte) error {
+	firstPubkey, secondPubkey := context[0:32], context[32:64]
+	groupedCiphertextLo, groupedCiphertextHi := context[64:160], context[160:256]
+
+	transcript := newZkTranscript("batched-grouped-ciphertext-validity-2-handles-instruction")
+	transcript.AppendMessage("first-pubkey", firstPubkey)
+	transcript.AppendMessage("second-pubkey", secondPubkey)
+	transcript.AppendMessage("grouped-ciphertext-lo", groupedCiphertextLo)
+	transcript.AppendMessage("grouped-ciphertext-hi", groupedCiphertextHi)
+
+	return verifyBatchedGroupedCiphertextHandlesValidityProof([][]byte{firstPubkey, secondPubkey}, groupedCiphertextLo, groupedCiphertextHi, proof, transcript)
+}
+
+func verifyGroupedCiphertext3HandlesValidityInstr(context []byte, proof []byte) error {
+	firstPubkey, secondPubkey, thirdPubkey := context[0:32], context[32:64], context[64:96]
+	groupedCiphertext := context[96:224]
+
+	transcript := newZkTranscript("grouped-ciphertext-validity-3-handles-instruction")
+	transcript.AppendMessage("first-pubkey", firstPubkey)
+	transcript.AppendMessage("second-pubkey", secondPubkey)
+	transcript.AppendMessage("third-pubkey", thirdPubkey)
+	transcript.AppendMessage("grouped-ciphertext", groupedCiphertext)
+
+	return verifyGroupedCiphertextHandlesValidityProof([][]byte{firstPubkey, secondPubkey, thirdPubkey}, groupedCiphertext, proof, transcript)
+}
+
+func verifyBatchedGroupedCiphertext3HandlesValidityInstr(context []byte, proof []byte) error {
+	firstPubkey, secondPubkey, thirdPubkey := context[0:32], context[32:64], context[64:96]
+	groupedCiphertextLo, groupedCiphertextHi := context[96:224], context[224:352]
+
+	transcript := newZkTranscript("batched-grouped-ciphertext-validity-3-handles-instruction")
+	transcript.AppendMessage("first-pubkey", firstPubkey)
+	transcript.AppendMessage("second-pubkey", secondPubkey)
+	transcript.AppendMessage("third-pubkey", thirdPubkey)
+	transcript.AppendMessage("grouped-ciphertext-lo", groupedCiphertextLo)
+	transcript.AppendMessage("grouped-ciphertext-hi", groupedCiphertextHi)
+
+	return verifyBatchedGroupedCiphertextHandlesValidityProof([][]byte{firstPubkey, secondPubkey, thirdPubkey}, groupedCiphertextLo, groupedCiphertextHi, proof, transcript)
+}
+
+// ZkElGamalProofVerify verifies the proof in the instruction data, and records its context
+// data in a proof context state account owned by the proof program, if one is passed to the
+// instruction.
+func ZkElGamalProofVerify(execCtx *ExecutionCtx, programAddr solana.PublicKey, proofType byte, contextLen int, proofLen int, verify zkProofVerifier) error {
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	if len(instrCtx.Data) == zkInstrDataLenWithProofAccount {
+		klog.Infof("zk proof from account not enabled")
+		return InstrErrInvalidInstructionData
+	}
+
+	proofData := instrCtx.Data[1:]
+	if len(proofData) != contextLen+proofLen {
+		klog.Infof("invalid proof data")
+		return InstrErrInvalidInstructionData
+	}
+
+	contextData := proofData[:contextLen]
+	err = verify(contextData, proofData[contextLen:])
+	if err != nil {
+		klog.Infof("proof verification failed: %s", err)
+		return InstrErrInvalidInstructionData
+	}
+
+	if instrCtx.NumberOfInstructionAccounts() == 0 {
+		return nil
+	}
+
+	idx, err := instrCtx.IndexOfInstructionAccountInTransaction(1)
+	if err != nil {
+		return err
+	}
+	contextStateAuthority, err := txCtx.KeyOfAccountAtIndex(idx)
+	if err != nil {
+		return err
+	}
+
+	proofContextAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer proofContextAcct.Drop()
+
+	if proofContextAcct.Owner() != programAddr {
+		return InstrErrInvalidAccountOwner
+	}
+
+	if len(proofContextAcct.Data()) < ZkProofContextStateMetaLen {
+		return InstrErrInvalidAccountData
+	}
+
+	if proofContextAcct.Data()[32] != ZkProofTypeUninitialized {
+		return InstrErrAccountAlreadyInitialized
+	}
+
+	contextStateData := make([]byte, 0, ZkProofContextStateMetaLen+contextLen)
+	contextStateData = append(contextStateData, contextStateAuthority[:]...)
+	contextStateData = append(contextStateData, proofType)
+	contextStateData = append(contextStateData, contextData...)
+
+	if len(proofContextAcct.Data()) != len(contextStateData) {
+		return InstrErrInvalidAccountData
+	}
+
+	return proofContextAcct.SetData(execCtx.GlobalCtx.Features, contextStateData)
+}
+
+// ZkElGamalProofCloseContextState closes a proof context state account owned by the proof
+// program, transferring its lamports to the destination account.
+func ZkElGamalProofCloseContextState(execCtx *ExecutionCtx, programAddr solana.PublicKey) error {
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	ownerAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 2)
+	if err != nil {
+		return err
+	}
+	if !ownerAcct.IsSigner() {
+		ownerAcct.Drop()
+		return InstrErrMissingRequiredSignature
+	}
+	ownerPubkey := ownerAcct.Key()
+	ownerAcct.Drop()
+
+	proofContextAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	proofContextPubkey := proofContextAcct.Key()
+	proofContextAcct.Drop()
+
+	destinationAcct, err := instrCtx.BorrowInstructionAccount(txCtx, 1)
+	if err != nil {
+		return err
+	}
+	destinationPubkey := destinationAcct.Key()
+	destinationAcct.Drop()
+
+	if proofContextPubkey == destinationPubkey {
+		return InstrErrInvalidInstructionData
+	}
+
+	proofContextAcct, err = instrCtx.BorrowInstructionAccount(txCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer proofContextAcct.Drop()
+
+	if proofContextAcct.Owner() != programAddr {
+		return InstrErrInvalidAccountOwner
+	}
+
+	if len(proofContextAcct.Data()) < ZkProofContextStateMetaLen {
+		return InstrErrInvalidAccountData
+	}
+
+	contextStateAuthority := solana.PublicKeyFromBytes(proofContextAcct.Data()[:32])
+	if ownerPubkey != contextStateAuthority {
+		return InstrErrInvalidAccountOwner
+	}
+
+	destinationAcct, err = instrCtx.BorrowInstructionAccount(txCtx, 1)
+	if err != nil {
+		return err
+	}
+	defer destinationAcct.Drop()
+
+	f := execCtx.GlobalCtx.Features
+
+	err = destinationAcct.CheckedAddLamports(proofContextAcct.Lamports(), f)
+	if err != nil {
+		return err
+	}
+
+	err = proofContextAcct.SetLamports(0, f)
+	if err != nil {
+		return err
+	}
+
+	err = proofContextAcct.SetDataLength(0, f)
+	if err != nil {
+		return err
+	}
+
+	return proofContextAcct.SetOwner(f, SystemProgramAddr)
+}
+
+func ZkElGamalProofProgramExecute(execCtx *ExecutionCtx) error {
+	klog.Infof("ZkElGamalProofProgramExecute")
+
+	if !execCtx.GlobalCtx.Features.IsActive(features.ZkElgamalProofProgramEnabled) {
+		return InstrErrUnsupportedProgramId
+	}
+
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	if len(instrCtx.Data) == 0 || instrCtx.Data[0] > ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity {
+		return InstrErrInvalidInstructionData
+	}
+	instrType := instrCtx.Data[0]
+
+	// proof verification instructions are not supported as inner instructions
+	if execCtx.StackHeight() != 1 && instrType != ZkElGamalProofInstrTypeCloseContextState {
+		return InstrErrUnsupportedProgramId
+	}
+
+	var cost uint64
+	var contextLen, proofLen int
+	var verify zkProofVerifier
+
+	switch instrType {
+	case ZkElGamalProofInstrTypeCloseContextState:
+		{
+			err = execCtx.ComputeMeter.Consume(CUZkCloseContextStateComputeUnits)
+			if err != nil {
+				return err
+			}
+			klog.Infof("CloseContextState")
+			return ZkElGamalProofCloseContextState(execCtx, ZkElGamalProofProgramAddr)
+		}
+
+	case ZkElGamalProofInstrTypeVerifyZeroCiphertext:
+		{
+			cost = CUZkVerifyZeroCiphertextComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen+ZkElGamalCiphertextLen, ZkZeroCiphertextProofLen
+			verify = verifyZeroCiphertextInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyCiphertextCiphertextEquality:
+		{
+			cost = CUZkVerifyCiphertextCiphertextEqualityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+2*ZkElGamalCiphertextLen, ZkCiphertextCiphertextEqualityLen
+			verify = verifyCiphertextCiphertextEqualityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyCiphertextCommitmentEquality:
+		{
+			cost = CUZkVerifyCiphertextCommitmentEqualityComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen+ZkElGamalCiphertextLen+ZkPedersenCommitmentLen, ZkCiphertextCommitmentEqualityLen
+			verify = verifyCiphertextCommitmentEqualityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyPubkeyValidity:
+		{
+			cost = CUZkVerifyPubkeyValidityComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen, ZkPubkeyValidityProofLen
+			verify = verifyPubkeyValidityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyPercentageWithCap:
+		{
+			cost = CUZkVerifyPercentageWithCapComputeUnits
+			contextLen, proofLen = 3*ZkPedersenCommitmentLen+8, ZkPercentageWithCapProofLen
+			verify = verifyPercentageWithCapInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyBatchedRangeProofU64:
+		{
+			cost = CUZkVerifyBatchedRangeProofU64ComputeUnits
+			contextLen, proofLen = ZkBatchedRangeProofCtxLen, ZkRangeProofU64Len
+			verify = zkBatchedRangeProofVerifier(64)
+		}
+
+	case ZkElGamalProofInstrTypeVerifyBatchedRangeProofU128:
+		{
+			cost = CUZkVerifyBatchedRangeProofU128ComputeUnits
+			contextLen, proofLen = ZkBatchedRangeProofCtxLen, ZkRangeProofU128Len
+			verify = zkBatchedRangeProofVerifier(128)
+		}
+
+	case ZkElGamalProofInstrTypeVerifyBatchedRangeProofU256:
+		{
+			cost = CUZkVerifyBatchedRangeProofU256ComputeUnits
+			contextLen, proofLen = ZkBatchedRangeProofCtxLen, ZkRangeProofU256Len
+			verify = zkBatchedRangeProofVerifier(256)
+		}
+
+	case ZkElGamalProofInstrTypeVerifyGroupedCiphertext2HandlesValidity:
+		{
+			cost = CUZkVerifyGroupedCiphertext2HandlesValidityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+ZkGroupedCiphertext2HandlesLen, ZkGroupedCiphertext2HandlesProof
+			verify = verifyGroupedCiphertext2HandlesValidityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext2HandlesValidity:
+		{
+			cost = CUZkVerifyBatchedGroupedCiphertext2HandlesValidityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+2*ZkGroupedCiphertext2HandlesLen, ZkGroupedCiphertext2HandlesProof
+			verify = verifyBatchedGroupedCiphertext2HandlesValidityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyGroupedCiphertext3HandlesValidity:
+		{
+			cost = CUZkVerifyGroupedCiphertext3HandlesValidityComputeUnits
+			contextLen, proofLen = 3*ZkElGamalPubkeyLen+ZkGroupedCiphertext3HandlesLen, ZkGroupedCiphertext3HandlesProof
+			verify = verifyGroupedCiphertext3HandlesValidityInstr
+		}
+
+	case ZkElGamalProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity:
+		{
+			cost = CUZkVerifyBatchedGroupedCiphertext3HandlesValidityComputeUnits
+			contextLen, proofLen = 3*ZkElGamalPubkeyLen+2*ZkGroupedCiphertext3HandlesLen, ZkGroupedCiphertext3HandlesProof
+			verify = verifyBatchedGroupedCiphertext3HandlesValidityInstr
+		}
+	}
+
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return err
+	}
+
+	return ZkElGamalProofVerify(execCtx, ZkElGamalProofProgramAddr, instrType, contextLen, proofLen, verify)
+}
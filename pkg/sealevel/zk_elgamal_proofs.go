@@ -0,0 +1,585 @@
+package sealevel
+
+import (
+	"encoding/binary"
+
+	"github.com/gtank/ristretto255"
+	"github.com/oasisprotocol/curve25519-voi/primitives/merlin"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	ZkElGamalPubkeyLen                = 32
+	ZkPedersenCommitmentLen           = 32
+	ZkDecryptHandleLen                = 32
+	ZkElGamalCiphertextLen            = ZkPedersenCommitmentLen + ZkDecryptHandleLen
+	ZkGroupedCiphertext2HandlesLen    = ZkPedersenCommitmentLen + 2*ZkDecryptHandleLen
+	ZkGroupedCiphertext3HandlesLen    = ZkPedersenCommitmentLen + 3*ZkDecryptHandleLen
+	ZkZeroCiphertextProofLen          = 96
+	ZkCiphertextCiphertextEqualityLen = 224
+	ZkCiphertextCommitmentEqualityLen = 192
+	ZkPubkeyValidityProofLen          = 64
+	ZkPercentageWithCapProofLen       = 256
+	ZkGroupedCiphertext2HandlesProof  = 160
+	ZkGroupedCiphertext3HandlesProof  = 192
+)
+
+// the Pedersen commitment generators: G is the ristretto basepoint, and H is derived by
+// hashing the encoding of G
+var (
+	zkPedersenG = ristretto255.NewElement().Base()
+	zkPedersenH = zkHashToPoint(ristretto255.NewElement().Base().Encode(nil))
+)
+
+func zkHashToPoint(data []byte) *ristretto255.Element {
+	digest := sha3.Sum512(data)
+	return ristretto255.NewElement().FromUniformBytes(digest[:])
+}
+
+// zkTranscript is a merlin transcript with the message encodings of the zk-sdk proofs.
+type zkTranscript struct {
+	*merlin.Transcript
+
+	// whether the transcript is of a proof of the zk-token-sdk, which the zk-sdk was forked
+	// from, and whose proofs differ only in some of their domain separators
+	zkToken bool
+}
+
+func newZkTranscript(label string) *zkTranscript {
+	return &zkTranscript{Transcript: merlin.NewTranscript(label)}
+}
+
+func newZkTokenTranscript(label string) *zkTranscript {
+	return &zkTranscript{Transcript: merlin.NewTranscript(label), zkToken: true}
+}
+
+func (t *zkTranscript) appendDomainSeparator(label string) {
+	t.AppendMessage("dom-sep", []byte(label))
+}
+
+func (t *zkTranscript) zeroCiphertextProofDomainSeparator() {
+	if t.zkToken {
+		t.appendDomainSeparator("zero-balance-proof")
+	} else {
+		t.appendDomainSeparator("zero-ciphertext-proof")
+	}
+}
+
+func (t *zkTranscript) ciphertextCiphertextEqualityProofDomainSeparator() {
+	if t.zkToken {
+		t.appendDomainSeparator("equality-proof")
+	} else {
+		t.appendDomainSeparator("ciphertext-ciphertext-equality-proof")
+	}
+}
+
+func (t *zkTranscript) ciphertextCommitmentEqualityProofDomainSeparator() {
+	if t.zkToken {
+		t.appendDomainSeparator("equality-proof")
+	} else {
+		t.appendDomainSeparator("ciphertext-commitment-equality-proof")
+	}
+}
+
+// the zk-token-sdk's validity proof domain separators don't include the number of handles
+func (t *zkTranscript) groupedCiphertextValidityProofDomainSeparator(numHandles int) {
+	t.appendDomainSeparator("validity-proof")
+	if !t.zkToken {
+		t.appendU64("handles", uint64(numHandles))
+	}
+}
+
+func (t *zkTranscript) batchedGroupedCiphertextValidityProofDomainSeparator(numHandles int) {
+	t.appendDomainSeparator("batched-validity-proof")
+	if !t.zkToken {
+		t.appendU64("handles", uint64(numHandles))
+	}
+}
+
+func (t *zkTranscript) appendU64(label string, x uint64) {
+	t.AppendMessage(label, binary.LittleEndian.AppendUint64(nil, x))
+}
+
+func (t *zkTranscript) appendScalar(label string, s *ristretto255.Scalar) {
+	t.AppendMessage(label, s.Encode(nil))
+}
+
+// validateAndAppendPoint appends the encoding of a point, rejecting the identity.
+func (t *zkTranscript) validateAndAppendPoint(label string, point []byte) error {
+	if isZeroBytes(point) {
+		return ZkProofErrIdentityPoint
+	}
+	t.AppendMessage(label, point)
+	return nil
+}
+
+func (t *zkTranscript) challengeScalar(label string) *ristretto255.Scalar {
+	buf := make([]byte, 64)
+	t.ExtractBytes(buf, label)
+	return ristretto255.NewScalar().FromUniformBytes(buf)
+}
+
+func isZeroBytes(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func zkDecodePoint(data []byte) (*ristretto255.Element, error) {
+	point := ristretto255.NewElement()
+	err := point.Decode(data[:CurvePointBytesLen])
+	if err != nil {
+		return nil, ZkProofErrDeserialization
+	}
+	return point, nil
+}
+
+func zkDecodeScalar(data []byte) (*ristretto255.Scalar, error) {
+	scalar := ristretto255.NewScalar()
+	err := scalar.Decode(data[:CurveScalarBytesLen])
+	if err != nil {
+		return nil, ZkProofErrDeserialization
+	}
+	return scalar, nil
+}
+
+// zkDecodePoints decodes the consecutive 32-byte encodings of points in data.
+func zkDecodePoints(data []byte, count int) ([]*ristretto255.Element, error) {
+	points := make([]*ristretto255.Element, count)
+	for i := range points {
+		point, err := zkDecodePoint(data[i*CurvePointBytesLen:])
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+func zkDecodeScalars(data []byte, count int) ([]*ristretto255.Scalar, error) {
+	scalars := make([]*ristretto255.Scalar, count)
+	for i := range scalars {
+		scalar, err := zkDecodeScalar(data[i*CurveScalarBytesLen:])
+		if err != nil {
+			return nil, err
+		}
+		scalars[i] = scalar
+	}
+	return scalars, nil
+}
+
+func zkScalarFromU64(x uint64) *ristretto255.Scalar {
+	buf := make([]byte, CurveScalarBytesLen)
+	binary.LittleEndian.PutUint64(buf, x)
+	scalar := ristretto255.NewScalar()
+	_ = scalar.Decode(buf)
+	return scalar
+}
+
+func zkScalarOne() *ristretto255.Scalar {
+	return zkScalarFromU64(1)
+}
+
+func zkMul(x, y *ristretto255.Scalar) *ristretto255.Scalar {
+	return ristretto255.NewScalar().Multiply(x, y)
+}
+
+func zkNeg(x *ristretto255.Scalar) *ristretto255.Scalar {
+	return ristretto255.NewScalar().Negate(x)
+}
+
+// zkCheckIdentity checks the algebraic relation of a proof, that the multiscalar
+// multiplication of the scalars and points is the identity.
+func zkCheckIdentity(scalars []*ristretto255.Scalar, points []*ristretto255.Element) error {
+	check := ristretto255.NewElement().VarTimeMultiScalarMult(scalars, points)
+	if check.Equal(ristretto255.NewElement().Zero()) != 1 {
+		return ZkProofErrAlgebraicRelation
+	}
+	return nil
+}
+
+// verifyZeroCiphertextProof verifies a proof that an ElGamal ciphertext encrypts zero under
+// the pubkey, i.e. that the prover knows the secret key s such that H = s*P and C = s*D.
+func verifyZeroCiphertextProof(pubkey, ciphertext, proof []byte, transcript *zkTranscript) error {
+	transcript.zeroCiphertextProofDomainSeparator()
+
+	// ciphertext points are the commitment C and the decrypt handle D
+	P, err := zkDecodePoint(pubkey)
+	if err != nil {
+		return err
+	}
+	ciphertextPoints, err := zkDecodePoints(ciphertext, 2)
+	if err != nil {
+		return err
+	}
+	C, D := ciphertextPoints[0], ciphertextPoints[1]
+
+	err = transcript.validateAndAppendPoint("Y_P", proof[0:32])
+	if err != nil {
+		return err
+	}
+	transcript.AppendMessage("Y_D", proof[32:64])
+
+	c := transcript.challengeScalar("c")
+
+	z, err := zkDecodeScalar(proof[64:96])
+	if err != nil {
+		return err
+	}
+	transcript.appendScalar("z", z)
+
+	w := transcript.challengeScalar("w")
+
+	Y, err := zkDecodePoints(proof, 2)
+	if err != nil {
+		return err
+	}
+	YP, YD := Y[0], Y[1]
+
+	return zkCheckIdentity(
+		[]*ristretto255.Scalar{z, zkNeg(c), zkNeg(zkScalarOne()), zkMul(w, z), zkNeg(zkMul(w, c)), zkNeg(w)},
+		[]*ristretto255.Element{P, zkPedersenH, YP, D, C, YD})
+}
+
+// verifyCiphertextCiphertextEqualityProof verifies a proof that two ElGamal ciphertexts
+// under different pubkeys encrypt the same amount.
+func verifyCiphertextCiphertextEqualityProof(firstPubkey, secondPubkey, firstCiphertext, secondCiphertext, proof []byte, transcript *zkTranscript) error {
+	transcript.ciphertextCiphertextEqualityProofDomainSeparator()
+
+	PFirst, err := zkDecodePoint(firstPubkey)
+	if err != nil {
+		return err
+	}
+	firstPoints, err := zkDecodePoints(firstCiphertext, 2)
+	if err != nil {
+		return err
+	}
+	CFirst, DFirst := firstPoints[0], firstPoints[1]
+
+	PSecond, err := zkDecodePoint(secondPubkey)
+	if err != nil {
+		return err
+	}
+	secondPoints, err := zkDecodePoints(secondCiphertext, 2)
+	if err != nil {
+		return err
+	}
+	CSecond, DSecond := secondPoints[0], secondPoints[1]
+
+	for i, label := range []string{"Y_0", "Y_1", "Y_2", "Y_3"} {
+		err = transcript.validateAndAppendPoint(label, proof[i*32:(i+1)*32])
+		if err != nil {
+			return err
+		}
+	}
+
+	c := transcript.challengeScalar("c")
+
+	z, err := zkDecodeScalars(proof[128:224], 3)
+	if err != nil {
+		return err
+	}
+	zs, zx, zr := z[0], z[1], z[2]
+	transcript.appendScalar("z_s", zs)
+	transcript.appendScalar("z_x", zx)
+	transcript.appendScalar("z_r", zr)
+
+	w := transcript.challengeScalar("w")
+	ww := zkMul(w, w)
+	www := zkMul(ww, w)
+
+	Y, err := zkDecodePoints(proof, 4)
+	if err != nil {
+		return err
+	}
+
+	return zkCheckIdentity(
+		[]*ristretto255.Scalar{
+			zs, zkNeg(c), zkNeg(zkScalarOne()),
+			zkMul(w, zx), zkMul(w, zs), zkNeg(zkMul(w, c)), zkNeg(w),
+			zkMul(ww, zx), zkMul(ww, zr), zkNeg(zkMul(ww, c)), zkNeg(ww),
+			zkMul(www, zr), zkNeg(zkMul(www, c)), zkNeg(www),
+		},
+		[]*ristretto255.Element{
+			PFirst, zkPedersenH, Y[0],
+			zkPedersenG, DFirst, CFirst, Y[1],
+			zkPedersenG, zkPedersenH, CSecond, Y[2],
+			PSecond, DSecond, Y[3],
+		})
+}
+
+// verifyCiphertextCommitmentEqualityProof verifies a proof that an ElGamal ciphertext and a
+// Pedersen commitment encode the same amount.
+func verifyCiphertextCommitmentEqualityProof(pubkey, ciphertext, commitment, proof []byte, transcript *zkTranscript) error {
+	transcript.ciphertextCommitmentEqualityProofDomainSeparator()
+
+	P, err := zkDecodePoint(pubkey)
+	if err != nil {
+		return err
+	}
+	ciphertextPoints, err := zkDecodePoints(ciphertext, 2)
+	if err != nil {
+		return err
+	}
+	CEq, D := ciphertextPoints[0], ciphertextPoints[1]
+	CCommitment, err := zkDecodePoint(commitment)
+	if err != nil {
+		return err
+	}
+
+	for i, label := range []string{"Y_0", "Y_1", "Y_2"} {
+		err = transcript.validateAndAppendPoint(label, proof[i*32:(i+1)*32])
+		if err != nil {
+			return err
+		}
+	}
+
+	c := transcript.challengeScalar("c")
+
+	z, err := zkDecodeScalars(proof[96:192], 3)
+	if err != nil {
+		return err
+	}
+	zs, zx, zr := z[0], z[1], z[2]
+	transcript.appendScalar("z_s", zs)
+	transcript.appendScalar("z_x", zx)
+	transcript.appendScalar("z_r", zr)
+
+	w := transcript.challengeScalar("w")
+	ww := zkMul(w, w)
+
+	Y, err := zkDecodePoints(proof, 3)
+	if err != nil {
+		return err
+	}
+
+	return zkCheckIdentity(
+		[]*ristretto255.Scalar{
+			zs, zkNeg(c), zkNeg(zkScalarOne()),
+			zkMul(w, zx), zkMul(w, zs), zkNeg(zkMul(w, c)), zkNeg(w),
+			zkMul(ww, zx), zkMul(ww, zr), zkNeg(zkMul(ww, c)), zkNeg(ww),
+		},
+		[]*ristretto255.Element{
+			P, zkPedersenH, Y[0],
+			zkPedersenG, D, CEq, Y[1],
+			zkPedersenG, zkPedersenH, CCommitment, Y[2],
+		})
+}
+
+// verifyPubkeyValidityProof verifies a proof that the prover knows the secret key of an
+// ElGamal pubkey.
+func verifyPubkeyValidityProof(pubkey, proof []byte, transcript *zkTranscript) error {
+	transcript.appendDomainSeparator("pubkey-proof")
+
+	P, err := zkDecodePoint(pubkey)
+	if err != nil {
+		return err
+	}
+
+	err = transcript.validateAndAppendPoint("Y", proof[0:32])
+	if err != nil {
+		return err
+	}
+
+	c := transcript.challengeScalar("c")
+
+	Y, err := zkDecodePoint(proof[0:32])
+	if err != nil {
+		return err
+	}
+	z, err := zkDecodeScalar(proof[32:64])
+	if err != nil {
+		return err
+	}
+
+	return zkCheckIdentity(
+		[]*ristretto255.Scalar{z, zkNeg(c), zkNeg(zkScalarOne())},
+		[]*ristretto255.Element{P, zkPedersenH, Y})
+}
+
+// verifyPercentageWithCapProof verifies a proof that either the percentage commitment
+// commits to the max value, or the delta and claimed commitments commit to the same value.
+func verifyPercentageWithCapProof(percentageCommitment, deltaCommitment, claimedCommitment []byte, maxValue uint64, proof []byte, transcript *zkTranscript) error {
+	transcript.appendDomainSeparator("percentage-with-cap-proof")
+
+	m := zkScalarFromU64(maxValue)
+
+	CMax, err := zkDecodePoint(percentageCommitment)
+	if err != nil {
+		return err
+	}
+	CDelta, err := zkDecodePoint(deltaCommitment)
+	if err != nil {
+		return err
+	}
+	CClaimed, err := zkDecodePoint(claimedCommitment)
+	if err != nil {
+		return err
+	}
+
+	// the max proof is (Y_max_proof, z_max_proof, c_max_proof), and the equality proof is
+	// (Y_delta, Y_claimed, z_x, z_delta, z_claimed)
+	yMaxBytes, yDeltaBytes, yClaimedBytes := proof[0:32], proof[96:128], proof[128:160]
+
+	err = transcript.validateAndAppendPoint("Y_max_proof", yMaxBytes)
+	if err != nil {
+		return err
+	}
+	err = transcript.validateAndAppendPoint("Y_delta", yDeltaBytes)
+	if err != nil {
+		return err
+	}
+	err = transcript.validateAndAppendPoint("Y_claimed", yClaimedBytes)
+	if err != nil {
+		return err
+	}
+
+	YMax, err := zkDecodePoint(yMaxBytes)
+	if err != nil {
+		return err
+	}
+	YDelta, err := zkDecodePoint(yDeltaBytes)
+	if err != nil {
+		return err
+	}
+	YClaimed, err := zkDecodePoint(yClaimedBytes)
+	if err != nil {
+		return err
+	}
+
+	maxScalars, err := zkDecodeScalars(proof[32:96], 2)
+	if err != nil {
+		return err
+	}
+	zMax, cMax := maxScalars[0], maxScalars[1]
+
+	equalityScalars, err := zkDecodeScalars(proof[160:256], 3)
+	if err != nil {
+		return err
+	}
+	zx, zDelta, zClaimed := equalityScalars[0], equalityScalars[1], equalityScalars[2]
+
+	c := transcript.challengeScalar("c")
+	cEquality := ristretto255.NewScalar().Subtract(c, cMax)
+
+	transcript.appendScalar("z_max", zMax)
+	transcript.appendScalar("c_max_proof", cMax)
+	transcript.appendScalar("z_x", zx)
+	transcript.appendScalar("z_delta_real", zDelta)
+	transcript.appendScalar("z_claimed", zClaimed)
+
+	w := transcript.challengeScalar("w")
+	ww := zkMul(w, w)
+
+	return zkCheckIdentity(
+		[]*ristretto255.Scalar{
+			cMax, zkNeg(zkMul(cMax, m)), zkNeg(zMax), zkScalarOne(),
+			zkMul(w, zx), zkMul(w, zDelta), zkNeg(zkMul(w, cEquality)), zkNeg(w),
+			zkMul(ww, zx), zkMul(ww, zClaimed), zkNeg(zkMul(ww, cEquality)), zkNeg(ww),
+		},
+		[]*ristretto255.Element{
+			CMax, zkPedersenG, zkPedersenH, YMax,
+			zkPedersenG, zkPedersenH, CDelta, YDelta,
+			zkPedersenG, zkPedersenH, CClaimed, YClaimed,
+		})
+}
+
+// verifyGroupedCiphertextValidityProof verifies a proof that a grouped ciphertext with two or
+// three decrypt handles is well-formed, i.e. that each handle decrypts the commitment under
+// its pubkey. The last pubkey may be the identity, for an optional auditor.
+func verifyGroupedCiphertextValidityProof(pubkeys [][]byte, groupedCiphertext []*ristretto255.Element, proof []byte, transcript *zkTranscript) error {
+	numHandles := len(pubkeys)
+
+	P := make([]*ristretto255.Element, numHandles)
+	for i, pubkey := range pubkeys {
+		point, err := zkDecodePoint(pubkey)
+		if err != nil {
+			return err
+		}
+		P[i] = point
+	}
+
+	// all but the last of Y_0, ..., Y_numHandles must not be the identity
+	for i := 0; i <= numHandles; i++ {
+		label := []string{"Y_0", "Y_1", "Y_2", "Y_3"}[i]
+		if i < numHandles {
+			err := transcript.validateAndAppendPoint(label, proof[i*32:(i+1)*32])
+			if err != nil {
+				return err
+			}
+		} else {
+			transcript.AppendMessage(label, proof[i*32:(i+1)*32])
+		}
+	}
+
+	c := transcript.challengeScalar("c")
+
+	scalarsOffset := (numHandles + 1) * 32
+	z, err := zkDecodeScalars(proof[scalarsOffset:scalarsOffset+64], 2)
+	if err != nil {
+		return err
+	}
+	zr, zx := z[0], z[1]
+	transcript.appendScalar("z_r", zr)
+	transcript.appendScalar("z_x", zx)
+
+	w := transcript.challengeScalar("w")
+
+	Y, err := zkDecodePoints(proof, numHandles+1)
+	if err != nil {
+		return err
+	}
+
+	C := groupedCiphertext[0]
+	scalars := []*ristretto255.Scalar{zr, zx, zkNeg(c), zkNeg(zkScalarOne())}
+	points := []*ristretto255.Element{zkPedersenH, zkPedersenG, C, Y[0]}
+
+	exp := w
+	for i := 0; i < numHandles; i++ {
+		D := groupedCiphertext[i+1]
+		scalars = append(scalars, zkMul(exp, zr), zkNeg(zkMul(exp, c)), zkNeg(exp))
+		points = append(points, P[i], D, Y[i+1])
+		exp = zkMul(exp, w)
+	}
+
+	return zkCheckIdentity(scalars, points)
+}
+
+func verifyGroupedCiphertextHandlesValidityProof(pubkeys [][]byte, groupedCiphertext, proof []byte, transcript *zkTranscript) error {
+	transcript.groupedCiphertextValidityProofDomainSeparator(len(pubkeys))
+
+	ciphertextPoints, err := zkDecodePoints(groupedCiphertext, len(pubkeys)+1)
+	if err != nil {
+		return err
+	}
+
+	return verifyGroupedCiphertextValidityProof(pubkeys, ciphertextPoints, proof, transcript)
+}
+
+// verifyBatchedGroupedCiphertextHandlesValidityProof verifies a validity proof for a pair of
+// grouped ciphertexts, by verifying the proof for the combination lo + t*hi of them.
+func verifyBatchedGroupedCiphertextHandlesValidityProof(pubkeys [][]byte, groupedCiphertextLo, groupedCiphertextHi, proof []byte, transcript *zkTranscript) error {
+	transcript.batchedGroupedCiphertextValidityProofDomainSeparator(len(pubkeys))
+
+	t := transcript.challengeScalar("t")
+
+	lo, err := zkDecodePoints(groupedCiphertextLo, len(pubkeys)+1)
+	if err != nil {
+		return err
+	}
+	hi, err := zkDecodePoints(groupedCiphertextHi, len(pubkeys)+1)
+	if err != nil {
+		return err
+	}
+
+	batched := make([]*ristretto255.Element, len(lo))
+	for i := range lo {
+		batched[i] = ristretto255.NewElement().Add(lo[i], ristretto255.NewElement().ScalarMult(t, hi[i]))
+	}
+
+	transcript.groupedCiphertextValidityProofDomainSeparator(len(pubkeys))
+	return verifyGroupedCiphertextValidityProof(pubkeys, batched, proof, transcript)
+}
@@ -0,0 +1,269 @@
+package sealevel
+
+import (
+	"math/bits"
+
+	"github.com/gtank/ristretto255"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	ZkRangeProofMaxCommitments = 8
+	ZkRangeProofU64Len         = 672
+	ZkRangeProofU128Len        = 736
+	ZkRangeProofU256Len        = 800
+	ZkBatchedRangeProofCtxLen  = ZkRangeProofMaxCommitments*ZkPedersenCommitmentLen + ZkRangeProofMaxCommitments
+
+	zkRangeProofMaxGenerators = 256
+)
+
+// the bulletproof generators for up to 256 bits, from the "G" and "H" generator chains
+var zkBulletproofGensG, zkBulletproofGensH = zkGeneratorsChain("G", zkRangeProofMaxGenerators), zkGeneratorsChain("H", zkRangeProofMaxGenerators)
+
+func zkGeneratorsChain(label string, n int) []*ristretto255.Element {
+	shake := sha3.NewShake256()
+	shake.Write([]byte("GeneratorsChain"))
+	shake.Write([]byte(label))
+
+	generators := make([]*ristretto255.Element, n)
+	buf := make([]byte, 64)
+	for i := range generators {
+		shake.Read(buf)
+		generators[i] = ristretto255.NewElement().FromUniformBytes(buf)
+	}
+	return generators
+}
+
+// zkSumOfPowers returns 1 + x + x^2 + ... + x^(n-1).
+func zkSumOfPowers(x *ristretto255.Scalar, n int) *ristretto255.Scalar {
+	sum := ristretto255.NewScalar()
+	exp := zkScalarOne()
+	for i := 0; i < n; i++ {
+		sum.Add(sum, exp)
+		exp = zkMul(exp, x)
+	}
+	return sum
+}
+
+// zkRangeProofDelta computes delta(y, z) = (z - z^2) * <1, y^nm> - sum_j z^(j+3) * <1, 2^n_j>.
+func zkRangeProofDelta(bitLengths []int, y, z *ristretto255.Scalar) *ristretto255.Scalar {
+	var nm int
+	for _, n := range bitLengths {
+		nm += n
+	}
+
+	zz := zkMul(z, z)
+	delta := zkMul(ristretto255.NewScalar().Subtract(z, zz), zkSumOfPowers(y, nm))
+
+	two := zkScalarFromU64(2)
+	expZ := zkMul(zz, z)
+	for _, n := range bitLengths {
+		delta.Subtract(delta, zkMul(expZ, zkSumOfPowers(two, n)))
+		expZ = zkMul(expZ, z)
+	}
+	return delta
+}
+
+// zkInnerProductVerificationScalars derives the inner product proof challenges from the L and R
+// points of the proof, returning their squares, inverse squares, and the scalars s.
+func zkInnerProductVerificationScalars(n int, lVec, rVec [][]byte, transcript *zkTranscript) ([]*ristretto255.Scalar, []*ristretto255.Scalar, []*ristretto255.Scalar, error) {
+	lgN := len(lVec)
+	if lgN == 0 || lgN >= 32 || n != 1<<lgN {
+		return nil, nil, nil, ZkProofErrInvalidGeneratorsLength
+	}
+
+	transcript.appendDomainSeparator("inner-product")
+	transcript.appendU64("n", uint64(n))
+
+	challenges := make([]*ristretto255.Scalar, lgN)
+	for i := range lVec {
+		err := transcript.validateAndAppendPoint("L", lVec[i])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		err = transcript.validateAndAppendPoint("R", rVec[i])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		challenges[i] = transcript.challengeScalar("u")
+	}
+
+	allInv := zkScalarOne()
+	challengesSq := make([]*ristretto255.Scalar, lgN)
+	challengesInvSq := make([]*ristretto255.Scalar, lgN)
+	for i, u := range challenges {
+		uInv := ristretto255.NewScalar().Invert(u)
+		allInv = zkMul(allInv, uInv)
+		challengesSq[i] = zkMul(u, u)
+		challengesInvSq[i] = zkMul(uInv, uInv)
+	}
+
+	s := make([]*ristretto255.Scalar, n)
+	s[0] = allInv
+	for i := 1; i < n; i++ {
+		lgI := bits.Len(uint(i)) - 1
+		k := 1 << lgI
+		s[i] = zkMul(s[i-k], challengesSq[(lgN-1)-lgI])
+	}
+
+	return challengesSq, challengesInvSq, s, nil
+}
+
+// verifyRangeProof verifies an aggregated bulletproof that each commitment commits to a value
+// within its bit length.
+func verifyRangeProof(commitments [][]byte, bitLengths []int, proof []byte, transcript *zkTranscript) error {
+	if len(commitments) > ZkRangeProofMaxCommitments || len(commitments) != len(bitLengths) {
+		return ZkProofErrIllegalCommitmentLength
+	}
+
+	var nm int
+	for _, n := range bitLengths {
+		nm += n
+	}
+	if nm == 0 || nm > zkRangeProofMaxGenerators || nm&(nm-1) != 0 {
+		return ZkProofErrInvalidBitSize
+	}
+
+	// A, S, T_1, T_2, t_x, t_x_blinding, e_blinding, then the inner product proof of
+	// interleaved L and R points followed by a and b
+	lgN := (len(proof) - 7*32 - 2*32) / 64
+	if len(proof) != 7*32+lgN*64+2*32 {
+		return ZkProofErrDeserialization
+	}
+	lVec := make([][]byte, lgN)
+	rVec := make([][]byte, lgN)
+	for i := 0; i < lgN; i++ {
+		lVec[i] = proof[224+i*64 : 224+i*64+32]
+		rVec[i] = proof[224+i*64+32 : 224+i*64+64]
+	}
+	scalarsOffset := 224 + lgN*64
+
+	transcript.appendDomainSeparator("range-proof")
+	transcript.appendU64("n", uint64(nm))
+
+	err := transcript.validateAndAppendPoint("A", proof[0:32])
+	if err != nil {
+		return err
+	}
+	err = transcript.validateAndAppendPoint("S", proof[32:64])
+	if err != nil {
+		return err
+	}
+
+	y := transcript.challengeScalar("y")
+	z := transcript.challengeScalar("z")
+	zz := zkMul(z, z)
+	minusZ := zkNeg(z)
+
+	err = transcript.validateAndAppendPoint("T_1", proof[64:96])
+	if err != nil {
+		return err
+	}
+	err = transcript.validateAndAppendPoint("T_2", proof[96:128])
+	if err != nil {
+		return err
+	}
+
+	x := transcript.challengeScalar("x")
+
+	blindings, err := zkDecodeScalars(proof[128:224], 3)
+	if err != nil {
+		return err
+	}
+	tx, txBlinding, eBlinding := blindings[0], blindings[1], blindings[2]
+	transcript.appendScalar("t_x", tx)
+	transcript.appendScalar("t_x_blinding", txBlinding)
+	transcript.appendScalar("e_blinding", eBlinding)
+
+	w := transcript.challengeScalar("w")
+	c := transcript.challengeScalar("c")
+
+	xSq, xInvSq, s, err := zkInnerProductVerificationScalars(nm, lVec, rVec, transcript)
+	if err != nil {
+		return err
+	}
+
+	ab, err := zkDecodeScalars(proof[scalarsOffset:scalarsOffset+64], 2)
+	if err != nil {
+		return err
+	}
+	a, b := ab[0], ab[1]
+
+	// z^0 * 2^n_0 || z^1 * 2^n_1 || ... || z^(m-1) * 2^n_(m-1)
+	two := zkScalarFromU64(2)
+	concatZAnd2 := make([]*ristretto255.Scalar, 0, nm)
+	expZ := zkScalarOne()
+	for _, n := range bitLengths {
+		exp2 := zkScalarOne()
+		for i := 0; i < n; i++ {
+			concatZAnd2 = append(concatZAnd2, zkMul(exp2, expZ))
+			exp2 = zkMul(exp2, two)
+		}
+		expZ = zkMul(expZ, z)
+	}
+
+	points, err := zkDecodePoints(proof, 4)
+	if err != nil {
+		return err
+	}
+	A, S, T1, T2 := points[0], points[1], points[2], points[3]
+
+	basepointScalar := ristretto255.NewScalar().Add(
+		zkMul(w, ristretto255.NewScalar().Subtract(tx, zkMul(a, b))),
+		zkMul(c, ristretto255.NewScalar().Subtract(zkRangeProofDelta(bitLengths, y, z), tx)))
+
+	scalars := []*ristretto255.Scalar{
+		zkScalarOne(),
+		x,
+		zkMul(c, x),
+		zkMul(c, zkMul(x, x)),
+		ristretto255.NewScalar().Subtract(zkNeg(eBlinding), zkMul(c, txBlinding)),
+		basepointScalar,
+	}
+	elements := []*ristretto255.Element{A, S, T1, T2, zkPedersenH, zkPedersenG}
+
+	for i := 0; i < lgN; i++ {
+		L, err := zkDecodePoint(lVec[i])
+		if err != nil {
+			return err
+		}
+		scalars = append(scalars, xSq[i])
+		elements = append(elements, L)
+	}
+	for i := 0; i < lgN; i++ {
+		R, err := zkDecodePoint(rVec[i])
+		if err != nil {
+			return err
+		}
+		scalars = append(scalars, xInvSq[i])
+		elements = append(elements, R)
+	}
+
+	for i := 0; i < nm; i++ {
+		scalars = append(scalars, ristretto255.NewScalar().Subtract(minusZ, zkMul(a, s[i])))
+		elements = append(elements, zkBulletproofGensG[i])
+	}
+
+	yInv := ristretto255.NewScalar().Invert(y)
+	expYInv := zkScalarOne()
+	for i := 0; i < nm; i++ {
+		sInv := s[nm-1-i]
+		hScalar := zkMul(expYInv, ristretto255.NewScalar().Subtract(zkMul(zz, concatZAnd2[i]), zkMul(b, sInv)))
+		scalars = append(scalars, hScalar.Add(hScalar, z))
+		elements = append(elements, zkBulletproofGensH[i])
+		expYInv = zkMul(expYInv, yInv)
+	}
+
+	expZ = zkScalarOne()
+	for _, commitment := range commitments {
+		V, err := zkDecodePoint(commitment)
+		if err != nil {
+			return err
+		}
+		scalars = append(scalars, zkMul(c, zkMul(zz, expZ)))
+		elements = append(elements, V)
+		expZ = zkMul(expZ, z)
+	}
+
+	return zkCheckIdentity(scalars, elements)
+}
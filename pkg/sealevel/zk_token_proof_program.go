@@ -0,0 +1,371 @@
+package sealevel
+
+import (
+	"github.com/gtank/ristretto255"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/features"
+	"k8s.io/klog/v2"
+)
+
+const ZkTokenProofProgramAddrStr = "ZkTokenProof1111111111111111111111111111111"
+
+var ZkTokenProofProgramAddr = base58.MustDecodeFromString(ZkTokenProofProgramAddrStr)
+
+// The instructions of the zk-token proof program, which the ZK ElGamal proof program
+// replaced. Its proofs are those of the zk-token-sdk, which differ from the zk-sdk's in their
+// context transcripts and in some of their domain separators.
+const (
+	ZkTokenProofInstrTypeCloseContextState = iota
+	ZkTokenProofInstrTypeVerifyZeroBalance
+	ZkTokenProofInstrTypeVerifyWithdraw
+	ZkTokenProofInstrTypeVerifyCiphertextCiphertextEquality
+	ZkTokenProofInstrTypeVerifyTransfer
+	ZkTokenProofInstrTypeVerifyTransferWithFee
+	ZkTokenProofInstrTypeVerifyPubkeyValidity
+	ZkTokenProofInstrTypeVerifyRangeProofU64
+	ZkTokenProofInstrTypeVerifyBatchedRangeProofU64
+	ZkTokenProofInstrTypeVerifyBatchedRangeProofU128
+	ZkTokenProofInstrTypeVerifyBatchedRangeProofU256
+	ZkTokenProofInstrTypeVerifyCiphertextCommitmentEquality
+	ZkTokenProofInstrTypeVerifyGroupedCiphertext2HandlesValidity
+	ZkTokenProofInstrTypeVerifyBatchedGroupedCiphertext2HandlesValidity
+	ZkTokenProofInstrTypeVerifyFeeSigma
+	ZkTokenProofInstrTypeVerifyGroupedCiphertext3HandlesValidity
+	ZkTokenProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity
+)
+
+const (
+	ZkWithdrawProofLen = ZkPedersenCommitmentLen + ZkCiphertextCommitmentEqualityLen + ZkRangeProofU64Len
+	ZkTransferProofLen = ZkPedersenCommitmentLen + ZkCiphertextCommitmentEqualityLen + ZkGroupedCiphertext2HandlesProof + ZkRangeProofU128Len
+
+	// the transfer amount ciphertexts are encrypted under the source, destination and auditor
+	// pubkeys, and are followed by the pubkeys and the new source balance ciphertext
+	ZkTransferProofCtxLen = 2*ZkGroupedCiphertext3HandlesLen + 3*ZkElGamalPubkeyLen + ZkElGamalCiphertextLen
+)
+
+// the bit lengths of the new source balance, the low and high bits of the transfer amount,
+// and the negation of the low bits, which are range proven together in a transfer proof
+const (
+	zkTransferSourceAmountBits      = 64
+	zkTransferAmountLoBits          = 16
+	zkTransferAmountLoNegatedBits   = 16
+	zkTransferAmountHiBits          = 32
+	zkTransferAmountLoNegatedMaxVal = 1<<zkTransferAmountLoNegatedBits - 1
+)
+
+func verifyZeroBalanceInstr(context []byte, proof []byte) error {
+	pubkey, ciphertext := context[0:32], context[32:96]
+
+	transcript := newZkTokenTranscript("ZeroBalanceProof")
+	transcript.AppendMessage("pubkey", pubkey)
+	transcript.AppendMessage("ciphertext", ciphertext)
+
+	return verifyZeroCiphertextProof(pubkey, ciphertext, proof, transcript)
+}
+
+// verifyWithdrawInstr verifies a proof that the final balance ciphertext of a withdrawal
+// encrypts a non-negative 64-bit amount, by way of a commitment to that amount.
+func verifyWithdrawInstr(context []byte, proof []byte) error {
+	pubkey, finalCiphertext := context[0:32], context[32:96]
+	commitment := proof[0:32]
+	equalityProof := proof[32 : 32+ZkCiphertextCommitmentEqualityLen]
+	rangeProof := proof[32+ZkCiphertextCommitmentEqualityLen:]
+
+	transcript := newZkTokenTranscript("WithdrawProof")
+	transcript.AppendMessage("pubkey", pubkey)
+	transcript.AppendMessage("ciphertext", finalCiphertext)
+	transcript.AppendMessage("commitment", commitment)
+
+	err := verifyCiphertextCommitmentEqualityProof(pubkey, finalCiphertext, commitment, equalityProof, transcript)
+	if err != nil {
+		return err
+	}
+
+	return verifyRangeProof([][]byte{commitment}, []int{64}, rangeProof, transcript)
+}
+
+func verifyZkTokenCiphertextCiphertextEqualityInstr(context []byte, proof []byte) error {
+	sourcePubkey, destinationPubkey := context[0:32], context[32:64]
+	sourceCiphertext, destinationCiphertext := context[64:128], context[128:192]
+
+	transcript := newZkTokenTranscript("CiphertextCiphertextEqualityProof")
+	transcript.AppendMessage("pubkey-source", sourcePubkey)
+	transcript.AppendMessage("pubkey-dest", destinationPubkey)
+	transcript.AppendMessage("ciphertext-source", sourceCiphertext)
+	transcript.AppendMessage("ciphertext-dest", destinationCiphertext)
+
+	return verifyCiphertextCiphertextEqualityProof(sourcePubkey, destinationPubkey, sourceCiphertext, destinationCiphertext, proof, transcript)
+}
+
+// verifyTransferInstr verifies a proof that the transfer amount ciphertexts are well-formed,
+// and that the source's new balance and the transfer amount are in range.
+func verifyTransferInstr(context []byte, proof []byte) error {
+	ciphertextLo, ciphertextHi := context[0:128], context[128:256]
+	transferPubkeys := context[256:352]
+	sourcePubkey, destinationPubkey, auditorPubkey := transferPubkeys[0:32], transferPubkeys[32:64], transferPubkeys[64:96]
+	newSourceCiphertext := context[352:416]
+
+	newSourceCommitment := proof[0:32]
+	equalityProof := proof[32:224]
+	validityProof := proof[224:384]
+	rangeProof := proof[384:]
+
+	transcript := newZkTokenTranscript("transfer-proof")
+	transcript.AppendMessage("ciphertext-lo", ciphertextLo)
+	transcript.AppendMessage("ciphertext-hi", ciphertextHi)
+	transcript.AppendMessage("transfer-pubkeys", transferPubkeys)
+	transcript.AppendMessage("ciphertext-new-source", newSourceCiphertext)
+	transcript.AppendMessage("commitment-new-source", newSourceCommitment)
+
+	err := verifyCiphertextCommitmentEqualityProof(sourcePubkey, newSourceCiphertext, newSourceCommitment, equalityProof, transcript)
+	if err != nil {
+		return err
+	}
+
+	// the amount ciphertexts' destination and auditor handles are proven valid, the source
+	// handles being covered by the equality proof
+	groupedCiphertextLo := append(append([]byte{}, ciphertextLo[0:32]...), ciphertextLo[64:128]...)
+	groupedCiphertextHi := append(append([]byte{}, ciphertextHi[0:32]...), ciphertextHi[64:128]...)
+	err = verifyBatchedGroupedCiphertextHandlesValidityProof([][]byte{destinationPubkey, auditorPubkey}, groupedCiphertextLo, groupedCiphertextHi, validityProof, transcript)
+	if err != nil {
+		return err
+	}
+
+	commitmentLo, err := zkDecodePoint(ciphertextLo[0:32])
+	if err != nil {
+		return err
+	}
+	maxCommitment := ristretto255.NewElement().ScalarMult(zkScalarFromU64(zkTransferAmountLoNegatedMaxVal), zkPedersenG)
+	commitmentLoNegated := ristretto255.NewElement().Subtract(maxCommitment, commitmentLo)
+
+	return verifyRangeProof(
+		[][]byte{newSourceCommitment, ciphertextLo[0:32], commitmentLoNegated.Encode(nil), ciphertextHi[0:32]},
+		[]int{zkTransferSourceAmountBits, zkTransferAmountLoBits, zkTransferAmountLoNegatedBits, zkTransferAmountHiBits},
+		rangeProof, transcript)
+}
+
+func verifyZkTokenPubkeyValidityInstr(context []byte, proof []byte) error {
+	transcript := newZkTokenTranscript("PubkeyProof")
+	transcript.AppendMessage("pubkey", context)
+
+	return verifyPubkeyValidityProof(context, proof, transcript)
+}
+
+func verifyRangeProofU64Instr(context []byte, proof []byte) error {
+	transcript := newZkTokenTranscript("RangeProof")
+	transcript.AppendMessage("commitment", context)
+
+	return verifyRangeProof([][]byte{context}, []int{64}, proof, transcript)
+}
+
+func zkTokenBatchedRangeProofVerifier(batchedBitLength int) zkProofVerifier {
+	return func(context []byte, proof []byte) error {
+		return verifyBatchedRangeProofContext(context, proof, batchedBitLength, newZkTokenTranscript("BatchedRangeProof"))
+	}
+}
+
+func verifyZkTokenCiphertextCommitmentEqualityInstr(context []byte, proof []byte) error {
+	pubkey, ciphertext, commitment := context[0:32], context[32:96], context[96:128]
+
+	transcript := newZkTokenTranscript("CtxtCommEqualityProof")
+	transcript.AppendMessage("pubkey", pubkey)
+	transcript.AppendMessage("ciphertext", ciphertext)
+	transcript.AppendMessage("commitment", commitment)
+
+	return verifyCiphertextCommitmentEqualityProof(pubkey, ciphertext, commitment, proof, transcript)
+}
+
+func verifyZkTokenGroupedCiphertext2HandlesValidityInstr(context []byte, proof []byte) error {
+	destinationPubkey, auditorPubkey, groupedCiphertext := context[0:32], context[32:64], context[64:160]
+
+	transcript := newZkTokenTranscript("CiphertextValidityProof")
+	transcript.AppendMessage("destination-pubkey", destinationPubkey)
+	transcript.AppendMessage("auditor-pubkey", auditorPubkey)
+	transcript.AppendMessage("grouped-ciphertext", groupedCiphertext)
+
+	return verifyGroupedCiphertextHandlesValidityProof([][]byte{destinationPubkey, auditorPubkey}, groupedCiphertext, proof, transcript)
+}
+
+func verifyZkTokenBatchedGroupedCiphertext2HandlesValidityInstr(context []byte, proof []byte) error {
+	destinationPubkey, auditorPubkey := context[0:32], context[32:64]
+	groupedCiphertextLo, groupedCiphertextHi := context[64:160], context[160:256]
+
+	transcript := newZkTokenTranscript("BatchedGroupedCiphertextValidityProof")
+	transcript.AppendMessage("destination-pubkey", destinationPubkey)
+	transcript.AppendMessage("auditor-pubkey", auditorPubkey)
+	transcript.AppendMessage("grouped-ciphertext-lo", groupedCiphertextLo)
+	transcript.AppendMessage("grouped-ciphertext-hi", groupedCiphertextHi)
+
+	return verifyBatchedGroupedCiphertextHandlesValidityProof([][]byte{destinationPubkey, auditorPubkey}, groupedCiphertextLo, groupedCiphertextHi, proof, transcript)
+}
+
+func verifyZkTokenGroupedCiphertext3HandlesValidityInstr(context []byte, proof []byte) error {
+	sourcePubkey, destinationPubkey, auditorPubkey := context[0:32], context[32:64], context[64:96]
+	groupedCiphertext := context[96:224]
+
+	transcript := newZkTokenTranscript("GroupedCiphertext3HandlesValidityProof")
+	transcript.AppendMessage("source-pubkey", sourcePubkey)
+	transcript.AppendMessage("destination-pubkey", destinationPubkey)
+	transcript.AppendMessage("auditor-pubkey", auditorPubkey)
+	transcript.AppendMessage("grouped-ciphertext", groupedCiphertext)
+
+	return verifyGroupedCiphertextHandlesValidityProof([][]byte{sourcePubkey, destinationPubkey, auditorPubkey}, groupedCiphertext, proof, transcript)
+}
+
+func verifyZkTokenBatchedGroupedCiphertext3HandlesValidityInstr(context []byte, proof []byte) error {
+	sourcePubkey, destinationPubkey, auditorPubkey := context[0:32], context[32:64], context[64:96]
+	groupedCiphertextLo, groupedCiphertextHi := context[96:224], context[224:352]
+
+	transcript := newZkTokenTranscript("BatchedGroupedCiphertext3HandlesValidityProof")
+	transcript.AppendMessage("source-pubkey", sourcePubkey)
+	transcript.AppendMessage("destination-pubkey", destinationPubkey)
+	transcript.AppendMessage("auditor-pubkey", auditorPubkey)
+	transcript.AppendMessage("grouped-ciphertext-lo", groupedCiphertextLo)
+	transcript.AppendMessage("grouped-ciphertext-hi", groupedCiphertextHi)
+
+	return verifyBatchedGroupedCiphertextHandlesValidityProof([][]byte{sourcePubkey, destinationPubkey, auditorPubkey}, groupedCiphertextLo, groupedCiphertextHi, proof, transcript)
+}
+
+func ZkTokenProofProgramExecute(execCtx *ExecutionCtx) error {
+	klog.Infof("ZkTokenProofProgramExecute")
+
+	if !execCtx.GlobalCtx.Features.IsActive(features.ZkTokenSdkEnabled) {
+		return InstrErrUnsupportedProgramId
+	}
+
+	txCtx := execCtx.TransactionContext
+	instrCtx, err := txCtx.CurrentInstructionCtx()
+	if err != nil {
+		return err
+	}
+
+	if len(instrCtx.Data) == 0 || instrCtx.Data[0] > ZkTokenProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity {
+		return InstrErrInvalidInstructionData
+	}
+	instrType := instrCtx.Data[0]
+
+	// proof verification instructions are not supported as inner instructions
+	if execCtx.StackHeight() != 1 && instrType != ZkTokenProofInstrTypeCloseContextState {
+		return InstrErrUnsupportedProgramId
+	}
+
+	var cost uint64
+	var contextLen, proofLen int
+	var verify zkProofVerifier
+
+	switch instrType {
+	case ZkTokenProofInstrTypeCloseContextState:
+		{
+			err = execCtx.ComputeMeter.Consume(CUZkCloseContextStateComputeUnits)
+			if err != nil {
+				return err
+			}
+			klog.Infof("CloseContextState")
+			return ZkElGamalProofCloseContextState(execCtx, ZkTokenProofProgramAddr)
+		}
+
+	case ZkTokenProofInstrTypeVerifyZeroBalance:
+		{
+			cost = CUZkVerifyZeroCiphertextComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen+ZkElGamalCiphertextLen, ZkZeroCiphertextProofLen
+			verify = verifyZeroBalanceInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyWithdraw:
+		{
+			cost = CUZkVerifyWithdrawComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen+ZkElGamalCiphertextLen, ZkWithdrawProofLen
+			verify = verifyWithdrawInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyCiphertextCiphertextEquality:
+		{
+			cost = CUZkVerifyCiphertextCiphertextEqualityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+2*ZkElGamalCiphertextLen, ZkCiphertextCiphertextEqualityLen
+			verify = verifyZkTokenCiphertextCiphertextEqualityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyTransfer:
+		{
+			cost = CUZkVerifyTransferComputeUnits
+			contextLen, proofLen = ZkTransferProofCtxLen, ZkTransferProofLen
+			verify = verifyTransferInstr
+		}
+
+	// the transfer with fee related proofs were never enabled
+	case ZkTokenProofInstrTypeVerifyTransferWithFee, ZkTokenProofInstrTypeVerifyBatchedRangeProofU256, ZkTokenProofInstrTypeVerifyFeeSigma:
+		{
+			return InstrErrInvalidInstructionData
+		}
+
+	case ZkTokenProofInstrTypeVerifyPubkeyValidity:
+		{
+			cost = CUZkVerifyPubkeyValidityComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen, ZkPubkeyValidityProofLen
+			verify = verifyZkTokenPubkeyValidityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyRangeProofU64:
+		{
+			cost = CUZkVerifyRangeProofU64ComputeUnits
+			contextLen, proofLen = ZkPedersenCommitmentLen, ZkRangeProofU64Len
+			verify = verifyRangeProofU64Instr
+		}
+
+	case ZkTokenProofInstrTypeVerifyBatchedRangeProofU64:
+		{
+			cost = CUZkVerifyBatchedRangeProofU64ComputeUnits
+			contextLen, proofLen = ZkBatchedRangeProofCtxLen, ZkRangeProofU64Len
+			verify = zkTokenBatchedRangeProofVerifier(64)
+		}
+
+	case ZkTokenProofInstrTypeVerifyBatchedRangeProofU128:
+		{
+			cost = CUZkVerifyBatchedRangeProofU128ComputeUnits
+			contextLen, proofLen = ZkBatchedRangeProofCtxLen, ZkRangeProofU128Len
+			verify = zkTokenBatchedRangeProofVerifier(128)
+		}
+
+	case ZkTokenProofInstrTypeVerifyCiphertextCommitmentEquality:
+		{
+			cost = CUZkVerifyCiphertextCommitmentEqualityComputeUnits
+			contextLen, proofLen = ZkElGamalPubkeyLen+ZkElGamalCiphertextLen+ZkPedersenCommitmentLen, ZkCiphertextCommitmentEqualityLen
+			verify = verifyZkTokenCiphertextCommitmentEqualityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyGroupedCiphertext2HandlesValidity:
+		{
+			cost = CUZkVerifyGroupedCiphertext2HandlesValidityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+ZkGroupedCiphertext2HandlesLen, ZkGroupedCiphertext2HandlesProof
+			verify = verifyZkTokenGroupedCiphertext2HandlesValidityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyBatchedGroupedCiphertext2HandlesValidity:
+		{
+			cost = CUZkVerifyBatchedGroupedCiphertext2HandlesValidityComputeUnits
+			contextLen, proofLen = 2*ZkElGamalPubkeyLen+2*ZkGroupedCiphertext2HandlesLen, ZkGroupedCiphertext2HandlesProof
+			verify = verifyZkTokenBatchedGroupedCiphertext2HandlesValidityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyGroupedCiphertext3HandlesValidity:
+		{
+			cost = CUZkVerifyGroupedCiphertext3HandlesValidityComputeUnits
+			contextLen, proofLen = 3*ZkElGamalPubkeyLen+ZkGroupedCiphertext3HandlesLen, ZkGroupedCiphertext3HandlesProof
+			verify = verifyZkTokenGroupedCiphertext3HandlesValidityInstr
+		}
+
+	case ZkTokenProofInstrTypeVerifyBatchedGroupedCiphertext3HandlesValidity:
+		{
+			cost = CUZkVerifyBatchedGroupedCiphertext3HandlesValidityComputeUnits
+			contextLen, proofLen = 3*ZkElGamalPubkeyLen+2*ZkGroupedCiphertext3HandlesLen, ZkGroupedCiphertext3HandlesProof
+			verify = verifyZkTokenBatchedGroupedCiphertext3HandlesValidityInstr
+		}
+	}
+
+	err = execCtx.ComputeMeter.Consume(cost)
+	if err != nil {
+		return err
+	}
+
+	return ZkElGamalProofVerify(execCtx, ZkTokenProofProgramAddr, instrType, contextLen, proofLen, verify)
+}
@@ -58,6 +58,7 @@ type Inflation struct {
 
 type VoteAccount struct {
 	Lamports          uint64
+	Data              []byte
 	NodePubkey        solana.PublicKey
 	LastTimestampTs   int64
 	LastTimestampSlot uint64
@@ -421,12 +422,19 @@ func (voteAcct *VoteAccount) UnmarshalWithDecoder(decoder *bin.Decoder) error {
 	}
 
 	if dataLen > 0 {
-		positionAfter := decoder.Position() + uint(dataLen)
+		positionBefore := decoder.Position()
 
 		var voteState sealevel.VoteStateVersions
 
 		err = voteState.UnmarshalWithDecoder(decoder)
-		decoder.SetPosition(positionAfter)
+		decoder.SetPosition(positionBefore)
+
+		// the raw vote account data is retained so that the manifest can be re-serialized
+		var dataErr error
+		voteAcct.Data, dataErr = decoder.ReadBytes(int(dataLen))
+		if dataErr != nil {
+			return dataErr
+		}
 
 		var voteTimestamp sealevel.BlockTimestamp
 
@@ -0,0 +1,916 @@
+package snapshot
+
+import (
+	"sort"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+func (bhv *BlockHashVec) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(bhv.LastHashIndex, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBool(bhv.LastHash != nil)
+	if err != nil {
+		return err
+	}
+
+	if bhv.LastHash != nil {
+		err = encoder.WriteBytes(bhv.LastHash[:], false)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(bhv.Ages)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range bhv.Ages {
+		err = bhv.Ages[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoder.WriteUint64(bhv.MaxAge, bin.LE)
+}
+
+func (age *HashAge) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(age.FeeCalculator.LamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(age.HashIndex, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(age.Timestamp, bin.LE)
+}
+
+func (hashAgePair *HashAgePair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(hashAgePair.Key[:], false)
+	if err != nil {
+		return err
+	}
+
+	return hashAgePair.Val.MarshalWithEncoder(encoder)
+}
+
+func (slotPair *SlotPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint64(slotPair.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(slotPair.Val, bin.LE)
+}
+
+func (rateGovernor *FeeRateGovernor) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(rateGovernor.TargetLamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rateGovernor.TargetSignaturesPerSlot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rateGovernor.MinLamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rateGovernor.MaxLamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteByte(rateGovernor.BurnPercent)
+}
+
+func (rentCollector *RentCollector) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(rentCollector.Epoch, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = rentCollector.EpochSchedule.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(rentCollector.SlotsPerYear, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return rentCollector.Rent.MarshalWithEncoder(encoder)
+}
+
+func (inflation *Inflation) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteFloat64(inflation.Initial, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(inflation.Terminal, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(inflation.Taper, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(inflation.Foundation, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(inflation.FoundationTerm, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteFloat64(inflation.Unused, bin.LE)
+}
+
+func (voteAcct *VoteAccount) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(voteAcct.Lamports, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(voteAcct.Data)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(voteAcct.Data, false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(voteAcct.Owner[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteByte(voteAcct.Executable)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(voteAcct.RentEpoch, bin.LE)
+}
+
+func (voteAcctsPair *VoteAccountsPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteBytes(voteAcctsPair.Key[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(voteAcctsPair.Stake, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return voteAcctsPair.Value.MarshalWithEncoder(encoder)
+}
+
+func (stakes *Stakes) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(uint64(len(stakes.VoteAccounts)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range stakes.VoteAccounts {
+		err = stakes.VoteAccounts[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(stakes.StakeDelegations)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range stakes.StakeDelegations {
+		err = stakes.StakeDelegations[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(stakes.Unused, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(stakes.Epoch, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return stakes.StakeHistory.MarshalWithEncoder(encoder)
+}
+
+func (delegation *Delegation) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteBytes(delegation.VoterPubkey[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(delegation.Stake, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(delegation.ActivationEpoch, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(delegation.DeactivationEpoch, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteFloat64(delegation.WarmupCooldownRate, bin.LE)
+}
+
+func (delegationPair *DelegationPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(delegationPair.Account[:], false)
+	if err != nil {
+		return err
+	}
+
+	return delegationPair.Delegation.MarshalWithEncoder(encoder)
+}
+
+func (pair *UnusedAccountsU64Pair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(pair._0[:], false)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(pair._1, bin.LE)
+}
+
+func (unusedAccts *UnusedAccounts) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(uint64(len(unusedAccts.Unused1)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for _, pk := range unusedAccts.Unused1 {
+		err = encoder.WriteBytes(pk[:], false)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(unusedAccts.Unused2)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for _, pk := range unusedAccts.Unused2 {
+		err = encoder.WriteBytes(pk[:], false)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(unusedAccts.Unused3)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range unusedAccts.Unused3 {
+		err = unusedAccts.Unused3[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (nodeVoteAccts *NodeVoteAccounts) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(uint64(len(nodeVoteAccts.VoteAccounts)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for _, pk := range nodeVoteAccts.VoteAccounts {
+		err = encoder.WriteBytes(pk[:], false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoder.WriteUint64(nodeVoteAccts.TotalStake, bin.LE)
+}
+
+func (pair *NodeVoteAccountsPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(pair.Key[:], false)
+	if err != nil {
+		return err
+	}
+
+	return pair.Val.MarshalWithEncoder(encoder)
+}
+
+func (pubkeyPair *PubkeyPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(pubkeyPair.Key[:], false)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteBytes(pubkeyPair.Val[:], false)
+}
+
+func (epochStakes *EpochStakes) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = epochStakes.Stakes.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(epochStakes.TotalStake, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(epochStakes.NodeIdToVoteAccounts)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range epochStakes.NodeIdToVoteAccounts {
+		err = epochStakes.NodeIdToVoteAccounts[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(epochStakes.EpochAuthorizedVoters)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range epochStakes.EpochAuthorizedVoters {
+		err = epochStakes.EpochAuthorizedVoters[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (epochStakesPair *EpochStakesPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint64(epochStakesPair.Key, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return epochStakesPair.Val.MarshalWithEncoder(encoder)
+}
+
+func (dsv *DeserializableVersionedBank) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = dsv.BlockhashQueue.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(dsv.Ancestors)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range dsv.Ancestors {
+		err = dsv.Ancestors[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteBytes(dsv.Hash[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(dsv.ParentHash[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.ParentSlot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(dsv.HardForks)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range dsv.HardForks {
+		err = dsv.HardForks[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(dsv.TransactionCount, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.TickHeight, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.SignatureCount, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.Capitalization, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.MaxTickHeight, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBool(dsv.HashesPerTick != nil)
+	if err != nil {
+		return err
+	}
+
+	if dsv.HashesPerTick != nil {
+		err = encoder.WriteUint64(*dsv.HashesPerTick, bin.LE)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(dsv.TicksPerSlot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint128(dsv.NsPerSlot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.GenesisCreationTime, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteFloat64(dsv.SlotsPerYear, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.AccountsDataLen, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.Epoch, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.BlockHeight, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(dsv.CollectorId[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.CollectorFees, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.FeeCalculator.LamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.FeeRateGovernor.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(dsv.CollectedRent, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.RentCollector.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.EpochSchedule.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.Inflation.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.Stakes.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = dsv.UnusedAccounts.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(dsv.EpochStakes)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range dsv.EpochStakes {
+		err = dsv.EpochStakes[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoder.WriteBool(dsv.IsDelta)
+}
+
+func (acctVec *AcctVec) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint64(acctVec.Id, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(acctVec.FileSize, bin.LE)
+}
+
+func (slotAcctVecs *SlotAcctVecs) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(slotAcctVecs.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(slotAcctVecs.AcctVecs)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range slotAcctVecs.AcctVecs {
+		err = slotAcctVecs.AcctVecs[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (stats *BankHashStats) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(stats.NumUpdatedAccts, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(stats.NumLamportsStored, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(stats.TotalDataLen, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(stats.NumExecutableAccts, bin.LE)
+}
+
+func (info *BankHashInfo) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteBytes(info.Hash[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(info.SnapshotHash[:], false)
+	if err != nil {
+		return err
+	}
+
+	return info.Stats.MarshalWithEncoder(encoder)
+}
+
+func (pair *SlotMapPair) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint64(pair.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteBytes(pair.Hash[:], false)
+}
+
+func (bankIncrSnapshotPersistence *BankIncrementalSnapshotPersistence) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(bankIncrSnapshotPersistence.FullSlot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(bankIncrSnapshotPersistence.FullHash[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(bankIncrSnapshotPersistence.FullCapitalization, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBytes(bankIncrSnapshotPersistence.IncrementalHash[:], false)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(bankIncrSnapshotPersistence.IncrementalCapitalization, bin.LE)
+}
+
+func (acctDbFields *AccountsDbFields) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(uint64(len(acctDbFields.Storages)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	// the storages are held in a map, so they are written in slot order for determinism
+	slots := make([]uint64, 0, len(acctDbFields.Storages))
+	for slot := range acctDbFields.Storages {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+
+	for _, slot := range slots {
+		slotAcctVecs := acctDbFields.Storages[slot]
+		err = slotAcctVecs.MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(acctDbFields.Version, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(acctDbFields.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = acctDbFields.BankHashInfo.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(acctDbFields.HistoricalRoots)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for _, historicalRoot := range acctDbFields.HistoricalRoots {
+		err = encoder.WriteUint64(historicalRoot, bin.LE)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = encoder.WriteUint64(uint64(len(acctDbFields.HistoricalRootsWithHash)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range acctDbFields.HistoricalRootsWithHash {
+		err = acctDbFields.HistoricalRootsWithHash[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (epochRewardStatus *SerializableEpochRewardStatus) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteUint32(epochRewardStatus.Type, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	if epochRewardStatus.Type == 0 {
+		err = epochRewardStatus.Active.MarshalWithEncoder(encoder)
+	}
+
+	return err
+}
+
+func (startBlockHeightAndRewards *StartBlockHeightAndRewards) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(startBlockHeightAndRewards.StartBlockHeight, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(startBlockHeightAndRewards.StakeRewardsByPartition)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range startBlockHeightAndRewards.StakeRewardsByPartition {
+		err = startBlockHeightAndRewards.StakeRewardsByPartition[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rewardInfo *RewardInfo) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint32(rewardInfo.RewardType, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rewardInfo.Lamports, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rewardInfo.StakerRewards, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rewardInfo.NewCreditsObserved, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(rewardInfo.PostBalance, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return encoder.WriteUint64(rewardInfo.Commission, bin.LE)
+}
+
+func (stakeRewards *SerializableStakeRewards) MarshalWithEncoder(encoder *bin.Encoder) error {
+	err := encoder.WriteBytes(stakeRewards.StakePubkey[:], false)
+	if err != nil {
+		return err
+	}
+
+	return stakeRewards.RewardInfo.MarshalWithEncoder(encoder)
+}
+
+// isZero returns true if no epoch reward status is set, in which case it is omitted
+// from the serialized manifest.
+func (epochRewardStatus *SerializableEpochRewardStatus) isZero() bool {
+	return epochRewardStatus.Type == 0 && epochRewardStatus.Active.StartBlockHeight == 0 &&
+		len(epochRewardStatus.Active.StakeRewardsByPartition) == 0
+}
+
+// MarshalWithEncoder serializes the manifest in the same format as it is read by
+// UnmarshalWithDecoder. The optional trailing fields (incremental snapshot persistence,
+// epoch accounts hash and epoch reward status) are written as None when zero-valued.
+func (snapshot *SnapshotManifest) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = snapshot.Bank.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = snapshot.AccountsDb.MarshalWithEncoder(encoder)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(snapshot.LamportsPerSignature, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	hasIncrementalSnapshotPersistence := snapshot.BankIncrementalSnapshotPersistence != BankIncrementalSnapshotPersistence{}
+	err = encoder.WriteBool(hasIncrementalSnapshotPersistence)
+	if err != nil {
+		return err
+	}
+
+	if hasIncrementalSnapshotPersistence {
+		err = snapshot.BankIncrementalSnapshotPersistence.MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	hasEpochAcctHash := snapshot.EpochAccountHash != [32]byte{}
+	err = encoder.WriteBool(hasEpochAcctHash)
+	if err != nil {
+		return err
+	}
+
+	if hasEpochAcctHash {
+		err = encoder.WriteBytes(snapshot.EpochAccountHash[:], false)
+		if err != nil {
+			return err
+		}
+	}
+
+	hasEpochRewardStatus := !snapshot.EpochRewardStatus.isZero()
+	err = encoder.WriteBool(hasEpochRewardStatus)
+	if err != nil {
+		return err
+	}
+
+	if hasEpochRewardStatus {
+		err = snapshot.EpochRewardStatus.MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
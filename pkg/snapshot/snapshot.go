@@ -24,23 +24,29 @@ import (
 )
 
 func UnmarshalManifestFromSnapshot(filename string, accountsDbDir string) (*SnapshotManifest, error) {
-	manifest := new(SnapshotManifest)
-
-	file, err := os.Open(filename)
+	manifestBytes, err := readManifestFromSnapshot(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	manifestOutputFile := fmt.Sprintf("%s/manifest", accountsDbDir)
-	if err = os.MkdirAll(accountsDbDir, 0775); err != nil {
+	if err = writeManifest(accountsDbDir, manifestBytes); err != nil {
 		return nil, err
 	}
-	manifestOut, err := os.Create(manifestOutputFile)
+
+	manifest := new(SnapshotManifest)
+	decoder := bin.NewBinDecoder(manifestBytes)
+	err = manifest.UnmarshalWithDecoder(decoder)
+
+	return manifest, err
+}
+
+// readManifestFromSnapshot returns the serialized manifest held within a snapshot archive.
+func readManifestFromSnapshot(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer manifestOut.Close()
+	defer file.Close()
 
 	zstdReader, err := zstd.NewReader(file)
 	if err != nil {
@@ -64,20 +70,24 @@ func UnmarshalManifestFromSnapshot(filename string, accountsDbDir string) (*Snap
 				if err != nil {
 					return nil, err
 				}
-				_, err = io.Copy(manifestOut, bytes.NewBuffer(writer.Bytes()))
-				if err != nil {
-					fmt.Printf("err copying manifest file out: %s\n", err)
-					return nil, err
-				}
-				break
+				return writer.Bytes(), nil
 			}
 		}
 	}
+}
 
-	decoder := bin.NewBinDecoder(writer.Bytes())
-	err = manifest.UnmarshalWithDecoder(decoder)
+func writeManifest(accountsDbDir string, manifestBytes []byte) error {
+	if err := os.MkdirAll(accountsDbDir, 0775); err != nil {
+		return err
+	}
 
-	return manifest, err
+	err := os.WriteFile(fmt.Sprintf("%s/manifest", accountsDbDir), manifestBytes, 0664)
+	if err != nil {
+		fmt.Printf("err copying manifest file out: %s\n", err)
+		return err
+	}
+
+	return nil
 }
 
 type appendVecCopyingTask struct {
@@ -104,15 +114,96 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 		return err
 	}
 
-	file, err := os.Open(snapshotFile)
+	largestFileId, err := indexAppendVecsFromSnapshot(snapshotFile, accountsDbDir, manifest)
 	if err != nil {
 		return err
 	}
+
+	err = writeLargestFileId(accountsDbDir, largestFileId)
+	if err != nil {
+		return err
+	}
+
+	return writeBankHash(accountsDbDir, manifest.Bank.Hash)
+}
+
+// ApplyIncrementalSnapshot applies an incremental snapshot on top of an AccountsDB built from
+// the full snapshot that the incremental snapshot is based upon. Appendvecs from the incremental
+// snapshot are added to the AccountsDB and indexed, with the most recent version of each account
+// taking precedence, and the manifest, largest file ID and bank hash are replaced by those of
+// the incremental snapshot.
+func ApplyIncrementalSnapshot(incrementalSnapshotFile string, accountsDbDir string) error {
+	baseManifest, err := LoadManifestFromFile(fmt.Sprintf("%s/manifest", accountsDbDir))
+	if err != nil {
+		return err
+	}
+
+	// blocks replayed on top of the full snapshot would be clobbered by older account states
+	_, err = os.Stat(fmt.Sprintf("%s/last_slot", accountsDbDir))
+	if err == nil {
+		return fmt.Errorf("AccountsDB at %s has been replayed beyond its snapshot slot %d", accountsDbDir, baseManifest.Bank.Slot)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	manifestBytes, err := readManifestFromSnapshot(incrementalSnapshotFile)
+	if err != nil {
+		return err
+	}
+
+	manifest := new(SnapshotManifest)
+	err = manifest.UnmarshalWithDecoder(bin.NewBinDecoder(manifestBytes))
+	if err != nil {
+		return err
+	}
+
+	fullSlot := manifest.BankIncrementalSnapshotPersistence.FullSlot
+	if fullSlot == 0 {
+		return fmt.Errorf("%s is not an incremental snapshot", incrementalSnapshotFile)
+	} else if fullSlot != baseManifest.Bank.Slot {
+		return fmt.Errorf("incremental snapshot is based on full snapshot slot %d, but AccountsDB is at slot %d", fullSlot, baseManifest.Bank.Slot)
+	}
+
+	largestFileIdBytes, err := os.ReadFile(fmt.Sprintf("%s/largest_file_id", accountsDbDir))
+	if err != nil {
+		return err
+	} else if len(largestFileIdBytes) != 8 {
+		return fmt.Errorf("largest_file_id file had %d bytes rather than 8", len(largestFileIdBytes))
+	}
+	largestFileId := binary.LittleEndian.Uint64(largestFileIdBytes)
+
+	incrLargestFileId, err := indexAppendVecsFromSnapshot(incrementalSnapshotFile, accountsDbDir, manifest)
+	if err != nil {
+		return err
+	}
+
+	// the manifest is replaced last, such that a failed application is detectable by the
+	// AccountsDB remaining at the full snapshot slot.
+	err = writeLargestFileId(accountsDbDir, max(largestFileId, incrLargestFileId))
+	if err != nil {
+		return err
+	}
+
+	err = writeBankHash(accountsDbDir, manifest.Bank.Hash)
+	if err != nil {
+		return err
+	}
+
+	return writeManifest(accountsDbDir, manifestBytes)
+}
+
+// indexAppendVecsFromSnapshot extracts the appendvecs in a snapshot archive into the AccountsDB
+// and adds their accounts to the index, returning the largest appendvec file ID encountered.
+func indexAppendVecsFromSnapshot(snapshotFile string, accountsDbDir string, manifest *SnapshotManifest) (uint64, error) {
+	file, err := os.Open(snapshotFile)
+	if err != nil {
+		return 0, err
+	}
 	defer file.Close()
 
 	zstdReader, err := zstd.NewReader(file)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer zstdReader.Close()
 
@@ -122,18 +213,24 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 
 	appendVecsOutputDir := fmt.Sprintf("%s/accounts", accountsDbDir)
 	if err = os.MkdirAll(appendVecsOutputDir, 0775); err != nil {
-		return err
+		return 0, err
 	}
 
 	indexOutputDir := fmt.Sprintf("%s/index", accountsDbDir)
 	if err = os.MkdirAll(indexOutputDir, 0775); err != nil {
-		return err
+		return 0, err
+	}
+
+	// the AccountsDB's pubkey range index doesn't include the snapshot's accounts, and is
+	// rebuilt upon next use
+	if err = os.RemoveAll(fmt.Sprintf("%s/range_index", accountsDbDir)); err != nil {
+		return 0, err
 	}
 
 	db, err := sniper.Open(sniper.Dir(indexOutputDir), sniper.ChunksCollision(32))
 	if err != nil {
 		fmt.Printf("failed to open database: %s\n", err)
-		return err
+		return 0, err
 	}
 	defer db.Close()
 
@@ -272,14 +369,14 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 			break
 		} else if err != nil {
 			fmt.Printf("err reading next tar: %s\n", err)
-			return err
+			return 0, err
 		}
 
 		writer := new(bytes.Buffer)
 		_, err = io.Copy(writer, tarReader)
 		if err != nil {
 			fmt.Printf("err copying data to reader: %s\n", err)
-			return err
+			return 0, err
 		}
 
 		task := appendVecCopyingTask{TarBuffer: writer, Filename: header.Name}
@@ -298,14 +395,19 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 
 	fmt.Printf("snapshot processed in %s.\n", time.Since(start))
 
+	return largestFileId.Load(), nil
+}
+
+func writeLargestFileId(accountsDbDir string, largestFileId uint64) error {
 	largestFileIdFile, err := os.Create(fmt.Sprintf("%s/largest_file_id", accountsDbDir))
 	if err != nil {
 		fmt.Printf("err creating new: %s\n", err)
 		return err
 	}
+	defer largestFileIdFile.Close()
 
 	largestFileIdBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(largestFileIdBytes, largestFileId.Load())
+	binary.LittleEndian.PutUint64(largestFileIdBytes, largestFileId)
 
 	numBytesWritten, err := largestFileIdFile.Write(largestFileIdBytes[:])
 	if err != nil {
@@ -316,16 +418,19 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 		return fmt.Errorf("error writing largest file ID to file, wrote %d bytes", numBytesWritten)
 	}
 
-	largestFileIdFile.Close()
+	return nil
+}
 
+func writeBankHash(accountsDbDir string, bankHash [32]byte) error {
 	bankHashOutputFileName := fmt.Sprintf("%s/bank_hash", accountsDbDir)
 	bankHashFile, err := os.Create(bankHashOutputFileName)
 	if err != nil {
 		fmt.Printf("err creating new: %s\n", err)
 		return err
 	}
+	defer bankHashFile.Close()
 
-	numBytesWritten, err = bankHashFile.Write(manifest.Bank.Hash[:])
+	numBytesWritten, err := bankHashFile.Write(bankHash[:])
 	if err != nil {
 		fmt.Printf("error writing bank hash to file: %s\n", err)
 		return err
@@ -334,8 +439,6 @@ func BuildAccountsIndexFromSnapshot(snapshotFile string, accountsDbDir string) e
 		return fmt.Errorf("error writing bank hash to file, wrote %d bytes", numBytesWritten)
 	}
 
-	bankHashFile.Close()
-
 	return nil
 }
 
@@ -0,0 +1,239 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/klauspost/compress/zstd"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/base58"
+	"k8s.io/klog/v2"
+)
+
+// version of the snapshot archive format, written out to the archive's version file
+const snapshotArchiveVersion = "1.2.0"
+
+// WriteFullSnapshot writes a full snapshot archive named "snapshot-<slot>-<hash>.tar.zst" to
+// outputDir, holding every appendvec in the AccountsDB up to and including the manifest's slot.
+// The manifest's BankHashInfo.SnapshotHash must already be set to the full accounts hash
+// as of that slot. The status cache holds the statuses of the txs processed in the rooted
+// slots preceding and including the manifest's slot. The path of the written archive is returned.
+func WriteFullSnapshot(accountsDb *accountsdb.AccountsDb, manifest *SnapshotManifest, statusCache []BankSlotDelta, outputDir string) (string, error) {
+	slot := manifest.Bank.Slot
+	snapshotHash := calculateSnapshotHash(manifest.AccountsDb.BankHashInfo.SnapshotHash, manifest.EpochAccountHash)
+	filename := fmt.Sprintf("snapshot-%d-%s.tar.zst", slot, base58.Encode(snapshotHash[:]))
+
+	return writeSnapshotArchive(accountsDb, manifest, statusCache, 0, slot, filepath.Join(outputDir, filename))
+}
+
+// WriteIncrementalSnapshot writes an incremental snapshot archive named
+// "incremental-snapshot-<full slot>-<slot>-<hash>.tar.zst" to outputDir, holding the appendvecs
+// stored after the full snapshot slot. The manifest's BankIncrementalSnapshotPersistence must
+// already be populated with the full and incremental accounts hashes and capitalizations.
+// The status cache is as for WriteFullSnapshot. The path of the written archive is returned.
+func WriteIncrementalSnapshot(accountsDb *accountsdb.AccountsDb, manifest *SnapshotManifest, statusCache []BankSlotDelta, outputDir string) (string, error) {
+	slot := manifest.Bank.Slot
+	fullSlot := manifest.BankIncrementalSnapshotPersistence.FullSlot
+	if fullSlot == 0 || fullSlot >= slot {
+		return "", fmt.Errorf("invalid full snapshot slot %d for incremental snapshot at slot %d", fullSlot, slot)
+	}
+
+	snapshotHash := calculateSnapshotHash(manifest.BankIncrementalSnapshotPersistence.IncrementalHash, manifest.EpochAccountHash)
+	filename := fmt.Sprintf("incremental-snapshot-%d-%d-%s.tar.zst", fullSlot, slot, base58.Encode(snapshotHash[:]))
+
+	return writeSnapshotArchive(accountsDb, manifest, statusCache, fullSlot+1, slot, filepath.Join(outputDir, filename))
+}
+
+// calculateSnapshotHash returns the hash identifying a snapshot archive, which is the accounts
+// hash, mixed with the epoch accounts hash if the snapshot slot is one at which it is included.
+func calculateSnapshotHash(accountsHash [32]byte, epochAcctsHash [32]byte) [32]byte {
+	if epochAcctsHash == [32]byte{} {
+		return accountsHash
+	}
+
+	hasher := sha256.New()
+	hasher.Write(accountsHash[:])
+	hasher.Write(epochAcctsHash[:])
+
+	var snapshotHash [32]byte
+	copy(snapshotHash[:], hasher.Sum(nil))
+	return snapshotHash
+}
+
+type slotAppendVec struct {
+	Slot   uint64
+	FileId uint64
+	Data   []byte
+}
+
+// mergeSlotAppendVecs combines the appendvecs stored for a slot into a single appendvec,
+// since a snapshot may only contain one appendvec per slot. Where an account was stored more
+// than once in the slot, only the most recently stored version is retained.
+func mergeSlotAppendVecs(accountsDb *accountsdb.AccountsDb, slot uint64, appendVecs []accountsdb.AppendVecInfo) (*slotAppendVec, error) {
+	var accts []*accounts.Account
+	acctIdxs := make(map[[32]byte]int)
+
+	for _, appendVec := range appendVecs {
+		err := accountsDb.ScanAppendVec(appendVec, func(acct *accounts.Account, entry *accountsdb.AccountIndexEntry) error {
+			acctCopy := *acct
+			acctCopy.Data = bytes.Clone(acct.Data)
+
+			if idx, exists := acctIdxs[acct.Key]; exists {
+				accts[idx] = &acctCopy
+			} else {
+				acctIdxs[acct.Key] = len(accts)
+				accts = append(accts, &acctCopy)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, acct := range accts {
+		appendVecAcct := accountsdb.AppendVecAccount{DataLen: uint64(len(acct.Data)), Pubkey: acct.Key, Lamports: acct.Lamports,
+			RentEpoch: acct.RentEpoch, Owner: acct.Owner, Executable: acct.Executable, Data: acct.Data}
+
+		err := appendVecAcct.Marshal(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// file IDs are unique across slots, so the last of the slot's file IDs is reused
+	return &slotAppendVec{Slot: slot, FileId: appendVecs[len(appendVecs)-1].FileId, Data: buf.Bytes()}, nil
+}
+
+func writeSnapshotArchive(accountsDb *accountsdb.AccountsDb, manifest *SnapshotManifest, statusCache []BankSlotDelta, minSlot uint64, maxSlot uint64,
+	archivePath string) (string, error) {
+	start := time.Now()
+
+	release := accountsDb.RetainAppendVecs()
+	defer release()
+
+	appendVecInfos, err := accountsDb.AppendVecs()
+	if err != nil {
+		return "", err
+	}
+
+	// appendvecs are ordered by slot and then file ID, so each slot's appendvecs are contiguous
+	var appendVecs []*slotAppendVec
+	for idx := 0; idx < len(appendVecInfos); {
+		slot := appendVecInfos[idx].Slot
+		end := idx
+		for end < len(appendVecInfos) && appendVecInfos[end].Slot == slot {
+			end++
+		}
+
+		if slot >= minSlot && slot <= maxSlot {
+			appendVec, err := mergeSlotAppendVecs(accountsDb, slot, appendVecInfos[idx:end])
+			if err != nil {
+				return "", err
+			}
+			if len(appendVec.Data) != 0 {
+				appendVecs = append(appendVecs, appendVec)
+			}
+		}
+
+		idx = end
+	}
+
+	// the storages in the written manifest must describe the appendvecs in the archive
+	archiveManifest := *manifest
+	archiveManifest.AccountsDb.Slot = maxSlot
+	archiveManifest.AccountsDb.Storages = make(map[uint64]SlotAcctVecs, len(appendVecs))
+	for _, appendVec := range appendVecs {
+		archiveManifest.AccountsDb.Storages[appendVec.Slot] = SlotAcctVecs{Slot: appendVec.Slot,
+			AcctVecs: []AcctVec{{Id: appendVec.FileId, FileSize: uint64(len(appendVec.Data))}}}
+	}
+
+	manifestBuf := new(bytes.Buffer)
+	err = archiveManifest.MarshalWithEncoder(bin.NewBinEncoder(manifestBuf))
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+
+	statusCacheBytes, err := MarshalStatusCache(statusCache)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize status cache: %w", err)
+	}
+
+	// write to a temporary file first, so that a partially written archive is never mistaken for a valid one
+	tmpPath := archivePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpPath)
+	defer file.Close()
+
+	zstdWriter, err := zstd.NewWriter(file)
+	if err != nil {
+		return "", err
+	}
+
+	tarWriter := tar.NewWriter(zstdWriter)
+
+	err = writeTarFile(tarWriter, "version", []byte(snapshotArchiveVersion))
+	if err != nil {
+		return "", err
+	}
+
+	err = writeTarFile(tarWriter, "snapshots/status_cache", statusCacheBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeTarFile(tarWriter, fmt.Sprintf("snapshots/%d/%d", maxSlot, maxSlot), manifestBuf.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	for _, appendVec := range appendVecs {
+		err = writeTarFile(tarWriter, fmt.Sprintf("accounts/%d.%d", appendVec.Slot, appendVec.FileId), appendVec.Data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return "", err
+	}
+
+	if err = zstdWriter.Close(); err != nil {
+		return "", err
+	}
+
+	if err = file.Close(); err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tmpPath, archivePath); err != nil {
+		return "", err
+	}
+
+	klog.Infof("wrote snapshot archive %s with %d appendvecs in %s", archivePath, len(appendVecs), time.Since(start))
+
+	return archivePath, nil
+}
+
+func writeTarFile(tarWriter *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+
+	err := tarWriter.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(data)
+	return err
+}
@@ -0,0 +1,246 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.firedancer.io/radiance/pkg/accounts"
+	"go.firedancer.io/radiance/pkg/accountsdb"
+	"go.firedancer.io/radiance/pkg/accountsdb/accountsdbtest"
+	"go.firedancer.io/radiance/pkg/base58"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+func newTestManifest() *SnapshotManifest {
+	lastHash := [32]byte{9}
+	hashesPerTick := uint64(12500)
+
+	manifest := new(SnapshotManifest)
+	manifest.Bank.BlockhashQueue = BlockHashVec{LastHashIndex: 3, LastHash: &lastHash, MaxAge: 300,
+		Ages: []HashAgePair{{Key: lastHash, Val: HashAge{HashIndex: 3, Timestamp: 1234}}}}
+	manifest.Bank.Ancestors = []SlotPair{{Slot: 6}}
+	manifest.Bank.Hash = [32]byte{1}
+	manifest.Bank.ParentHash = [32]byte{2}
+	manifest.Bank.ParentSlot = 5
+	manifest.Bank.HashesPerTick = &hashesPerTick
+	manifest.Bank.TicksPerSlot = 64
+	manifest.Bank.Slot = 6
+	manifest.Bank.EpochSchedule = sealevel.SysvarEpochSchedule{SlotsPerEpoch: 432000, LeaderScheduleSlotOffset: 432000}
+	manifest.Bank.Stakes.VoteAccounts = []VoteAccountsPair{{Key: solana.NewWallet().PublicKey(), Stake: 100,
+		Value: VoteAccount{Lamports: 5, Data: []byte{0xff, 0, 0, 0, 1, 2, 3}, Owner: solana.VoteProgramID}}}
+	manifest.Bank.EpochStakes = []EpochStakesPair{{Key: 1, Val: EpochStakes{TotalStake: 100}}}
+	manifest.AccountsDb.Storages = map[uint64]SlotAcctVecs{}
+	manifest.AccountsDb.Slot = 6
+	manifest.AccountsDb.BankHashInfo.SnapshotHash = [32]byte{3}
+	manifest.LamportsPerSignature = 5000
+	manifest.EpochAccountHash = [32]byte{4}
+
+	return manifest
+}
+
+func TestManifestMarshalRoundTrip(t *testing.T) {
+	manifest := newTestManifest()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, manifest.MarshalWithEncoder(bin.NewBinEncoder(buf)))
+
+	decoded := new(SnapshotManifest)
+	require.NoError(t, decoded.UnmarshalWithDecoder(bin.NewBinDecoder(buf.Bytes())))
+
+	assert.Equal(t, manifest.Bank.Slot, decoded.Bank.Slot)
+	assert.Equal(t, manifest.Bank.Hash, decoded.Bank.Hash)
+	assert.Equal(t, *manifest.Bank.BlockhashQueue.LastHash, *decoded.Bank.BlockhashQueue.LastHash)
+	assert.Equal(t, manifest.Bank.Stakes.VoteAccounts[0].Value.Data, decoded.Bank.Stakes.VoteAccounts[0].Value.Data)
+	assert.Equal(t, manifest.AccountsDb.BankHashInfo.SnapshotHash, decoded.AccountsDb.BankHashInfo.SnapshotHash)
+	assert.Equal(t, manifest.EpochAccountHash, decoded.EpochAccountHash)
+	assert.Equal(t, BankIncrementalSnapshotPersistence{}, decoded.BankIncrementalSnapshotPersistence)
+
+	// re-serializing the decoded manifest yields identical bytes
+	reencoded := new(bytes.Buffer)
+	require.NoError(t, decoded.MarshalWithEncoder(bin.NewBinEncoder(reencoded)))
+	assert.Equal(t, buf.Bytes(), reencoded.Bytes())
+}
+
+func TestMarshalStatusCache(t *testing.T) {
+	insufficientFunds := sealevel.NewTransactionError(sealevel.TxErrCodeInsufficientFundsForFee)
+	slotDeltas := []BankSlotDelta{{Slot: 5, IsRoot: true, Statuses: []BlockhashStatuses{{Blockhash: [32]byte{1}, KeyIndex: 2,
+		Txs: []TxStatus{{Key: [20]byte{3}}, {Key: [20]byte{4}, Err: insufficientFunds}}}}}, {Slot: 6, IsRoot: true}}
+
+	statusCacheBytes, err := MarshalStatusCache(slotDeltas)
+	require.NoError(t, err)
+
+	expected := binary.LittleEndian.AppendUint64(nil, 2)
+	expected = binary.LittleEndian.AppendUint64(expected, 5)
+	expected = append(expected, 1)
+	expected = binary.LittleEndian.AppendUint64(expected, 1)
+	expected = append(expected, slotDeltas[0].Statuses[0].Blockhash[:]...)
+	expected = binary.LittleEndian.AppendUint64(expected, 2)
+	expected = binary.LittleEndian.AppendUint64(expected, 2)
+	expected = append(expected, slotDeltas[0].Statuses[0].Txs[0].Key[:]...)
+	expected = binary.LittleEndian.AppendUint32(expected, 0)
+	expected = append(expected, slotDeltas[0].Statuses[0].Txs[1].Key[:]...)
+	expected = binary.LittleEndian.AppendUint32(expected, 1)
+	expected = binary.LittleEndian.AppendUint32(expected, uint32(sealevel.TxErrCodeInsufficientFundsForFee))
+	expected = binary.LittleEndian.AppendUint64(expected, 6)
+	expected = append(expected, 1)
+	expected = binary.LittleEndian.AppendUint64(expected, 0)
+	assert.Equal(t, expected, statusCacheBytes)
+
+	// an empty status cache is an empty vec
+	statusCacheBytes, err = MarshalStatusCache(nil)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 8), statusCacheBytes)
+}
+
+func TestWriteFullSnapshot(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID, Data: []byte{1, 2, 3}}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b}, 5))
+
+	// stored twice in the same slot, so the appendvecs are merged and only the later version kept
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 1500, Owner: solana.SystemProgramID, Data: []byte{4, 5}}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 5))
+
+	bUpdated := &accounts.Account{Key: b.Key, Lamports: 2500, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{bUpdated}, 6))
+
+	// beyond the snapshot slot, and so not included
+	bLater := &accounts.Account{Key: b.Key, Lamports: 3000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{bLater}, 7))
+
+	manifest := newTestManifest()
+	manifest.EpochAccountHash = [32]byte{}
+
+	slotDeltas := []BankSlotDelta{{Slot: 6, IsRoot: true, Statuses: []BlockhashStatuses{{Blockhash: *manifest.Bank.BlockhashQueue.LastHash, Txs: []TxStatus{{Key: [20]byte{1}}}}}}}
+	archivePath, err := WriteFullSnapshot(acctsDb, manifest, slotDeltas, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot-6-"+base58.Encode(manifest.AccountsDb.BankHashInfo.SnapshotHash[:])+".tar.zst", filepath.Base(archivePath))
+
+	// load the snapshot back into a new AccountsDB
+	loadedDir := t.TempDir()
+	require.NoError(t, BuildAccountsIndexFromSnapshot(archivePath, loadedDir))
+
+	loadedManifest, err := LoadManifestFromFile(filepath.Join(loadedDir, "manifest"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), loadedManifest.Bank.Slot)
+	assert.Equal(t, 2, len(loadedManifest.AccountsDb.Storages))
+
+	loadedDb, err := accountsdb.OpenDb(loadedDir)
+	require.NoError(t, err)
+	defer loadedDb.CloseDb()
+
+	assert.Equal(t, manifest.Bank.Hash, loadedDb.BankHash())
+
+	acct, err := loadedDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1500), acct.Lamports)
+	assert.Equal(t, []byte{4, 5}, acct.Data)
+
+	acct, err = loadedDb.GetAccount(b.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2500), acct.Lamports)
+}
+
+func TestApplyIncrementalSnapshot(t *testing.T) {
+	acctsDb := accountsdbtest.NewAccountsDb(t)
+
+	a := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 1000, Owner: solana.SystemProgramID, Data: []byte{1, 2, 3}}
+	b := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 2000, Owner: solana.SystemProgramID}
+	c := &accounts.Account{Key: solana.NewWallet().PublicKey(), Lamports: 3000, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{a, b, c}, 6))
+
+	fullManifest := newTestManifest()
+	fullManifest.EpochAccountHash = [32]byte{}
+	fullArchive, err := WriteFullSnapshot(acctsDb, fullManifest, nil, t.TempDir())
+	require.NoError(t, err)
+
+	aUpdated := &accounts.Account{Key: a.Key, Lamports: 1500, Owner: solana.SystemProgramID, Data: []byte{4, 5}}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{aUpdated}, 7))
+	bClosed := &accounts.Account{Key: b.Key, Lamports: 0, Owner: solana.SystemProgramID}
+	require.NoError(t, acctsDb.StoreAccounts([]*accounts.Account{bClosed}, 8))
+
+	incrManifest := newTestManifest()
+	incrManifest.EpochAccountHash = [32]byte{}
+	incrManifest.Bank.Slot = 8
+	incrManifest.Bank.ParentSlot = 7
+	incrManifest.Bank.Hash = [32]byte{5}
+	incrManifest.BankIncrementalSnapshotPersistence = BankIncrementalSnapshotPersistence{FullSlot: 6, IncrementalHash: [32]byte{6}}
+	incrArchive, err := WriteIncrementalSnapshot(acctsDb, incrManifest, nil, t.TempDir())
+	require.NoError(t, err)
+
+	appendVecs, err := acctsDb.AppendVecs()
+	require.NoError(t, err)
+	largestFileId := appendVecs[len(appendVecs)-1].FileId
+
+	loadFull := func() string {
+		dir := t.TempDir()
+		require.NoError(t, BuildAccountsIndexFromSnapshot(fullArchive, dir))
+		return dir
+	}
+
+	loadedDir := loadFull()
+	require.NoError(t, ApplyIncrementalSnapshot(incrArchive, loadedDir))
+
+	manifest, err := LoadManifestFromFile(filepath.Join(loadedDir, "manifest"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), manifest.Bank.Slot)
+	assert.Equal(t, uint64(6), manifest.BankIncrementalSnapshotPersistence.FullSlot)
+
+	largestFileIdBytes, err := os.ReadFile(filepath.Join(loadedDir, "largest_file_id"))
+	require.NoError(t, err)
+	assert.Equal(t, largestFileId, binary.LittleEndian.Uint64(largestFileIdBytes))
+
+	loadedDb, err := accountsdb.OpenDb(loadedDir)
+	require.NoError(t, err)
+	defer loadedDb.CloseDb()
+
+	assert.Equal(t, [32]byte{5}, loadedDb.BankHash())
+
+	// the versions in the incremental snapshot supersede those in the full snapshot, including
+	// that of the account closed after the full snapshot slot
+	acct, err := loadedDb.GetAccount(a.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1500), acct.Lamports)
+	assert.Equal(t, []byte{4, 5}, acct.Data)
+
+	acct, err = loadedDb.GetAccount(b.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), acct.Lamports)
+
+	acct, err = loadedDb.GetAccount(c.Key)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3000), acct.Lamports)
+
+	// an incremental snapshot based on another full snapshot is refused
+	otherManifest := *incrManifest
+	otherManifest.BankIncrementalSnapshotPersistence.FullSlot = 5
+	otherArchive, err := WriteIncrementalSnapshot(acctsDb, &otherManifest, nil, t.TempDir())
+	require.NoError(t, err)
+
+	loadedDir = loadFull()
+	assert.ErrorContains(t, ApplyIncrementalSnapshot(otherArchive, loadedDir), "based on full snapshot slot 5")
+
+	// as is applying one to an AccountsDB that has been replayed beyond its snapshot slot
+	require.NoError(t, os.WriteFile(filepath.Join(loadedDir, "last_slot"), make([]byte, 8), 0666))
+	assert.ErrorContains(t, ApplyIncrementalSnapshot(incrArchive, loadedDir), "replayed beyond")
+
+	// the manifest is only replaced once the rest of the incremental snapshot has been applied
+	loadedDir = loadFull()
+	bankHashPath := filepath.Join(loadedDir, "bank_hash")
+	require.NoError(t, os.Remove(bankHashPath))
+	require.NoError(t, os.Mkdir(bankHashPath, 0775))
+	assert.Error(t, ApplyIncrementalSnapshot(incrArchive, loadedDir))
+
+	manifest, err = LoadManifestFromFile(filepath.Join(loadedDir, "manifest"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), manifest.Bank.Slot)
+}
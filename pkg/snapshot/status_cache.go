@@ -0,0 +1,133 @@
+package snapshot
+
+import (
+	"bytes"
+
+	bin "github.com/gagliardetto/binary"
+	"go.firedancer.io/radiance/pkg/sealevel"
+)
+
+// StatusCacheKeyLen is the number of bytes of each transaction's message hash or signature
+// that the status cache records, starting at the KeyIndex of the transaction's blockhash.
+const StatusCacheKeyLen = 20
+
+// BankSlotDelta is the status cache's record of the transactions processed in a rooted slot,
+// grouped by their recent blockhash. A snapshot's status cache holds one per slot that
+// remains in the status cache, with IsRoot set.
+type BankSlotDelta struct {
+	Slot     uint64
+	IsRoot   bool
+	Statuses []BlockhashStatuses
+}
+
+// BlockhashStatuses records the outcome of the transactions processed in a slot with the
+// given recent blockhash.
+type BlockhashStatuses struct {
+	Blockhash [32]byte
+	KeyIndex  uint64
+	Txs       []TxStatus
+}
+
+// TxStatus is the outcome of a processed transaction, keyed by a slice of its message hash
+// or signature. Err is nil for transactions that succeeded.
+type TxStatus struct {
+	Key [StatusCacheKeyLen]byte
+	Err *sealevel.TransactionError
+}
+
+// MarshalStatusCache serializes the slot deltas into the form of a snapshot's status cache.
+func MarshalStatusCache(slotDeltas []BankSlotDelta) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := bin.NewBinEncoder(buf)
+
+	err := encoder.WriteUint64(uint64(len(slotDeltas)), bin.LE)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range slotDeltas {
+		err = slotDeltas[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (slotDelta *BankSlotDelta) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteUint64(slotDelta.Slot, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteBool(slotDelta.IsRoot)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(slotDelta.Statuses)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range slotDelta.Statuses {
+		err = slotDelta.Statuses[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (statuses *BlockhashStatuses) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteBytes(statuses.Blockhash[:], false)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(statuses.KeyIndex, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.WriteUint64(uint64(len(statuses.Txs)), bin.LE)
+	if err != nil {
+		return err
+	}
+
+	for idx := range statuses.Txs {
+		err = statuses.Txs[idx].MarshalWithEncoder(encoder)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalWithEncoder encodes the tx's key, followed by its outcome as a Result<(), TransactionError>.
+func (txStatus *TxStatus) MarshalWithEncoder(encoder *bin.Encoder) error {
+	var err error
+
+	err = encoder.WriteBytes(txStatus.Key[:], false)
+	if err != nil {
+		return err
+	}
+
+	if txStatus.Err == nil {
+		return encoder.WriteUint32(0, bin.LE)
+	}
+
+	err = encoder.WriteUint32(1, bin.LE)
+	if err != nil {
+		return err
+	}
+
+	return txStatus.Err.MarshalWithEncoder(encoder)
+}